@@ -20,10 +20,19 @@ import (
 
 // RegisterAdminRoutes initialize all auth related routes
 func RegisterAdminRoutes(endpoint *echo.Group) {
-	endpoint.POST("/add/", createAdmin, middlewares.JWTAuth(true), middlewares.IsSuperAdmin())
-	endpoint.PATCH("/update/:adminId/", updateAdmin, middlewares.JWTAuth(true), middlewares.IsSuperAdmin())
-	endpoint.GET("/all/", allAdmins, middlewares.JWTAuth(true) /*  middlewares.IsSuperAdmin() */)
+	endpoint.POST("/add/", createAdmin, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.AdminManage), middlewares.Audit(middlewares.AuditAdmin, ""))
+	endpoint.PATCH("/update/:adminId/", updateAdmin, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.AdminManage), middlewares.Audit(middlewares.AuditAdmin, "adminId"))
+	endpoint.GET("/all/", allAdmins, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.AdminRead))
 	endpoint.GET("/profile/", profile, middlewares.JWTAuth(true))
+	endpoint.POST("/profile/picture/", uploadProfilePic, middlewares.JWTAuth(true), middlewares.Audit(middlewares.AuditAdmin, ""))
+	endpoint.GET("/permissions/", rolePermissions, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.AdminRead))
+	endpoint.PATCH("/permissions/", updateRolePermissions, middlewares.JWTAuth(true), middlewares.IsSuperAdmin(), middlewares.Audit(middlewares.AuditRolePermission, ""))
+}
+
+// isSuperAdminOnly reports whether requester is not allowed to manage admins of the role,
+// only super admins can manage super admins
+func isSuperAdminOnly(ctx echo.Context, role constants.AdminRole) bool {
+	return role == constants.SuperAdmin && ctx.Get(constants.Role) != string(constants.SuperAdmin)
 }
 
 func createAdmin(ctx echo.Context) error {
@@ -37,6 +46,13 @@ func createAdmin(ctx echo.Context) error {
 		resp.Errors = err
 		return resp.Send(ctx)
 	}
+	if isSuperAdminOnly(ctx, admin.Role) {
+		resp.Title = "You are not super admin"
+		resp.Status = http.StatusForbidden
+		resp.Code = codes.NotSuperAdmin
+		resp.Errors = errors.NewError("Only super admin can add super admin")
+		return resp.Send(ctx)
+	}
 	hash, err := password.HashPassword(admin.Password)
 	if err != nil {
 		logger.Log.Errorln(err)
@@ -48,6 +64,11 @@ func createAdmin(ctx echo.Context) error {
 	}
 	admin.Password = hash
 	db := database.GetDB()
+	if err := data.NewLocationRepo().HubsExist(db, admin.Hubs); err != nil {
+		logger.Log.Errorln(err)
+		return sendLocationError(ctx, err)
+	}
+	middlewares.AuditTargets(ctx, admin.ID)
 	adminRepo := data.NewAdminRepo()
 	if err := adminRepo.Create(db, admin); err != nil {
 		logger.Log.Errorln(err)
@@ -83,6 +104,16 @@ func updateAdmin(ctx echo.Context) error {
 		resp.Errors = err
 		return resp.Send(ctx)
 	}
+	if _id, err := primitive.ObjectIDFromHex(ID); err == nil {
+		target, err := adminRepo.FindByID(db, _id)
+		if err == nil && (isSuperAdminOnly(ctx, target.Role) || isSuperAdminOnly(ctx, body.Role)) {
+			resp.Title = "You are not super admin"
+			resp.Status = http.StatusForbidden
+			resp.Code = codes.NotSuperAdmin
+			resp.Errors = errors.NewError("Only super admin can manage super admin")
+			return resp.Send(ctx)
+		}
+	}
 	if body.Password != "" {
 		body.Password, err = password.HashPassword(body.Password)
 		if err != nil {
@@ -94,6 +125,12 @@ func updateAdmin(ctx echo.Context) error {
 			return resp.Send(ctx)
 		}
 	}
+	if body.Hubs != nil {
+		if err := data.NewLocationRepo().HubsExist(db, *body.Hubs); err != nil {
+			logger.Log.Errorln(err)
+			return sendLocationError(ctx, err)
+		}
+	}
 	admin, err := adminRepo.UpdateAdminByID(db, body, ID)
 	if err != nil {
 		logger.Log.Errorln(err)
@@ -120,6 +157,7 @@ func updateAdmin(ctx echo.Context) error {
 			return resp.Send(ctx)
 		}
 	}
+	setAdminURLs(admin)
 	resp.Data = admin
 	resp.Status = http.StatusOK
 	return resp.Send(ctx)
@@ -138,6 +176,9 @@ func allAdmins(ctx echo.Context) error {
 		resp.Errors = err
 		return resp.Send(ctx)
 	}
+	for i := range *admins {
+		setAdminURLs(&(*admins)[i])
+	}
 	resp.Data = admins
 	resp.Status = http.StatusOK
 	return resp.Send(ctx)
@@ -164,7 +205,115 @@ func profile(ctx echo.Context) error {
 		resp.Errors = err
 		return resp.Send(ctx)
 	}
+	setAdminURLs(admin)
 	resp.Data = admin
 	resp.Status = http.StatusOK
 	return resp.Send(ctx)
 }
+
+// uploadProfilePic replaces profile picture of the requesting admin
+func uploadProfilePic(ctx echo.Context) error {
+	resp := response.Response{}
+	image, err := validators.ValidateImageUpload(ctx)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Invalid image"
+		resp.Status = http.StatusBadRequest
+		resp.Code = codes.InvalidFileData
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	userID := ctx.Get(constants.UserID).(primitive.ObjectID)
+	db := database.GetDB()
+	adminRepo := data.NewAdminRepo()
+	admin, err := adminRepo.FindByID(db, userID)
+	if err != nil {
+		logger.Log.Errorln(err)
+		if err == mongo.ErrNoDocuments {
+			resp.Title = "Admin not found"
+			resp.Status = http.StatusNotFound
+			resp.Code = codes.AdminNotFound
+			resp.Errors = errors.NewError(err.Error())
+			return resp.Send(ctx)
+		}
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	key, err := storeImage("admins/"+userID.Hex(), "profile", image, profilePicSize)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Failed to store image"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.FileUploadFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	middlewares.AuditTargets(ctx, userID)
+	updated, err := adminRepo.SetProfilePic(db, userID, key)
+	if err != nil {
+		logger.Log.Errorln(err)
+		removeFile(key)
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	removeFile(admin.ProfilePic)
+	setAdminURLs(updated)
+	resp.Data = updated
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
+
+func rolePermissions(ctx echo.Context) error {
+	resp := response.Response{}
+	db := database.GetDB()
+	permissionRepo := data.NewPermissionRepo()
+	roles, err := permissionRepo.AllRolePermissions(db)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	resp.Data = map[string]interface{}{
+		"permissions": constants.Permissions,
+		"roles":       roles,
+	}
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
+
+func updateRolePermissions(ctx echo.Context) error {
+	resp := response.Response{}
+	rolePermission, err := validators.ValidateRolePermission(ctx)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Invalid role permission request data"
+		resp.Status = http.StatusBadRequest
+		resp.Code = codes.InvalidPermissionData
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	db := database.GetDB()
+	middlewares.AuditTargets(ctx, rolePermission.Role)
+	permissionRepo := data.NewPermissionRepo()
+	updated, err := permissionRepo.SetRolePermissions(db, rolePermission)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	resp.Data = updated
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
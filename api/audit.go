@@ -0,0 +1,117 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/techartificer/swiftex/constants/codes"
+	"github.com/techartificer/swiftex/data"
+	"github.com/techartificer/swiftex/database"
+	"github.com/techartificer/swiftex/lib/response"
+	"github.com/techartificer/swiftex/logger"
+	"github.com/techartificer/swiftex/middlewares"
+	"github.com/techartificer/swiftex/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func RegisterAuditRoutes(endpoint *echo.Group) {
+	endpoint.GET("/", auditLogs, middlewares.JWTAuth(true), middlewares.IsSuperAdmin())
+}
+
+// auditLogs searches audit logs, newest first
+func auditLogs(ctx echo.Context) error {
+	resp := response.Response{}
+	query := make(bson.M)
+	for _, param := range []string{"actorId", "lastId"} {
+		value := ctx.QueryParam(param)
+		if value == "" {
+			continue
+		}
+		_id, err := primitive.ObjectIDFromHex(value)
+		if err != nil {
+			logger.Log.Errorln(err)
+			resp.Title = "Invalid " + param
+			resp.Status = http.StatusUnprocessableEntity
+			resp.Code = codes.InvalidMongoID
+			resp.Errors = err
+			return resp.Send(ctx)
+		}
+		if param == "lastId" {
+			query["_id"] = bson.M{"$lt": _id}
+		} else {
+			query["actorId"] = _id
+		}
+	}
+	if accountType := ctx.QueryParam("accountType"); accountType != "" {
+		query["accountType"] = accountType
+	}
+	if entity := ctx.QueryParam("entity"); entity != "" {
+		query["entity"] = entity
+	}
+	if targetID := ctx.QueryParam("targetId"); targetID != "" {
+		query["targets.id"] = targetID
+	}
+	if route := ctx.QueryParam("route"); route != "" {
+		query["route"] = primitive.Regex{Pattern: route, Options: ""}
+	}
+	if ip := ctx.QueryParam("ip"); ip != "" {
+		query["ip"] = ip
+	}
+	startDate, endDate := ctx.QueryParam("startDate"), ctx.QueryParam("endDate")
+	if startDate != "" && endDate != "" {
+		std, err := strconv.ParseInt(startDate, 10, 64)
+		if err != nil {
+			logger.Log.Errorln(err)
+			resp.Title = "Invalid timestamp"
+			resp.Status = http.StatusUnprocessableEntity
+			resp.Code = codes.SomethingWentWrong
+			resp.Errors = err
+			return resp.Send(ctx)
+		}
+		end, err := strconv.ParseInt(endDate, 10, 64)
+		if err != nil {
+			logger.Log.Errorln(err)
+			resp.Title = "Invalid timestamp"
+			resp.Status = http.StatusUnprocessableEntity
+			resp.Code = codes.SomethingWentWrong
+			resp.Errors = err
+			return resp.Send(ctx)
+		}
+		query["createdAt"] = bson.M{"$gte": time.Unix(std/1000, 0), "$lte": time.Unix(end/1000, 0)}
+	}
+	var limitNum int64 = 50
+	if limit := ctx.QueryParam("limit"); limit != "" {
+		ln, err := strconv.Atoi(limit)
+		if err != nil || ln <= 0 {
+			logger.Log.Errorln(err)
+			resp.Errors = err
+			resp.Title = "Invalid limit"
+			resp.Status = http.StatusUnprocessableEntity
+			resp.Code = codes.InvalidLimit
+			return resp.Send(ctx)
+		}
+		limitNum = int64(ln)
+	}
+	db := database.GetDB()
+	auditRepo := data.NewAuditRepo()
+	logs, err := auditRepo.AuditLogs(db, query, limitNum)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	resp.Data = func() []models.AuditLog {
+		if logs == nil {
+			return []models.AuditLog{}
+		}
+		return *logs
+	}()
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
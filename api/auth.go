@@ -181,11 +181,27 @@ func merchantLogin(ctx echo.Context) error {
 		resp.Errors = err
 		return resp.Send(ctx)
 	}
+	// pending moderator invitations are accepted with the issued token
+	invitations, err := data.NewShopModeratorRepo().Invitations(db, merchant.Phone)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
 	result := map[string]interface{}{
 		"accessToken":  sess.AccessToken,
 		"refreshToken": sess.RefreshToken,
 		"expiresOn":    sess.ExpiresOn,
 		"permission":   "Owner",
+		"invitations": func() []models.ShopModerator {
+			if invitations == nil {
+				return []models.ShopModerator{}
+			}
+			return *invitations
+		}(),
 	}
 	resp.Status = http.StatusOK
 	resp.Data = result
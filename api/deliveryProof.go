@@ -0,0 +1,135 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/techartificer/swiftex/constants"
+	"github.com/techartificer/swiftex/constants/codes"
+	"github.com/techartificer/swiftex/data"
+	"github.com/techartificer/swiftex/database"
+	"github.com/techartificer/swiftex/lib/errors"
+	"github.com/techartificer/swiftex/lib/password"
+	"github.com/techartificer/swiftex/lib/random"
+	"github.com/techartificer/swiftex/lib/response"
+	"github.com/techartificer/swiftex/lib/sms"
+	"github.com/techartificer/swiftex/lib/storage"
+	"github.com/techartificer/swiftex/logger"
+	"github.com/techartificer/swiftex/models"
+	"github.com/techartificer/swiftex/validators"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// requestDeliveryOTP sends a new delivery code to the recipient of an out for delivery parcel
+func requestDeliveryOTP(ctx echo.Context) error {
+	resp := response.Response{}
+	orderID, err := primitive.ObjectIDFromHex(ctx.Param("orderId"))
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Invalid order ID"
+		resp.Status = http.StatusUnprocessableEntity
+		resp.Code = codes.InvalidMongoID
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	if !sms.Enabled() {
+		resp.Title = "Delivery codes are not available"
+		resp.Status = http.StatusUnprocessableEntity
+		resp.Code = codes.DeliveryOTPUnavailable
+		resp.Errors = sms.ErrNotConfigured
+		return resp.Send(ctx)
+	}
+	var riderID *primitive.ObjectID
+	if ctx.Get(constants.AccountType) == constants.RiderType {
+		userID := ctx.Get(constants.UserID).(primitive.ObjectID)
+		riderID = &userID
+	}
+	otp, err := random.GenerateRandomCode(constants.DeliveryOTPSize)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.SomethingWentWrong
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	hash, err := password.HashPassword(otp)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.PasswordHashFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	expiresAt := time.Now().Add(constants.DeliveryOTPTTL * time.Minute).Unix()
+	db := database.GetDB()
+	orderRepo := data.NewOrderRepo()
+	order, err := orderRepo.SetDeliveryOTP(db, orderID, riderID, hash, expiresAt)
+	if err != nil {
+		logger.Log.Errorln(err)
+		if err == mongo.ErrNoDocuments {
+			resp.Title = "Order not found or not out for delivery"
+			resp.Status = http.StatusNotFound
+			resp.Code = codes.OrderNotFound
+			resp.Errors = errors.NewError(err.Error())
+			return resp.Send(ctx)
+		}
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	text := fmt.Sprintf("Your delivery code for parcel %s is %s. Share it with the rider after receiving the parcel.", order.TrackID, otp)
+	if err := sms.Send(order.RecipientPhone, text); err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Failed to send delivery code"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.SomethingWentWrong
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	resp.Data = map[string]interface{}{"expiresAt": expiresAt}
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
+
+// storeDeliveryProof uploads submitted files of a delivery and sets their keys on proof
+func storeDeliveryProof(orderID primitive.ObjectID, req *validators.DeliveryProofReq, proof *models.DeliveryProof) error {
+	var err error
+	if req.Signature != nil {
+		if proof.SignatureKey, err = storeImage("proofs/"+orderID.Hex(), constants.ProofSignature, req.Signature, proofImageSize); err != nil {
+			return err
+		}
+	}
+	if req.Photo != nil {
+		if proof.PhotoKey, err = storeImage("proofs/"+orderID.Hex(), constants.ProofPhoto, req.Photo, proofImageSize); err != nil {
+			deleteDeliveryProof(proof)
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteDeliveryProof removes uploaded files of a delivery which could not be completed
+func deleteDeliveryProof(proof *models.DeliveryProof) {
+	removeFile(proof.SignatureKey)
+	removeFile(proof.PhotoKey)
+}
+
+// setProofURLs links signature and photo of a delivered order to signed download urls
+func setProofURLs(order *models.Order) {
+	if order.Proof == nil {
+		return
+	}
+	if order.Proof.SignatureKey != "" {
+		order.Proof.SignatureURL = storage.SignedURL(order.Proof.SignatureKey)
+	}
+	if order.Proof.PhotoKey != "" {
+		order.Proof.PhotoURL = storage.SignedURL(order.Proof.PhotoKey)
+	}
+}
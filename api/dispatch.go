@@ -0,0 +1,146 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/techartificer/swiftex/constants/codes"
+	"github.com/techartificer/swiftex/data"
+	"github.com/techartificer/swiftex/database"
+	"github.com/techartificer/swiftex/lib/errors"
+	"github.com/techartificer/swiftex/lib/response"
+	"github.com/techartificer/swiftex/logger"
+	"github.com/techartificer/swiftex/middlewares"
+	"github.com/techartificer/swiftex/models"
+	"github.com/techartificer/swiftex/validators"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// dispatchPreview proposes riders for the orders without assigning them
+func dispatchPreview(ctx echo.Context) error {
+	resp := response.Response{}
+	orderIDs, err := validators.ValidateDispatchPreview(ctx)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Invalid dispatch request data"
+		resp.Status = http.StatusBadRequest
+		resp.Code = codes.InvalidAssignParcelData
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	hubs, _ := hubScope(ctx)
+	db := database.GetDB()
+	dispatchRepo := data.NewDispatchRepo()
+	plan, err := dispatchRepo.Plan(db, orderIDs, hubs)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	resp.Data = plan
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
+
+// dispatchCommit assigns riders to orders in bulk, all or none of the assignments are made
+func dispatchCommit(ctx echo.Context) error {
+	resp := response.Response{}
+	parcels, err := validators.ValidateDispatchCommit(ctx)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Invalid dispatch request data"
+		resp.Status = http.StatusBadRequest
+		resp.Code = codes.InvalidAssignParcelData
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	db := database.GetDB()
+	orderIDs, targets := []primitive.ObjectID{}, []interface{}{}
+	for _, parcel := range parcels {
+		orderIDs = append(orderIDs, parcel.OrderID)
+		targets = append(targets, parcel.OrderID)
+	}
+	if sent, err := checkOrdersScope(ctx, db, orderIDs...); sent {
+		return err
+	}
+	middlewares.AuditTargets(ctx, targets...)
+	hubs, _ := hubScope(ctx)
+	dispatchRepo := data.NewDispatchRepo()
+	orders, err := dispatchRepo.Commit(db, parcels, hubs)
+	if err != nil {
+		logger.Log.Errorln(err)
+		if mongo.ErrNoDocuments == err {
+			resp.Title = "Order not exist"
+			resp.Status = http.StatusNotFound
+			resp.Code = codes.OrderNotFound
+			resp.Errors = err
+			return resp.Send(ctx)
+		}
+		if err.Error() == string(codes.RiderNotFound) {
+			resp.Title = "Rider not found"
+			resp.Status = http.StatusNotFound
+			resp.Code = codes.RiderNotFound
+			resp.Errors = err
+			return resp.Send(ctx)
+		}
+		if err.Error() == string(codes.OutOfHubScope) {
+			resp.Title = "Rider is out of your hubs"
+			resp.Status = http.StatusForbidden
+			resp.Code = codes.OutOfHubScope
+			resp.Errors = errors.NewError("Rider is out of your hubs")
+			return resp.Send(ctx)
+		}
+		if err.Error() == string(codes.OrderAlreadyInTransit) {
+			resp.Title = "Order already assigned to a rider"
+			resp.Status = http.StatusUnprocessableEntity
+			resp.Code = codes.OrderAlreadyInTransit
+			resp.Errors = err
+			return resp.Send(ctx)
+		}
+		if err.Error() == string(codes.OrderAlreadyDelevired) {
+			resp.Title = "Order already delivered"
+			resp.Status = http.StatusUnprocessableEntity
+			resp.Code = codes.OrderAlreadyDelevired
+			resp.Errors = err
+			return resp.Send(ctx)
+		}
+		if err.Error() == string(codes.InvalidStatusTransition) {
+			resp.Title = "Order status transition not allowed"
+			resp.Status = http.StatusUnprocessableEntity
+			resp.Code = codes.InvalidStatusTransition
+			resp.Errors = err
+			return resp.Send(ctx)
+		}
+		if err.Error() == string(codes.RiderCashLimitExceeded) {
+			resp.Title = "Rider is holding more cash than the limit, cash has to be deposited first"
+			resp.Status = http.StatusLocked
+			resp.Code = codes.RiderCashLimitExceeded
+			resp.Errors = err
+			return resp.Send(ctx)
+		}
+		if err.Error() == string(codes.RiderCapacityExceeded) {
+			resp.Title = "Rider can not take more parcels today"
+			resp.Status = http.StatusLocked
+			resp.Code = codes.RiderCapacityExceeded
+			resp.Errors = err
+			return resp.Send(ctx)
+		}
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	resp.Data = func() []models.Order {
+		if orders == nil {
+			return []models.Order{}
+		}
+		return *orders
+	}()
+	resp.Status = http.StatusCreated
+	return resp.Send(ctx)
+}
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/techartificer/swiftex/constants/codes"
+	"github.com/techartificer/swiftex/lib/errors"
+	"github.com/techartificer/swiftex/lib/imaging"
+	"github.com/techartificer/swiftex/lib/response"
+	"github.com/techartificer/swiftex/lib/storage"
+	"github.com/techartificer/swiftex/logger"
+	"github.com/techartificer/swiftex/models"
+	"github.com/techartificer/swiftex/validators"
+)
+
+// Longest side of stored images in pixels
+const (
+	profilePicSize = 512
+	shopImageSize  = 1024
+	proofImageSize = 1600
+)
+
+func RegisterFileRoutes(endpoint *echo.Group) {
+	endpoint.GET("/*", serveFile)
+}
+
+// storeImage resizes and uploads an image under dir and returns its key
+func storeImage(dir, name string, image *validators.ImageFile, size int) (string, error) {
+	content, err := imaging.Fit(image.Content, size)
+	if err != nil {
+		return "", err
+	}
+	key := fmt.Sprintf("%s/%s-%d%s", dir, name, time.Now().UnixNano(), image.Ext)
+	if err := storage.GetStore().Put(key, bytes.NewReader(content), image.ContentType); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// removeFile deletes a stored file which is no longer referenced, external urls are left alone
+func removeFile(key string) {
+	if !storage.IsKey(key) {
+		return
+	}
+	if err := storage.GetStore().Delete(key); err != nil {
+		logger.Log.Errorln(err)
+	}
+}
+
+func setAdminURLs(admin *models.Admin) {
+	admin.ProfilePicURL = storage.SignedURL(admin.ProfilePic)
+}
+
+func setShopURLs(shop *models.Shop) {
+	shop.ImageURL = storage.SignedURL(shop.Image)
+}
+
+func setShopsURLs(shops *[]models.Shop) {
+	if shops == nil {
+		return
+	}
+	for i := range *shops {
+		setShopURLs(&(*shops)[i])
+	}
+}
+
+// serveFile streams a file of a signed download url
+func serveFile(ctx echo.Context) error {
+	resp := response.Response{}
+	key := strings.TrimSuffix(ctx.Param("*"), "/")
+	if !storage.VerifySignature(key, ctx.QueryParam("expires"), ctx.QueryParam("signature")) {
+		resp.Title = "Invalid or expired download url"
+		resp.Status = http.StatusForbidden
+		resp.Code = codes.InvalidFileSignature
+		resp.Errors = errors.NewError("Invalid or expired download url")
+		return resp.Send(ctx)
+	}
+	file, err := storage.GetStore().Open(key)
+	if err != nil {
+		logger.Log.Errorln(err)
+		if err == storage.ErrNotFound {
+			resp.Title = "File not found"
+			resp.Status = http.StatusNotFound
+			resp.Code = codes.FileNotFound
+			resp.Errors = err
+			return resp.Send(ctx)
+		}
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.SomethingWentWrong
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	defer file.Close()
+	ctx.Response().Header().Set("Cache-Control", "private, max-age=3600")
+	return ctx.Stream(http.StatusOK, mime.TypeByExtension(path.Ext(key)), file)
+}
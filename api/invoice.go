@@ -0,0 +1,260 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/techartificer/swiftex/constants"
+	"github.com/techartificer/swiftex/constants/codes"
+	"github.com/techartificer/swiftex/data"
+	"github.com/techartificer/swiftex/database"
+	"github.com/techartificer/swiftex/lib/errors"
+	"github.com/techartificer/swiftex/lib/invoice"
+	"github.com/techartificer/swiftex/lib/response"
+	"github.com/techartificer/swiftex/logger"
+	"github.com/techartificer/swiftex/middlewares"
+	"github.com/techartificer/swiftex/models"
+	"github.com/techartificer/swiftex/validators"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func RegisterInvoiceRoutes(endpoint *echo.Group) {
+	endpoint.GET("/", invoices, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.LedgerRead))
+	endpoint.GET("/:invoiceId/", invoiceByID, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.LedgerRead))
+	endpoint.GET("/:invoiceId/pdf/", invoicePDF, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.LedgerRead))
+	endpoint.GET("/:invoiceId/csv/", invoiceCSV, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.LedgerRead))
+	endpoint.POST("/shop/:shopId/", generateInvoice, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.PayoutApprove), middlewares.Audit(middlewares.AuditInvoice, ""))
+	endpoint.GET("/shop/:shopId/", shopInvoices, middlewares.JWTAuth(false), middlewares.RequirePermission(constants.LedgerRead), middlewares.HasShopAccess(), middlewares.RequireShopPermission(constants.ShopFinanceView))
+	endpoint.GET("/shop/:shopId/:invoiceId/", invoiceByID, middlewares.JWTAuth(false), middlewares.RequirePermission(constants.LedgerRead), middlewares.HasShopAccess(), middlewares.RequireShopPermission(constants.ShopFinanceView))
+	endpoint.GET("/shop/:shopId/:invoiceId/pdf/", invoicePDF, middlewares.JWTAuth(false), middlewares.RequirePermission(constants.LedgerRead), middlewares.HasShopAccess(), middlewares.RequireShopPermission(constants.ShopFinanceView))
+	endpoint.GET("/shop/:shopId/:invoiceId/csv/", invoiceCSV, middlewares.JWTAuth(false), middlewares.RequirePermission(constants.LedgerRead), middlewares.HasShopAccess(), middlewares.RequireShopPermission(constants.ShopFinanceView))
+}
+
+// findInvoice returns invoice of the route, invoices of other shops are not found on shop routes.
+// sent is true if a response is sent
+func findInvoice(ctx echo.Context) (*models.Invoice, bool, error) {
+	resp := response.Response{}
+	invoiceID, err := primitive.ObjectIDFromHex(ctx.Param("invoiceId"))
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Invalid invoice ID"
+		resp.Status = http.StatusUnprocessableEntity
+		resp.Code = codes.InvalidMongoID
+		resp.Errors = err
+		return nil, true, resp.Send(ctx)
+	}
+	db := database.GetDB()
+	invoiceRepo := data.NewInvoiceRepo()
+	inv, err := invoiceRepo.InvoiceByID(db, invoiceID)
+	if err == nil {
+		if shop, ok := ctx.Get("shop").(*models.Shop); ok && shop.ID != inv.ShopID {
+			err = mongo.ErrNoDocuments
+		}
+	}
+	if err != nil {
+		logger.Log.Errorln(err)
+		if err == mongo.ErrNoDocuments {
+			resp.Title = "Invoice not found"
+			resp.Status = http.StatusNotFound
+			resp.Code = codes.InvoiceNotFound
+			resp.Errors = errors.NewError(err.Error())
+			return nil, true, resp.Send(ctx)
+		}
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return nil, true, resp.Send(ctx)
+	}
+	return inv, false, nil
+}
+
+// sendInvoices sends invoices matching query params period, lastId and limit, details are left out
+func sendInvoices(ctx echo.Context, query bson.M) error {
+	resp := response.Response{}
+	if lastID := ctx.QueryParam("lastId"); lastID != "" {
+		_id, err := primitive.ObjectIDFromHex(lastID)
+		if err != nil {
+			logger.Log.Errorln(err)
+			resp.Title = "Invalid lastId"
+			resp.Status = http.StatusUnprocessableEntity
+			resp.Code = codes.InvalidMongoID
+			resp.Errors = err
+			return resp.Send(ctx)
+		}
+		query["_id"] = bson.M{"$lt": _id}
+	}
+	if period := ctx.QueryParam("period"); period != "" {
+		start, err := validators.ValidateInvoicePeriod(period)
+		if err != nil {
+			logger.Log.Errorln(err)
+			resp.Title = "Invalid period"
+			resp.Status = http.StatusUnprocessableEntity
+			resp.Code = codes.InvalidInvoiceData
+			resp.Errors = err
+			return resp.Send(ctx)
+		}
+		query["periodStart"] = start
+	}
+	var limitNum int64 = 20
+	if limit := ctx.QueryParam("limit"); limit != "" {
+		ln, err := strconv.Atoi(limit)
+		if err != nil || ln <= 0 {
+			logger.Log.Errorln(err)
+			resp.Errors = err
+			resp.Title = "Invalid limit"
+			resp.Status = http.StatusUnprocessableEntity
+			resp.Code = codes.InvalidLimit
+			return resp.Send(ctx)
+		}
+		limitNum = int64(ln)
+	}
+	db := database.GetDB()
+	invoiceRepo := data.NewInvoiceRepo()
+	list, err := invoiceRepo.Invoices(db, query, limitNum)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	resp.Data = func() []models.Invoice {
+		if list == nil {
+			return []models.Invoice{}
+		}
+		return *list
+	}()
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
+
+// invoices lists invoices of all shops, newest first
+func invoices(ctx echo.Context) error {
+	query := make(bson.M)
+	if shopID := ctx.QueryParam("shopId"); shopID != "" {
+		_id, err := primitive.ObjectIDFromHex(shopID)
+		if err != nil {
+			logger.Log.Errorln(err)
+			resp := response.Response{}
+			resp.Title = "Invalid shopId"
+			resp.Status = http.StatusUnprocessableEntity
+			resp.Code = codes.InvalidMongoID
+			resp.Errors = err
+			return resp.Send(ctx)
+		}
+		query["shopId"] = _id
+	}
+	return sendInvoices(ctx, query)
+}
+
+func shopInvoices(ctx echo.Context) error {
+	shop := ctx.Get("shop").(*models.Shop)
+	return sendInvoices(ctx, bson.M{"shopId": shop.ID})
+}
+
+// generateInvoice invoices a shop for an ended month without waiting for the invoice job
+func generateInvoice(ctx echo.Context) error {
+	resp := response.Response{}
+	periodStart, err := validators.ValidateInvoiceGenerate(ctx)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Invalid invoice data"
+		resp.Status = http.StatusBadRequest
+		resp.Code = codes.InvalidInvoiceData
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	shopID, err := primitive.ObjectIDFromHex(ctx.Param("shopId"))
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Invalid shop ID"
+		resp.Status = http.StatusUnprocessableEntity
+		resp.Code = codes.InvalidMongoID
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	adminID := ctx.Get(constants.UserID).(primitive.ObjectID)
+	invoiceID := primitive.NewObjectID()
+	middlewares.AuditTargets(ctx, invoiceID)
+	db := database.GetDB()
+	invoiceRepo := data.NewInvoiceRepo()
+	inv, err := invoiceRepo.Generate(db, invoiceID, shopID, periodStart, &adminID)
+	if err != nil {
+		logger.Log.Errorln(err)
+		switch err.Error() {
+		case string(codes.InvoiceAlreadyExist):
+			resp.Title = "Shop is already invoiced for the period"
+			resp.Status = http.StatusConflict
+			resp.Code = codes.InvoiceAlreadyExist
+		case string(codes.ShopNotFound):
+			resp.Title = "Shop not found"
+			resp.Status = http.StatusNotFound
+			resp.Code = codes.ShopNotFound
+		default:
+			resp.Title = "Something went wrong"
+			resp.Status = http.StatusInternalServerError
+			resp.Code = codes.DatabaseQueryFailed
+		}
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	resp.Data = inv
+	resp.Status = http.StatusCreated
+	return resp.Send(ctx)
+}
+
+func invoiceByID(ctx echo.Context) error {
+	inv, sent, err := findInvoice(ctx)
+	if sent {
+		return err
+	}
+	resp := response.Response{}
+	resp.Data = inv
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
+
+func invoicePDF(ctx echo.Context) error {
+	inv, sent, err := findInvoice(ctx)
+	if sent {
+		return err
+	}
+	buf := &bytes.Buffer{}
+	if err := invoice.Render(buf, inv); err != nil {
+		logger.Log.Errorln(err)
+		resp := response.Response{}
+		resp.Title = "Invoice generation failed"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.SomethingWentWrong
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	ctx.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`inline; filename="%s.pdf"`, inv.Number))
+	return ctx.Blob(http.StatusOK, "application/pdf", buf.Bytes())
+}
+
+func invoiceCSV(ctx echo.Context) error {
+	inv, sent, err := findInvoice(ctx)
+	if sent {
+		return err
+	}
+	content, err := invoice.CSV(inv)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp := response.Response{}
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.SomethingWentWrong
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	ctx.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s.csv"`, inv.Number))
+	return ctx.Blob(http.StatusOK, "text/csv", content)
+}
@@ -0,0 +1,200 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/techartificer/swiftex/constants"
+	"github.com/techartificer/swiftex/constants/codes"
+	"github.com/techartificer/swiftex/data"
+	"github.com/techartificer/swiftex/database"
+	"github.com/techartificer/swiftex/lib/errors"
+	"github.com/techartificer/swiftex/lib/label"
+	"github.com/techartificer/swiftex/lib/response"
+	"github.com/techartificer/swiftex/logger"
+	"github.com/techartificer/swiftex/models"
+	"github.com/techartificer/swiftex/validators"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// labelOf returns shipping label of order
+func labelOf(order *models.Order, shop *models.Shop) label.Label {
+	cod := 0.0
+	if order.PaymentStatus == constants.COD {
+		cod = order.Price
+	}
+	return label.Label{
+		TrackID:          order.TrackID,
+		ShopName:         shop.Name,
+		ShopPhone:        shop.Phone,
+		PickHub:          order.PickHub,
+		RecipientName:    order.RecipientName,
+		RecipientPhone:   order.RecipientPhone,
+		RecipientAddress: order.RecipientAddress,
+		RecipientArea:    order.RecipientArea,
+		RecipientThana:   order.RecipientThana,
+		RecipientCity:    order.RecipientCity,
+		DeliveryType:     order.DeliveryType,
+		PercelType:       order.PercelType,
+		PaymentStatus:    order.PaymentStatus,
+		PackageCode:      order.PackageCode,
+		Weight:           order.Weight,
+		NumberOfItems:    order.NumberOfItems,
+		COD:              cod,
+		Charge:           order.Charge,
+		Comments:         order.Comments,
+		CreatedAt:        order.CreatedAt,
+	}
+}
+
+// sendLabels renders labels of orders as pdf, shops are looked up if shop is nil
+func sendLabels(ctx echo.Context, orders []models.Order, shop *models.Shop, size, filename string) error {
+	resp := response.Response{}
+	db := database.GetDB()
+	shops := make(map[primitive.ObjectID]*models.Shop)
+	if shop != nil {
+		shops[shop.ID] = shop
+	}
+	var labels []label.Label
+	for i := range orders {
+		order := &orders[i]
+		s, ok := shops[order.ShopID]
+		if !ok {
+			shopRepo := data.NewShopRepo()
+			found, err := shopRepo.ShopByID(db, order.ShopID.Hex())
+			if err != nil && err != mongo.ErrNoDocuments {
+				logger.Log.Errorln(err)
+				resp.Title = "Something went wrong"
+				resp.Status = http.StatusInternalServerError
+				resp.Code = codes.DatabaseQueryFailed
+				resp.Errors = err
+				return resp.Send(ctx)
+			}
+			if found == nil {
+				found = &models.Shop{}
+			}
+			shops[order.ShopID], s = found, found
+		}
+		labels = append(labels, labelOf(order, s))
+	}
+	buf := &bytes.Buffer{}
+	if err := label.Render(buf, size, labels); err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Label generation failed"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.SomethingWentWrong
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	ctx.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`inline; filename="%s.pdf"`, filename))
+	return ctx.Blob(http.StatusOK, "application/pdf", buf.Bytes())
+}
+
+func orderLabel(ctx echo.Context) error {
+	resp := response.Response{}
+	size, err := validators.ValidateLabelSize(ctx)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Invalid label size"
+		resp.Status = http.StatusBadRequest
+		resp.Code = codes.InvalidLabelData
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	shop := ctx.Get("shop").(*models.Shop)
+	orderID, err := primitive.ObjectIDFromHex(ctx.Param("orderId"))
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Invalid order id"
+		resp.Status = http.StatusUnprocessableEntity
+		resp.Code = codes.InvalidMongoID
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	db := database.GetDB()
+	orderRepo := data.NewOrderRepo()
+	orders, err := orderRepo.OrdersForLabel(db, bson.M{"_id": orderID, "shopId": shop.ID}, 1)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	if len(*orders) == 0 {
+		resp.Title = "Order not found"
+		resp.Status = http.StatusNotFound
+		resp.Code = codes.OrderNotFound
+		resp.Errors = errors.NewError(mongo.ErrNoDocuments.Error())
+		return resp.Send(ctx)
+	}
+	return sendLabels(ctx, *orders, shop, size, "label-"+(*orders)[0].TrackID)
+}
+
+func shopLabels(ctx echo.Context) error {
+	resp := response.Response{}
+	batch, err := validators.ValidateLabelBatch(ctx, false)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Invalid label request data"
+		resp.Status = http.StatusBadRequest
+		resp.Code = codes.InvalidLabelData
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	shop := ctx.Get("shop").(*models.Shop)
+	query := bson.M{"_id": bson.M{"$in": batch.OrderIDs}, "shopId": shop.ID}
+	return batchLabels(ctx, query, shop, batch.Size)
+}
+
+// pickupRunLabels prints labels of given orders or of accepted orders waiting for pickup at a hub
+func pickupRunLabels(ctx echo.Context) error {
+	resp := response.Response{}
+	batch, err := validators.ValidateLabelBatch(ctx, true)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Invalid label request data"
+		resp.Status = http.StatusBadRequest
+		resp.Code = codes.InvalidLabelData
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	query := make(bson.M)
+	if len(batch.OrderIDs) > 0 {
+		query["_id"] = bson.M{"$in": batch.OrderIDs}
+	}
+	if batch.PickHub != "" {
+		query["pickHub"] = batch.PickHub
+		query["currentStatus"] = constants.Accepted
+	}
+	return batchLabels(ctx, query, nil, batch.Size)
+}
+
+func batchLabels(ctx echo.Context, query bson.M, shop *models.Shop, size string) error {
+	resp := response.Response{}
+	db := database.GetDB()
+	orderRepo := data.NewOrderRepo()
+	orders, err := orderRepo.OrdersForLabel(db, query, validators.MaxLabelsPerBatch)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	if len(*orders) == 0 {
+		resp.Title = "Order not found"
+		resp.Status = http.StatusNotFound
+		resp.Code = codes.OrderNotFound
+		resp.Errors = errors.NewError(mongo.ErrNoDocuments.Error())
+		return resp.Send(ctx)
+	}
+	return sendLabels(ctx, *orders, shop, size, "labels-"+time.Now().Format("20060102-1504"))
+}
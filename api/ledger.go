@@ -0,0 +1,117 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/techartificer/swiftex/constants"
+	"github.com/techartificer/swiftex/constants/codes"
+	"github.com/techartificer/swiftex/data"
+	"github.com/techartificer/swiftex/database"
+	"github.com/techartificer/swiftex/lib/response"
+	"github.com/techartificer/swiftex/logger"
+	"github.com/techartificer/swiftex/middlewares"
+	"github.com/techartificer/swiftex/models"
+)
+
+func RegisterLedgerRoutes(endpoint *echo.Group) {
+	endpoint.GET("/statement/:shopId/", shopStatement, middlewares.JWTAuth(false), middlewares.RequirePermission(constants.LedgerRead), middlewares.HasShopAccess(), middlewares.RequireShopPermission(constants.ShopFinanceView))
+	endpoint.GET("/accounts/", ledgerAccounts, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.LedgerRead))
+	endpoint.GET("/verify/", verifyLedger, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.LedgerRead))
+	endpoint.POST("/recompute/", recomputeLedger, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.SystemManage))
+}
+
+func shopStatement(ctx echo.Context) error {
+	resp := response.Response{}
+	shopID, lastID := ctx.Param("shopId"), ctx.QueryParam("lastId")
+	startDate, endDate := ctx.QueryParam("startDate"), ctx.QueryParam("endDate")
+	var tms, tme time.Time
+	if startDate != "" && endDate != "" {
+		std, err := strconv.ParseInt(startDate, 10, 64) // startDate
+		if err != nil {
+			logger.Log.Errorln(err)
+			resp.Title = "Invalid timestamp"
+			resp.Status = http.StatusUnprocessableEntity
+			resp.Code = codes.SomethingWentWrong
+			resp.Errors = err
+			return resp.Send(ctx)
+		}
+		tms = time.Unix(std/1000, 0) //std => startDate
+		end, err := strconv.ParseInt(endDate, 10, 64)
+		if err != nil {
+			logger.Log.Errorln(err)
+			resp.Title = "Invalid timestamp"
+			resp.Status = http.StatusUnprocessableEntity
+			resp.Code = codes.SomethingWentWrong
+			resp.Errors = err
+			return resp.Send(ctx)
+		}
+		tme = time.Unix(end/1000, 0)
+	}
+	db := database.GetDB()
+	ledgerRepo := data.NewLedgerRepo()
+	statement, err := ledgerRepo.Statement(db, shopID, lastID, &tms, &tme)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	resp.Data = statement
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
+
+func ledgerAccounts(ctx echo.Context) error {
+	resp := response.Response{}
+	accountType, lastID := ctx.QueryParam("type"), ctx.QueryParam("lastId")
+	db := database.GetDB()
+	ledgerRepo := data.NewLedgerRepo()
+	accounts, err := ledgerRepo.Accounts(db, accountType, lastID)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	resp.Data = func() []models.LedgerAccount {
+		if *accounts == nil {
+			return []models.LedgerAccount{}
+		}
+		return *accounts
+	}()
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
+
+func verifyLedger(ctx echo.Context) error {
+	return ledgerVerification(ctx, false)
+}
+
+func recomputeLedger(ctx echo.Context) error {
+	return ledgerVerification(ctx, true)
+}
+
+func ledgerVerification(ctx echo.Context, fix bool) error {
+	resp := response.Response{}
+	db := database.GetDB()
+	ledgerRepo := data.NewLedgerRepo()
+	mismatches, err := ledgerRepo.Verify(db, fix)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	resp.Data = mismatches
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
@@ -0,0 +1,591 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/techartificer/swiftex/constants"
+	"github.com/techartificer/swiftex/constants/codes"
+	"github.com/techartificer/swiftex/data"
+	"github.com/techartificer/swiftex/database"
+	"github.com/techartificer/swiftex/lib/errors"
+	"github.com/techartificer/swiftex/lib/response"
+	"github.com/techartificer/swiftex/logger"
+	"github.com/techartificer/swiftex/middlewares"
+	"github.com/techartificer/swiftex/models"
+	"github.com/techartificer/swiftex/validators"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func RegisterLocationRoutes(endpoint *echo.Group) {
+	endpoint.POST("/hub/", createHub, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.LocationManage))
+	endpoint.GET("/hub/", hubs, middlewares.JWTAuth(false), middlewares.RequirePermission(constants.LocationRead))
+	endpoint.PATCH("/hub/:hubId/", updateHub, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.LocationManage))
+	endpoint.GET("/hub/:hubId/coverage/", hubCoverage, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.LocationRead))
+	endpoint.PATCH("/hub/:hubId/coverage/", updateHubCoverage, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.LocationManage))
+	endpoint.POST("/city/", createCity, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.LocationManage))
+	endpoint.GET("/city/", cities, middlewares.JWTAuth(false), middlewares.RequirePermission(constants.LocationRead))
+	endpoint.PATCH("/city/:cityId/", updateCity, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.LocationManage))
+	endpoint.POST("/thana/", createThana, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.LocationManage))
+	endpoint.GET("/thana/", thanas, middlewares.JWTAuth(false), middlewares.RequirePermission(constants.LocationRead))
+	endpoint.PATCH("/thana/:thanaId/", updateThana, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.LocationManage))
+	endpoint.POST("/area/", createArea, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.LocationManage))
+	endpoint.GET("/area/", areas, middlewares.JWTAuth(false), middlewares.RequirePermission(constants.LocationRead))
+	endpoint.PATCH("/area/:areaId/", updateArea, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.LocationManage))
+	endpoint.POST("/migrate/", migrateLocations, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.SystemManage))
+}
+
+// locationError returns title and code of registry lookup errors
+func locationError(err error) (string, codes.ErrorCode, bool) {
+	switch err.Error() {
+	case string(codes.HubNotFound):
+		return "Hub not found", codes.HubNotFound, true
+	case string(codes.CityNotFound):
+		return "City not found", codes.CityNotFound, true
+	case string(codes.ThanaNotFound):
+		return "Thana not found", codes.ThanaNotFound, true
+	case string(codes.AreaNotFound):
+		return "Area not found", codes.AreaNotFound, true
+	}
+	return "", "", false
+}
+
+// resolveLocation validates pickup hub and recipient address of an order against registry,
+// sets registry ids and canonical names to the order
+func resolveLocation(db *mongo.Database, order *models.Order) error {
+	locationRepo := data.NewLocationRepo()
+	location, err := locationRepo.ResolveOrderLocation(db, order.PickHub, order.RecipientCity, order.RecipientThana, order.RecipientArea)
+	if err != nil {
+		return err
+	}
+	order.PickHub = location.PickHub.Name
+	order.PickHubID = &location.PickHub.ID
+	order.RecipientCity = location.City.Name
+	order.RecipientCityID = &location.City.ID
+	if location.Thana != nil {
+		order.RecipientThana = location.Thana.Name
+		order.RecipientThanaID = &location.Thana.ID
+	}
+	order.RecipientArea = location.Area.Name
+	order.RecipientAreaID = &location.Area.ID
+	order.DeliveryHubID = location.DeliveryHubID
+	return nil
+}
+
+// sendLocationError sends registry lookup error or internal error
+func sendLocationError(ctx echo.Context, err error) error {
+	resp := response.Response{}
+	if title, code, ok := locationError(err); ok {
+		resp.Title = title
+		resp.Status = http.StatusNotFound
+		resp.Code = code
+		resp.Errors = errors.NewError(title)
+		return resp.Send(ctx)
+	}
+	resp.Title = "Something went wrong"
+	resp.Status = http.StatusInternalServerError
+	resp.Code = codes.DatabaseQueryFailed
+	resp.Errors = err
+	return resp.Send(ctx)
+}
+
+func createHub(ctx echo.Context) error {
+	resp := response.Response{}
+	hub, err := validators.ValidateHubCreate(ctx)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Invalid hub request data"
+		resp.Status = http.StatusBadRequest
+		resp.Code = codes.InvalidLocationData
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	db := database.GetDB()
+	locationRepo := data.NewLocationRepo()
+	if err := locationRepo.CreateHub(db, hub); err != nil {
+		logger.Log.Errorln(err)
+		if errors.IsMongoDupError(err) {
+			resp.Title = "Hub already exist"
+			resp.Status = http.StatusConflict
+			resp.Code = codes.LocationAlreadyExist
+			resp.Errors = err
+			return resp.Send(ctx)
+		}
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	resp.Data = hub
+	resp.Status = http.StatusCreated
+	return resp.Send(ctx)
+}
+
+func hubs(ctx echo.Context) error {
+	resp := response.Response{}
+	db := database.GetDB()
+	locationRepo := data.NewLocationRepo()
+	hubs, err := locationRepo.Hubs(db, ctx.QueryParam("status"))
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	resp.Data = func() []models.Hub {
+		if *hubs == nil {
+			return []models.Hub{}
+		}
+		return *hubs
+	}()
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
+
+func updateHub(ctx echo.Context) error {
+	resp := response.Response{}
+	hub, err := validators.ValidateHubUpdate(ctx)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Invalid hub request data"
+		resp.Status = http.StatusBadRequest
+		resp.Code = codes.InvalidLocationData
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	db := database.GetDB()
+	locationRepo := data.NewLocationRepo()
+	updatedHub, err := locationRepo.UpdateHub(db, ctx.Param("hubId"), hub)
+	if err != nil {
+		logger.Log.Errorln(err)
+		if err == mongo.ErrNoDocuments {
+			resp.Title = "Hub not found"
+			resp.Status = http.StatusNotFound
+			resp.Code = codes.HubNotFound
+			resp.Errors = errors.NewError(err.Error())
+			return resp.Send(ctx)
+		}
+		if errors.IsMongoDupError(err) {
+			resp.Title = "Hub already exist"
+			resp.Status = http.StatusConflict
+			resp.Code = codes.LocationAlreadyExist
+			resp.Errors = err
+			return resp.Send(ctx)
+		}
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	resp.Data = updatedHub
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
+
+func hubCoverage(ctx echo.Context) error {
+	resp := response.Response{}
+	hubID, err := primitive.ObjectIDFromHex(ctx.Param("hubId"))
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Invalid hub ID"
+		resp.Status = http.StatusUnprocessableEntity
+		resp.Code = codes.InvalidMongoID
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	db := database.GetDB()
+	locationRepo := data.NewLocationRepo()
+	areas, err := locationRepo.Areas(db, bson.M{"hubId": hubID})
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	resp.Data = func() []models.Area {
+		if *areas == nil {
+			return []models.Area{}
+		}
+		return *areas
+	}()
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
+
+func updateHubCoverage(ctx echo.Context) error {
+	resp := response.Response{}
+	body, err := validators.ValidateCoverage(ctx)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Invalid coverage request data"
+		resp.Status = http.StatusBadRequest
+		resp.Code = codes.InvalidLocationData
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	db := database.GetDB()
+	locationRepo := data.NewLocationRepo()
+	areas, err := locationRepo.SetCoverage(db, ctx.Param("hubId"), body.Add, body.Remove)
+	if err != nil {
+		logger.Log.Errorln(err)
+		if err == mongo.ErrNoDocuments {
+			resp.Title = "Hub not found"
+			resp.Status = http.StatusNotFound
+			resp.Code = codes.HubNotFound
+			resp.Errors = errors.NewError(err.Error())
+			return resp.Send(ctx)
+		}
+		return sendLocationError(ctx, err)
+	}
+	resp.Data = func() []models.Area {
+		if *areas == nil {
+			return []models.Area{}
+		}
+		return *areas
+	}()
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
+
+func createCity(ctx echo.Context) error {
+	resp := response.Response{}
+	city, err := validators.ValidateCityCreate(ctx)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Invalid city request data"
+		resp.Status = http.StatusBadRequest
+		resp.Code = codes.InvalidLocationData
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	db := database.GetDB()
+	locationRepo := data.NewLocationRepo()
+	if err := locationRepo.CreateCity(db, city); err != nil {
+		logger.Log.Errorln(err)
+		if errors.IsMongoDupError(err) {
+			resp.Title = "City already exist"
+			resp.Status = http.StatusConflict
+			resp.Code = codes.LocationAlreadyExist
+			resp.Errors = err
+			return resp.Send(ctx)
+		}
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	resp.Data = city
+	resp.Status = http.StatusCreated
+	return resp.Send(ctx)
+}
+
+func cities(ctx echo.Context) error {
+	resp := response.Response{}
+	db := database.GetDB()
+	locationRepo := data.NewLocationRepo()
+	cities, err := locationRepo.Cities(db, ctx.QueryParam("status"))
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	resp.Data = func() []models.City {
+		if *cities == nil {
+			return []models.City{}
+		}
+		return *cities
+	}()
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
+
+func updateCity(ctx echo.Context) error {
+	resp := response.Response{}
+	body, err := validators.ValidateLocationUpdate(ctx)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Invalid city request data"
+		resp.Status = http.StatusBadRequest
+		resp.Code = codes.InvalidLocationData
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	db := database.GetDB()
+	locationRepo := data.NewLocationRepo()
+	city, err := locationRepo.UpdateCity(db, ctx.Param("cityId"), &models.City{Name: body.Name, Status: body.Status})
+	if err != nil {
+		logger.Log.Errorln(err)
+		if err == mongo.ErrNoDocuments {
+			resp.Title = "City not found"
+			resp.Status = http.StatusNotFound
+			resp.Code = codes.CityNotFound
+			resp.Errors = errors.NewError(err.Error())
+			return resp.Send(ctx)
+		}
+		if errors.IsMongoDupError(err) {
+			resp.Title = "City already exist"
+			resp.Status = http.StatusConflict
+			resp.Code = codes.LocationAlreadyExist
+			resp.Errors = err
+			return resp.Send(ctx)
+		}
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	resp.Data = city
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
+
+func createThana(ctx echo.Context) error {
+	resp := response.Response{}
+	thana, err := validators.ValidateThanaCreate(ctx)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Invalid thana request data"
+		resp.Status = http.StatusBadRequest
+		resp.Code = codes.InvalidLocationData
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	db := database.GetDB()
+	locationRepo := data.NewLocationRepo()
+	if err := locationRepo.CreateThana(db, thana); err != nil {
+		logger.Log.Errorln(err)
+		if errors.IsMongoDupError(err) {
+			resp.Title = "Thana already exist"
+			resp.Status = http.StatusConflict
+			resp.Code = codes.LocationAlreadyExist
+			resp.Errors = err
+			return resp.Send(ctx)
+		}
+		return sendLocationError(ctx, err)
+	}
+	resp.Data = thana
+	resp.Status = http.StatusCreated
+	return resp.Send(ctx)
+}
+
+func thanas(ctx echo.Context) error {
+	resp := response.Response{}
+	db := database.GetDB()
+	locationRepo := data.NewLocationRepo()
+	thanas, err := locationRepo.Thanas(db, ctx.QueryParam("cityId"), ctx.QueryParam("status"))
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	resp.Data = func() []models.Thana {
+		if *thanas == nil {
+			return []models.Thana{}
+		}
+		return *thanas
+	}()
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
+
+func updateThana(ctx echo.Context) error {
+	resp := response.Response{}
+	body, err := validators.ValidateLocationUpdate(ctx)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Invalid thana request data"
+		resp.Status = http.StatusBadRequest
+		resp.Code = codes.InvalidLocationData
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	db := database.GetDB()
+	locationRepo := data.NewLocationRepo()
+	thana, err := locationRepo.UpdateThana(db, ctx.Param("thanaId"), &models.Thana{Name: body.Name, Status: body.Status})
+	if err != nil {
+		logger.Log.Errorln(err)
+		if err == mongo.ErrNoDocuments {
+			resp.Title = "Thana not found"
+			resp.Status = http.StatusNotFound
+			resp.Code = codes.ThanaNotFound
+			resp.Errors = errors.NewError(err.Error())
+			return resp.Send(ctx)
+		}
+		if errors.IsMongoDupError(err) {
+			resp.Title = "Thana already exist"
+			resp.Status = http.StatusConflict
+			resp.Code = codes.LocationAlreadyExist
+			resp.Errors = err
+			return resp.Send(ctx)
+		}
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	resp.Data = thana
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
+
+func createArea(ctx echo.Context) error {
+	resp := response.Response{}
+	area, err := validators.ValidateAreaCreate(ctx)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Invalid area request data"
+		resp.Status = http.StatusBadRequest
+		resp.Code = codes.InvalidLocationData
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	db := database.GetDB()
+	locationRepo := data.NewLocationRepo()
+	if err := locationRepo.CreateArea(db, area); err != nil {
+		logger.Log.Errorln(err)
+		if errors.IsMongoDupError(err) {
+			resp.Title = "Area already exist"
+			resp.Status = http.StatusConflict
+			resp.Code = codes.LocationAlreadyExist
+			resp.Errors = err
+			return resp.Send(ctx)
+		}
+		return sendLocationError(ctx, err)
+	}
+	resp.Data = area
+	resp.Status = http.StatusCreated
+	return resp.Send(ctx)
+}
+
+func areas(ctx echo.Context) error {
+	resp := response.Response{}
+	query := make(bson.M)
+	for _, param := range []string{"cityId", "thanaId", "hubId"} {
+		value := ctx.QueryParam(param)
+		if value == "" {
+			continue
+		}
+		_id, err := primitive.ObjectIDFromHex(value)
+		if err != nil {
+			logger.Log.Errorln(err)
+			resp.Title = "Invalid " + param
+			resp.Status = http.StatusUnprocessableEntity
+			resp.Code = codes.InvalidMongoID
+			resp.Errors = err
+			return resp.Send(ctx)
+		}
+		query[param] = _id
+	}
+	if status := ctx.QueryParam("status"); status != "" {
+		query["status"] = status
+	}
+	db := database.GetDB()
+	locationRepo := data.NewLocationRepo()
+	areas, err := locationRepo.Areas(db, query)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	resp.Data = func() []models.Area {
+		if *areas == nil {
+			return []models.Area{}
+		}
+		return *areas
+	}()
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
+
+func updateArea(ctx echo.Context) error {
+	resp := response.Response{}
+	body, err := validators.ValidateLocationUpdate(ctx)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Invalid area request data"
+		resp.Status = http.StatusBadRequest
+		resp.Code = codes.InvalidLocationData
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	area := &models.Area{Name: body.Name, Status: body.Status}
+	if body.ThanaID != "" {
+		thanaID, err := primitive.ObjectIDFromHex(body.ThanaID)
+		if err != nil {
+			logger.Log.Errorln(err)
+			resp.Title = "Invalid thana ID"
+			resp.Status = http.StatusUnprocessableEntity
+			resp.Code = codes.InvalidMongoID
+			resp.Errors = err
+			return resp.Send(ctx)
+		}
+		area.ThanaID = &thanaID
+	}
+	db := database.GetDB()
+	locationRepo := data.NewLocationRepo()
+	updatedArea, err := locationRepo.UpdateArea(db, ctx.Param("areaId"), area)
+	if err != nil {
+		logger.Log.Errorln(err)
+		if err == mongo.ErrNoDocuments {
+			resp.Title = "Area not found"
+			resp.Status = http.StatusNotFound
+			resp.Code = codes.AreaNotFound
+			resp.Errors = errors.NewError(err.Error())
+			return resp.Send(ctx)
+		}
+		if errors.IsMongoDupError(err) {
+			resp.Title = "Area already exist"
+			resp.Status = http.StatusConflict
+			resp.Code = codes.LocationAlreadyExist
+			resp.Errors = err
+			return resp.Send(ctx)
+		}
+		return sendLocationError(ctx, err)
+	}
+	resp.Data = updatedArea
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
+
+// migrateLocations maps free text hubs and zones to registry,
+// missing registry entries are created with create=true, dryRun=true only reports
+func migrateLocations(ctx echo.Context) error {
+	resp := response.Response{}
+	dryRun, _ := strconv.ParseBool(ctx.QueryParam("dryRun"))
+	create, _ := strconv.ParseBool(ctx.QueryParam("create"))
+	db := database.GetDB()
+	locationRepo := data.NewLocationRepo()
+	report, err := locationRepo.Migrate(db, dryRun, create)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	resp.Data = report
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
@@ -26,7 +26,7 @@ import (
 func RegisterMerchantRoutes(endpoint *echo.Group) {
 	endpoint.POST("/register/", register)
 	endpoint.GET("/is-available/:phone/", isUsernameAvilable)
-	endpoint.GET("/", allMerchants, middlewares.JWTAuth(true))
+	endpoint.GET("/", allMerchants, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.MerchantRead))
 	endpoint.PATCH("/forgot-password/", forgotPassword)
 }
 
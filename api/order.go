@@ -11,13 +11,14 @@ import (
 	"github.com/techartificer/swiftex/constants/codes"
 	"github.com/techartificer/swiftex/data"
 	"github.com/techartificer/swiftex/database"
-	"github.com/techartificer/swiftex/lib/charge"
 	"github.com/techartificer/swiftex/lib/errors"
+	"github.com/techartificer/swiftex/lib/orderstatus"
 	"github.com/techartificer/swiftex/lib/random"
 	"github.com/techartificer/swiftex/lib/response"
 	"github.com/techartificer/swiftex/logger"
 	"github.com/techartificer/swiftex/middlewares"
 	"github.com/techartificer/swiftex/models"
+	"github.com/techartificer/swiftex/serializer"
 	"github.com/techartificer/swiftex/validators"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -30,24 +31,37 @@ type orderError struct {
 }
 
 func RegisterOrderRoutes(endpoint *echo.Group) {
-	endpoint.GET("/", ordersAdmin, middlewares.JWTAuth(true))
-	endpoint.POST("/create/:shopId/", orderCreate, middlewares.JWTAuth(false), middlewares.HasShopAccess(), middlewares.ShopByID())
-	endpoint.GET("/all/:shopId/", orders, middlewares.JWTAuth(false), middlewares.HasShopAccess())
-	endpoint.PATCH("/id/:orderId/shopId/:shopId/", updateOrder, middlewares.JWTAuth(false), middlewares.HasShopAccess(), middlewares.ShopByID())
-	endpoint.PATCH("/add/order-status/:orderId/", addOrderStatus, middlewares.JWTAuth(true)) // TODO: Delivery boy access
-	endpoint.PATCH("/cancel/id/:orderId/shopId/:shopId/", cancelOrder, middlewares.JWTAuth(false), middlewares.HasShopAccess())
-	endpoint.GET("/id/:orderId/shopId/:shopId/", orderByID, middlewares.JWTAuth(false), middlewares.HasShopAccess())
+	endpoint.GET("/", ordersAdmin, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.OrderRead), middlewares.HubScope())
+	endpoint.POST("/create/:shopId/", orderCreate, middlewares.JWTAuth(false), middlewares.RequirePermission(constants.OrderWrite), middlewares.HasShopAccess(), middlewares.RequireShopPermission(constants.ShopOrderCreate), middlewares.ShopByID(), middlewares.Idempotency(), middlewares.Audit(middlewares.AuditOrder, ""))
+	endpoint.GET("/all/:shopId/", orders, middlewares.JWTAuth(false), middlewares.RequirePermission(constants.OrderRead), middlewares.HasShopAccess())
+	endpoint.PATCH("/id/:orderId/shopId/:shopId/", updateOrder, middlewares.JWTAuth(false), middlewares.RequirePermission(constants.OrderWrite), middlewares.HasShopAccess(), middlewares.RequireShopPermission(constants.ShopOrderCreate), middlewares.ShopByID(), middlewares.Audit(middlewares.AuditOrder, "orderId"))
+	endpoint.PATCH("/add/order-status/:orderId/", addOrderStatus, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.OrderStatusWrite), middlewares.HubScope(), middlewares.Audit(middlewares.AuditOrder, "orderId")) // TODO: Delivery boy access
+	endpoint.PATCH("/cancel/id/:orderId/shopId/:shopId/", cancelOrder, middlewares.JWTAuth(false), middlewares.RequirePermission(constants.OrderWrite), middlewares.HasShopAccess(), middlewares.RequireShopPermission(constants.ShopOrderCreate), middlewares.Audit(middlewares.AuditOrder, "orderId"))
+	endpoint.GET("/id/:orderId/shopId/:shopId/", orderByID, middlewares.JWTAuth(false), middlewares.RequirePermission(constants.OrderRead), middlewares.HasShopAccess())
 	endpoint.GET("/track/:trackId/", trackOrder)
-	endpoint.POST("/assign-rider/", assignRider, middlewares.JWTAuth(true))
-	endpoint.GET("/riders-parcel/:riderId/", ridersParcel, middlewares.RiderJWTAuth())
-	endpoint.POST("/deliver/:orderId/", deliverParcel, middlewares.RiderJWTAuth())
-	endpoint.PATCH("/change/status/", changeStatus, middlewares.JWTAuth(true))
-	endpoint.POST("/create/:shopId/multiples/", createMultipleOrder, middlewares.JWTAuth(false), middlewares.HasShopAccess(), middlewares.ShopByID())
+	endpoint.POST("/dispatch/preview/", dispatchPreview, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.OrderAssign), middlewares.HubScope())
+	endpoint.POST("/dispatch/", dispatchCommit, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.OrderAssign), middlewares.HubScope(), middlewares.Audit(middlewares.AuditOrder, ""))
+	endpoint.POST("/assign-rider/", assignRider, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.OrderAssign), middlewares.HubScope(), middlewares.Audit(middlewares.AuditOrder, ""))
+	endpoint.GET("/riders-parcel/:riderId/", ridersParcel, middlewares.RiderJWTAuth(), middlewares.RequirePermission(constants.OrderRead))
+	endpoint.POST("/deliver/:orderId/otp/", requestDeliveryOTP, middlewares.RiderJWTAuth(), middlewares.RequirePermission(constants.OrderStatusWrite), middlewares.Audit(middlewares.AuditOrder, "orderId"))
+	endpoint.POST("/deliver/:orderId/", deliverParcel, middlewares.RiderJWTAuth(), middlewares.RequirePermission(constants.OrderStatusWrite), middlewares.Audit(middlewares.AuditOrder, "orderId"))
+	endpoint.PATCH("/parcel/:parcelId/accept/", acceptParcel, middlewares.RiderJWTAuth(), middlewares.RequirePermission(constants.OrderStatusWrite), middlewares.Audit(middlewares.AuditRiderParcel, "parcelId"))
+	endpoint.PATCH("/parcel/:parcelId/pickup/", pickupParcel, middlewares.RiderJWTAuth(), middlewares.RequirePermission(constants.OrderStatusWrite), middlewares.Audit(middlewares.AuditRiderParcel, "parcelId"))
+	endpoint.PATCH("/parcel/:parcelId/attempt/", failParcelAttempt, middlewares.RiderJWTAuth(), middlewares.RequirePermission(constants.OrderStatusWrite), middlewares.Audit(middlewares.AuditRiderParcel, "parcelId"))
+	endpoint.PATCH("/parcel/:parcelId/return/", returnParcel, middlewares.RiderJWTAuth(), middlewares.RequirePermission(constants.OrderStatusWrite), middlewares.Audit(middlewares.AuditRiderParcel, "parcelId"))
+	endpoint.PATCH("/change/status/", changeStatus, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.OrderStatusWrite), middlewares.HubScope(), middlewares.Audit(middlewares.AuditOrder, ""))
+	endpoint.GET("/next-status/:orderId/", nextOrderStatus, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.OrderRead), middlewares.HubScope())
+	endpoint.POST("/create/:shopId/multiples/", createMultipleOrder, middlewares.JWTAuth(false), middlewares.RequirePermission(constants.OrderWrite), middlewares.HasShopAccess(), middlewares.RequireShopPermission(constants.ShopOrderCreate), middlewares.ShopByID(), middlewares.Idempotency(), middlewares.Audit(middlewares.AuditOrder, ""))
+	endpoint.GET("/import/template/", orderImportTemplate, middlewares.JWTAuth(false), middlewares.RequirePermission(constants.OrderWrite))
+	endpoint.GET("/label/:orderId/shopId/:shopId/", orderLabel, middlewares.JWTAuth(false), middlewares.RequirePermission(constants.OrderRead), middlewares.HasShopAccess())
+	endpoint.POST("/labels/:shopId/", shopLabels, middlewares.JWTAuth(false), middlewares.RequirePermission(constants.OrderRead), middlewares.HasShopAccess())
+	endpoint.POST("/labels/", pickupRunLabels, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.OrderRead))
+	endpoint.POST("/import/:shopId/", importOrders, middlewares.JWTAuth(false), middlewares.RequirePermission(constants.OrderWrite), middlewares.HasShopAccess(), middlewares.RequireShopPermission(constants.ShopOrderCreate), middlewares.ShopByID(), middlewares.Idempotency(), middlewares.Audit(middlewares.AuditOrder, ""))
 }
 
 func deliverParcel(ctx echo.Context) error {
 	resp := response.Response{}
-	trxHistory, err := validators.ValidateOrderDeliver(ctx)
+	trxHistory, proofReq, deliveredItems, err := validators.ValidateOrderDeliver(ctx)
 	if err != nil {
 		logger.Log.Errorln(err)
 		resp.Title = "Invalid delivery request data"
@@ -59,9 +73,68 @@ func deliverParcel(ctx echo.Context) error {
 	trxRepo := data.NewTransactionRepo()
 	db := database.GetDB()
 
-	result, err := trxRepo.AddTrxHistory(db, trxHistory)
+	proof := &models.DeliveryProof{Time: time.Now().UTC()}
+	otpHash := ""
+	if proofReq.OTP != "" {
+		orderRepo := data.NewOrderRepo()
+		if otpHash, err = orderRepo.VerifyDeliveryOTP(db, *trxHistory.OrderID, trxHistory.CreatedBy, proofReq.OTP); err != nil {
+			logger.Log.Errorln(err)
+			if mongo.ErrNoDocuments == err {
+				resp.Title = "Order not found"
+				resp.Status = http.StatusNotFound
+				resp.Code = codes.OrderNotFound
+				resp.Errors = errors.NewError(err.Error())
+				return resp.Send(ctx)
+			}
+			if err.Error() == string(codes.InvalidDeliveryOTP) {
+				resp.Title = "Invalid delivery code"
+				resp.Status = http.StatusForbidden
+				resp.Code = codes.InvalidDeliveryOTP
+				resp.Errors = err
+				return resp.Send(ctx)
+			}
+			if err.Error() == string(codes.DeliveryOTPExpired) {
+				resp.Title = "Delivery code expired"
+				resp.Status = http.StatusForbidden
+				resp.Code = codes.DeliveryOTPExpired
+				resp.Errors = err
+				return resp.Send(ctx)
+			}
+			if err.Error() == string(codes.TooManyRequest) {
+				resp.Title = "Too many wrong delivery codes, request a new code"
+				resp.Status = http.StatusTooManyRequests
+				resp.Code = codes.TooManyRequest
+				resp.Errors = err
+				return resp.Send(ctx)
+			}
+			resp.Title = "Something went wrong"
+			resp.Status = http.StatusInternalServerError
+			resp.Code = codes.DatabaseQueryFailed
+			resp.Errors = err
+			return resp.Send(ctx)
+		}
+		proof.OTPVerified = true
+	}
+	if err := storeDeliveryProof(*trxHistory.OrderID, proofReq, proof); err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Failed to store delivery proof"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.FileUploadFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+
+	result, err := trxRepo.AddTrxHistory(db, trxHistory, proof, deliveredItems, otpHash)
 	if err != nil {
 		logger.Log.Errorln(err)
+		deleteDeliveryProof(proof)
+		if err.Error() == string(codes.ProofRequired) {
+			resp.Title = "Proof of delivery required by the shop is missing"
+			resp.Status = http.StatusUnprocessableEntity
+			resp.Code = codes.ProofRequired
+			resp.Errors = err
+			return resp.Send(ctx)
+		}
 		if mongo.ErrNoDocuments == err {
 			resp.Title = "Order not found"
 			resp.Status = http.StatusNotFound
@@ -69,6 +142,34 @@ func deliverParcel(ctx echo.Context) error {
 			resp.Errors = err
 			return resp.Send(ctx)
 		}
+		if err.Error() == string(codes.InvalidDeliveredItems) {
+			resp.Title = "Delivered items can not be more than items of the order"
+			resp.Status = http.StatusBadRequest
+			resp.Code = codes.InvalidDeliveredItems
+			resp.Errors = err
+			return resp.Send(ctx)
+		}
+		if err.Error() == string(codes.InvalidDeliveryOTP) {
+			resp.Title = "Delivery code was changed or already used"
+			resp.Status = http.StatusForbidden
+			resp.Code = codes.InvalidDeliveryOTP
+			resp.Errors = err
+			return resp.Send(ctx)
+		}
+		if err.Error() == string(codes.OrderNotAssignedToRider) {
+			resp.Title = "Order is not assigned to you"
+			resp.Status = http.StatusForbidden
+			resp.Code = codes.OrderNotAssignedToRider
+			resp.Errors = err
+			return resp.Send(ctx)
+		}
+		if err.Error() == string(codes.InvalidCollectedAmount) {
+			resp.Title = "Collected amount can not be more than price of the order"
+			resp.Status = http.StatusBadRequest
+			resp.Code = codes.InvalidCollectedAmount
+			resp.Errors = err
+			return resp.Send(ctx)
+		}
 		if err.Error() == string(codes.OrderAlreadyDelevired) {
 			resp.Title = "Order already delivered"
 			resp.Status = http.StatusUnprocessableEntity
@@ -97,6 +198,13 @@ func deliverParcel(ctx echo.Context) error {
 			resp.Errors = err
 			return resp.Send(ctx)
 		}
+		if err.Error() == string(codes.InvalidStatusTransition) {
+			resp.Title = "Order status transition not allowed"
+			resp.Status = http.StatusUnprocessableEntity
+			resp.Code = codes.InvalidStatusTransition
+			resp.Errors = err
+			return resp.Send(ctx)
+		}
 		resp.Title = "Something went wrong"
 		resp.Status = http.StatusInternalServerError
 		resp.Code = codes.DatabaseQueryFailed
@@ -146,6 +254,36 @@ func assignRider(ctx echo.Context) error {
 		return resp.Send(ctx)
 	}
 	db := database.GetDB()
+	if sent, err := checkOrdersScope(ctx, db, body.OrderID); sent {
+		return err
+	}
+	middlewares.AuditTargets(ctx, body.OrderID)
+	if _, ok := hubScope(ctx); ok {
+		riderRepo := data.NewRiderRepo()
+		rider, err := riderRepo.FindByID(db, body.RiderID.Hex())
+		if err != nil {
+			logger.Log.Errorln(err)
+			if err == mongo.ErrNoDocuments {
+				resp.Title = "Rider not found"
+				resp.Status = http.StatusNotFound
+				resp.Code = codes.RiderNotFound
+				resp.Errors = errors.NewError(err.Error())
+				return resp.Send(ctx)
+			}
+			resp.Title = "Something went wrong"
+			resp.Status = http.StatusInternalServerError
+			resp.Code = codes.DatabaseQueryFailed
+			resp.Errors = err
+			return resp.Send(ctx)
+		}
+		if !isRiderInScope(ctx, rider) {
+			resp.Title = "Rider is out of your hubs"
+			resp.Status = http.StatusForbidden
+			resp.Code = codes.OutOfHubScope
+			resp.Errors = errors.NewError("Rider is out of your hubs")
+			return resp.Send(ctx)
+		}
+	}
 	riderParcelRepo := data.NewRiderParcelRepo()
 	order, err := riderParcelRepo.Create(db, body)
 	if err != nil {
@@ -171,6 +309,20 @@ func assignRider(ctx echo.Context) error {
 			resp.Errors = err
 			return resp.Send(ctx)
 		}
+		if err.Error() == string(codes.InvalidStatusTransition) {
+			resp.Title = "Order status transition not allowed"
+			resp.Status = http.StatusUnprocessableEntity
+			resp.Code = codes.InvalidStatusTransition
+			resp.Errors = err
+			return resp.Send(ctx)
+		}
+		if err.Error() == string(codes.RiderCashLimitExceeded) {
+			resp.Title = "Rider is holding more cash than the limit, cash has to be deposited first"
+			resp.Status = http.StatusLocked
+			resp.Code = codes.RiderCashLimitExceeded
+			resp.Errors = err
+			return resp.Send(ctx)
+		}
 		resp.Title = "Something went wrong"
 		resp.Status = http.StatusInternalServerError
 		resp.Code = codes.DatabaseQueryFailed
@@ -193,6 +345,33 @@ func ordersAdmin(ctx echo.Context) error {
 	if deliveryZone != "" {
 		query["recipientArea"] = primitive.Regex{Pattern: deliveryZone, Options: "i"}
 	}
+	if areaID := ctx.QueryParam("areaId"); areaID != "" {
+		_areaID, err := primitive.ObjectIDFromHex(areaID)
+		if err != nil {
+			logger.Log.Errorln(err)
+			resp.Title = "Invalid area ID"
+			resp.Status = http.StatusUnprocessableEntity
+			resp.Code = codes.InvalidMongoID
+			resp.Errors = err
+			return resp.Send(ctx)
+		}
+		query["recipientAreaId"] = _areaID
+	}
+	if hubID := ctx.QueryParam("hubId"); hubID != "" {
+		_hubID, err := primitive.ObjectIDFromHex(hubID)
+		if err != nil {
+			logger.Log.Errorln(err)
+			resp.Title = "Invalid hub ID"
+			resp.Status = http.StatusUnprocessableEntity
+			resp.Code = codes.InvalidMongoID
+			resp.Errors = err
+			return resp.Send(ctx)
+		}
+		query["deliveryHubId"] = _hubID
+	}
+	if hubs, ok := hubScope(ctx); ok {
+		query["$or"] = orderScope(hubs)
+	}
 	if shopID != "" {
 		_shopID, err := primitive.ObjectIDFromHex(shopID)
 		if err != nil {
@@ -283,7 +462,12 @@ func trackOrder(ctx echo.Context) error {
 		resp.Errors = err
 		return resp.Send(ctx)
 	}
-	resp.Data = order.Status
+	setProofURLs(order)
+	resp.Data = serializer.OrderTracking{
+		TrackID: order.TrackID,
+		Status:  order.Status,
+		Proof:   order.Proof,
+	}
 	resp.Status = http.StatusOK
 	return resp.Send(ctx)
 }
@@ -319,7 +503,6 @@ func updateOrder(ctx echo.Context) error {
 		resp.Errors = err
 		return resp.Send(ctx)
 	}
-	body.Charge = charge.Calculate(body.Weight, body.DeliveryType, body.RecipientCity, shop.DeliveryCharge)
 	if order.DeliveredAt != nil || order.IsPicked || order.IsCancelled {
 		resp.Title = "You can not update parcel"
 		resp.Status = http.StatusLocked
@@ -327,6 +510,50 @@ func updateOrder(ctx echo.Context) error {
 		resp.Errors = errors.NewError("Parcel status is not allowing to update")
 		return resp.Send(ctx)
 	}
+	if body.PickHub == "" {
+		body.PickHub = order.PickHub
+	}
+	if body.RecipientArea == "" {
+		body.RecipientArea = order.RecipientArea
+		if body.RecipientThana == "" {
+			body.RecipientThana = order.RecipientThana
+		}
+	}
+	if err := resolveLocation(db, body); err != nil {
+		logger.Log.Errorln(err)
+		return sendLocationError(ctx, err)
+	}
+	parcel := parcelOf(body)
+	if parcel.PercelType == "" {
+		parcel.PercelType = order.PercelType
+	}
+	if parcel.PaymentStatus == "" {
+		parcel.PaymentStatus = order.PaymentStatus
+	}
+	if parcel.Price == 0 {
+		parcel.Price = order.Price
+	}
+	if parcel.ToZone == "" {
+		parcel.ToZone = order.RecipientCity
+	}
+	if parcel.Weight == 0 {
+		parcel.Weight = order.Weight
+	}
+	if parcel.DeliveryType == "" {
+		parcel.DeliveryType = order.DeliveryType
+	}
+	breakdown, err := quoteCharge(db, &shop, parcel)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	body.Charge = breakdown.Charge
+	body.CODCharge = breakdown.CODCharge
+	body.RateCardID = breakdown.RateCardID
 	updatedOrder, err := orderRepo.UpdateOrder(db, body, orderID, shop.ID.Hex())
 	if err != nil {
 		logger.Log.Errorln(err)
@@ -352,24 +579,56 @@ func cancelOrder(ctx echo.Context) error {
 	resp := response.Response{}
 	orderID, shopID := ctx.Param("orderId"), ctx.Param("shopId")
 
-	cancelled := constants.Cancelled
 	db := database.GetDB()
 	orderRepo := data.NewOrderRepo()
-	order := &models.Order{
-		CurrentStatus: &cancelled,
-		IsCancelled:   true,
-		UpdatedAt:     time.Now().UTC(),
+	order, err := orderRepo.OrderByID(db, orderID)
+	if err != nil {
+		logger.Log.Errorln(err)
+		if err == mongo.ErrNoDocuments {
+			resp.Title = "Order not found"
+			resp.Status = http.StatusNotFound
+			resp.Code = codes.OrderNotFound
+			resp.Errors = errors.NewError(err.Error())
+			return resp.Send(ctx)
+		}
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
 	}
-	updatedOrder, err := orderRepo.UpdateOrder(db, order, orderID, shopID)
+	if order.ShopID.Hex() != shopID {
+		resp.Title = "You don't have access"
+		resp.Status = http.StatusForbidden
+		resp.Code = codes.AccessDenied
+		resp.Errors = errors.NewError("Order does not belong to this shop")
+		return resp.Send(ctx)
+	}
+	userID := ctx.Get(constants.UserID).(primitive.ObjectID)
+	orderStatus := &models.OrderStatus{
+		ID:         primitive.NewObjectID(),
+		MerchantID: &userID,
+		Status:     constants.Cancelled,
+		Text:       constants.CancelledMsg,
+		Time:       time.Now().UTC(),
+	}
+	updatedOrder, err := orderRepo.AddOrderStatus(db, orderStatus, orderID)
 	if err != nil {
 		logger.Log.Errorln(err)
 		if err == mongo.ErrNoDocuments {
 			resp.Title = "Order not found"
 			resp.Status = http.StatusNotFound
-			resp.Code = codes.ShopNotFound
+			resp.Code = codes.OrderNotFound
 			resp.Errors = errors.NewError(err.Error())
 			return resp.Send(ctx)
 		}
+		if err.Error() == string(codes.InvalidStatusTransition) {
+			resp.Title = "Order status transition not allowed"
+			resp.Status = http.StatusUnprocessableEntity
+			resp.Code = codes.InvalidStatusTransition
+			resp.Errors = err
+			return resp.Send(ctx)
+		}
 		resp.Title = "Something went wrong"
 		resp.Status = http.StatusInternalServerError
 		resp.Code = codes.DatabaseQueryFailed
@@ -412,17 +671,13 @@ func orderByID(ctx echo.Context) error {
 		return resp.Send(ctx)
 	}
 
+	setProofURLs(order)
 	resp.Data = order
 	resp.Status = http.StatusOK
 	return resp.Send(ctx)
 }
 
 func addOrderStatus(ctx echo.Context) error {
-	/*
-		TODO: check order status
-		TODO: If accepted can not update
-	*/
-
 	resp := response.Response{}
 	orderID := ctx.Param("orderId")
 	body, err := validators.UpdateOrderStatus(ctx)
@@ -434,25 +689,61 @@ func addOrderStatus(ctx echo.Context) error {
 		resp.Errors = err
 		return resp.Send(ctx)
 	}
+	if body.Status == constants.Delivered {
+		resp.Title = "Invalid order status change request"
+		resp.Status = http.StatusBadRequest
+		resp.Code = codes.InvalidOrderStatusUpdateData
+		resp.Errors = errors.NewError("Order can only be delivered by rider")
+		return resp.Send(ctx)
+	}
 	db := database.GetDB()
+	if _id, err := primitive.ObjectIDFromHex(orderID); err == nil {
+		if sent, err := checkOrdersScope(ctx, db, _id); sent {
+			return err
+		}
+	}
 	orderRepo := data.NewOrderRepo()
-	order, err := orderRepo.OrderByID(db, orderID)
+	orderStatus, err := orderRepo.AddOrderStatus(db, body, orderID)
 	if err != nil {
+		logger.Log.Errorln(err)
+		if err == mongo.ErrNoDocuments {
+			resp.Title = "Order not found"
+			resp.Status = http.StatusNotFound
+			resp.Code = codes.OrderNotFound
+			resp.Errors = errors.NewError(err.Error())
+			return resp.Send(ctx)
+		}
+		if err.Error() == string(codes.TransactionNotFound) {
+			resp.Title = "Transaction not found"
+			resp.Status = http.StatusNotFound
+			resp.Code = codes.TransactionNotFound
+			resp.Errors = err
+			return resp.Send(ctx)
+		}
+		if err.Error() == string(codes.InvalidStatusTransition) {
+			resp.Title = "Order status transition not allowed"
+			resp.Status = http.StatusUnprocessableEntity
+			resp.Code = codes.InvalidStatusTransition
+			resp.Errors = err
+			return resp.Send(ctx)
+		}
 		resp.Title = "Something went wrong"
 		resp.Status = http.StatusInternalServerError
 		resp.Code = codes.DatabaseQueryFailed
 		resp.Errors = err
 		return resp.Send(ctx)
 	}
+	resp.Data = orderStatus
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
 
-	if order.DeliveredAt != nil {
-		resp.Title = "Order already delivered"
-		resp.Status = http.StatusUnprocessableEntity
-		resp.Code = codes.OrderAlreadyDelevired
-		return resp.Send(ctx)
-	}
-
-	orderStatus, err := orderRepo.AddOrderStatus(db, body, orderID)
+func nextOrderStatus(ctx echo.Context) error {
+	resp := response.Response{}
+	orderID := ctx.Param("orderId")
+	db := database.GetDB()
+	orderRepo := data.NewOrderRepo()
+	order, err := orderRepo.OrderByID(db, orderID)
 	if err != nil {
 		logger.Log.Errorln(err)
 		if err == mongo.ErrNoDocuments {
@@ -468,7 +759,14 @@ func addOrderStatus(ctx echo.Context) error {
 		resp.Errors = err
 		return resp.Send(ctx)
 	}
-	resp.Data = orderStatus
+	if sent, err := checkOrdersScope(ctx, db, order.ID); sent {
+		return err
+	}
+	currentStatus := orderstatus.Current(order.CurrentStatus)
+	resp.Data = map[string]interface{}{
+		"currentStatus": currentStatus,
+		"nextStatus":    orderstatus.Next(currentStatus),
+	}
 	resp.Status = http.StatusOK
 	return resp.Send(ctx)
 }
@@ -578,8 +876,20 @@ func orderCreate(ctx echo.Context) error {
 		resp.Errors = err
 		return resp.Send(ctx)
 	}
-	order.Charge = charge.Calculate(order.Weight, order.DeliveryType, order.RecipientCity, shop.DeliveryCharge)
+	if err := resolveLocation(db, order); err != nil {
+		logger.Log.Errorln(err)
+		return sendLocationError(ctx, err)
+	}
+	if err := applyCharge(db, &shop, order); err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
 	order.ShopID = shop.ID
+	order.ShopModeratorID = shopModeratorOf(ctx)
 	orderRepo := data.NewOrderRepo()
 	tid, err := random.GenerateRandomString(constants.TrackIDSize)
 	if err != nil {
@@ -591,6 +901,7 @@ func orderCreate(ctx echo.Context) error {
 		return resp.Send(ctx)
 	}
 	order.TrackID = tid
+	middlewares.AuditTargets(ctx, order.ID)
 	if err := orderRepo.Create(db, order); err != nil {
 		logger.Log.Errorln(err)
 		if errors.IsMongoDupError(err) {
@@ -633,6 +944,14 @@ func changeStatus(ctx echo.Context) error {
 
 	var wg sync.WaitGroup
 	db := database.GetDB()
+	if sent, err := checkOrdersScope(ctx, db, body.OrderIDs...); sent {
+		return err
+	}
+	orderIDs := []interface{}{}
+	for _, orderID := range body.OrderIDs {
+		orderIDs = append(orderIDs, orderID)
+	}
+	middlewares.AuditTargets(ctx, orderIDs...)
 	orderRepo := data.NewOrderRepo()
 	errChan := make(chan orderError, len(body.OrderIDs))
 	ordersChan := make(chan models.Order, len(body.OrderIDs))
@@ -641,15 +960,6 @@ func changeStatus(ctx echo.Context) error {
 		wg.Add(1)
 		go func(w *sync.WaitGroup, oid primitive.ObjectID) {
 			defer w.Done()
-			order, err := orderRepo.OrderByID(db, oid.Hex())
-			if err != nil {
-				errChan <- orderError{err.Error(), oid}
-				return
-			}
-			if order.DeliveredAt != nil {
-				errChan <- orderError{"Order already delivered", oid}
-				return
-			}
 			orderStatus := models.OrderStatus{
 				ID:            primitive.NewObjectID(),
 				Text:          body.Text,
@@ -658,7 +968,7 @@ func changeStatus(ctx echo.Context) error {
 				Status:        body.Status,
 				Time:          time.Now().UTC(),
 			}
-			order, err = orderRepo.AddOrderStatus(db, &orderStatus, oid.Hex())
+			order, err := orderRepo.AddOrderStatus(db, &orderStatus, oid.Hex())
 			if err != nil {
 				errChan <- orderError{err.Error(), oid}
 			} else {
@@ -706,11 +1016,24 @@ func createMultipleOrder(ctx echo.Context) error {
 		return resp.Send(ctx)
 	}
 	shop := ctx.Get("shop").(models.Shop)
-	var os []interface{}
+	db := database.GetDB()
+	var os, orderIDs []interface{}
 	for i := 0; i < len(orders); i++ {
 		order := &orders[i]
 		order.ShopID = shop.ID
-		order.Charge = charge.Calculate(order.Weight, order.DeliveryType, order.RecipientCity, shop.DeliveryCharge)
+		order.ShopModeratorID = shopModeratorOf(ctx)
+		if err := resolveLocation(db, order); err != nil {
+			logger.Log.Errorln(err)
+			return sendLocationError(ctx, err)
+		}
+		if err := applyCharge(db, &shop, order); err != nil {
+			logger.Log.Errorln(err)
+			resp.Title = "Something went wrong"
+			resp.Status = http.StatusInternalServerError
+			resp.Code = codes.DatabaseQueryFailed
+			resp.Errors = err
+			return resp.Send(ctx)
+		}
 		tid, err := random.GenerateRandomString(constants.TrackIDSize)
 		if err != nil {
 			logger.Log.Errorln(err)
@@ -722,8 +1045,9 @@ func createMultipleOrder(ctx echo.Context) error {
 		}
 		order.TrackID = tid
 		os = append(os, order)
+		orderIDs = append(orderIDs, order.ID)
 	}
-	db := database.GetDB()
+	middlewares.AuditTargets(ctx, orderIDs...)
 	orderRepo := data.NewOrderRepo()
 	if err := orderRepo.CreateMultiple(db, os); err != nil {
 		logger.Log.Errorln(err)
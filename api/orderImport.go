@@ -0,0 +1,150 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/techartificer/swiftex/constants"
+	"github.com/techartificer/swiftex/constants/codes"
+	"github.com/techartificer/swiftex/data"
+	"github.com/techartificer/swiftex/database"
+	"github.com/techartificer/swiftex/lib/errors"
+	"github.com/techartificer/swiftex/lib/random"
+	"github.com/techartificer/swiftex/lib/response"
+	"github.com/techartificer/swiftex/lib/sheet"
+	"github.com/techartificer/swiftex/logger"
+	"github.com/techartificer/swiftex/middlewares"
+	"github.com/techartificer/swiftex/models"
+	"github.com/techartificer/swiftex/serializer"
+	"github.com/techartificer/swiftex/validators"
+)
+
+// orderImportTemplate returns csv template of order import with a sample row
+func orderImportTemplate(ctx echo.Context) error {
+	resp := response.Response{}
+	sample := []string{
+		"John Doe", "01700000000", "Dhaka", "Gulshan", "Gulshan 1", "1212", "House 1, Road 1",
+		"PKG-1", constants.COD, "1200", "Regular", "", "Shop address", "Dhaka", "", "1", "0.5", constants.Regular, "0",
+	}
+	content, err := sheet.WriteCSV([][]string{validators.ImportColumns, sample})
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.SomethingWentWrong
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	ctx.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="order-import-template.csv"`)
+	return ctx.Blob(http.StatusOK, "text/csv", content)
+}
+
+// importOrders creates orders from a csv or xlsx file, only valid rows are created.
+// Rows are validated and charged without creating orders if dryRun is set
+func importOrders(ctx echo.Context) error {
+	resp := response.Response{}
+	orderImport, err := validators.ValidateOrderImport(ctx)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Invalid order import file"
+		resp.Status = http.StatusBadRequest
+		resp.Code = codes.InvalidOrderImportData
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	shop := ctx.Get("shop").(models.Shop)
+	db := database.GetDB()
+	report := serializer.OrderImportReport{DryRun: orderImport.DryRun, Total: len(orderImport.Rows)}
+	var orders []interface{}
+	var orderIDs []interface{}
+	var validRows []int // index of report rows of orders
+	for _, row := range orderImport.Rows {
+		result := serializer.ImportRowResult{Row: row.Row, Status: serializer.ImportRowValid, Errors: row.Errors}
+		if row.Order == nil {
+			result.Status = serializer.ImportRowInvalid
+			report.Invalid++
+			report.Rows = append(report.Rows, result)
+			continue
+		}
+		order := row.Order
+		order.ShopID = shop.ID
+		order.ShopModeratorID = shopModeratorOf(ctx)
+		if err := resolveLocation(db, order); err != nil {
+			title, _, ok := locationError(err)
+			if !ok {
+				logger.Log.Errorln(err)
+				resp.Title = "Something went wrong"
+				resp.Status = http.StatusInternalServerError
+				resp.Code = codes.DatabaseQueryFailed
+				resp.Errors = err
+				return resp.Send(ctx)
+			}
+			result.Status = serializer.ImportRowInvalid
+			result.Errors = map[string]string{"Location": title}
+			report.Invalid++
+			report.Rows = append(report.Rows, result)
+			continue
+		}
+		if err := applyCharge(db, &shop, order); err != nil {
+			logger.Log.Errorln(err)
+			resp.Title = "Something went wrong"
+			resp.Status = http.StatusInternalServerError
+			resp.Code = codes.DatabaseQueryFailed
+			resp.Errors = err
+			return resp.Send(ctx)
+		}
+		tid, err := random.GenerateRandomString(constants.TrackIDSize)
+		if err != nil {
+			logger.Log.Errorln(err)
+			resp.Title = "Something went wrong"
+			resp.Status = http.StatusInternalServerError
+			resp.Code = codes.SomethingWentWrong
+			resp.Errors = err
+			return resp.Send(ctx)
+		}
+		order.TrackID = tid
+		result.TrackID = order.TrackID
+		result.Charge = order.Charge
+		result.CODCharge = order.CODCharge
+		report.Valid++
+		orders = append(orders, order)
+		orderIDs = append(orderIDs, order.ID)
+		validRows = append(validRows, len(report.Rows))
+		report.Rows = append(report.Rows, result)
+	}
+	if orderImport.DryRun {
+		resp.Data = report
+		resp.Status = http.StatusOK
+		return resp.Send(ctx)
+	}
+	middlewares.AuditTargets(ctx, orderIDs...)
+	orderRepo := data.NewOrderRepo()
+	failed, err := orderRepo.ImportMultiple(db, orders)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	for i, idx := range validRows {
+		result := &report.Rows[idx]
+		if ferr, ok := failed[i]; ok {
+			result.Status = serializer.ImportRowFailed
+			result.TrackID = ""
+			if errors.IsMongoDupError(ferr) {
+				result.Errors = map[string]string{"TrackID": "order track Id already exist"}
+			} else {
+				result.Errors = map[string]string{"Order": ferr.Error()}
+			}
+			report.Failed++
+			continue
+		}
+		result.Status = serializer.ImportRowCreated
+		report.Created++
+	}
+	resp.Data = report
+	resp.Status = http.StatusCreated
+	return resp.Send(ctx)
+}
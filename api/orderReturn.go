@@ -0,0 +1,292 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/techartificer/swiftex/constants"
+	"github.com/techartificer/swiftex/constants/codes"
+	"github.com/techartificer/swiftex/data"
+	"github.com/techartificer/swiftex/database"
+	"github.com/techartificer/swiftex/lib/errors"
+	"github.com/techartificer/swiftex/lib/response"
+	"github.com/techartificer/swiftex/logger"
+	"github.com/techartificer/swiftex/middlewares"
+	"github.com/techartificer/swiftex/models"
+	"github.com/techartificer/swiftex/validators"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func RegisterReturnRoutes(endpoint *echo.Group) {
+	endpoint.GET("/", returns, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.OrderRead), middlewares.HubScope())
+	endpoint.POST("/:orderId/", initiateReturn, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.OrderStatusWrite), middlewares.HubScope(), middlewares.Audit(middlewares.AuditOrder, "orderId"))
+	endpoint.PATCH("/:orderId/received/", receiveReturn, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.OrderStatusWrite), middlewares.HubScope(), middlewares.Audit(middlewares.AuditOrder, "orderId"))
+	endpoint.PATCH("/:orderId/handover/", handOverReturn, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.OrderStatusWrite), middlewares.HubScope(), middlewares.Audit(middlewares.AuditOrder, "orderId"))
+	endpoint.GET("/shop/:shopId/", shopReturns, middlewares.JWTAuth(false), middlewares.RequirePermission(constants.OrderRead), middlewares.HasShopAccess())
+	endpoint.PATCH("/shop/:shopId/:orderId/confirm/", confirmReturn, middlewares.JWTAuth(false), middlewares.RequirePermission(constants.OrderWrite), middlewares.HasShopAccess(), middlewares.RequireShopPermission(constants.ShopOrderCreate), middlewares.Audit(middlewares.AuditOrder, "orderId"))
+}
+
+// sendReturnError sends response of a failed return update
+func sendReturnError(ctx echo.Context, err error) error {
+	resp := response.Response{}
+	if err == mongo.ErrNoDocuments {
+		resp.Title = "Return not found"
+		resp.Status = http.StatusNotFound
+		resp.Code = codes.ReturnNotFound
+		resp.Errors = errors.NewError(err.Error())
+		return resp.Send(ctx)
+	}
+	switch err.Error() {
+	case string(codes.InvalidReturnTransition):
+		resp.Title = "Return status does not allow the change"
+		resp.Status = http.StatusUnprocessableEntity
+		resp.Code = codes.InvalidReturnTransition
+	case string(codes.InvalidStatusTransition):
+		resp.Title = "Order status transition not allowed"
+		resp.Status = http.StatusUnprocessableEntity
+		resp.Code = codes.InvalidStatusTransition
+	case string(codes.TransactionNotFound):
+		resp.Title = "Transaction not found"
+		resp.Status = http.StatusNotFound
+		resp.Code = codes.TransactionNotFound
+	default:
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+	}
+	resp.Errors = err
+	return resp.Send(ctx)
+}
+
+// returnQuery returns query of return list from query params status and lastId
+func returnQuery(ctx echo.Context) (bson.M, int64, error) {
+	query := make(bson.M)
+	if lastID := ctx.QueryParam("lastId"); lastID != "" {
+		_id, err := primitive.ObjectIDFromHex(lastID)
+		if err != nil {
+			return nil, 0, err
+		}
+		query["_id"] = bson.M{"$lt": _id}
+	}
+	if status := ctx.QueryParam("status"); status != "" {
+		query["return.status"] = status
+	}
+	var limitNum int64 = 20
+	if limit := ctx.QueryParam("limit"); limit != "" {
+		ln, err := strconv.Atoi(limit)
+		if err != nil {
+			return nil, 0, err
+		}
+		if ln > 0 {
+			limitNum = int64(ln)
+		}
+	}
+	return query, limitNum, nil
+}
+
+// sendReturns sends returned orders matching query
+func sendReturns(ctx echo.Context, query bson.M, limit int64) error {
+	resp := response.Response{}
+	db := database.GetDB()
+	returnRepo := data.NewReturnRepo()
+	orders, err := returnRepo.Returns(db, query, limit)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	resp.Data = func() []models.Order {
+		if orders == nil {
+			return []models.Order{}
+		}
+		return *orders
+	}()
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
+
+// returnOrderID parses order ID of a return route and checks it is in hub scope of the requester,
+// sent is true if a response is sent
+func returnOrderID(ctx echo.Context, db *mongo.Database) (primitive.ObjectID, bool, error) {
+	resp := response.Response{}
+	orderID, err := primitive.ObjectIDFromHex(ctx.Param("orderId"))
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Invalid order ID"
+		resp.Status = http.StatusUnprocessableEntity
+		resp.Code = codes.InvalidMongoID
+		resp.Errors = err
+		return orderID, true, resp.Send(ctx)
+	}
+	if sent, err := checkOrdersScope(ctx, db, orderID); sent {
+		return orderID, true, err
+	}
+	return orderID, false, nil
+}
+
+// returns lists returns of all shops, zone managers see returns routed to their hubs
+func returns(ctx echo.Context) error {
+	resp := response.Response{}
+	query, limit, err := returnQuery(ctx)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Invalid query"
+		resp.Status = http.StatusUnprocessableEntity
+		resp.Code = codes.InvalidReturnData
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	if shopID := ctx.QueryParam("shopId"); shopID != "" {
+		_id, err := primitive.ObjectIDFromHex(shopID)
+		if err != nil {
+			logger.Log.Errorln(err)
+			resp.Title = "Invalid shopId"
+			resp.Status = http.StatusUnprocessableEntity
+			resp.Code = codes.InvalidMongoID
+			resp.Errors = err
+			return resp.Send(ctx)
+		}
+		query["shopId"] = _id
+	}
+	if hub := ctx.QueryParam("hub"); hub != "" {
+		query["return.hub"] = hub
+	}
+	if hubs, ok := hubScope(ctx); ok {
+		query["return.hubId"] = bson.M{"$in": hubs}
+	}
+	return sendReturns(ctx, query, limit)
+}
+
+// shopReturns returns returned parcels of the shop, newest first
+func shopReturns(ctx echo.Context) error {
+	resp := response.Response{}
+	query, limit, err := returnQuery(ctx)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Invalid query"
+		resp.Status = http.StatusUnprocessableEntity
+		resp.Code = codes.InvalidReturnData
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	shop := ctx.Get("shop").(*models.Shop)
+	query["shopId"] = shop.ID
+	return sendReturns(ctx, query, limit)
+}
+
+// initiateReturn moves an order to Returned, the parcel is routed back to its pickup hub
+// and return charge is debited from the shop
+func initiateReturn(ctx echo.Context) error {
+	resp := response.Response{}
+	status, err := validators.ValidateReturnInitiate(ctx)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Invalid return request data"
+		resp.Status = http.StatusBadRequest
+		resp.Code = codes.InvalidReturnData
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	db := database.GetDB()
+	orderID, sent, err := returnOrderID(ctx, db)
+	if sent {
+		return err
+	}
+	orderRepo := data.NewOrderRepo()
+	order, err := orderRepo.AddOrderStatus(db, status, orderID.Hex())
+	if err != nil {
+		logger.Log.Errorln(err)
+		if err == mongo.ErrNoDocuments {
+			resp.Title = "Order not found"
+			resp.Status = http.StatusNotFound
+			resp.Code = codes.OrderNotFound
+			resp.Errors = errors.NewError(err.Error())
+			return resp.Send(ctx)
+		}
+		return sendReturnError(ctx, err)
+	}
+	resp.Data = order
+	resp.Status = http.StatusCreated
+	return resp.Send(ctx)
+}
+
+// receiveReturn records the returned parcel has reached its origin hub
+func receiveReturn(ctx echo.Context) error {
+	db := database.GetDB()
+	orderID, sent, err := returnOrderID(ctx, db)
+	if sent {
+		return err
+	}
+	adminID := ctx.Get(constants.UserID).(primitive.ObjectID)
+	returnRepo := data.NewReturnRepo()
+	order, err := returnRepo.ReceiveAtHub(db, orderID, adminID)
+	if err != nil {
+		logger.Log.Errorln(err)
+		return sendReturnError(ctx, err)
+	}
+	resp := response.Response{}
+	resp.Data = order
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
+
+// handOverReturn records the returned parcel has been handed over to the shop
+func handOverReturn(ctx echo.Context) error {
+	resp := response.Response{}
+	body, err := validators.ValidateReturnHandOver(ctx)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Invalid return handover data"
+		resp.Status = http.StatusBadRequest
+		resp.Code = codes.InvalidReturnData
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	db := database.GetDB()
+	orderID, sent, err := returnOrderID(ctx, db)
+	if sent {
+		return err
+	}
+	adminID := ctx.Get(constants.UserID).(primitive.ObjectID)
+	returnRepo := data.NewReturnRepo()
+	order, err := returnRepo.HandOver(db, orderID, adminID, body.ReceiverName, body.ReceiverPhone)
+	if err != nil {
+		logger.Log.Errorln(err)
+		return sendReturnError(ctx, err)
+	}
+	resp.Data = order
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
+
+// confirmReturn lets the shop confirm it has received the handed over parcel
+func confirmReturn(ctx echo.Context) error {
+	resp := response.Response{}
+	orderID, err := primitive.ObjectIDFromHex(ctx.Param("orderId"))
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Invalid order ID"
+		resp.Status = http.StatusUnprocessableEntity
+		resp.Code = codes.InvalidMongoID
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	shop := ctx.Get("shop").(*models.Shop)
+	merchantID := ctx.Get(constants.UserID).(primitive.ObjectID)
+	db := database.GetDB()
+	returnRepo := data.NewReturnRepo()
+	order, err := returnRepo.Confirm(db, shop.ID, orderID, merchantID)
+	if err != nil {
+		logger.Log.Errorln(err)
+		return sendReturnError(ctx, err)
+	}
+	resp.Data = order
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
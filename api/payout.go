@@ -0,0 +1,321 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/techartificer/swiftex/constants"
+	"github.com/techartificer/swiftex/constants/codes"
+	"github.com/techartificer/swiftex/data"
+	"github.com/techartificer/swiftex/database"
+	"github.com/techartificer/swiftex/lib/errors"
+	"github.com/techartificer/swiftex/lib/response"
+	"github.com/techartificer/swiftex/lib/sheet"
+	"github.com/techartificer/swiftex/logger"
+	"github.com/techartificer/swiftex/middlewares"
+	"github.com/techartificer/swiftex/models"
+	"github.com/techartificer/swiftex/validators"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func RegisterPayoutRoutes(endpoint *echo.Group) {
+	endpoint.PUT("/shop/:shopId/schedule/", savePayoutSchedule, middlewares.JWTAuth(false), middlewares.IsShopOwnerStrict(), middlewares.Audit(middlewares.AuditPayoutSchedule, "shopId"))
+	endpoint.GET("/shop/:shopId/schedule/", payoutSchedule, middlewares.JWTAuth(false), middlewares.RequirePermission(constants.PayoutRead), middlewares.HasShopAccess(), middlewares.RequireShopPermission(constants.ShopFinanceView))
+	endpoint.GET("/shop/:shopId/method/", payoutMethods, middlewares.JWTAuth(false), middlewares.IsShopOwnerStrict())
+	endpoint.POST("/shop/:shopId/method/", createPayoutMethod, middlewares.JWTAuth(false), middlewares.IsShopOwnerStrict(), middlewares.Audit(middlewares.AuditPayoutMethod, ""))
+	endpoint.PUT("/shop/:shopId/method/:methodId/", updatePayoutMethod, middlewares.JWTAuth(false), middlewares.IsShopOwnerStrict(), middlewares.Audit(middlewares.AuditPayoutMethod, "methodId"))
+	endpoint.DELETE("/shop/:shopId/method/:methodId/", deletePayoutMethod, middlewares.JWTAuth(false), middlewares.IsShopOwnerStrict(), middlewares.Audit(middlewares.AuditPayoutMethod, "methodId"))
+	endpoint.PATCH("/shop/:shopId/method/:methodId/verify/", verifyPayoutMethod, middlewares.JWTAuth(false), middlewares.IsShopOwnerStrict(), middlewares.Audit(middlewares.AuditPayoutMethod, "methodId"))
+	endpoint.GET("/batch/", payoutBatches, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.PayoutRead))
+	endpoint.POST("/batch/", createPayoutBatch, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.PayoutApprove))
+	endpoint.GET("/batch/:batchId/", payoutBatch, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.PayoutRead))
+	endpoint.GET("/batch/:batchId/csv/", payoutBankFile, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.PayoutRead))
+	endpoint.PATCH("/batch/:batchId/approve/", approvePayoutBatch, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.PayoutApprove), middlewares.Idempotency(), middlewares.Audit(middlewares.AuditPayoutBatch, "batchId"))
+	endpoint.PATCH("/batch/:batchId/reject/", rejectPayoutBatch, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.PayoutApprove), middlewares.Audit(middlewares.AuditPayoutBatch, "batchId"))
+}
+
+// sendPayoutError sends response of a failed payout batch update
+func sendPayoutError(ctx echo.Context, err error) error {
+	resp := response.Response{}
+	if err == mongo.ErrNoDocuments {
+		resp.Title = "Payout batch not found"
+		resp.Status = http.StatusNotFound
+		resp.Code = codes.PayoutBatchNotFound
+		resp.Errors = errors.NewError(err.Error())
+		return resp.Send(ctx)
+	}
+	switch err.Error() {
+	case string(codes.InvalidPayoutTransition):
+		resp.Title = "Payout batch is already reviewed"
+		resp.Status = http.StatusUnprocessableEntity
+		resp.Code = codes.InvalidPayoutTransition
+	default:
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+	}
+	resp.Errors = err
+	return resp.Send(ctx)
+}
+
+// payoutBatchID parses batch ID of the route, sent is true if a response is sent
+func payoutBatchID(ctx echo.Context) (primitive.ObjectID, bool, error) {
+	batchID, err := primitive.ObjectIDFromHex(ctx.Param("batchId"))
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp := response.Response{}
+		resp.Title = "Invalid batch ID"
+		resp.Status = http.StatusUnprocessableEntity
+		resp.Code = codes.InvalidMongoID
+		resp.Errors = err
+		return batchID, true, resp.Send(ctx)
+	}
+	return batchID, false, nil
+}
+
+// savePayoutSchedule creates or replaces payout schedule of the shop, only the owner can change it
+func savePayoutSchedule(ctx echo.Context) error {
+	resp := response.Response{}
+	shop := ctx.Get("shop").(*models.Shop)
+	schedule, err := validators.ValidatePayoutSchedule(ctx, shop.ID)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Invalid payout schedule data"
+		resp.Status = http.StatusBadRequest
+		resp.Code = codes.InvalidPayoutData
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	db := database.GetDB()
+	payoutRepo := data.NewPayoutRepo()
+	schedule, err = payoutRepo.SaveSchedule(db, schedule)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	resp.Data = schedule
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
+
+func payoutSchedule(ctx echo.Context) error {
+	resp := response.Response{}
+	shop := ctx.Get("shop").(*models.Shop)
+	db := database.GetDB()
+	payoutRepo := data.NewPayoutRepo()
+	schedule, err := payoutRepo.ScheduleByShop(db, shop.ID)
+	if err != nil {
+		logger.Log.Errorln(err)
+		if err == mongo.ErrNoDocuments {
+			resp.Title = "Payout schedule not found"
+			resp.Status = http.StatusNotFound
+			resp.Code = codes.PayoutScheduleNotFound
+			resp.Errors = errors.NewError(err.Error())
+			return resp.Send(ctx)
+		}
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	resp.Data = schedule
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
+
+// payoutBatches lists payout batches by status and shopId, newest first
+func payoutBatches(ctx echo.Context) error {
+	resp := response.Response{}
+	query := make(bson.M)
+	for _, param := range []string{"shopId", "lastId"} {
+		value := ctx.QueryParam(param)
+		if value == "" {
+			continue
+		}
+		_id, err := primitive.ObjectIDFromHex(value)
+		if err != nil {
+			logger.Log.Errorln(err)
+			resp.Title = "Invalid " + param
+			resp.Status = http.StatusUnprocessableEntity
+			resp.Code = codes.InvalidMongoID
+			resp.Errors = err
+			return resp.Send(ctx)
+		}
+		if param == "lastId" {
+			query["_id"] = bson.M{"$lt": _id}
+		} else {
+			query["items.shopId"] = _id
+		}
+	}
+	if status := ctx.QueryParam("status"); status != "" {
+		query["status"] = status
+	}
+	var limitNum int64 = 20
+	if limit := ctx.QueryParam("limit"); limit != "" {
+		ln, err := strconv.Atoi(limit)
+		if err != nil || ln <= 0 {
+			logger.Log.Errorln(err)
+			resp.Errors = err
+			resp.Title = "Invalid limit"
+			resp.Status = http.StatusUnprocessableEntity
+			resp.Code = codes.InvalidLimit
+			return resp.Send(ctx)
+		}
+		limitNum = int64(ln)
+	}
+	db := database.GetDB()
+	payoutRepo := data.NewPayoutRepo()
+	batches, err := payoutRepo.Batches(db, query, limitNum)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	resp.Data = func() []models.PayoutBatch {
+		if batches == nil {
+			return []models.PayoutBatch{}
+		}
+		return *batches
+	}()
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
+
+// createPayoutBatch batches due schedules now instead of waiting for the payout job
+func createPayoutBatch(ctx echo.Context) error {
+	resp := response.Response{}
+	db := database.GetDB()
+	payoutRepo := data.NewPayoutRepo()
+	batch, err := payoutRepo.CreateBatch(db, time.Now().UTC())
+	if err != nil {
+		logger.Log.Errorln(err)
+		if err == mongo.ErrNoDocuments {
+			resp.Title = "No payout is due"
+			resp.Status = http.StatusNotFound
+			resp.Code = codes.PayoutBatchNotFound
+			resp.Errors = errors.NewError(err.Error())
+			return resp.Send(ctx)
+		}
+		return sendPayoutError(ctx, err)
+	}
+	resp.Data = batch
+	resp.Status = http.StatusCreated
+	return resp.Send(ctx)
+}
+
+func payoutBatch(ctx echo.Context) error {
+	batchID, sent, err := payoutBatchID(ctx)
+	if sent {
+		return err
+	}
+	db := database.GetDB()
+	payoutRepo := data.NewPayoutRepo()
+	batch, err := payoutRepo.BatchByID(db, batchID)
+	if err != nil {
+		logger.Log.Errorln(err)
+		return sendPayoutError(ctx, err)
+	}
+	resp := response.Response{}
+	resp.Data = batch
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
+
+// payoutBankFile returns csv of a payout batch for the finance team to upload to the bank
+func payoutBankFile(ctx echo.Context) error {
+	batchID, sent, err := payoutBatchID(ctx)
+	if sent {
+		return err
+	}
+	db := database.GetDB()
+	payoutRepo := data.NewPayoutRepo()
+	batch, err := payoutRepo.BatchByID(db, batchID)
+	if err != nil {
+		logger.Log.Errorln(err)
+		return sendPayoutError(ctx, err)
+	}
+	rows := [][]string{{
+		"Reference", "Shop", "Method", "Account Name", "Bank", "Branch",
+		"Account Number", "Routing Number", "Wallet Number", "Amount", "Status",
+	}}
+	for _, item := range batch.Items {
+		rows = append(rows, []string{
+			batch.ID.Hex() + "-" + item.ShopID.Hex(), item.ShopName, item.Account.Method, item.Account.AccountName,
+			item.Account.BankName, item.Account.Branch, item.Account.AccountNumber, item.Account.RoutingNumber,
+			item.Account.WalletNumber, strconv.FormatInt(item.Amount, 10), item.Status,
+		})
+	}
+	content, err := sheet.WriteCSV(rows)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp := response.Response{}
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.SomethingWentWrong
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	ctx.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="payout-%s.csv"`, batch.ID.Hex()))
+	return ctx.Blob(http.StatusOK, "text/csv", content)
+}
+
+// approvePayoutBatch pays out shops of a pending batch
+func approvePayoutBatch(ctx echo.Context) error {
+	batchID, sent, err := payoutBatchID(ctx)
+	if sent {
+		return err
+	}
+	adminID := ctx.Get(constants.UserID).(primitive.ObjectID)
+	db := database.GetDB()
+	payoutRepo := data.NewPayoutRepo()
+	batch, err := payoutRepo.ApproveBatch(db, batchID, adminID)
+	if err != nil {
+		logger.Log.Errorln(err)
+		return sendPayoutError(ctx, err)
+	}
+	resp := response.Response{}
+	resp.Data = batch
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
+
+func rejectPayoutBatch(ctx echo.Context) error {
+	resp := response.Response{}
+	body, err := validators.ValidatePayoutReject(ctx)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Invalid payout reject data"
+		resp.Status = http.StatusBadRequest
+		resp.Code = codes.InvalidPayoutData
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	batchID, sent, err := payoutBatchID(ctx)
+	if sent {
+		return err
+	}
+	adminID := ctx.Get(constants.UserID).(primitive.ObjectID)
+	db := database.GetDB()
+	payoutRepo := data.NewPayoutRepo()
+	batch, err := payoutRepo.RejectBatch(db, batchID, adminID, body.Reason)
+	if err != nil {
+		logger.Log.Errorln(err)
+		return sendPayoutError(ctx, err)
+	}
+	resp.Data = batch
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
@@ -0,0 +1,230 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/techartificer/swiftex/constants"
+	"github.com/techartificer/swiftex/constants/codes"
+	"github.com/techartificer/swiftex/data"
+	"github.com/techartificer/swiftex/database"
+	"github.com/techartificer/swiftex/lib/errors"
+	"github.com/techartificer/swiftex/lib/firebase"
+	"github.com/techartificer/swiftex/lib/response"
+	"github.com/techartificer/swiftex/logger"
+	"github.com/techartificer/swiftex/middlewares"
+	"github.com/techartificer/swiftex/models"
+	"github.com/techartificer/swiftex/validators"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// sendPayoutMethodError sends response of a failed payout method query
+func sendPayoutMethodError(ctx echo.Context, err error) error {
+	resp := response.Response{}
+	if err == mongo.ErrNoDocuments {
+		resp.Title = "Payout method not found"
+		resp.Status = http.StatusNotFound
+		resp.Code = codes.PayoutMethodNotFound
+		resp.Errors = errors.NewError(err.Error())
+		return resp.Send(ctx)
+	}
+	if err.Error() == string(codes.PayoutMethodChanged) {
+		resp.Title = "Payout method changed while it was verified, verify it again"
+		resp.Status = http.StatusConflict
+		resp.Code = codes.PayoutMethodChanged
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	resp.Title = "Something went wrong"
+	resp.Status = http.StatusInternalServerError
+	resp.Code = codes.DatabaseQueryFailed
+	resp.Errors = err
+	return resp.Send(ctx)
+}
+
+// payoutMethodID parses method ID of the route, sent is true if a response is sent
+func payoutMethodID(ctx echo.Context) (primitive.ObjectID, bool, error) {
+	methodID, err := primitive.ObjectIDFromHex(ctx.Param("methodId"))
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp := response.Response{}
+		resp.Title = "Invalid payout method ID"
+		resp.Status = http.StatusUnprocessableEntity
+		resp.Code = codes.InvalidMongoID
+		resp.Errors = err
+		return methodID, true, resp.Send(ctx)
+	}
+	return methodID, false, nil
+}
+
+func payoutMethods(ctx echo.Context) error {
+	shop := ctx.Get("shop").(*models.Shop)
+	db := database.GetDB()
+	methodRepo := data.NewPayoutMethodRepo()
+	methods, err := methodRepo.Methods(db, shop.ID)
+	if err != nil {
+		logger.Log.Errorln(err)
+		return sendPayoutMethodError(ctx, err)
+	}
+	resp := response.Response{}
+	resp.Data = func() []models.PayoutMethod {
+		if methods == nil {
+			return []models.PayoutMethod{}
+		}
+		return *methods
+	}()
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
+
+// createPayoutMethod adds a pending payout method, it has to be verified before shop is paid to it
+func createPayoutMethod(ctx echo.Context) error {
+	resp := response.Response{}
+	account, phone, err := validators.ValidatePayoutMethod(ctx)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Invalid payout method data"
+		resp.Status = http.StatusBadRequest
+		resp.Code = codes.InvalidPayoutData
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	shop := ctx.Get("shop").(*models.Shop)
+	now := time.Now().UTC()
+	method := &models.PayoutMethod{
+		ID:        primitive.NewObjectID(),
+		ShopID:    shop.ID,
+		Account:   *account,
+		Phone:     phone,
+		Status:    constants.Pending,
+		CreatedBy: ctx.Get(constants.UserID).(primitive.ObjectID),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	middlewares.AuditTargets(ctx, method.ID)
+	db := database.GetDB()
+	methodRepo := data.NewPayoutMethodRepo()
+	method, err = methodRepo.Create(db, method)
+	if err != nil {
+		logger.Log.Errorln(err)
+		return sendPayoutMethodError(ctx, err)
+	}
+	resp.Data = method
+	resp.Status = http.StatusCreated
+	return resp.Send(ctx)
+}
+
+// updatePayoutMethod replaces account details, the method has to be verified again
+func updatePayoutMethod(ctx echo.Context) error {
+	resp := response.Response{}
+	account, phone, err := validators.ValidatePayoutMethod(ctx)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Invalid payout method data"
+		resp.Status = http.StatusBadRequest
+		resp.Code = codes.InvalidPayoutData
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	methodID, sent, err := payoutMethodID(ctx)
+	if sent {
+		return err
+	}
+	shop := ctx.Get("shop").(*models.Shop)
+	db := database.GetDB()
+	methodRepo := data.NewPayoutMethodRepo()
+	method, err := methodRepo.Update(db, shop.ID, methodID, *account, phone)
+	if err != nil {
+		logger.Log.Errorln(err)
+		return sendPayoutMethodError(ctx, err)
+	}
+	resp.Data = method
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
+
+func deletePayoutMethod(ctx echo.Context) error {
+	methodID, sent, err := payoutMethodID(ctx)
+	if sent {
+		return err
+	}
+	shop := ctx.Get("shop").(*models.Shop)
+	db := database.GetDB()
+	methodRepo := data.NewPayoutMethodRepo()
+	method, err := methodRepo.Delete(db, shop.ID, methodID)
+	if err != nil {
+		logger.Log.Errorln(err)
+		return sendPayoutMethodError(ctx, err)
+	}
+	resp := response.Response{}
+	resp.Data = method
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
+
+// verifyPayoutMethod activates a payout method once the phone of the shop owner is verified with the
+// firebase token of the FirebaseToken header. Wallet phone of a mobile wallet which is not the owner's
+// phone is verified with the WalletFirebaseToken header too, the previously active method is deactivated
+func verifyPayoutMethod(ctx echo.Context) error {
+	resp := response.Response{}
+	methodID, sent, err := payoutMethodID(ctx)
+	if sent {
+		return err
+	}
+	shop := ctx.Get("shop").(*models.Shop)
+	db := database.GetDB()
+	methodRepo := data.NewPayoutMethodRepo()
+	method, err := methodRepo.MethodByID(db, shop.ID, methodID)
+	if err != nil {
+		logger.Log.Errorln(err)
+		return sendPayoutMethodError(ctx, err)
+	}
+	merchantRepo := data.NewMerchantRepo()
+	owner, err := merchantRepo.FindById(db, shop.Owner)
+	if err != nil {
+		logger.Log.Errorln(err)
+		if err == mongo.ErrNoDocuments {
+			resp.Title = "Shop owner not found"
+			resp.Status = http.StatusNotFound
+			resp.Code = codes.MerchantNotFound
+			resp.Errors = errors.NewError(err.Error())
+			return resp.Send(ctx)
+		}
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	token := ctx.Request().Header.Get("FirebaseToken")
+	if err := firebase.ValidateToken(token, owner.Phone); err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Phone number of the shop owner is not verified"
+		resp.Status = http.StatusBadRequest
+		resp.Code = codes.PhoneNumberNotVerified
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	if method.Phone != "" && method.Phone != owner.Phone {
+		walletToken := ctx.Request().Header.Get("WalletFirebaseToken")
+		if err := firebase.ValidateToken(walletToken, method.Phone); err != nil {
+			logger.Log.Errorln(err)
+			resp.Title = "Wallet phone number is not verified"
+			resp.Status = http.StatusBadRequest
+			resp.Code = codes.PhoneNumberNotVerified
+			resp.Errors = err
+			return resp.Send(ctx)
+		}
+	}
+	merchantID := ctx.Get(constants.UserID).(primitive.ObjectID)
+	method, err = methodRepo.Activate(db, method, merchantID)
+	if err != nil {
+		logger.Log.Errorln(err)
+		return sendPayoutMethodError(ctx, err)
+	}
+	resp.Data = method
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
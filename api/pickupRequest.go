@@ -0,0 +1,355 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/techartificer/swiftex/constants"
+	"github.com/techartificer/swiftex/constants/codes"
+	"github.com/techartificer/swiftex/data"
+	"github.com/techartificer/swiftex/database"
+	"github.com/techartificer/swiftex/lib/errors"
+	"github.com/techartificer/swiftex/lib/response"
+	"github.com/techartificer/swiftex/logger"
+	"github.com/techartificer/swiftex/middlewares"
+	"github.com/techartificer/swiftex/models"
+	"github.com/techartificer/swiftex/validators"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func RegisterPickupRoutes(endpoint *echo.Group) {
+	endpoint.POST("/shop/:shopId/", createPickup, middlewares.JWTAuth(false), middlewares.RequirePermission(constants.OrderWrite), middlewares.HasShopAccess(), middlewares.RequireShopPermission(constants.ShopOrderCreate), middlewares.Audit(middlewares.AuditPickupRequest, ""))
+	endpoint.GET("/shop/:shopId/", shopPickups, middlewares.JWTAuth(false), middlewares.RequirePermission(constants.OrderRead), middlewares.HasShopAccess())
+	endpoint.PATCH("/shop/:shopId/:pickupId/cancel/", cancelPickup, middlewares.JWTAuth(false), middlewares.RequirePermission(constants.OrderWrite), middlewares.HasShopAccess(), middlewares.RequireShopPermission(constants.ShopOrderCreate), middlewares.Audit(middlewares.AuditPickupRequest, "pickupId"))
+	endpoint.GET("/", pickups, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.OrderRead), middlewares.HubScope())
+	endpoint.PATCH("/:pickupId/assign/", assignPickupRider, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.OrderAssign), middlewares.HubScope(), middlewares.Audit(middlewares.AuditPickupRequest, "pickupId"))
+	endpoint.GET("/rider/", riderPickups, middlewares.RiderJWTAuth(), middlewares.RequirePermission(constants.OrderRead))
+	endpoint.PATCH("/rider/:pickupId/picked/", confirmPickup, middlewares.RiderJWTAuth(), middlewares.RequirePermission(constants.OrderStatusWrite), middlewares.Audit(middlewares.AuditPickupRequest, "pickupId"))
+}
+
+// sendPickupError sends response of a failed pickup request update
+func sendPickupError(ctx echo.Context, err error) error {
+	resp := response.Response{}
+	if err == mongo.ErrNoDocuments {
+		resp.Title = "Pickup request not found"
+		resp.Status = http.StatusNotFound
+		resp.Code = codes.PickupNotFound
+		resp.Errors = errors.NewError(err.Error())
+		return resp.Send(ctx)
+	}
+	switch err.Error() {
+	case string(codes.InvalidPickupData):
+		resp.Title = "Orders must belong to the shop and be waiting for pickup"
+		resp.Status = http.StatusBadRequest
+		resp.Code = codes.InvalidPickupData
+	case string(codes.OrderAlreadyInPickup):
+		resp.Title = "Order is already in another pickup request"
+		resp.Status = http.StatusConflict
+		resp.Code = codes.OrderAlreadyInPickup
+	case string(codes.InvalidPickupTransition):
+		resp.Title = "Pickup request status does not allow the change"
+		resp.Status = http.StatusUnprocessableEntity
+		resp.Code = codes.InvalidPickupTransition
+	case string(codes.InvalidStatusTransition):
+		resp.Title = "Order status transition not allowed"
+		resp.Status = http.StatusUnprocessableEntity
+		resp.Code = codes.InvalidStatusTransition
+	default:
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+	}
+	resp.Errors = err
+	return resp.Send(ctx)
+}
+
+// pickupQuery returns query of pickup request list from query params status and lastId
+func pickupQuery(ctx echo.Context) (bson.M, int64, error) {
+	query := make(bson.M)
+	if lastID := ctx.QueryParam("lastId"); lastID != "" {
+		_id, err := primitive.ObjectIDFromHex(lastID)
+		if err != nil {
+			return nil, 0, err
+		}
+		query["_id"] = bson.M{"$lt": _id}
+	}
+	if status := ctx.QueryParam("status"); status != "" {
+		query["status"] = status
+	}
+	var limitNum int64 = 20
+	if limit := ctx.QueryParam("limit"); limit != "" {
+		ln, err := strconv.Atoi(limit)
+		if err != nil {
+			return nil, 0, err
+		}
+		if ln > 0 {
+			limitNum = int64(ln)
+		}
+	}
+	return query, limitNum, nil
+}
+
+// sendPickups sends pickup requests matching query
+func sendPickups(ctx echo.Context, query bson.M, limit int64) error {
+	resp := response.Response{}
+	db := database.GetDB()
+	pickupRepo := data.NewPickupRepo()
+	pickups, err := pickupRepo.Pickups(db, query, limit)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	resp.Data = func() []models.PickupRequest {
+		if pickups == nil {
+			return []models.PickupRequest{}
+		}
+		return *pickups
+	}()
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
+
+// createPickup requests the shop's parcels to be collected in a time window
+func createPickup(ctx echo.Context) error {
+	resp := response.Response{}
+	shop := ctx.Get("shop").(*models.Shop)
+	pickup, err := validators.ValidatePickupCreate(ctx, shop)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Invalid pickup request data"
+		resp.Status = http.StatusBadRequest
+		resp.Code = codes.InvalidPickupData
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	db := database.GetDB()
+	hub, err := data.NewLocationRepo().ResolveHub(db, pickup.Hub)
+	if err != nil {
+		logger.Log.Errorln(err)
+		return sendLocationError(ctx, err)
+	}
+	pickup.Hub = hub.Name
+	pickup.HubID = &hub.ID
+
+	middlewares.AuditTargets(ctx, pickup.ID)
+	pickupRepo := data.NewPickupRepo()
+	pickup, err = pickupRepo.Create(db, pickup)
+	if err != nil {
+		logger.Log.Errorln(err)
+		return sendPickupError(ctx, err)
+	}
+	resp.Data = pickup
+	resp.Status = http.StatusCreated
+	return resp.Send(ctx)
+}
+
+// shopPickups returns pickup history of the shop, newest first
+func shopPickups(ctx echo.Context) error {
+	resp := response.Response{}
+	query, limit, err := pickupQuery(ctx)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Invalid query"
+		resp.Status = http.StatusUnprocessableEntity
+		resp.Code = codes.InvalidPickupData
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	shop := ctx.Get("shop").(*models.Shop)
+	query["shopId"] = shop.ID
+	return sendPickups(ctx, query, limit)
+}
+
+func cancelPickup(ctx echo.Context) error {
+	resp := response.Response{}
+	pickupID, err := primitive.ObjectIDFromHex(ctx.Param("pickupId"))
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Invalid pickup ID"
+		resp.Status = http.StatusUnprocessableEntity
+		resp.Code = codes.InvalidMongoID
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	shop := ctx.Get("shop").(*models.Shop)
+	db := database.GetDB()
+	pickupRepo := data.NewPickupRepo()
+	pickup, err := pickupRepo.Cancel(db, shop.ID, pickupID)
+	if err != nil {
+		logger.Log.Errorln(err)
+		return sendPickupError(ctx, err)
+	}
+	resp.Data = pickup
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
+
+// pickups returns pickup requests of all shops, requests are restricted to hub scope of zone managers
+func pickups(ctx echo.Context) error {
+	resp := response.Response{}
+	query, limit, err := pickupQuery(ctx)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Invalid query"
+		resp.Status = http.StatusUnprocessableEntity
+		resp.Code = codes.InvalidPickupData
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	if shopID := ctx.QueryParam("shopId"); shopID != "" {
+		_id, err := primitive.ObjectIDFromHex(shopID)
+		if err != nil {
+			logger.Log.Errorln(err)
+			resp.Title = "Invalid shopId"
+			resp.Status = http.StatusUnprocessableEntity
+			resp.Code = codes.InvalidMongoID
+			resp.Errors = err
+			return resp.Send(ctx)
+		}
+		query["shopId"] = _id
+	}
+	if hub := ctx.QueryParam("hub"); hub != "" {
+		query["hub"] = hub
+	}
+	if hubs, ok := hubScope(ctx); ok {
+		query["hubId"] = bson.M{"$in": hubs}
+	}
+	return sendPickups(ctx, query, limit)
+}
+
+// assignPickupRider hands a pickup request over to a rider of its hub
+func assignPickupRider(ctx echo.Context) error {
+	resp := response.Response{}
+	pickupID, err := primitive.ObjectIDFromHex(ctx.Param("pickupId"))
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Invalid pickup ID"
+		resp.Status = http.StatusUnprocessableEntity
+		resp.Code = codes.InvalidMongoID
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	riderID, err := validators.ValidatePickupAssign(ctx)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Invalid pickup assign request data"
+		resp.Status = http.StatusBadRequest
+		resp.Code = codes.InvalidPickupData
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	db := database.GetDB()
+	pickupRepo := data.NewPickupRepo()
+	pickup, err := pickupRepo.PickupByID(db, pickupID)
+	if err != nil {
+		logger.Log.Errorln(err)
+		return sendPickupError(ctx, err)
+	}
+	riderRepo := data.NewRiderRepo()
+	rider, err := riderRepo.FindByID(db, riderID.Hex())
+	if err != nil {
+		logger.Log.Errorln(err)
+		if err == mongo.ErrNoDocuments {
+			resp.Title = "Rider not found"
+			resp.Status = http.StatusNotFound
+			resp.Code = codes.RiderNotFound
+			resp.Errors = errors.NewError(err.Error())
+			return resp.Send(ctx)
+		}
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	if rider.Status != constants.Active {
+		resp.Title = "Rider status not active"
+		resp.Status = http.StatusForbidden
+		resp.Code = codes.StatusNotActive
+		resp.Errors = errors.NewError("Rider status not active")
+		return resp.Send(ctx)
+	}
+	if !isRiderInScope(ctx, &models.Rider{HubID: pickup.HubID}) || !isRiderInScope(ctx, rider) {
+		resp.Title = "Pickup request or rider is out of your hubs"
+		resp.Status = http.StatusForbidden
+		resp.Code = codes.OutOfHubScope
+		resp.Errors = errors.NewError("Pickup request or rider is out of your hubs")
+		return resp.Send(ctx)
+	}
+	assignedBy := ctx.Get(constants.UserID).(primitive.ObjectID)
+	pickup, err = pickupRepo.Assign(db, pickupID, rider.ID, assignedBy)
+	if err != nil {
+		logger.Log.Errorln(err)
+		return sendPickupError(ctx, err)
+	}
+	resp.Data = pickup
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
+
+// riderPickups returns pickup requests assigned to the requesting rider
+func riderPickups(ctx echo.Context) error {
+	resp := response.Response{}
+	query, limit, err := pickupQuery(ctx)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Invalid query"
+		resp.Status = http.StatusUnprocessableEntity
+		resp.Code = codes.InvalidPickupData
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	if _, ok := query["status"]; !ok {
+		query["status"] = constants.Assigned
+	}
+	query["riderId"] = ctx.Get(constants.UserID).(primitive.ObjectID)
+	return sendPickups(ctx, query, limit)
+}
+
+// confirmPickup records orders the rider has collected from the shop
+func confirmPickup(ctx echo.Context) error {
+	resp := response.Response{}
+	pickupID, err := primitive.ObjectIDFromHex(ctx.Param("pickupId"))
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Invalid pickup ID"
+		resp.Status = http.StatusUnprocessableEntity
+		resp.Code = codes.InvalidMongoID
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	body, err := validators.ValidatePickupConfirm(ctx)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Invalid pickup confirm request data"
+		resp.Status = http.StatusBadRequest
+		resp.Code = codes.InvalidPickupData
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	riderID := ctx.Get(constants.UserID).(primitive.ObjectID)
+	db := database.GetDB()
+	pickupRepo := data.NewPickupRepo()
+	pickup, err := pickupRepo.ConfirmPicked(db, pickupID, riderID, body.OrderIDs, body.Complete)
+	if err != nil {
+		logger.Log.Errorln(err)
+		if err.Error() == string(codes.InvalidPickupData) {
+			resp.Title = "Order is not in the pickup request"
+			resp.Status = http.StatusBadRequest
+			resp.Code = codes.InvalidPickupData
+			resp.Errors = err
+			return resp.Send(ctx)
+		}
+		return sendPickupError(ctx, err)
+	}
+	resp.Data = pickup
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
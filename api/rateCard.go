@@ -0,0 +1,329 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/techartificer/swiftex/constants"
+	"github.com/techartificer/swiftex/constants/codes"
+	"github.com/techartificer/swiftex/data"
+	"github.com/techartificer/swiftex/database"
+	"github.com/techartificer/swiftex/lib/charge"
+	"github.com/techartificer/swiftex/lib/errors"
+	"github.com/techartificer/swiftex/lib/response"
+	"github.com/techartificer/swiftex/logger"
+	"github.com/techartificer/swiftex/middlewares"
+	"github.com/techartificer/swiftex/models"
+	"github.com/techartificer/swiftex/validators"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func RegisterRateCardRoutes(endpoint *echo.Group) {
+	endpoint.POST("/", createRateCard, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.RateCardManage))
+	endpoint.GET("/", rateCards, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.RateCardRead))
+	endpoint.GET("/id/:rateCardId/", rateCardByID, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.RateCardRead))
+	endpoint.DELETE("/id/:rateCardId/", deleteRateCard, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.RateCardManage))
+	endpoint.GET("/code/:code/versions/", rateCardVersions, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.RateCardRead))
+	endpoint.POST("/code/:code/versions/", createRateCardVersion, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.RateCardManage))
+	endpoint.PATCH("/assign/:shopId/", assignRateCard, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.RateCardManage))
+	endpoint.POST("/quote/:shopId/", quote, middlewares.JWTAuth(false), middlewares.RequirePermission(constants.RateCardRead), middlewares.HasShopAccess())
+}
+
+// parcelOf returns pricing attributes of an order
+func parcelOf(order *models.Order) charge.Parcel {
+	return charge.Parcel{
+		FromZone:      order.PickHub,
+		ToZone:        order.RecipientCity,
+		Weight:        order.Weight,
+		DeliveryType:  order.DeliveryType,
+		PercelType:    order.PercelType,
+		PaymentStatus: order.PaymentStatus,
+		Price:         order.Price,
+	}
+}
+
+// quoteCharge calculates charge with shop's rate card,
+// falls back to legacy charge if no rate card is applicable
+func quoteCharge(db *mongo.Database, shop *models.Shop, parcel charge.Parcel) (*charge.Breakdown, error) {
+	rateCardRepo := data.NewRateCardRepo()
+	card, err := rateCardRepo.ActiveRateCard(db, shop.RateCard, time.Now().UTC())
+	if err != nil && err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+	if card != nil {
+		if breakdown, ok := charge.Quote(card, parcel); ok {
+			return breakdown, nil
+		}
+	}
+	return charge.Legacy(parcel, shop.DeliveryCharge, shop.COD), nil
+}
+
+// applyCharge sets charge of order from shop's rate card
+func applyCharge(db *mongo.Database, shop *models.Shop, order *models.Order) error {
+	breakdown, err := quoteCharge(db, shop, parcelOf(order))
+	if err != nil {
+		return err
+	}
+	order.Charge = breakdown.Charge
+	order.CODCharge = breakdown.CODCharge
+	order.RateCardID = breakdown.RateCardID
+	return nil
+}
+
+func createRateCard(ctx echo.Context) error {
+	resp := response.Response{}
+	card, err := validators.ValidateRateCardCreate(ctx)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Invalid rate card request data"
+		resp.Status = http.StatusBadRequest
+		resp.Code = codes.InvalidRateCardData
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	db := database.GetDB()
+	rateCardRepo := data.NewRateCardRepo()
+	if err := rateCardRepo.Create(db, card); err != nil {
+		logger.Log.Errorln(err)
+		if errors.IsMongoDupError(err) {
+			resp.Title = "Rate card already exist"
+			resp.Status = http.StatusConflict
+			resp.Code = codes.RateCardAlreadyExist
+			resp.Errors = err
+			return resp.Send(ctx)
+		}
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	resp.Data = card
+	resp.Status = http.StatusCreated
+	return resp.Send(ctx)
+}
+
+func createRateCardVersion(ctx echo.Context) error {
+	resp := response.Response{}
+	card, err := validators.ValidateRateCardVersion(ctx)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Invalid rate card request data"
+		resp.Status = http.StatusBadRequest
+		resp.Code = codes.InvalidRateCardData
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	db := database.GetDB()
+	rateCardRepo := data.NewRateCardRepo()
+	if err := rateCardRepo.CreateVersion(db, card); err != nil {
+		logger.Log.Errorln(err)
+		if err == mongo.ErrNoDocuments {
+			resp.Title = "Rate card not found"
+			resp.Status = http.StatusNotFound
+			resp.Code = codes.RateCardNotFound
+			resp.Errors = errors.NewError(err.Error())
+			return resp.Send(ctx)
+		}
+		if errors.IsMongoDupError(err) {
+			resp.Title = "Rate card version already exist"
+			resp.Status = http.StatusConflict
+			resp.Code = codes.RateCardAlreadyExist
+			resp.Errors = err
+			return resp.Send(ctx)
+		}
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	resp.Data = card
+	resp.Status = http.StatusCreated
+	return resp.Send(ctx)
+}
+
+func rateCards(ctx echo.Context) error {
+	resp := response.Response{}
+	lastID := ctx.QueryParam("lastId")
+	db := database.GetDB()
+	rateCardRepo := data.NewRateCardRepo()
+	cards, err := rateCardRepo.RateCards(db, lastID)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	resp.Data = func() []models.RateCard {
+		if *cards == nil {
+			return []models.RateCard{}
+		}
+		return *cards
+	}()
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
+
+func rateCardVersions(ctx echo.Context) error {
+	resp := response.Response{}
+	code := ctx.Param("code")
+	db := database.GetDB()
+	rateCardRepo := data.NewRateCardRepo()
+	cards, err := rateCardRepo.Versions(db, code)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	resp.Data = func() []models.RateCard {
+		if *cards == nil {
+			return []models.RateCard{}
+		}
+		return *cards
+	}()
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
+
+func rateCardByID(ctx echo.Context) error {
+	resp := response.Response{}
+	ID := ctx.Param("rateCardId")
+	db := database.GetDB()
+	rateCardRepo := data.NewRateCardRepo()
+	card, err := rateCardRepo.RateCardByID(db, ID)
+	if err != nil {
+		logger.Log.Errorln(err)
+		if err == mongo.ErrNoDocuments {
+			resp.Title = "Rate card not found"
+			resp.Status = http.StatusNotFound
+			resp.Code = codes.RateCardNotFound
+			resp.Errors = errors.NewError(err.Error())
+			return resp.Send(ctx)
+		}
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	resp.Data = card
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
+
+func deleteRateCard(ctx echo.Context) error {
+	resp := response.Response{}
+	ID := ctx.Param("rateCardId")
+	db := database.GetDB()
+	rateCardRepo := data.NewRateCardRepo()
+	if err := rateCardRepo.Delete(db, ID); err != nil {
+		logger.Log.Errorln(err)
+		if err == mongo.ErrNoDocuments {
+			resp.Title = "Rate card not found"
+			resp.Status = http.StatusNotFound
+			resp.Code = codes.RateCardNotFound
+			resp.Errors = errors.NewError(err.Error())
+			return resp.Send(ctx)
+		}
+		if err.Error() == string(codes.RateCardNotDeletable) {
+			resp.Title = "Rate card already in effect"
+			resp.Status = http.StatusLocked
+			resp.Code = codes.RateCardNotDeletable
+			resp.Errors = err
+			return resp.Send(ctx)
+		}
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	resp.Title = "Rate card deleted"
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
+
+func assignRateCard(ctx echo.Context) error {
+	resp := response.Response{}
+	shopID := ctx.Param("shopId")
+	body, err := validators.ValidateRateCardAssign(ctx)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Invalid rate card assign request data"
+		resp.Status = http.StatusBadRequest
+		resp.Code = codes.InvalidRateCardData
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	db := database.GetDB()
+	rateCardRepo := data.NewRateCardRepo()
+	shop, err := rateCardRepo.AssignToShop(db, shopID, body.Code)
+	if err != nil {
+		logger.Log.Errorln(err)
+		if err.Error() == string(codes.RateCardNotFound) {
+			resp.Title = "Rate card not found"
+			resp.Status = http.StatusNotFound
+			resp.Code = codes.RateCardNotFound
+			resp.Errors = err
+			return resp.Send(ctx)
+		}
+		if err == mongo.ErrNoDocuments {
+			resp.Title = "Shop not found"
+			resp.Status = http.StatusNotFound
+			resp.Code = codes.ShopNotFound
+			resp.Errors = errors.NewError(err.Error())
+			return resp.Send(ctx)
+		}
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	resp.Data = shop
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
+
+func quote(ctx echo.Context) error {
+	resp := response.Response{}
+	body, err := validators.ValidateQuote(ctx)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Invalid quote request data"
+		resp.Status = http.StatusBadRequest
+		resp.Code = codes.InvalidQuoteData
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	db := database.GetDB()
+	shop := ctx.Get("shop").(*models.Shop)
+	parcel := charge.Parcel{
+		FromZone:      body.PickHub,
+		ToZone:        body.RecipientCity,
+		Weight:        body.Weight,
+		DeliveryType:  body.DeliveryType,
+		PercelType:    body.PercelType,
+		PaymentStatus: body.PaymentStatus,
+		Price:         body.Price,
+	}
+	breakdown, err := quoteCharge(db, shop, parcel)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	resp.Data = breakdown
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/labstack/echo/v4"
+	"github.com/techartificer/swiftex/constants"
 	"github.com/techartificer/swiftex/constants/codes"
 	"github.com/techartificer/swiftex/data"
 	"github.com/techartificer/swiftex/database"
@@ -14,12 +15,15 @@ import (
 	"github.com/techartificer/swiftex/middlewares"
 	"github.com/techartificer/swiftex/models"
 	"github.com/techartificer/swiftex/validators"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 func RegisterRiderRoutes(endpoint *echo.Group) {
-	endpoint.POST("/create/", createRider, middlewares.JWTAuth(true))
-	endpoint.GET("/", riders, middlewares.JWTAuth(true))
-	endpoint.GET("/:hub/", ridersByHub, middlewares.JWTAuth(true))
+	endpoint.POST("/create/", createRider, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.RiderManage), middlewares.Audit(middlewares.AuditRider, ""))
+	endpoint.GET("/", riders, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.RiderRead), middlewares.HubScope())
+	endpoint.PATCH("/capacity/:riderId/", updateRiderCapacity, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.RiderManage), middlewares.Audit(middlewares.AuditRider, "riderId"))
+	endpoint.GET("/:hub/", ridersByHub, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.RiderRead), middlewares.HubScope())
 }
 
 func createRider(ctx echo.Context) error {
@@ -45,7 +49,15 @@ func createRider(ctx echo.Context) error {
 	rider.Password = hash
 	riderRepo := data.NewRiderRepo()
 	db := database.GetDB()
+	hub, err := data.NewLocationRepo().ResolveHub(db, rider.Hub)
+	if err != nil {
+		logger.Log.Errorln(err)
+		return sendLocationError(ctx, err)
+	}
+	rider.Hub = hub.Name
+	rider.HubID = &hub.ID
 
+	middlewares.AuditTargets(ctx, rider.ID)
 	if err := riderRepo.Create(db, rider); err != nil {
 		logger.Log.Errorln(err)
 		if errors.IsMongoDupError(err) {
@@ -71,7 +83,8 @@ func ridersByHub(ctx echo.Context) error {
 	hub := ctx.Param("hub")
 	db := database.GetDB()
 	riderRepo := data.NewRiderRepo()
-	riders, err := riderRepo.RidersByHub(db, hub)
+	hubs, _ := hubScope(ctx)
+	riders, err := riderRepo.RidersByHub(db, hub, hubs)
 
 	if err != nil {
 		logger.Log.Errorln(err)
@@ -97,7 +110,8 @@ func riders(ctx echo.Context) error {
 	riderRepo := data.NewRiderRepo()
 	lastID := ctx.QueryParam("lastId")
 
-	riders, err := riderRepo.Riders(db, lastID)
+	hubs, _ := hubScope(ctx)
+	riders, err := riderRepo.Riders(db, lastID, hubs)
 	if err != nil {
 		logger.Log.Errorln(err)
 		resp.Title = "Something went wrong"
@@ -115,3 +129,46 @@ func riders(ctx echo.Context) error {
 	resp.Status = http.StatusOK
 	return resp.Send(ctx)
 }
+
+func updateRiderCapacity(ctx echo.Context) error {
+	resp := response.Response{}
+	riderID, err := primitive.ObjectIDFromHex(ctx.Param("riderId"))
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Invalid rider ID"
+		resp.Status = http.StatusUnprocessableEntity
+		resp.Code = codes.InvalidMongoID
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	capacity, err := validators.ValidateRiderCapacity(ctx)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Invalid rider capacity data"
+		resp.Status = http.StatusBadRequest
+		resp.Code = codes.InvalidRegisterData
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	db := database.GetDB()
+	riderRepo := data.NewRiderRepo()
+	rider, err := riderRepo.SetCapacity(db, riderID, capacity)
+	if err != nil {
+		logger.Log.Errorln(err)
+		if err == mongo.ErrNoDocuments {
+			resp.Title = "Rider not found"
+			resp.Status = http.StatusNotFound
+			resp.Code = codes.RiderNotFound
+			resp.Errors = errors.NewError(err.Error())
+			return resp.Send(ctx)
+		}
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	resp.Data = rider
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
@@ -0,0 +1,189 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/techartificer/swiftex/config"
+	"github.com/techartificer/swiftex/constants"
+	"github.com/techartificer/swiftex/constants/codes"
+	"github.com/techartificer/swiftex/data"
+	"github.com/techartificer/swiftex/database"
+	"github.com/techartificer/swiftex/lib/errors"
+	"github.com/techartificer/swiftex/lib/response"
+	"github.com/techartificer/swiftex/logger"
+	"github.com/techartificer/swiftex/middlewares"
+	"github.com/techartificer/swiftex/models"
+	"github.com/techartificer/swiftex/serializer"
+	"github.com/techartificer/swiftex/validators"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func RegisterRiderCashRoutes(endpoint *echo.Group) {
+	endpoint.GET("/in-hand/", myCashInHand, middlewares.RiderJWTAuth(), middlewares.RequirePermission(constants.RiderCash))
+	endpoint.POST("/deposit/:riderId/", depositRiderCash, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.RiderCash))
+	endpoint.GET("/deposits/", cashDeposits, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.RiderCash))
+	endpoint.GET("/outstanding/riders/", outstandingByRider, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.RiderCash))
+	endpoint.GET("/outstanding/hubs/", outstandingByHub, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.RiderCash))
+}
+
+func myCashInHand(ctx echo.Context) error {
+	resp := response.Response{}
+	riderID := ctx.Get(constants.UserID).(primitive.ObjectID)
+	db := database.GetDB()
+	riderCashRepo := data.NewRiderCashRepo()
+	cashInHand, err := riderCashRepo.CashInHand(db, riderID)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	resp.Data = map[string]interface{}{
+		"cashInHand": cashInHand,
+		"limit":      config.GetCash().RiderLimit,
+	}
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
+
+func depositRiderCash(ctx echo.Context) error {
+	resp := response.Response{}
+	deposit, err := validators.ValidateCashDeposit(ctx)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Invalid cash deposit request data"
+		resp.Status = http.StatusBadRequest
+		resp.Code = codes.InvalidCashDepositData
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	db := database.GetDB()
+	riderCashRepo := data.NewRiderCashRepo()
+	deposit, err = riderCashRepo.Deposit(db, deposit)
+	if err != nil {
+		logger.Log.Errorln(err)
+		if err == mongo.ErrNoDocuments {
+			resp.Title = "Rider not found"
+			resp.Status = http.StatusNotFound
+			resp.Code = codes.RiderNotFound
+			resp.Errors = errors.NewError(err.Error())
+			return resp.Send(ctx)
+		}
+		if err.Error() == string(codes.DepositExceedsCashInHand) {
+			resp.Title = "Deposit is more than the cash rider is holding"
+			resp.Status = http.StatusUnprocessableEntity
+			resp.Code = codes.DepositExceedsCashInHand
+			resp.Errors = err
+			return resp.Send(ctx)
+		}
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	resp.Data = deposit
+	resp.Status = http.StatusCreated
+	return resp.Send(ctx)
+}
+
+func cashDeposits(ctx echo.Context) error {
+	resp := response.Response{}
+	riderID, hub, lastID := ctx.QueryParam("riderId"), ctx.QueryParam("hub"), ctx.QueryParam("lastId")
+	startDate, endDate := ctx.QueryParam("startDate"), ctx.QueryParam("endDate")
+	var tms, tme time.Time
+	if startDate != "" && endDate != "" {
+		std, err := strconv.ParseInt(startDate, 10, 64) // startDate
+		if err != nil {
+			logger.Log.Errorln(err)
+			resp.Title = "Invalid timestamp"
+			resp.Status = http.StatusUnprocessableEntity
+			resp.Code = codes.SomethingWentWrong
+			resp.Errors = err
+			return resp.Send(ctx)
+		}
+		tms = time.Unix(std/1000, 0) //std => startDate
+		end, err := strconv.ParseInt(endDate, 10, 64)
+		if err != nil {
+			logger.Log.Errorln(err)
+			resp.Title = "Invalid timestamp"
+			resp.Status = http.StatusUnprocessableEntity
+			resp.Code = codes.SomethingWentWrong
+			resp.Errors = err
+			return resp.Send(ctx)
+		}
+		tme = time.Unix(end/1000, 0)
+	}
+	db := database.GetDB()
+	riderCashRepo := data.NewRiderCashRepo()
+	deposits, err := riderCashRepo.Deposits(db, riderID, hub, lastID, &tms, &tme)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	resp.Data = func() []models.CashDeposit {
+		if *deposits == nil {
+			return []models.CashDeposit{}
+		}
+		return *deposits
+	}()
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
+
+func outstandingByRider(ctx echo.Context) error {
+	resp := response.Response{}
+	hub := ctx.QueryParam("hub")
+	db := database.GetDB()
+	riderCashRepo := data.NewRiderCashRepo()
+	riders, err := riderCashRepo.OutstandingByRider(db, hub)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	resp.Data = func() []serializer.RiderCash {
+		if *riders == nil {
+			return []serializer.RiderCash{}
+		}
+		return *riders
+	}()
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
+
+func outstandingByHub(ctx echo.Context) error {
+	resp := response.Response{}
+	db := database.GetDB()
+	riderCashRepo := data.NewRiderCashRepo()
+	hubs, err := riderCashRepo.OutstandingByHub(db)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	resp.Data = func() []serializer.HubCash {
+		if *hubs == nil {
+			return []serializer.HubCash{}
+		}
+		return *hubs
+	}()
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
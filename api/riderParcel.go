@@ -0,0 +1,105 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/techartificer/swiftex/constants"
+	"github.com/techartificer/swiftex/constants/codes"
+	"github.com/techartificer/swiftex/data"
+	"github.com/techartificer/swiftex/database"
+	"github.com/techartificer/swiftex/lib/errors"
+	"github.com/techartificer/swiftex/lib/response"
+	"github.com/techartificer/swiftex/logger"
+	"github.com/techartificer/swiftex/models"
+	"github.com/techartificer/swiftex/validators"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// parcelStepFunc moves a parcel of the rider to its next status
+type parcelStepFunc func(db *mongo.Database, riderID, parcelID primitive.ObjectID) (*models.RiderParcel, error)
+
+func acceptParcel(ctx echo.Context) error {
+	return moveParcel(ctx, data.NewRiderParcelRepo().Accept)
+}
+
+func pickupParcel(ctx echo.Context) error {
+	return moveParcel(ctx, data.NewRiderParcelRepo().Pickup)
+}
+
+func returnParcel(ctx echo.Context) error {
+	return moveParcel(ctx, data.NewRiderParcelRepo().ReturnToHub)
+}
+
+func failParcelAttempt(ctx echo.Context) error {
+	resp := response.Response{}
+	attempt, err := validators.ValidateDeliveryAttempt(ctx)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Invalid delivery attempt data"
+		resp.Status = http.StatusBadRequest
+		resp.Code = codes.InvalidParcelData
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	return moveParcel(ctx, func(db *mongo.Database, riderID, parcelID primitive.ObjectID) (*models.RiderParcel, error) {
+		return data.NewRiderParcelRepo().FailAttempt(db, riderID, parcelID, attempt)
+	})
+}
+
+// moveParcel runs a workflow step on a parcel assigned to the requesting rider
+func moveParcel(ctx echo.Context, step parcelStepFunc) error {
+	resp := response.Response{}
+	parcelID, err := primitive.ObjectIDFromHex(ctx.Param("parcelId"))
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Invalid parcel ID"
+		resp.Status = http.StatusUnprocessableEntity
+		resp.Code = codes.InvalidMongoID
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	if ctx.Get(constants.AccountType) != constants.RiderType {
+		resp.Title = "Only riders can update their parcels"
+		resp.Status = http.StatusForbidden
+		resp.Code = codes.InvalidAccountType
+		resp.Errors = errors.NewError("Only riders can update their parcels")
+		return resp.Send(ctx)
+	}
+	riderID := ctx.Get(constants.UserID).(primitive.ObjectID)
+	db := database.GetDB()
+	parcel, err := step(db, riderID, parcelID)
+	if err != nil {
+		logger.Log.Errorln(err)
+		if err == mongo.ErrNoDocuments {
+			resp.Title = "Parcel not found"
+			resp.Status = http.StatusNotFound
+			resp.Code = codes.ParcelNotFound
+			resp.Errors = errors.NewError(err.Error())
+			return resp.Send(ctx)
+		}
+		if err.Error() == string(codes.InvalidParcelTransition) {
+			resp.Title = "Parcel status transition not allowed"
+			resp.Status = http.StatusUnprocessableEntity
+			resp.Code = codes.InvalidParcelTransition
+			resp.Errors = err
+			return resp.Send(ctx)
+		}
+		if err.Error() == string(codes.InvalidStatusTransition) {
+			resp.Title = "Order status transition not allowed"
+			resp.Status = http.StatusUnprocessableEntity
+			resp.Code = codes.InvalidStatusTransition
+			resp.Errors = err
+			return resp.Send(ctx)
+		}
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	resp.Data = parcel
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
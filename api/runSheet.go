@@ -0,0 +1,327 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/techartificer/swiftex/constants"
+	"github.com/techartificer/swiftex/constants/codes"
+	"github.com/techartificer/swiftex/data"
+	"github.com/techartificer/swiftex/database"
+	"github.com/techartificer/swiftex/lib/errors"
+	"github.com/techartificer/swiftex/lib/response"
+	"github.com/techartificer/swiftex/lib/runsheet"
+	"github.com/techartificer/swiftex/logger"
+	"github.com/techartificer/swiftex/middlewares"
+	"github.com/techartificer/swiftex/models"
+	"github.com/techartificer/swiftex/validators"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func RegisterRunSheetRoutes(endpoint *echo.Group) {
+	endpoint.POST("/", openRunSheet, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.OrderAssign), middlewares.HubScope(), middlewares.Audit(middlewares.AuditRunSheet, ""))
+	endpoint.GET("/", runSheets, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.RiderRead), middlewares.HubScope())
+	endpoint.GET("/rider/", myRunSheet, middlewares.RiderJWTAuth(), middlewares.RequirePermission(constants.OrderRead))
+	endpoint.GET("/:runSheetId/", runSheetByID, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.RiderRead), middlewares.HubScope())
+	endpoint.GET("/:runSheetId/pdf/", runSheetPDF, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.RiderRead), middlewares.HubScope())
+	endpoint.PATCH("/:runSheetId/close/", closeRunSheet, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.OrderAssign), middlewares.HubScope(), middlewares.Audit(middlewares.AuditRunSheet, "runSheetId"))
+}
+
+// isRunSheetInScope reports whether hub of the run sheet is in hub scope of the requester
+func isRunSheetInScope(ctx echo.Context, sheet *models.RunSheet) bool {
+	return isRiderInScope(ctx, &models.Rider{HubID: sheet.HubID})
+}
+
+// findRunSheet returns run sheet of route param runSheetId, sent is true if an error response is sent
+func findRunSheet(ctx echo.Context) (*models.RunSheet, bool, error) {
+	resp := response.Response{}
+	sheetID, err := primitive.ObjectIDFromHex(ctx.Param("runSheetId"))
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Invalid run sheet ID"
+		resp.Status = http.StatusUnprocessableEntity
+		resp.Code = codes.InvalidMongoID
+		resp.Errors = err
+		return nil, true, resp.Send(ctx)
+	}
+	db := database.GetDB()
+	runSheetRepo := data.NewRunSheetRepo()
+	sheet, err := runSheetRepo.RunSheetByID(db, sheetID)
+	if err != nil {
+		logger.Log.Errorln(err)
+		if err == mongo.ErrNoDocuments {
+			resp.Title = "Run sheet not found"
+			resp.Status = http.StatusNotFound
+			resp.Code = codes.RunSheetNotFound
+			resp.Errors = errors.NewError(err.Error())
+			return nil, true, resp.Send(ctx)
+		}
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return nil, true, resp.Send(ctx)
+	}
+	if !isRunSheetInScope(ctx, sheet) {
+		resp.Title = "Run sheet is out of your hubs"
+		resp.Status = http.StatusForbidden
+		resp.Code = codes.OutOfHubScope
+		resp.Errors = errors.NewError("Run sheet is out of your hubs")
+		return nil, true, resp.Send(ctx)
+	}
+	return sheet, false, nil
+}
+
+// openRunSheet lists parcels a rider carries out on a date
+func openRunSheet(ctx echo.Context) error {
+	resp := response.Response{}
+	body, err := validators.ValidateRunSheetOpen(ctx)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Invalid run sheet request data"
+		resp.Status = http.StatusBadRequest
+		resp.Code = codes.InvalidRunSheetData
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	db := database.GetDB()
+	riderRepo := data.NewRiderRepo()
+	rider, err := riderRepo.FindByID(db, body.RiderID.Hex())
+	if err != nil {
+		logger.Log.Errorln(err)
+		if err == mongo.ErrNoDocuments {
+			resp.Title = "Rider not found"
+			resp.Status = http.StatusNotFound
+			resp.Code = codes.RiderNotFound
+			resp.Errors = errors.NewError(err.Error())
+			return resp.Send(ctx)
+		}
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	if !isRiderInScope(ctx, rider) {
+		resp.Title = "Rider is out of your hubs"
+		resp.Status = http.StatusForbidden
+		resp.Code = codes.OutOfHubScope
+		resp.Errors = errors.NewError("Rider is out of your hubs")
+		return resp.Send(ctx)
+	}
+	now := time.Now().UTC()
+	sheet := &models.RunSheet{
+		ID:         primitive.NewObjectID(),
+		RiderID:    rider.ID,
+		RiderName:  rider.Name,
+		RiderPhone: rider.Phone,
+		Hub:        rider.Hub,
+		HubID:      rider.HubID,
+		Date:       body.Date,
+		Status:     constants.Open,
+		OpenedBy:   ctx.Get(constants.UserID).(primitive.ObjectID),
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	middlewares.AuditTargets(ctx, sheet.ID)
+	runSheetRepo := data.NewRunSheetRepo()
+	sheet, err = runSheetRepo.Open(db, sheet)
+	if err != nil {
+		logger.Log.Errorln(err)
+		if err.Error() == string(codes.RunSheetAlreadyExist) {
+			resp.Title = "Rider already has a run sheet for the date"
+			resp.Status = http.StatusConflict
+			resp.Code = codes.RunSheetAlreadyExist
+			resp.Errors = err
+			return resp.Send(ctx)
+		}
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	resp.Data = sheet
+	resp.Status = http.StatusCreated
+	return resp.Send(ctx)
+}
+
+// runSheets searches run sheets by rider, date and status, newest first
+func runSheets(ctx echo.Context) error {
+	resp := response.Response{}
+	query := make(bson.M)
+	for _, param := range []string{"riderId", "lastId"} {
+		value := ctx.QueryParam(param)
+		if value == "" {
+			continue
+		}
+		_id, err := primitive.ObjectIDFromHex(value)
+		if err != nil {
+			logger.Log.Errorln(err)
+			resp.Title = "Invalid " + param
+			resp.Status = http.StatusUnprocessableEntity
+			resp.Code = codes.InvalidMongoID
+			resp.Errors = err
+			return resp.Send(ctx)
+		}
+		if param == "lastId" {
+			query["_id"] = bson.M{"$lt": _id}
+		} else {
+			query["riderId"] = _id
+		}
+	}
+	if ctx.QueryParam("date") != "" {
+		date, err := validators.ValidateRunSheetDate(ctx)
+		if err != nil {
+			logger.Log.Errorln(err)
+			resp.Title = "Invalid date"
+			resp.Status = http.StatusBadRequest
+			resp.Code = codes.InvalidRunSheetData
+			resp.Errors = err
+			return resp.Send(ctx)
+		}
+		query["date"] = date
+	}
+	if status := ctx.QueryParam("status"); status != "" {
+		query["status"] = status
+	}
+	if hubs, ok := hubScope(ctx); ok {
+		query["hubId"] = bson.M{"$in": hubs}
+	}
+	var limitNum int64 = 20
+	if limit := ctx.QueryParam("limit"); limit != "" {
+		ln, err := strconv.Atoi(limit)
+		if err != nil || ln <= 0 {
+			logger.Log.Errorln(err)
+			resp.Errors = err
+			resp.Title = "Invalid limit"
+			resp.Status = http.StatusUnprocessableEntity
+			resp.Code = codes.InvalidLimit
+			return resp.Send(ctx)
+		}
+		limitNum = int64(ln)
+	}
+	db := database.GetDB()
+	runSheetRepo := data.NewRunSheetRepo()
+	sheets, err := runSheetRepo.RunSheets(db, query, limitNum)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	resp.Data = func() []models.RunSheet {
+		if sheets == nil {
+			return []models.RunSheet{}
+		}
+		return *sheets
+	}()
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
+
+// myRunSheet returns run sheet of the requesting rider, today is the default date
+func myRunSheet(ctx echo.Context) error {
+	resp := response.Response{}
+	date, err := validators.ValidateRunSheetDate(ctx)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Invalid date"
+		resp.Status = http.StatusBadRequest
+		resp.Code = codes.InvalidRunSheetData
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	riderID := ctx.Get(constants.UserID).(primitive.ObjectID)
+	db := database.GetDB()
+	runSheetRepo := data.NewRunSheetRepo()
+	sheet, err := runSheetRepo.RunSheetByRider(db, riderID, date)
+	if err != nil {
+		logger.Log.Errorln(err)
+		if err == mongo.ErrNoDocuments {
+			resp.Title = "Run sheet not found"
+			resp.Status = http.StatusNotFound
+			resp.Code = codes.RunSheetNotFound
+			resp.Errors = errors.NewError(err.Error())
+			return resp.Send(ctx)
+		}
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	resp.Data = sheet
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
+
+func runSheetByID(ctx echo.Context) error {
+	resp := response.Response{}
+	sheet, sent, err := findRunSheet(ctx)
+	if sent {
+		return err
+	}
+	resp.Data = sheet
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
+
+func runSheetPDF(ctx echo.Context) error {
+	resp := response.Response{}
+	sheet, sent, err := findRunSheet(ctx)
+	if sent {
+		return err
+	}
+	buf := &bytes.Buffer{}
+	if err := runsheet.Render(buf, sheet); err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Run sheet generation failed"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.SomethingWentWrong
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	filename := fmt.Sprintf("run-sheet-%s-%s", sheet.Date, sheet.RiderPhone)
+	ctx.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`inline; filename="%s.pdf"`, filename))
+	return ctx.Blob(http.StatusOK, "application/pdf", buf.Bytes())
+}
+
+// closeRunSheet reconciles parcels of the run sheet at end of day
+func closeRunSheet(ctx echo.Context) error {
+	resp := response.Response{}
+	sheet, sent, err := findRunSheet(ctx)
+	if sent {
+		return err
+	}
+	closedBy := ctx.Get(constants.UserID).(primitive.ObjectID)
+	db := database.GetDB()
+	runSheetRepo := data.NewRunSheetRepo()
+	sheet, err = runSheetRepo.Close(db, sheet.ID, closedBy)
+	if err != nil {
+		logger.Log.Errorln(err)
+		if err.Error() == string(codes.RunSheetClosed) {
+			resp.Title = "Run sheet is already closed"
+			resp.Status = http.StatusUnprocessableEntity
+			resp.Code = codes.RunSheetClosed
+			resp.Errors = err
+			return resp.Send(ctx)
+		}
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	resp.Data = sheet
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/techartificer/swiftex/constants"
+	"github.com/techartificer/swiftex/constants/codes"
+	"github.com/techartificer/swiftex/data"
+	"github.com/techartificer/swiftex/lib/errors"
+	"github.com/techartificer/swiftex/lib/response"
+	"github.com/techartificer/swiftex/logger"
+	"github.com/techartificer/swiftex/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// hubScope returns assigned hubs of a zone manager, ok is false for unrestricted admins
+func hubScope(ctx echo.Context) ([]primitive.ObjectID, bool) {
+	hubs, ok := ctx.Get(constants.HubScope).([]primitive.ObjectID)
+	return hubs, ok
+}
+
+// orderScope matches orders picked up or delivered by one of the hubs
+func orderScope(hubs []primitive.ObjectID) []bson.M {
+	return []bson.M{
+		{"pickHubId": bson.M{"$in": hubs}},
+		{"deliveryHubId": bson.M{"$in": hubs}},
+	}
+}
+
+// ordersInScope reports whether all of the orders are in hub scope of the requester
+func ordersInScope(ctx echo.Context, db *mongo.Database, orderIDs ...primitive.ObjectID) (bool, error) {
+	hubs, ok := hubScope(ctx)
+	if !ok {
+		return true, nil
+	}
+	orderRepo := data.NewOrderRepo()
+	query := bson.M{"_id": bson.M{"$in": orderIDs}, "$or": orderScope(hubs)}
+	count, err := orderRepo.CountOrders(db, query)
+	if err != nil {
+		return false, err
+	}
+	return count == int64(len(orderIDs)), nil
+}
+
+// isRiderInScope reports whether rider belongs to hub scope of the requester
+func isRiderInScope(ctx echo.Context, rider *models.Rider) bool {
+	hubs, ok := hubScope(ctx)
+	if !ok {
+		return true
+	}
+	if rider.HubID == nil {
+		return false
+	}
+	for _, hub := range hubs {
+		if hub == *rider.HubID {
+			return true
+		}
+	}
+	return false
+}
+
+// checkOrdersScope sends forbidden response if any of the orders is out of hub scope,
+// sent is true if a response is sent
+func checkOrdersScope(ctx echo.Context, db *mongo.Database, orderIDs ...primitive.ObjectID) (bool, error) {
+	resp := response.Response{}
+	inScope, err := ordersInScope(ctx, db, orderIDs...)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return true, resp.Send(ctx)
+	}
+	if !inScope {
+		resp.Title = "Order is out of your hubs"
+		resp.Status = http.StatusForbidden
+		resp.Code = codes.OutOfHubScope
+		resp.Errors = errors.NewError("Order is out of your hubs")
+		return true, resp.Send(ctx)
+	}
+	return false, nil
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/techartificer/swiftex/lib/response"
 	"github.com/techartificer/swiftex/logger"
 	"github.com/techartificer/swiftex/middlewares"
+	"github.com/techartificer/swiftex/models"
 	"github.com/techartificer/swiftex/validators"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -22,14 +23,22 @@ import (
 )
 
 func RegisterShopRoutes(endpoint *echo.Group) {
-	endpoint.POST("/create/", shopCreate, middlewares.JWTAuth(false))
-	endpoint.GET("/myshops/", myShops, middlewares.JWTAuth(false))
-	endpoint.GET("/all-shops/", allShops, middlewares.JWTAuth(true))
-	endpoint.GET("/id/:shopId/", shopByID, middlewares.JWTAuth(false), middlewares.HasShopAccess())
-	endpoint.PATCH("/id/:shopId/", updateShop, middlewares.JWTAuth(false), middlewares.IsShopOwner())
-	endpoint.GET("/search/", searchShop, middlewares.JWTAuth(true))
-	endpoint.GET("/dashboard/:shopId/", dashboard, middlewares.JWTAuth(false), middlewares.HasShopAccess())
-	endpoint.GET("/all-shops-name/", allShopsName, middlewares.JWTAuth(true))
+	endpoint.POST("/create/", shopCreate, middlewares.JWTAuth(false), middlewares.RequirePermission(constants.ShopManage), middlewares.Audit(middlewares.AuditShop, ""))
+	endpoint.GET("/myshops/", myShops, middlewares.JWTAuth(false), middlewares.RequirePermission(constants.ShopRead))
+	endpoint.GET("/all-shops/", allShops, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.ShopRead))
+	endpoint.GET("/id/:shopId/", shopByID, middlewares.JWTAuth(false), middlewares.RequirePermission(constants.ShopRead), middlewares.HasShopAccess())
+	endpoint.PATCH("/id/:shopId/", updateShop, middlewares.JWTAuth(false), middlewares.RequirePermission(constants.ShopManage), middlewares.IsShopOwner(), middlewares.Audit(middlewares.AuditShop, "shopId"))
+	endpoint.POST("/id/:shopId/image/", uploadShopImage, middlewares.JWTAuth(false), middlewares.RequirePermission(constants.ShopManage), middlewares.IsShopOwner(), middlewares.Audit(middlewares.AuditShop, "shopId"))
+	endpoint.GET("/search/", searchShop, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.ShopRead))
+	endpoint.GET("/dashboard/:shopId/", dashboard, middlewares.JWTAuth(false), middlewares.RequirePermission(constants.ShopRead), middlewares.HasShopAccess())
+	endpoint.GET("/all-shops-name/", allShopsName, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.ShopRead))
+	endpoint.POST("/id/:shopId/moderators/", inviteModerator, middlewares.JWTAuth(false), middlewares.IsShopOwnerStrict(), middlewares.Audit(middlewares.AuditShopModerator, ""))
+	endpoint.GET("/id/:shopId/moderators/", shopModerators, middlewares.JWTAuth(false), middlewares.RequirePermission(constants.ShopRead), middlewares.IsShopOwner())
+	endpoint.PATCH("/id/:shopId/moderators/:moderatorId/", updateModerator, middlewares.JWTAuth(false), middlewares.IsShopOwnerStrict(), middlewares.Audit(middlewares.AuditShopModerator, "moderatorId"))
+	endpoint.DELETE("/id/:shopId/moderators/:moderatorId/", revokeModerator, middlewares.JWTAuth(false), middlewares.RequirePermission(constants.ShopManage), middlewares.IsShopOwner(), middlewares.Audit(middlewares.AuditShopModerator, "moderatorId"))
+	endpoint.GET("/invitations/", moderatorInvitations, middlewares.JWTAuth(false))
+	endpoint.PATCH("/invitations/:moderatorId/accept/", acceptInvitation, middlewares.JWTAuth(false), middlewares.Audit(middlewares.AuditShopModerator, "moderatorId"))
+	endpoint.PATCH("/invitations/:moderatorId/decline/", declineInvitation, middlewares.JWTAuth(false), middlewares.Audit(middlewares.AuditShopModerator, "moderatorId"))
 }
 
 func dashboard(ctx echo.Context) error {
@@ -116,6 +125,7 @@ func searchShop(ctx echo.Context) error {
 		resp.Errors = err
 		return resp.Send(ctx)
 	}
+	setShopsURLs(shops)
 	resp.Data = shops
 	resp.Status = http.StatusOK
 	return resp.Send(ctx)
@@ -159,6 +169,7 @@ func shopCreate(ctx echo.Context) error {
 		return resp.Send(ctx)
 	}
 	shop.ShopID = slug.Make(shop.Name)
+	middlewares.AuditTargets(ctx, shop.ID)
 	trx, err := shopRepo.Create(db, shop)
 	if err != nil {
 		logger.Log.Errorln(err)
@@ -209,6 +220,7 @@ func allShops(ctx echo.Context) error {
 		resp.Code = codes.DatabaseQueryFailed
 		resp.Errors = err
 	}
+	setShopsURLs(shops)
 	resp.Data = shops
 	resp.Status = http.StatusOK
 	return resp.Send(ctx)
@@ -248,6 +260,7 @@ func updateShop(ctx echo.Context) error {
 		return resp.Send(ctx)
 	}
 	resp.Status = http.StatusOK
+	setShopURLs(updatedShop)
 	resp.Data = updatedShop
 	return resp.Send(ctx)
 }
@@ -266,6 +279,7 @@ func myShops(ctx echo.Context) error {
 		resp.Errors = err
 		return resp.Send(ctx)
 	}
+	setShopsURLs(shops)
 	resp.Data = shops
 	resp.Status = http.StatusOK
 	return resp.Send(ctx)
@@ -273,8 +287,50 @@ func myShops(ctx echo.Context) error {
 
 func shopByID(ctx echo.Context) error {
 	resp := response.Response{}
-	shop := ctx.Get("shop")
+	shop := ctx.Get("shop").(*models.Shop)
+	setShopURLs(shop)
 	resp.Data = shop
 	resp.Status = http.StatusOK
 	return resp.Send(ctx)
 }
+
+// uploadShopImage replaces image of the shop
+func uploadShopImage(ctx echo.Context) error {
+	resp := response.Response{}
+	image, err := validators.ValidateImageUpload(ctx)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Invalid image"
+		resp.Status = http.StatusBadRequest
+		resp.Code = codes.InvalidFileData
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	shop := ctx.Get("shop").(*models.Shop)
+	key, err := storeImage("shops/"+shop.ID.Hex(), "image", image, shopImageSize)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Failed to store image"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.FileUploadFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	db := database.GetDB()
+	shopRepo := data.NewShopRepo()
+	updatedShop, err := shopRepo.SetImage(db, shop.ID, key)
+	if err != nil {
+		logger.Log.Errorln(err)
+		removeFile(key)
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	removeFile(shop.Image)
+	setShopURLs(updatedShop)
+	resp.Data = updatedShop
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
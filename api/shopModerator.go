@@ -0,0 +1,246 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/techartificer/swiftex/constants"
+	"github.com/techartificer/swiftex/constants/codes"
+	"github.com/techartificer/swiftex/data"
+	"github.com/techartificer/swiftex/database"
+	"github.com/techartificer/swiftex/lib/errors"
+	"github.com/techartificer/swiftex/lib/response"
+	"github.com/techartificer/swiftex/logger"
+	"github.com/techartificer/swiftex/middlewares"
+	"github.com/techartificer/swiftex/models"
+	"github.com/techartificer/swiftex/validators"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// shopModeratorOf returns merchant ID of the requesting moderator, nil for owners and admins
+func shopModeratorOf(ctx echo.Context) *primitive.ObjectID {
+	moderator, ok := ctx.Get(constants.ActiveModerator).(*models.ShopModerator)
+	if !ok {
+		return nil
+	}
+	return moderator.MerchantID
+}
+
+func inviteModerator(ctx echo.Context) error {
+	resp := response.Response{}
+	shop := ctx.Get("shop").(*models.Shop)
+	moderator, err := validators.ValidateModeratorInvite(ctx, shop)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Invalid moderator request data"
+		resp.Status = http.StatusBadRequest
+		resp.Code = codes.InvalidModeratorData
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	db := database.GetDB()
+	moderatorRepo := data.NewShopModeratorRepo()
+	// renewed invitations keep their ID
+	if existing, err := moderatorRepo.ModeratorByPhone(db, shop.ID, moderator.Phone); err == nil {
+		moderator.ID = existing.ID
+	}
+	middlewares.AuditTargets(ctx, moderator.ID)
+	invitation, err := moderatorRepo.Invite(db, moderator)
+	if err != nil {
+		logger.Log.Errorln(err)
+		if err.Error() == string(codes.ModeratorAlreadyExist) {
+			resp.Title = "Moderator already exist"
+			resp.Status = http.StatusConflict
+			resp.Code = codes.ModeratorAlreadyExist
+			resp.Errors = err
+			return resp.Send(ctx)
+		}
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	resp.Data = invitation
+	resp.Status = http.StatusCreated
+	return resp.Send(ctx)
+}
+
+func shopModerators(ctx echo.Context) error {
+	resp := response.Response{}
+	shop := ctx.Get("shop").(*models.Shop)
+	db := database.GetDB()
+	moderatorRepo := data.NewShopModeratorRepo()
+	moderators, err := moderatorRepo.Moderators(db, shop.ID)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	resp.Data = func() []models.ShopModerator {
+		if moderators == nil {
+			return []models.ShopModerator{}
+		}
+		return *moderators
+	}()
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
+
+func updateModerator(ctx echo.Context) error {
+	resp := response.Response{}
+	moderatorID, err := primitive.ObjectIDFromHex(ctx.Param("moderatorId"))
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Invalid moderator ID"
+		resp.Status = http.StatusUnprocessableEntity
+		resp.Code = codes.InvalidMongoID
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	permissions, err := validators.ValidateModeratorPermissions(ctx)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Invalid moderator request data"
+		resp.Status = http.StatusBadRequest
+		resp.Code = codes.InvalidModeratorData
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	shop := ctx.Get("shop").(*models.Shop)
+	db := database.GetDB()
+	moderatorRepo := data.NewShopModeratorRepo()
+	moderator, err := moderatorRepo.UpdatePermissions(db, shop.ID, moderatorID, permissions)
+	if err != nil {
+		logger.Log.Errorln(err)
+		if err == mongo.ErrNoDocuments {
+			resp.Title = "Moderator not found"
+			resp.Status = http.StatusNotFound
+			resp.Code = codes.ModeratorNotFound
+			resp.Errors = errors.NewError(err.Error())
+			return resp.Send(ctx)
+		}
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	resp.Data = moderator
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
+
+func revokeModerator(ctx echo.Context) error {
+	resp := response.Response{}
+	moderatorID, err := primitive.ObjectIDFromHex(ctx.Param("moderatorId"))
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Invalid moderator ID"
+		resp.Status = http.StatusUnprocessableEntity
+		resp.Code = codes.InvalidMongoID
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	shop := ctx.Get("shop").(*models.Shop)
+	db := database.GetDB()
+	moderatorRepo := data.NewShopModeratorRepo()
+	moderator, err := moderatorRepo.Revoke(db, shop.ID, moderatorID)
+	if err != nil {
+		logger.Log.Errorln(err)
+		if err == mongo.ErrNoDocuments {
+			resp.Title = "Moderator not found"
+			resp.Status = http.StatusNotFound
+			resp.Code = codes.ModeratorNotFound
+			resp.Errors = errors.NewError(err.Error())
+			return resp.Send(ctx)
+		}
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	resp.Data = moderator
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
+
+func moderatorInvitations(ctx echo.Context) error {
+	resp := response.Response{}
+	db := database.GetDB()
+	moderatorRepo := data.NewShopModeratorRepo()
+	invitations, err := moderatorRepo.Invitations(db, ctx.Get(constants.Phone).(string))
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	resp.Data = func() []models.ShopModerator {
+		if invitations == nil {
+			return []models.ShopModerator{}
+		}
+		return *invitations
+	}()
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
+
+func acceptInvitation(ctx echo.Context) error {
+	return respondInvitation(ctx, true)
+}
+
+func declineInvitation(ctx echo.Context) error {
+	return respondInvitation(ctx, false)
+}
+
+// respondInvitation lets the invited merchant accept or decline, invitation is matched by phone of the merchant
+func respondInvitation(ctx echo.Context, accept bool) error {
+	resp := response.Response{}
+	if ctx.Get(constants.AccountType) != constants.MerchantType {
+		resp.Title = "Only merchants can respond to invitations"
+		resp.Status = http.StatusForbidden
+		resp.Code = codes.AccessDenied
+		resp.Errors = errors.NewError("Only merchants can respond to invitations")
+		return resp.Send(ctx)
+	}
+	moderatorID, err := primitive.ObjectIDFromHex(ctx.Param("moderatorId"))
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Invalid invitation ID"
+		resp.Status = http.StatusUnprocessableEntity
+		resp.Code = codes.InvalidMongoID
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	merchantID := ctx.Get(constants.UserID).(primitive.ObjectID)
+	phone := ctx.Get(constants.Phone).(string)
+	db := database.GetDB()
+	moderatorRepo := data.NewShopModeratorRepo()
+	moderator, err := moderatorRepo.Respond(db, moderatorID, merchantID, phone, accept)
+	if err != nil {
+		logger.Log.Errorln(err)
+		if err == mongo.ErrNoDocuments {
+			resp.Title = "Invitation not found"
+			resp.Status = http.StatusNotFound
+			resp.Code = codes.ModeratorNotFound
+			resp.Errors = errors.NewError(err.Error())
+			return resp.Send(ctx)
+		}
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	resp.Data = moderator
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
@@ -17,10 +17,10 @@ import (
 )
 
 func RegisterTransactionRoutes(endpoint *echo.Group) {
-	endpoint.GET("/shopId/:shopId/", transactionByShopId, middlewares.JWTAuth(false), middlewares.IsShopOwner())
-	endpoint.PATCH("/generate-trx-code/:shopId/", generateTrxCode, middlewares.JWTAuth(false), middlewares.IsShopOwnerStrict())
-	endpoint.GET("/cash-out-requests/", cashOutRequests, middlewares.JWTAuth(true))
-	endpoint.PATCH("/cash-out/:trxId/", makeCashOut, middlewares.JWTAuth(true))
+	endpoint.GET("/shopId/:shopId/", transactionByShopId, middlewares.JWTAuth(false), middlewares.RequirePermission(constants.PayoutRead), middlewares.HasShopAccess(), middlewares.RequireShopPermission(constants.ShopFinanceView))
+	endpoint.PATCH("/generate-trx-code/:shopId/", generateTrxCode, middlewares.JWTAuth(false), middlewares.RequirePermission(constants.PayoutApprove), middlewares.IsShopMember(), middlewares.RequireShopPermission(constants.ShopCashOutRequest), middlewares.Audit(middlewares.AuditShopTransaction, "shopId"))
+	endpoint.GET("/cash-out-requests/", cashOutRequests, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.PayoutRead), middlewares.HubScope())
+	endpoint.PATCH("/cash-out/:trxId/", makeCashOut, middlewares.JWTAuth(true), middlewares.RequirePermission(constants.PayoutApprove), middlewares.Idempotency(), middlewares.Audit(middlewares.AuditTransaction, "trxId"))
 }
 
 func makeCashOut(ctx echo.Context) error {
@@ -86,8 +86,22 @@ func cashOutRequests(ctx echo.Context) error {
 	resp := response.Response{}
 	lastID := ctx.QueryParam("lastId")
 	db := database.GetDB()
+	var shopIDs []primitive.ObjectID
+	if hubs, ok := hubScope(ctx); ok {
+		locationRepo := data.NewLocationRepo()
+		ids, err := locationRepo.ShopsInHubs(db, hubs)
+		if err != nil {
+			logger.Log.Errorln(err)
+			resp.Title = "Something went wrong"
+			resp.Status = http.StatusInternalServerError
+			resp.Code = codes.DatabaseQueryFailed
+			resp.Errors = err
+			return resp.Send(ctx)
+		}
+		shopIDs = ids
+	}
 	trxRepo := data.NewTransactionRepo()
-	result, err := trxRepo.CashOutRequests(db, lastID)
+	result, err := trxRepo.CashOutRequests(db, lastID, shopIDs)
 	if err != nil {
 		logger.Log.Errorln(err)
 		resp.Title = "Something went wrong"
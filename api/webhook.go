@@ -0,0 +1,221 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/techartificer/swiftex/constants"
+	"github.com/techartificer/swiftex/constants/codes"
+	"github.com/techartificer/swiftex/data"
+	"github.com/techartificer/swiftex/database"
+	"github.com/techartificer/swiftex/lib/errors"
+	"github.com/techartificer/swiftex/lib/response"
+	"github.com/techartificer/swiftex/lib/webhook"
+	"github.com/techartificer/swiftex/logger"
+	"github.com/techartificer/swiftex/middlewares"
+	"github.com/techartificer/swiftex/models"
+	"github.com/techartificer/swiftex/validators"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func RegisterWebhookRoutes(endpoint *echo.Group) {
+	endpoint.POST("/shop/:shopId/", createWebhook, middlewares.JWTAuth(false), middlewares.RequirePermission(constants.ShopManage), middlewares.IsShopOwner())
+	endpoint.GET("/shop/:shopId/", webhooks, middlewares.JWTAuth(false), middlewares.RequirePermission(constants.ShopManage), middlewares.IsShopOwner())
+	endpoint.PATCH("/shop/:shopId/id/:webhookId/", updateWebhook, middlewares.JWTAuth(false), middlewares.RequirePermission(constants.ShopManage), middlewares.IsShopOwner())
+	endpoint.DELETE("/shop/:shopId/id/:webhookId/", deleteWebhook, middlewares.JWTAuth(false), middlewares.RequirePermission(constants.ShopManage), middlewares.IsShopOwner())
+	endpoint.GET("/shop/:shopId/deliveries/", webhookDeliveries, middlewares.JWTAuth(false), middlewares.RequirePermission(constants.ShopManage), middlewares.IsShopOwner())
+	endpoint.POST("/shop/:shopId/deliveries/:deliveryId/replay/", replayWebhookDelivery, middlewares.JWTAuth(false), middlewares.RequirePermission(constants.ShopManage), middlewares.IsShopOwner())
+}
+
+func createWebhook(ctx echo.Context) error {
+	resp := response.Response{}
+	hook, err := validators.ValidateWebhookCreate(ctx)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Invalid webhook request data"
+		resp.Status = http.StatusBadRequest
+		resp.Code = codes.InvalidWebhookData
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.SomethingWentWrong
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	hook.Secret = secret
+	db := database.GetDB()
+	webhookRepo := data.NewWebhookRepo()
+	if err := webhookRepo.Create(db, hook); err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	// secret is only shown once, receivers need it to verify signatures
+	resp.Data = map[string]interface{}{
+		"webhook": hook,
+		"secret":  secret,
+	}
+	resp.Status = http.StatusCreated
+	return resp.Send(ctx)
+}
+
+func webhooks(ctx echo.Context) error {
+	resp := response.Response{}
+	shopID := ctx.Param("shopId")
+	db := database.GetDB()
+	webhookRepo := data.NewWebhookRepo()
+	hooks, err := webhookRepo.Webhooks(db, shopID)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	resp.Data = func() []models.Webhook {
+		if *hooks == nil {
+			return []models.Webhook{}
+		}
+		return *hooks
+	}()
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
+
+func updateWebhook(ctx echo.Context) error {
+	resp := response.Response{}
+	shopID, ID := ctx.Param("shopId"), ctx.Param("webhookId")
+	body, err := validators.ValidateWebhookUpdate(ctx)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Invalid webhook request data"
+		resp.Status = http.StatusBadRequest
+		resp.Code = codes.InvalidWebhookData
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	hook := &models.Webhook{URL: body.URL, Events: body.Events, Status: body.Status}
+	if body.RotateSecret {
+		if hook.Secret, err = webhook.NewSecret(); err != nil {
+			logger.Log.Errorln(err)
+			resp.Title = "Something went wrong"
+			resp.Status = http.StatusInternalServerError
+			resp.Code = codes.SomethingWentWrong
+			resp.Errors = err
+			return resp.Send(ctx)
+		}
+	}
+	db := database.GetDB()
+	webhookRepo := data.NewWebhookRepo()
+	updatedHook, err := webhookRepo.Update(db, hook, ID, shopID)
+	if err != nil {
+		logger.Log.Errorln(err)
+		if err == mongo.ErrNoDocuments {
+			resp.Title = "Webhook not found"
+			resp.Status = http.StatusNotFound
+			resp.Code = codes.WebhookNotFound
+			resp.Errors = errors.NewError(err.Error())
+			return resp.Send(ctx)
+		}
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	if body.RotateSecret {
+		resp.Data = map[string]interface{}{
+			"webhook": updatedHook,
+			"secret":  hook.Secret,
+		}
+	} else {
+		resp.Data = updatedHook
+	}
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
+
+func deleteWebhook(ctx echo.Context) error {
+	resp := response.Response{}
+	shopID, ID := ctx.Param("shopId"), ctx.Param("webhookId")
+	db := database.GetDB()
+	webhookRepo := data.NewWebhookRepo()
+	if err := webhookRepo.Delete(db, ID, shopID); err != nil {
+		logger.Log.Errorln(err)
+		if err == mongo.ErrNoDocuments {
+			resp.Title = "Webhook not found"
+			resp.Status = http.StatusNotFound
+			resp.Code = codes.WebhookNotFound
+			resp.Errors = errors.NewError(err.Error())
+			return resp.Send(ctx)
+		}
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	resp.Title = "Webhook deleted"
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
+
+// webhookDeliveries returns deliveries of shop, status=Dead returns the dead letters
+func webhookDeliveries(ctx echo.Context) error {
+	resp := response.Response{}
+	shopID, status, lastID := ctx.Param("shopId"), ctx.QueryParam("status"), ctx.QueryParam("lastId")
+	db := database.GetDB()
+	webhookRepo := data.NewWebhookRepo()
+	deliveries, err := webhookRepo.Deliveries(db, shopID, status, lastID)
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	resp.Data = func() []models.WebhookDelivery {
+		if *deliveries == nil {
+			return []models.WebhookDelivery{}
+		}
+		return *deliveries
+	}()
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
+
+func replayWebhookDelivery(ctx echo.Context) error {
+	resp := response.Response{}
+	shopID, ID := ctx.Param("shopId"), ctx.Param("deliveryId")
+	db := database.GetDB()
+	webhookRepo := data.NewWebhookRepo()
+	delivery, err := webhookRepo.Replay(db, ID, shopID)
+	if err != nil {
+		logger.Log.Errorln(err)
+		if err == mongo.ErrNoDocuments {
+			resp.Title = "Webhook delivery not found"
+			resp.Status = http.StatusNotFound
+			resp.Code = codes.WebhookDeliveryNotFound
+			resp.Errors = errors.NewError(err.Error())
+			return resp.Send(ctx)
+		}
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.DatabaseQueryFailed
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	resp.Data = delivery
+	resp.Status = http.StatusOK
+	return resp.Send(ctx)
+}
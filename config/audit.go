@@ -0,0 +1,32 @@
+package config
+
+import (
+	"github.com/spf13/viper"
+)
+
+// Audit holds the audit log configuration
+type Audit struct {
+	// RetentionDays is how long audit logs are kept before they expire
+	RetentionDays int
+}
+
+var audit Audit
+
+// GetAudit returns the default audit configuration
+func GetAudit() Audit {
+	return audit
+}
+
+// LoadAudit loads audit configuration, logs are kept for a year by default
+func LoadAudit() {
+	mu.Lock()
+	defer mu.Unlock()
+	envs := []string{"AUDIT_RETENTION_DAYS"}
+	bindEnvs(envs)
+	audit = Audit{
+		RetentionDays: viper.GetInt("AUDIT_RETENTION_DAYS"),
+	}
+	if audit.RetentionDays <= 0 {
+		audit.RetentionDays = 365
+	}
+}
@@ -0,0 +1,29 @@
+package config
+
+import (
+	"github.com/spf13/viper"
+)
+
+// Cash holds the cash handling configuration
+type Cash struct {
+	// RiderLimit is the cash a rider can hold before new parcels are blocked, 0 means no limit
+	RiderLimit float64
+}
+
+var cash Cash
+
+// GetCash returns the default cash configuration
+func GetCash() Cash {
+	return cash
+}
+
+// LoadCash loads cash configuration
+func LoadCash() {
+	mu.Lock()
+	defer mu.Unlock()
+	envs := []string{"RIDER_CASH_LIMIT"}
+	bindEnvs(envs)
+	cash = Cash{
+		RiderLimit: viper.GetFloat64("RIDER_CASH_LIMIT"),
+	}
+}
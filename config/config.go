@@ -23,5 +23,11 @@ func LoadConfig() error {
 	LoadServer()
 	LoadJWT()
 	LoadRedis()
+	LoadCash()
+	LoadAudit()
+	LoadStorage()
+	LoadDispatch()
+	LoadReturn()
+	LoadSMS()
 	return nil
 }
@@ -0,0 +1,32 @@
+package config
+
+import (
+	"github.com/spf13/viper"
+)
+
+// Dispatch holds the rider dispatch configuration
+type Dispatch struct {
+	// RiderCapacity is the number of parcels a rider can be assigned a day unless the rider has its own capacity
+	RiderCapacity int
+}
+
+var dispatch Dispatch
+
+// GetDispatch returns the default dispatch configuration
+func GetDispatch() Dispatch {
+	return dispatch
+}
+
+// LoadDispatch loads dispatch configuration, riders take 40 parcels a day by default
+func LoadDispatch() {
+	mu.Lock()
+	defer mu.Unlock()
+	envs := []string{"DISPATCH_RIDER_CAPACITY"}
+	bindEnvs(envs)
+	dispatch = Dispatch{
+		RiderCapacity: viper.GetInt("DISPATCH_RIDER_CAPACITY"),
+	}
+	if dispatch.RiderCapacity <= 0 {
+		dispatch.RiderCapacity = 40
+	}
+}
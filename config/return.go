@@ -0,0 +1,32 @@
+package config
+
+import (
+	"github.com/spf13/viper"
+)
+
+// Return holds the return to merchant configuration
+type Return struct {
+	// Charge is the flat charge of a returned parcel, ChargePercent is used if it is 0
+	Charge float64
+	// ChargePercent is the percent of delivery charge taken for a returned parcel, 0 means returns are free
+	ChargePercent float64
+}
+
+var ret Return
+
+// GetReturn returns the default return configuration
+func GetReturn() Return {
+	return ret
+}
+
+// LoadReturn loads return configuration
+func LoadReturn() {
+	mu.Lock()
+	defer mu.Unlock()
+	envs := []string{"RETURN_CHARGE", "RETURN_CHARGE_PERCENT"}
+	bindEnvs(envs)
+	ret = Return{
+		Charge:        viper.GetFloat64("RETURN_CHARGE"),
+		ChargePercent: viper.GetFloat64("RETURN_CHARGE_PERCENT"),
+	}
+}
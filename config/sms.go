@@ -0,0 +1,39 @@
+package config
+
+import (
+	"github.com/spf13/viper"
+)
+
+// SMS holds the sms gateway configuration
+type SMS struct {
+	// Driver selects the gateway, http or none. Delivery codes are disabled with none
+	Driver string
+	// URL is the endpoint messages are posted to by the http driver
+	URL      string
+	APIKey   string
+	SenderID string
+}
+
+var sms SMS
+
+// GetSMS returns the default sms configuration
+func GetSMS() SMS {
+	return sms
+}
+
+// LoadSMS loads sms configuration, no gateway is used by default
+func LoadSMS() {
+	mu.Lock()
+	defer mu.Unlock()
+	envs := []string{"SMS_DRIVER", "SMS_URL", "SMS_API_KEY", "SMS_SENDER_ID"}
+	bindEnvs(envs)
+	sms = SMS{
+		Driver:   viper.GetString("SMS_DRIVER"),
+		URL:      viper.GetString("SMS_URL"),
+		APIKey:   viper.GetString("SMS_API_KEY"),
+		SenderID: viper.GetString("SMS_SENDER_ID"),
+	}
+	if sms.Driver == "" {
+		sms.Driver = "none"
+	}
+}
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"github.com/spf13/viper"
+)
+
+// Storage holds the blob store configuration
+type Storage struct {
+	// Driver selects the blob store, local or s3
+	Driver string
+	// LocalPath is the directory files are kept in by the local driver
+	LocalPath string
+	// S3Endpoint is set for s3 compatible servers like MinIO, empty for AWS
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+	// S3PathStyle addresses buckets by path instead of subdomain, MinIO needs it
+	S3PathStyle bool
+	// URLSecret signs download urls, jwt secret is used if it is not set
+	URLSecret string
+	// URLTTL is how long a signed download url is valid in seconds
+	URLTTL int
+}
+
+var storage Storage
+
+// GetStorage returns the default storage configuration
+func GetStorage() Storage {
+	return storage
+}
+
+// LoadStorage loads storage configuration, files are kept on local filesystem by default
+func LoadStorage() {
+	mu.Lock()
+	defer mu.Unlock()
+	envs := []string{
+		"STORAGE_DRIVER", "STORAGE_LOCAL_PATH", "STORAGE_S3_ENDPOINT", "STORAGE_S3_REGION", "STORAGE_S3_BUCKET",
+		"STORAGE_S3_ACCESS_KEY", "STORAGE_S3_SECRET_KEY", "STORAGE_S3_PATH_STYLE", "STORAGE_URL_SECRET", "STORAGE_URL_TTL",
+	}
+	bindEnvs(envs)
+	storage = Storage{
+		Driver:      viper.GetString("STORAGE_DRIVER"),
+		LocalPath:   viper.GetString("STORAGE_LOCAL_PATH"),
+		S3Endpoint:  viper.GetString("STORAGE_S3_ENDPOINT"),
+		S3Region:    viper.GetString("STORAGE_S3_REGION"),
+		S3Bucket:    viper.GetString("STORAGE_S3_BUCKET"),
+		S3AccessKey: viper.GetString("STORAGE_S3_ACCESS_KEY"),
+		S3SecretKey: viper.GetString("STORAGE_S3_SECRET_KEY"),
+		S3PathStyle: viper.GetBool("STORAGE_S3_PATH_STYLE"),
+		URLSecret:   viper.GetString("STORAGE_URL_SECRET"),
+		URLTTL:      viper.GetInt("STORAGE_URL_TTL"),
+	}
+	if storage.Driver == "" {
+		storage.Driver = "local"
+	}
+	if storage.LocalPath == "" {
+		storage.LocalPath = "storage"
+	}
+	if storage.URLTTL <= 0 {
+		storage.URLTTL = 3600
+	}
+}
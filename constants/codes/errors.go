@@ -15,6 +15,25 @@ const (
 	InvalidGenTrxCode            ErrorCode = "400009"
 	InvalidCashOutData           ErrorCode = "400010"
 	InvalidForgotPassData        ErrorCode = "400011"
+	InvalidRateCardData          ErrorCode = "400012"
+	InvalidQuoteData             ErrorCode = "400013"
+	InvalidWebhookData           ErrorCode = "400014"
+	InvalidCashDepositData       ErrorCode = "400015"
+	InvalidOrderImportData       ErrorCode = "400016"
+	InvalidLabelData             ErrorCode = "400017"
+	InvalidLocationData          ErrorCode = "400018"
+	InvalidPermissionData        ErrorCode = "400019"
+	InvalidModeratorData         ErrorCode = "400020"
+	InvalidParcelData            ErrorCode = "400021"
+	InvalidProofData             ErrorCode = "400022"
+	InvalidFileData              ErrorCode = "400023"
+	InvalidRunSheetData          ErrorCode = "400024"
+	InvalidPickupData            ErrorCode = "400025"
+	InvalidReturnData            ErrorCode = "400026"
+	InvalidDeliveredItems        ErrorCode = "400027"
+	InvalidPayoutData            ErrorCode = "400028"
+	InvalidInvoiceData           ErrorCode = "400029"
+	InvalidCollectedAmount       ErrorCode = "400030"
 	InvalidLoginCredential       ErrorCode = "401001"
 	BearerTokenGiven             ErrorCode = "401002"
 	InvalidAuthorizationToken    ErrorCode = "401003"
@@ -26,6 +45,13 @@ const (
 	InvalidTrxCode               ErrorCode = "403004"
 	TrxCodeExpired               ErrorCode = "403005"
 	MerchantDeactive             ErrorCode = "403006"
+	OutOfHubScope                ErrorCode = "403007"
+	PermissionDenied             ErrorCode = "403008"
+	ShopPermissionDenied         ErrorCode = "403009"
+	InvalidDeliveryOTP           ErrorCode = "403010"
+	DeliveryOTPExpired           ErrorCode = "403011"
+	InvalidFileSignature         ErrorCode = "403012"
+	OrderNotAssignedToRider      ErrorCode = "403013"
 	AdminNotFound                ErrorCode = "404001"
 	RefreshTokenNotFound         ErrorCode = "404002"
 	BearerTokenNotFound          ErrorCode = "404003"
@@ -34,21 +60,61 @@ const (
 	OrderNotFound                ErrorCode = "404006"
 	RiderNotFound                ErrorCode = "404007"
 	TransactionNotFound          ErrorCode = "404008"
+	RateCardNotFound             ErrorCode = "404009"
+	WebhookNotFound              ErrorCode = "404010"
+	WebhookDeliveryNotFound      ErrorCode = "404011"
+	HubNotFound                  ErrorCode = "404012"
+	CityNotFound                 ErrorCode = "404013"
+	ThanaNotFound                ErrorCode = "404014"
+	AreaNotFound                 ErrorCode = "404015"
+	ModeratorNotFound            ErrorCode = "404016"
+	ParcelNotFound               ErrorCode = "404017"
+	FileNotFound                 ErrorCode = "404018"
+	RunSheetNotFound             ErrorCode = "404019"
+	PickupNotFound               ErrorCode = "404020"
+	ReturnNotFound               ErrorCode = "404021"
+	PayoutScheduleNotFound       ErrorCode = "404022"
+	PayoutBatchNotFound          ErrorCode = "404023"
+	PayoutMethodNotFound         ErrorCode = "404024"
+	InvoiceNotFound              ErrorCode = "404025"
 	AdminAlreadyExist            ErrorCode = "409001"
 	MerchantAlreadyExist         ErrorCode = "409002"
 	ShopAlreadyExist             ErrorCode = "409003"
 	OrderAlreadyExist            ErrorCode = "409004"
+	RateCardAlreadyExist         ErrorCode = "409005"
+	IdempotencyKeyReused         ErrorCode = "409006"
+	IdempotentRequestInProgress  ErrorCode = "409007"
+	LocationAlreadyExist         ErrorCode = "409008"
+	ModeratorAlreadyExist        ErrorCode = "409009"
+	RunSheetAlreadyExist         ErrorCode = "409010"
+	OrderAlreadyInPickup         ErrorCode = "409011"
+	InvoiceAlreadyExist          ErrorCode = "409012"
+	PayoutMethodChanged          ErrorCode = "409013"
 	InvalidLimit                 ErrorCode = "422001"
 	InvalidMongoID               ErrorCode = "422002"
 	OrderAlreadyDelevired        ErrorCode = "422003"
 	OrderAlreadyInTransit        ErrorCode = "422004"
 	OrderNotAcceptedYet          ErrorCode = "422005"
 	InsufficientBalance          ErrorCode = "422006"
+	InvalidStatusTransition      ErrorCode = "422007"
+	DepositExceedsCashInHand     ErrorCode = "422008"
+	InvalidParcelTransition      ErrorCode = "422009"
+	ProofRequired                ErrorCode = "422010"
+	RunSheetClosed               ErrorCode = "422011"
+	InvalidPickupTransition      ErrorCode = "422012"
+	InvalidReturnTransition      ErrorCode = "422013"
+	InvalidPayoutTransition      ErrorCode = "422014"
+	DeliveryOTPUnavailable       ErrorCode = "422015"
 	OrderNotUpdateAble           ErrorCode = "423001"
+	RateCardNotDeletable         ErrorCode = "423002"
+	RiderCashLimitExceeded       ErrorCode = "423003"
+	RiderCapacityExceeded        ErrorCode = "423004"
 	TooManyRequest               ErrorCode = "429001"
 	DatabaseQueryFailed          ErrorCode = "500001"
 	UserLoginFailed              ErrorCode = "500002"
 	TokenRefreshFailed           ErrorCode = "500003"
 	SomethingWentWrong           ErrorCode = "500004"
 	PasswordHashFailed           ErrorCode = "500005"
+	UnbalancedJournal            ErrorCode = "500006"
+	FileUploadFailed             ErrorCode = "500007"
 )
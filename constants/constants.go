@@ -48,6 +48,44 @@ const (
 	Returned    string = "Returned"
 	Rescheduled string = "Rescheduled"
 	Picked      string = "Picked"
+	Invited     string = "Invited"
+	Revoked     string = "Revoked"
+	Failed      string = "Failed"
+	Open        string = "Open"
+	Closed      string = "Closed"
+	CarriedOver string = "Carried Over"
+	Initiated   string = "Initiated"
+	AtHub       string = "At Hub"
+	HandedOver  string = "Handed Over"
+	Confirmed   string = "Confirmed"
+	Rejected    string = "Rejected"
+	Paid        string = "Paid"
+)
+
+// Payout frequencies
+const (
+	Daily  string = "Daily"
+	Weekly string = "Weekly"
+)
+
+// Payout methods
+const (
+	Bank   string = "Bank"
+	BKash  string = "bKash"
+	Nagad  string = "Nagad"
+	Rocket string = "Rocket"
+)
+
+var PayoutMethods = []string{Bank, BKash, Nagad, Rocket}
+
+// Reasons of a failed delivery attempt
+const (
+	RecipientUnavailable string = "RecipientUnavailable"
+	RecipientRefused     string = "RecipientRefused"
+	WrongAddress         string = "WrongAddress"
+	PaymentNotReady      string = "PaymentNotReady"
+	RescheduleRequested  string = "RescheduleRequested"
+	OtherReason          string = "Other"
 )
 
 var AllStatus = []string{Active, Deactive}
@@ -61,10 +99,30 @@ const (
 	Role   string = "role"
 	Phone  string = "phone"
 	UserID string = "userId"
+	// HubScope holds hubs of a zone manager, absent for unrestricted admins
+	HubScope    string = "hubScope"
+	AccountType string = "accountType"
+	// ActiveModerator holds shop moderator of the requesting merchant, absent for owners and admins
+	ActiveModerator string = "activeModerator"
 )
 
 const TrackIDSize = 8
 
+const (
+	// DeliveryOTPSize is the number of digits of a delivery code
+	DeliveryOTPSize = 6
+	// DeliveryOTPTTL is how long a delivery code is valid in minutes
+	DeliveryOTPTTL = 30
+	// MaxDeliveryOTPAttempts is the number of wrong codes after which a new code has to be requested
+	MaxDeliveryOTPAttempts = 5
+)
+
+// Proofs collected on delivery
+const (
+	ProofSignature string = "signature"
+	ProofPhoto     string = "photo"
+)
+
 const (
 	Express string = "Express"
 	Regular string = "Regular"
@@ -79,6 +137,16 @@ const (
 	ReturnedMsg   string = "Parcel has been returned"
 	RescheduleMsg string = "Parcel has been rescheduled"
 	DeleveredMsg  string = "Successfully delevered at your door"
+	AssignedMsg   string = "Rider has been assigned"
+	RiderAccepted string = "Rider has accepted your parcel"
+	AttemptMsg    string = "Delivery attempt failed"
+	HubReturnMsg  string = "Parcel has been returned to hub"
+	ReturnHubMsg  string = "Returned parcel has reached origin hub"
+	HandOverMsg   string = "Returned parcel has been handed over to merchant"
+	ReturnConfMsg string = "Merchant has confirmed receiving returned parcel"
+	PartialMsg    string = "Parcel has been partially delivered"
+	RefusedMsg    string = "Items refused by recipient on partial delivery"
+	ExchangeMsg   string = "Items collected from recipient on exchange"
 )
 
 const (
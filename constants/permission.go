@@ -0,0 +1,100 @@
+package constants
+
+// Permission is a named action which can be granted to admin roles
+type Permission string
+
+const (
+	OrderRead        Permission = "order.read"
+	OrderWrite       Permission = "order.write"
+	OrderStatusWrite Permission = "order.status.write"
+	OrderAssign      Permission = "order.assign"
+	RiderRead        Permission = "rider.read"
+	RiderManage      Permission = "rider.manage"
+	RiderCash        Permission = "rider.cash"
+	ShopRead         Permission = "shop.read"
+	ShopManage       Permission = "shop.manage"
+	MerchantRead     Permission = "merchant.read"
+	PayoutRead       Permission = "payout.read"
+	PayoutApprove    Permission = "payout.approve"
+	RateCardRead     Permission = "ratecard.read"
+	RateCardManage   Permission = "ratecard.manage"
+	LedgerRead       Permission = "ledger.read"
+	LocationRead     Permission = "location.read"
+	LocationManage   Permission = "location.manage"
+	AdminRead        Permission = "admin.read"
+	AdminManage      Permission = "admin.manage"
+	// SystemManage allows maintenance jobs like ledger recompute and location migration
+	SystemManage Permission = "system.manage"
+)
+
+var Permissions = []Permission{
+	OrderRead, OrderWrite, OrderStatusWrite, OrderAssign,
+	RiderRead, RiderManage, RiderCash,
+	ShopRead, ShopManage, MerchantRead,
+	PayoutRead, PayoutApprove,
+	RateCardRead, RateCardManage,
+	LedgerRead, LocationRead, LocationManage,
+	AdminRead, AdminManage, SystemManage,
+}
+
+// DefaultRolePermissions is used for roles which are not configured by super admin,
+// super admin always has all permissions
+var DefaultRolePermissions = map[AdminRole][]Permission{
+	SuperAdmin: Permissions,
+	Admin: {
+		OrderRead, OrderWrite, OrderStatusWrite, OrderAssign,
+		RiderRead, RiderManage, RiderCash,
+		ShopRead, ShopManage, MerchantRead,
+		PayoutRead, PayoutApprove,
+		RateCardRead, RateCardManage,
+		LedgerRead, LocationRead, LocationManage,
+		AdminRead,
+	},
+	Moderator: {
+		OrderRead, OrderWrite, OrderStatusWrite, OrderAssign,
+		RiderRead, RiderManage, RiderCash,
+		ShopRead, ShopManage, MerchantRead,
+		PayoutRead,
+		RateCardRead,
+		LedgerRead, LocationRead,
+		AdminRead,
+	},
+	ZoneManager: {
+		OrderRead, OrderWrite, OrderStatusWrite, OrderAssign,
+		RiderRead, RiderManage, RiderCash,
+		ShopRead,
+		RateCardRead,
+		LocationRead,
+	},
+}
+
+// IsValidPermission reports whether p is a known permission
+func IsValidPermission(p Permission) bool {
+	for _, v := range Permissions {
+		if v == p {
+			return true
+		}
+	}
+	return false
+}
+
+// ShopPermission is an action a shop owner can grant to a shop moderator
+type ShopPermission string
+
+const (
+	ShopOrderCreate    ShopPermission = "order.create"
+	ShopFinanceView    ShopPermission = "finance.view"
+	ShopCashOutRequest ShopPermission = "cashout.request"
+)
+
+var ShopPermissions = []ShopPermission{ShopOrderCreate, ShopFinanceView, ShopCashOutRequest}
+
+// IsValidShopPermission reports whether p is a known shop permission
+func IsValidShopPermission(p ShopPermission) bool {
+	for _, v := range ShopPermissions {
+		if v == p {
+			return true
+		}
+	}
+	return false
+}
@@ -2,6 +2,7 @@ package data
 
 import (
 	"context"
+	"time"
 
 	"github.com/techartificer/swiftex/models"
 	"github.com/techartificer/swiftex/validators"
@@ -18,6 +19,7 @@ type AdminRepository interface {
 	FindByUsername(db *mongo.Database, phone string) (*models.Admin, error)
 	UpdateAdminByID(db *mongo.Database, data *validators.ReqAdminUpdate, ID string) (*models.Admin, error)
 	AdminList(db *mongo.Database) (*[]models.Admin, error)
+	SetProfilePic(db *mongo.Database, ID primitive.ObjectID, key string) (*models.Admin, error)
 }
 
 type adminRepositoryImpl struct{}
@@ -89,3 +91,15 @@ func (a *adminRepositoryImpl) AdminList(db *mongo.Database) (*[]models.Admin, er
 	}
 	return &admins, nil
 }
+
+func (a *adminRepositoryImpl) SetProfilePic(db *mongo.Database, ID primitive.ObjectID, key string) (*models.Admin, error) {
+	adminCollection := db.Collection(models.Admin{}.CollectionName())
+	update := bson.M{"$set": bson.M{"profilePic": key, "updatedAt": time.Now().UTC()}}
+	after := options.After
+	opt := options.FindOneAndUpdateOptions{ReturnDocument: &after}
+	admin := &models.Admin{}
+	if err := adminCollection.FindOneAndUpdate(context.Background(), bson.M{"_id": ID}, update, &opt).Decode(admin); err != nil {
+		return nil, err
+	}
+	return admin, nil
+}
@@ -0,0 +1,60 @@
+package data
+
+import (
+	"context"
+
+	"github.com/techartificer/swiftex/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type AuditRepository interface {
+	Create(db *mongo.Database, auditLog *models.AuditLog) error
+	AuditLogs(db *mongo.Database, query bson.M, limit int64) (*[]models.AuditLog, error)
+	Snapshot(db *mongo.Database, collection, key string, values []interface{}) ([]bson.M, error)
+}
+
+type auditRepoImpl struct{}
+
+var auditRepo AuditRepository
+
+func NewAuditRepo() AuditRepository {
+	if auditRepo == nil {
+		auditRepo = &auditRepoImpl{}
+	}
+	return auditRepo
+}
+
+func (a *auditRepoImpl) Create(db *mongo.Database, auditLog *models.AuditLog) error {
+	auditCollection := db.Collection(auditLog.CollectionName())
+	_, err := auditCollection.InsertOne(context.Background(), auditLog)
+	return err
+}
+
+func (a *auditRepoImpl) AuditLogs(db *mongo.Database, query bson.M, limit int64) (*[]models.AuditLog, error) {
+	auditCollection := db.Collection(models.AuditLog{}.CollectionName())
+	opts := options.Find().SetSort(bson.M{"_id": -1}).SetLimit(limit)
+	cursor, err := auditCollection.Find(context.Background(), query, opts)
+	if err != nil {
+		return nil, err
+	}
+	var auditLogs []models.AuditLog
+	if err = cursor.All(context.Background(), &auditLogs); err != nil {
+		return nil, err
+	}
+	return &auditLogs, nil
+}
+
+// Snapshot returns raw documents of the collection whose key is one of the values
+func (a *auditRepoImpl) Snapshot(db *mongo.Database, collection, key string, values []interface{}) ([]bson.M, error) {
+	cursor, err := db.Collection(collection).Find(context.Background(), bson.M{key: bson.M{"$in": values}})
+	if err != nil {
+		return nil, err
+	}
+	var docs []bson.M
+	if err = cursor.All(context.Background(), &docs); err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
@@ -0,0 +1,84 @@
+package data
+
+import (
+	"context"
+	"time"
+
+	"github.com/techartificer/swiftex/constants"
+	"github.com/techartificer/swiftex/constants/codes"
+	"github.com/techartificer/swiftex/lib/errors"
+	"github.com/techartificer/swiftex/lib/password"
+	"github.com/techartificer/swiftex/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SetDeliveryOTP replaces delivery code of an out for delivery order, riderID is nil if an admin requests it
+func (o *orderRepositoryImpl) SetDeliveryOTP(db *mongo.Database, ID primitive.ObjectID, riderID *primitive.ObjectID, hash string, expiresAt int64) (*models.Order, error) {
+	orderCollection := db.Collection(models.Order{}.CollectionName())
+	query := bson.M{"_id": ID, "currentStatus": constants.InTransit}
+	if riderID != nil {
+		query["riderId"] = *riderID
+	}
+	update := bson.M{"$set": bson.M{
+		"deliveryOtp":          hash,
+		"deliveryOtpExpiresAt": expiresAt,
+		"deliveryOtpAttempts":  0,
+		"updatedAt":            time.Now().UTC(),
+	}}
+	after := options.After
+	opts := options.FindOneAndUpdateOptions{ReturnDocument: &after}
+	order := &models.Order{}
+	if err := orderCollection.FindOneAndUpdate(context.Background(), query, update, &opts).Decode(order); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// VerifyDeliveryOTP checks delivery code of an order assigned to the rider, every check counts as an attempt.
+// Hash of the verified code is returned, the code is consumed when the order is delivered
+func (o *orderRepositoryImpl) VerifyDeliveryOTP(db *mongo.Database, ID, riderID primitive.ObjectID, otp string) (string, error) {
+	orderCollection := db.Collection(models.Order{}.CollectionName())
+	order := &models.Order{}
+	if err := orderCollection.FindOne(context.Background(), bson.M{"_id": ID, "riderId": riderID}).Decode(order); err != nil {
+		return "", err
+	}
+	if order.DeliveryOTP == "" {
+		return "", errors.NewError(string(codes.InvalidDeliveryOTP))
+	}
+	if order.DeliveryOTPExpiresAt < time.Now().Unix() {
+		return "", errors.NewError(string(codes.DeliveryOTPExpired))
+	}
+	query := bson.M{
+		"_id":                 ID,
+		"riderId":             riderID,
+		"deliveryOtp":         order.DeliveryOTP,
+		"deliveryOtpAttempts": bson.M{"$lt": constants.MaxDeliveryOTPAttempts},
+	}
+	result, err := orderCollection.UpdateOne(context.Background(), query, bson.M{"$inc": bson.M{"deliveryOtpAttempts": 1}})
+	if err != nil {
+		return "", err
+	}
+	if result.MatchedCount == 0 {
+		return "", errors.NewError(string(codes.TooManyRequest))
+	}
+	if !password.CheckPasswordHash(otp, order.DeliveryOTP) {
+		return "", errors.NewError(string(codes.InvalidDeliveryOTP))
+	}
+	return order.DeliveryOTP, nil
+}
+
+// checkDeliveryProof returns ProofRequired if a proof the shop asks for is missing
+func checkDeliveryProof(policy *models.ProofPolicy, proof *models.DeliveryProof) error {
+	if policy == nil {
+		return nil
+	}
+	if (policy.OTP && !proof.OTPVerified) ||
+		(policy.Signature && proof.SignatureKey == "") ||
+		(policy.Photo && proof.PhotoKey == "") {
+		return errors.NewError(string(codes.ProofRequired))
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package data
+
+import (
+	"testing"
+
+	"github.com/techartificer/swiftex/constants/codes"
+	"github.com/techartificer/swiftex/models"
+)
+
+func TestCheckDeliveryProof(t *testing.T) {
+	all := &models.ProofPolicy{OTP: true, Signature: true, Photo: true}
+	complete := &models.DeliveryProof{OTPVerified: true, SignatureKey: "signature", PhotoKey: "photo"}
+	tests := []struct {
+		name     string
+		policy   *models.ProofPolicy
+		proof    *models.DeliveryProof
+		required bool
+	}{
+		{"no policy", nil, &models.DeliveryProof{}, false},
+		{"empty policy", &models.ProofPolicy{}, &models.DeliveryProof{}, false},
+		{"all proofs", all, complete, false},
+		{"otp missing", &models.ProofPolicy{OTP: true}, &models.DeliveryProof{SignatureKey: "signature"}, true},
+		{"otp verified", &models.ProofPolicy{OTP: true}, &models.DeliveryProof{OTPVerified: true}, false},
+		{"signature missing", &models.ProofPolicy{Signature: true}, &models.DeliveryProof{PhotoKey: "photo"}, true},
+		{"photo missing", all, &models.DeliveryProof{OTPVerified: true, SignatureKey: "signature"}, true},
+		{"extra proof", &models.ProofPolicy{Photo: true}, complete, false},
+	}
+	for _, tt := range tests {
+		err := checkDeliveryProof(tt.policy, tt.proof)
+		if tt.required && (err == nil || err.Error() != string(codes.ProofRequired)) {
+			t.Errorf("%s: checkDeliveryProof() = %v, want %s", tt.name, err, codes.ProofRequired)
+		}
+		if !tt.required && err != nil {
+			t.Errorf("%s: checkDeliveryProof() = %v, want nil", tt.name, err)
+		}
+	}
+}
@@ -0,0 +1,318 @@
+package data
+
+import (
+	"context"
+	"time"
+
+	"github.com/techartificer/swiftex/config"
+	"github.com/techartificer/swiftex/constants"
+	"github.com/techartificer/swiftex/constants/codes"
+	"github.com/techartificer/swiftex/lib/errors"
+	"github.com/techartificer/swiftex/lib/orderstatus"
+	"github.com/techartificer/swiftex/models"
+	"github.com/techartificer/swiftex/serializer"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+)
+
+// DispatchRepository proposes riders for orders and assigns them in bulk
+type DispatchRepository interface {
+	Plan(db *mongo.Database, orderIDs []primitive.ObjectID, hubs []primitive.ObjectID) (*serializer.DispatchPlan, error)
+	Commit(db *mongo.Database, parcels []*models.RiderParcel, hubs []primitive.ObjectID) (*[]models.Order, error)
+}
+
+type dispatchRepoImpl struct{}
+
+var dispatchRepo DispatchRepository
+
+func NewDispatchRepo() DispatchRepository {
+	if dispatchRepo == nil {
+		dispatchRepo = &dispatchRepoImpl{}
+	}
+	return dispatchRepo
+}
+
+// openParcelStatuses are statuses of parcels a rider is still carrying
+var openParcelStatuses = []string{constants.Assigned, constants.Accepted, constants.Picked}
+
+// startOfDay returns midnight of the server day of t
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// orderHub returns the hub riders of an order are matched by, hub ID if the order is mapped to the registry, hub name otherwise
+func orderHub(order *models.Order) string {
+	if order.DeliveryHubID != nil {
+		return order.DeliveryHubID.Hex()
+	}
+	if order.PickHubID != nil {
+		return order.PickHubID.Hex()
+	}
+	return order.PickHub
+}
+
+// riderHubs returns the hubs a rider is matched by
+func riderHubs(rider *models.Rider) []string {
+	hubs := []string{rider.Hub}
+	if rider.HubID != nil {
+		hubs = append(hubs, rider.HubID.Hex())
+	}
+	return hubs
+}
+
+// riderLoads returns open and today's parcels of riders
+func riderLoads(ctx context.Context, db *mongo.Database, riderIDs []primitive.ObjectID) (map[primitive.ObjectID]serializer.DispatchRider, error) {
+	start := startOfDay(time.Now())
+	pipeline := []bson.M{
+		{"$match": bson.M{
+			"riderId": bson.M{"$in": riderIDs},
+			"$or": []bson.M{
+				{"status": bson.M{"$in": openParcelStatuses}},
+				{"createdAt": bson.M{"$gte": start}},
+			},
+		}},
+		{"$group": bson.M{
+			"_id":           "$riderId",
+			"open":          bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$in": bson.A{"$status", openParcelStatuses}}, 1, 0}}},
+			"assignedToday": bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$gte": bson.A{"$createdAt", start}}, 1, 0}}},
+		}},
+	}
+	cursor, err := db.Collection(models.RiderParcel{}.CollectionName()).Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	var loads []serializer.DispatchRider
+	if err := cursor.All(ctx, &loads); err != nil {
+		return nil, err
+	}
+	result := make(map[primitive.ObjectID]serializer.DispatchRider)
+	for _, load := range loads {
+		result[load.RiderID] = load
+	}
+	return result, nil
+}
+
+// Plan proposes a rider for every assignable order, orders go to the least loaded rider
+// of their hub who has capacity left for the day, riders are restricted to hubs if hubs is not nil
+func (d *dispatchRepoImpl) Plan(db *mongo.Database, orderIDs []primitive.ObjectID, hubs []primitive.ObjectID) (*serializer.DispatchPlan, error) {
+	ctx := context.Background()
+	plan := &serializer.DispatchPlan{
+		Assignments: []serializer.DispatchAssignment{},
+		Skipped:     []serializer.DispatchSkipped{},
+		Riders:      []serializer.DispatchRider{},
+	}
+	orderCollection := db.Collection(models.Order{}.CollectionName())
+	opts := options.Find().SetSort(bson.M{"_id": 1})
+	cursor, err := orderCollection.Find(ctx, bson.M{"_id": bson.M{"$in": orderIDs}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	var orders []models.Order
+	if err := cursor.All(ctx, &orders); err != nil {
+		return nil, err
+	}
+	found := make(map[primitive.ObjectID]bool)
+	for _, order := range orders {
+		found[order.ID] = true
+	}
+	for _, ID := range orderIDs {
+		if !found[ID] {
+			plan.Skipped = append(plan.Skipped, serializer.DispatchSkipped{OrderID: ID, Reason: "Order not found"})
+		}
+	}
+
+	assignable := []models.Order{}
+	hubIDs, hubNames := []primitive.ObjectID{}, []string{}
+	for _, order := range orders {
+		if hubs != nil && !containsObjectID(hubs, order.PickHubID) && !containsObjectID(hubs, order.DeliveryHubID) {
+			plan.Skipped = append(plan.Skipped, serializer.DispatchSkipped{OrderID: order.ID, TrackID: order.TrackID, Reason: "Order is out of your hubs"})
+			continue
+		}
+		current := orderstatus.Current(order.CurrentStatus)
+		if order.DeliveredAt != nil || !orderstatus.CanTransition(current, constants.Assigned) {
+			plan.Skipped = append(plan.Skipped, serializer.DispatchSkipped{
+				OrderID: order.ID,
+				TrackID: order.TrackID,
+				Reason:  "Order can not be assigned in " + current + " status",
+			})
+			continue
+		}
+		assignable = append(assignable, order)
+		if hub, err := primitive.ObjectIDFromHex(orderHub(&order)); err == nil {
+			hubIDs = append(hubIDs, hub)
+		} else {
+			hubNames = append(hubNames, order.PickHub)
+		}
+	}
+	if len(assignable) == 0 {
+		return plan, nil
+	}
+
+	riderQuery := bson.M{
+		"status": constants.Active,
+		"$or": []bson.M{
+			{"hubId": bson.M{"$in": hubIDs}},
+			{"hub": bson.M{"$in": hubNames}},
+		},
+	}
+	if hubs != nil {
+		riderQuery["hubId"] = bson.M{"$in": hubs}
+	}
+	cursor, err = db.Collection(models.Rider{}.CollectionName()).Find(ctx, riderQuery)
+	if err != nil {
+		return nil, err
+	}
+	var riders []models.Rider
+	if err := cursor.All(ctx, &riders); err != nil {
+		return nil, err
+	}
+	riderIDs := []primitive.ObjectID{}
+	for _, rider := range riders {
+		riderIDs = append(riderIDs, rider.ID)
+	}
+	loads, err := riderLoads(ctx, db, riderIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	defaultCapacity := config.GetDispatch().RiderCapacity
+	cashLimit := config.GetCash().RiderLimit
+	candidates := make([]*serializer.DispatchRider, len(riders))
+	byHub := make(map[string][]*serializer.DispatchRider)
+	for i := range riders {
+		rider := &riders[i]
+		load := loads[rider.ID]
+		candidate := &serializer.DispatchRider{
+			RiderID:       rider.ID,
+			Name:          rider.Name,
+			Hub:           rider.Hub,
+			Open:          load.Open,
+			AssignedToday: load.AssignedToday,
+			Capacity:      rider.Capacity(defaultCapacity),
+		}
+		if cashLimit > 0 {
+			cashInHand, err := riderCashInHand(ctx, db, rider.ID)
+			if err != nil {
+				return nil, err
+			}
+			candidate.OverCashLimit = cashInHand >= cashLimit
+		}
+		candidates[i] = candidate
+		for _, hub := range riderHubs(rider) {
+			byHub[hub] = append(byHub[hub], candidate)
+		}
+	}
+
+	for _, order := range assignable {
+		hubRiders := byHub[orderHub(&order)]
+		var best *serializer.DispatchRider
+		for _, candidate := range hubRiders {
+			if candidate.OverCashLimit || candidate.AssignedToday+candidate.Proposed >= candidate.Capacity {
+				continue
+			}
+			if best == nil || candidate.Open+candidate.Proposed < best.Open+best.Proposed ||
+				(candidate.Open+candidate.Proposed == best.Open+best.Proposed && candidate.AssignedToday < best.AssignedToday) {
+				best = candidate
+			}
+		}
+		if best == nil {
+			reason := "No active rider in hub"
+			if len(hubRiders) > 0 {
+				reason = "Riders of hub are at capacity or over cash limit"
+			}
+			plan.Skipped = append(plan.Skipped, serializer.DispatchSkipped{OrderID: order.ID, TrackID: order.TrackID, Reason: reason})
+			continue
+		}
+		best.Proposed++
+		plan.Assignments = append(plan.Assignments, serializer.DispatchAssignment{
+			OrderID:   order.ID,
+			TrackID:   order.TrackID,
+			RiderID:   best.RiderID,
+			RiderName: best.Name,
+			Hub:       best.Hub,
+		})
+	}
+	for _, candidate := range candidates {
+		plan.Riders = append(plan.Riders, *candidate)
+	}
+	return plan, nil
+}
+
+// Commit assigns all parcels in one transaction, nothing is assigned if a rider is no longer active,
+// out of hubs, over daily capacity or over cash limit or an order can not be assigned
+func (d *dispatchRepoImpl) Commit(db *mongo.Database, parcels []*models.RiderParcel, hubs []primitive.ObjectID) (*[]models.Order, error) {
+	wc := writeconcern.New(writeconcern.WMajority())
+	rc := readconcern.Snapshot()
+	txnOpts := options.Transaction().SetWriteConcern(wc).SetReadConcern(rc)
+	session, err := db.Client().StartSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.EndSession(context.Background())
+
+	callBack := func(sessionCtx mongo.SessionContext) (interface{}, error) {
+		proposed := make(map[primitive.ObjectID]int)
+		riderIDs := []primitive.ObjectID{}
+		for _, parcel := range parcels {
+			if proposed[parcel.RiderID] == 0 {
+				riderIDs = append(riderIDs, parcel.RiderID)
+			}
+			proposed[parcel.RiderID]++
+		}
+		cursor, err := db.Collection(models.Rider{}.CollectionName()).Find(sessionCtx, bson.M{"_id": bson.M{"$in": riderIDs}, "status": constants.Active})
+		if err != nil {
+			return nil, err
+		}
+		var riders []models.Rider
+		if err := cursor.All(sessionCtx, &riders); err != nil {
+			return nil, err
+		}
+		if len(riders) != len(riderIDs) {
+			return nil, errors.NewError(string(codes.RiderNotFound))
+		}
+		loads, err := riderLoads(sessionCtx, db, riderIDs)
+		if err != nil {
+			return nil, err
+		}
+		defaultCapacity := config.GetDispatch().RiderCapacity
+		for _, rider := range riders {
+			if hubs != nil && !containsObjectID(hubs, rider.HubID) {
+				return nil, errors.NewError(string(codes.OutOfHubScope))
+			}
+			if loads[rider.ID].AssignedToday+proposed[rider.ID] > rider.Capacity(defaultCapacity) {
+				return nil, errors.NewError(string(codes.RiderCapacityExceeded))
+			}
+		}
+		orders := []models.Order{}
+		for _, parcel := range parcels {
+			order, err := assignParcel(sessionCtx, db, parcel)
+			if err != nil {
+				return nil, err
+			}
+			orders = append(orders, *order)
+		}
+		return &orders, nil
+	}
+
+	result, err := session.WithTransaction(context.Background(), callBack, txnOpts)
+	if err != nil {
+		return nil, err
+	}
+	return result.(*[]models.Order), nil
+}
+
+func containsObjectID(IDs []primitive.ObjectID, ID *primitive.ObjectID) bool {
+	if ID == nil {
+		return false
+	}
+	for _, v := range IDs {
+		if v == *ID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,116 @@
+package data
+
+import (
+	"context"
+	"time"
+
+	"github.com/techartificer/swiftex/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type EventRepository interface {
+	ClaimEvent(db *mongo.Database, lease time.Duration) (*models.Event, error)
+	EventHandled(db *mongo.Database, ID primitive.ObjectID, subscriber string) error
+	EventDispatched(db *mongo.Database, ID primitive.ObjectID) error
+	EventFailed(db *mongo.Database, ID primitive.ObjectID, reason string, nextAttemptAt *time.Time) error
+}
+
+type eventRepoImpl struct{}
+
+var eventRepo EventRepository
+
+func NewEventRepo() EventRepository {
+	if eventRepo == nil {
+		eventRepo = &eventRepoImpl{}
+	}
+	return eventRepo
+}
+
+// publishEvent writes an event to the outbox,
+// ctx should be a session context to keep the event in caller's transaction
+func publishEvent(ctx context.Context, db *mongo.Database, eventType models.EventType, shopID, orderID *primitive.ObjectID, payload interface{}) error {
+	raw, err := bson.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	t := time.Now().UTC()
+	event := models.Event{
+		ID:            primitive.NewObjectID(),
+		Type:          eventType,
+		ShopID:        shopID,
+		OrderID:       orderID,
+		Payload:       raw,
+		Status:        models.EventPending,
+		HandledBy:     []string{},
+		NextAttemptAt: t,
+		CreatedAt:     t,
+	}
+	eventCollection := db.Collection(event.CollectionName())
+	_, err = eventCollection.InsertOne(ctx, event)
+	return err
+}
+
+// publishOrderStatus writes OrderStatusChanged event of order
+func publishOrderStatus(ctx context.Context, db *mongo.Database, order *models.Order, status *models.OrderStatus) error {
+	payload := models.OrderStatusChangedPayload{
+		OrderID: order.ID,
+		TrackID: order.TrackID,
+		ShopID:  order.ShopID,
+		RiderID: order.RiderID,
+		Status:  status.Status,
+		Text:    status.Text,
+		Price:   order.Price,
+		Charge:  order.Charge,
+		Time:    status.Time,
+	}
+	return publishEvent(ctx, db, models.OrderStatusChanged, &order.ShopID, &order.ID, payload)
+}
+
+// ClaimEvent picks a due event and postpones it by lease,
+// so that it isn't dispatched twice while being processed
+func (e *eventRepoImpl) ClaimEvent(db *mongo.Database, lease time.Duration) (*models.Event, error) {
+	eventCollection := db.Collection(models.Event{}.CollectionName())
+	t := time.Now().UTC()
+	filter := bson.M{"status": models.EventPending, "nextAttemptAt": bson.M{"$lte": t}}
+	update := bson.M{"$set": bson.M{"nextAttemptAt": t.Add(lease)}}
+	opt := options.FindOneAndUpdate().SetSort(bson.M{"nextAttemptAt": 1})
+	event := &models.Event{}
+	if err := eventCollection.FindOneAndUpdate(context.Background(), filter, update, opt).Decode(event); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// EventHandled records that subscriber has handled the event, it won't be called again on retries
+func (e *eventRepoImpl) EventHandled(db *mongo.Database, ID primitive.ObjectID, subscriber string) error {
+	eventCollection := db.Collection(models.Event{}.CollectionName())
+	_, err := eventCollection.UpdateOne(context.Background(), bson.M{"_id": ID}, bson.M{"$addToSet": bson.M{"handledBy": subscriber}})
+	return err
+}
+
+func (e *eventRepoImpl) EventDispatched(db *mongo.Database, ID primitive.ObjectID) error {
+	eventCollection := db.Collection(models.Event{}.CollectionName())
+	update := bson.M{
+		"$set":   bson.M{"status": models.EventDispatched, "dispatchedAt": time.Now().UTC()},
+		"$inc":   bson.M{"attempts": 1},
+		"$unset": bson.M{"lastError": ""},
+	}
+	_, err := eventCollection.UpdateOne(context.Background(), bson.M{"_id": ID}, update)
+	return err
+}
+
+// EventFailed records a failed dispatch, event is marked failed if nextAttemptAt is nil
+func (e *eventRepoImpl) EventFailed(db *mongo.Database, ID primitive.ObjectID, reason string, nextAttemptAt *time.Time) error {
+	eventCollection := db.Collection(models.Event{}.CollectionName())
+	set := bson.M{"lastError": reason}
+	if nextAttemptAt == nil {
+		set["status"] = models.EventFailed
+	} else {
+		set["nextAttemptAt"] = nextAttemptAt
+	}
+	_, err := eventCollection.UpdateOne(context.Background(), bson.M{"_id": ID}, bson.M{"$set": set, "$inc": bson.M{"attempts": 1}})
+	return err
+}
@@ -0,0 +1,310 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/techartificer/swiftex/constants/codes"
+	"github.com/techartificer/swiftex/lib/errors"
+	"github.com/techartificer/swiftex/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+)
+
+type InvoiceRepository interface {
+	Generate(db *mongo.Database, ID, shopID primitive.ObjectID, periodStart time.Time, createdBy *primitive.ObjectID) (*models.Invoice, error)
+	Invoices(db *mongo.Database, query bson.M, limit int64) (*[]models.Invoice, error)
+	InvoiceByID(db *mongo.Database, ID primitive.ObjectID) (*models.Invoice, error)
+	DueShops(db *mongo.Database, periodStart time.Time) ([]primitive.ObjectID, error)
+}
+
+type invoiceRepoImpl struct{}
+
+var invoiceRepo InvoiceRepository
+
+func NewInvoiceRepo() InvoiceRepository {
+	if invoiceRepo == nil {
+		invoiceRepo = &invoiceRepoImpl{}
+	}
+	return invoiceRepo
+}
+
+// invoiceCounter is the counter invoice numbers are taken from
+const invoiceCounter = "invoice"
+
+// invoicePeriodEnd returns end of the monthly settlement period starting at periodStart
+func invoicePeriodEnd(periodStart time.Time) time.Time {
+	return periodStart.AddDate(0, 1, 0)
+}
+
+// nextSequence increments the counter and returns its new value
+func nextSequence(ctx context.Context, db *mongo.Database, name string) (int64, error) {
+	counter := &models.Counter{}
+	counterCollection := db.Collection(counter.CollectionName())
+	after := options.After
+	upsert := true
+	opt := options.FindOneAndUpdateOptions{ReturnDocument: &after, Upsert: &upsert}
+	update := bson.M{"$inc": bson.M{"seq": 1}}
+	if err := counterCollection.FindOneAndUpdate(ctx, bson.M{"_id": name}, update, &opt).Decode(counter); err != nil {
+		return 0, err
+	}
+	return counter.Seq, nil
+}
+
+// lineAmount returns what the journal line adds to balance of its account
+func lineAmount(line models.JournalLine) float64 {
+	if line.AccountType.IsDebitNormal() {
+		return line.Debit - line.Credit
+	}
+	return line.Credit - line.Debit
+}
+
+// addInvoiceEntry adds a journal entry of the shop to the invoice as a delivered order,
+// a return or a payout, entries like opening balance are left out
+func addInvoiceEntry(invoice *models.Invoice, entry *models.JournalEntry) {
+	var collected, deliveryCharge, codCharge, payable, returnCharge, paid float64
+	delivery := false
+	for _, line := range entry.Lines {
+		switch line.AccountType {
+		case models.RiderCashInHand:
+			collected += lineAmount(line)
+			delivery = true
+		case models.PlatformRevenue:
+			deliveryCharge += lineAmount(line)
+			delivery = true
+		case models.CODFee:
+			codCharge += lineAmount(line)
+			delivery = true
+		case models.ReturnFee:
+			returnCharge += lineAmount(line)
+		case models.PlatformCash:
+			paid -= lineAmount(line)
+		case models.ShopReceivable:
+			if line.OwnerID != nil && *line.OwnerID == invoice.ShopID {
+				payable += lineAmount(line)
+			}
+		}
+	}
+	switch {
+	case delivery && entry.OrderID != nil:
+		invoice.Orders = append(invoice.Orders, models.InvoiceOrder{
+			OrderID:     *entry.OrderID,
+			Collected:   roundAmount(collected),
+			Charge:      roundAmount(deliveryCharge),
+			CODCharge:   roundAmount(codCharge),
+			Payable:     roundAmount(payable),
+			DeliveredAt: entry.CreatedAt,
+		})
+		invoice.TotalCollected += collected
+		invoice.TotalCharge += deliveryCharge
+		invoice.TotalCODCharge += codCharge
+	case returnCharge != 0 && entry.OrderID != nil:
+		invoice.Returns = append(invoice.Returns, models.InvoiceReturn{
+			OrderID:    *entry.OrderID,
+			Charge:     roundAmount(returnCharge),
+			ReturnedAt: entry.CreatedAt,
+		})
+		invoice.TotalReturn += returnCharge
+	case paid > 0:
+		invoice.Payouts = append(invoice.Payouts, models.InvoicePayout{
+			TrxHistoryID: entry.TrxHistoryID,
+			Description:  entry.Description,
+			Amount:       roundAmount(paid),
+			PaidAt:       entry.CreatedAt,
+		})
+		invoice.TotalPayout += paid
+	}
+}
+
+// totalInvoice rounds totals of the invoice, net payable is what the shop earned in the period
+func totalInvoice(invoice *models.Invoice) {
+	invoice.TotalCollected = roundAmount(invoice.TotalCollected)
+	invoice.TotalCharge = roundAmount(invoice.TotalCharge)
+	invoice.TotalCODCharge = roundAmount(invoice.TotalCODCharge)
+	invoice.TotalReturn = roundAmount(invoice.TotalReturn)
+	invoice.TotalPayout = roundAmount(invoice.TotalPayout)
+	invoice.NetPayable = roundAmount(invoice.TotalCollected - invoice.TotalCharge - invoice.TotalCODCharge - invoice.TotalReturn)
+}
+
+// fillInvoiceOrders sets track ID, items and return reason of invoice orders
+func fillInvoiceOrders(ctx context.Context, db *mongo.Database, invoice *models.Invoice) error {
+	orderIDs := []primitive.ObjectID{}
+	for _, order := range invoice.Orders {
+		orderIDs = append(orderIDs, order.OrderID)
+	}
+	for _, orderReturn := range invoice.Returns {
+		orderIDs = append(orderIDs, orderReturn.OrderID)
+	}
+	if len(orderIDs) == 0 {
+		return nil
+	}
+	orderCollection := db.Collection(models.Order{}.CollectionName())
+	opts := options.Find().SetProjection(bson.M{"trackId": 1, "numberOfItems": 1, "deliveredItems": 1, "return": 1})
+	cursor, err := orderCollection.Find(ctx, bson.M{"_id": bson.M{"$in": orderIDs}}, opts)
+	if err != nil {
+		return err
+	}
+	orders := []models.Order{}
+	if err := cursor.All(ctx, &orders); err != nil {
+		return err
+	}
+	byID := make(map[primitive.ObjectID]*models.Order)
+	for i := range orders {
+		byID[orders[i].ID] = &orders[i]
+	}
+	for i := range invoice.Orders {
+		if order, ok := byID[invoice.Orders[i].OrderID]; ok {
+			invoice.Orders[i].TrackID = order.TrackID
+			invoice.Orders[i].NumberOfItems = order.NumberOfItems
+			invoice.Orders[i].DeliveredItems = order.DeliveredItems
+		}
+	}
+	for i := range invoice.Returns {
+		if order, ok := byID[invoice.Returns[i].OrderID]; ok {
+			invoice.Returns[i].TrackID = order.TrackID
+			if order.Return != nil {
+				invoice.Returns[i].Reason = order.Return.Reason
+			}
+		}
+	}
+	return nil
+}
+
+// Generate snapshots deliveries, returns and payouts of the shop in the monthly period from its journal
+// and numbers the invoice from the invoice counter, a period is invoiced once
+func (i *invoiceRepoImpl) Generate(db *mongo.Database, ID, shopID primitive.ObjectID, periodStart time.Time, createdBy *primitive.ObjectID) (*models.Invoice, error) {
+	wc := writeconcern.New(writeconcern.WMajority())
+	rc := readconcern.Snapshot()
+	txnOpts := options.Transaction().SetWriteConcern(wc).SetReadConcern(rc)
+	session, err := db.Client().StartSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.EndSession(context.Background())
+
+	callBack := func(sessionCtx mongo.SessionContext) (interface{}, error) {
+		invoiceCollection := db.Collection(models.Invoice{}.CollectionName())
+		count, err := invoiceCollection.CountDocuments(sessionCtx, bson.M{"shopId": shopID, "periodStart": periodStart})
+		if err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			return nil, errors.NewError(string(codes.InvoiceAlreadyExist))
+		}
+		shop := &models.Shop{}
+		shopCollection := db.Collection(shop.CollectionName())
+		if err := shopCollection.FindOne(sessionCtx, bson.M{"_id": shopID}).Decode(shop); err != nil {
+			if err == mongo.ErrNoDocuments {
+				return nil, errors.NewError(string(codes.ShopNotFound))
+			}
+			return nil, err
+		}
+		invoice := &models.Invoice{
+			ID:          ID,
+			ShopID:      shop.ID,
+			ShopName:    shop.Name,
+			ShopAddress: shop.Address,
+			Period:      periodStart.Format("2006-01"),
+			PeriodStart: periodStart,
+			PeriodEnd:   invoicePeriodEnd(periodStart),
+			Orders:      []models.InvoiceOrder{},
+			Returns:     []models.InvoiceReturn{},
+			Payouts:     []models.InvoicePayout{},
+			CreatedBy:   createdBy,
+			CreatedAt:   time.Now().UTC(),
+		}
+		journalCollection := db.Collection(models.JournalEntry{}.CollectionName())
+		query := bson.M{"shopId": shopID, "createdAt": bson.M{"$gte": invoice.PeriodStart, "$lt": invoice.PeriodEnd}}
+		opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}})
+		cursor, err := journalCollection.Find(sessionCtx, query, opts)
+		if err != nil {
+			return nil, err
+		}
+		entries := []models.JournalEntry{}
+		if err := cursor.All(sessionCtx, &entries); err != nil {
+			return nil, err
+		}
+		for i := range entries {
+			addInvoiceEntry(invoice, &entries[i])
+		}
+		if err := fillInvoiceOrders(sessionCtx, db, invoice); err != nil {
+			return nil, err
+		}
+		totalInvoice(invoice)
+
+		seq, err := nextSequence(sessionCtx, db, invoiceCounter)
+		if err != nil {
+			return nil, err
+		}
+		invoice.Sequence = seq
+		invoice.Number = fmt.Sprintf("INV-%06d", seq)
+		if _, err := invoiceCollection.InsertOne(sessionCtx, invoice); err != nil {
+			return nil, err
+		}
+		return invoice, nil
+	}
+	result, err := session.WithTransaction(context.Background(), callBack, txnOpts)
+	if err != nil {
+		return nil, err
+	}
+	return result.(*models.Invoice), nil
+}
+
+// Invoices returns invoices matching query, newest first
+func (i *invoiceRepoImpl) Invoices(db *mongo.Database, query bson.M, limit int64) (*[]models.Invoice, error) {
+	invoiceCollection := db.Collection(models.Invoice{}.CollectionName())
+	opts := options.Find().SetSort(bson.M{"_id": -1}).SetLimit(limit).
+		SetProjection(bson.M{"orders": 0, "returns": 0, "payouts": 0})
+	cursor, err := invoiceCollection.Find(context.Background(), query, opts)
+	if err != nil {
+		return nil, err
+	}
+	var invoices []models.Invoice
+	if err = cursor.All(context.Background(), &invoices); err != nil {
+		return nil, err
+	}
+	return &invoices, nil
+}
+
+func (i *invoiceRepoImpl) InvoiceByID(db *mongo.Database, ID primitive.ObjectID) (*models.Invoice, error) {
+	invoice := &models.Invoice{}
+	invoiceCollection := db.Collection(invoice.CollectionName())
+	if err := invoiceCollection.FindOne(context.Background(), bson.M{"_id": ID}).Decode(invoice); err != nil {
+		return nil, err
+	}
+	return invoice, nil
+}
+
+// DueShops returns shops which have journal entries in the monthly period but no invoice for it yet
+func (i *invoiceRepoImpl) DueShops(db *mongo.Database, periodStart time.Time) ([]primitive.ObjectID, error) {
+	ctx := context.Background()
+	journalCollection := db.Collection(models.JournalEntry{}.CollectionName())
+	query := bson.M{"shopId": bson.M{"$ne": nil}, "createdAt": bson.M{"$gte": periodStart, "$lt": invoicePeriodEnd(periodStart)}}
+	values, err := journalCollection.Distinct(ctx, "shopId", query)
+	if err != nil {
+		return nil, err
+	}
+	invoiceCollection := db.Collection(models.Invoice{}.CollectionName())
+	invoiced, err := invoiceCollection.Distinct(ctx, "shopId", bson.M{"periodStart": periodStart})
+	if err != nil {
+		return nil, err
+	}
+	done := make(map[primitive.ObjectID]bool)
+	for _, value := range invoiced {
+		if shopID, ok := value.(primitive.ObjectID); ok {
+			done[shopID] = true
+		}
+	}
+	shopIDs := []primitive.ObjectID{}
+	for _, value := range values {
+		if shopID, ok := value.(primitive.ObjectID); ok && !done[shopID] {
+			shopIDs = append(shopIDs, shopID)
+		}
+	}
+	return shopIDs, nil
+}
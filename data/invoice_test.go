@@ -0,0 +1,90 @@
+package data
+
+import (
+	"testing"
+
+	"github.com/techartificer/swiftex/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestAddInvoiceEntry(t *testing.T) {
+	shopID, otherShopID, riderID := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	orderID, returnedID, trxHistoryID := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	entries := []models.JournalEntry{
+		{
+			Description: "Opening balance",
+			Lines: []models.JournalLine{
+				debitLine(models.OpeningBalance, nil, 500, ""),
+				creditLine(models.ShopReceivable, &shopID, 500, ""),
+			},
+		},
+		{
+			OrderID:     &orderID,
+			Description: "Parcel delivered",
+			Lines: []models.JournalLine{
+				debitLine(models.RiderCashInHand, &riderID, 1060.5, ""),
+				creditLine(models.PlatformRevenue, nil, 60, ""),
+				creditLine(models.CODFee, nil, 10, ""),
+				creditLine(models.ShopReceivable, &shopID, 990.5, ""),
+			},
+		},
+		{
+			OrderID:     &returnedID,
+			Description: "Parcel returned",
+			Lines: []models.JournalLine{
+				debitLine(models.ShopReceivable, &shopID, 30, ""),
+				creditLine(models.ReturnFee, nil, 30, ""),
+			},
+		},
+		{
+			TrxHistoryID: &trxHistoryID,
+			Description:  "Scheduled payout",
+			Lines: []models.JournalLine{
+				debitLine(models.ShopReceivable, &shopID, 700, ""),
+				creditLine(models.PlatformCash, nil, 700, ""),
+			},
+		},
+		{
+			Description: "Other shop",
+			Lines: []models.JournalLine{
+				debitLine(models.OpeningBalance, nil, 100, ""),
+				creditLine(models.ShopReceivable, &otherShopID, 100, ""),
+			},
+		},
+	}
+	invoice := &models.Invoice{ShopID: shopID}
+	for i := range entries {
+		addInvoiceEntry(invoice, &entries[i])
+	}
+	totalInvoice(invoice)
+
+	if len(invoice.Orders) != 1 || len(invoice.Returns) != 1 || len(invoice.Payouts) != 1 {
+		t.Fatalf("addInvoiceEntry() added %d orders, %d returns and %d payouts, want 1 each", len(invoice.Orders), len(invoice.Returns), len(invoice.Payouts))
+	}
+	order := invoice.Orders[0]
+	if order.OrderID != orderID || order.Collected != 1060.5 || order.Charge != 60 || order.CODCharge != 10 || order.Payable != 990.5 {
+		t.Errorf("addInvoiceEntry() order = %+v", order)
+	}
+	if ret := invoice.Returns[0]; ret.OrderID != returnedID || ret.Charge != 30 {
+		t.Errorf("addInvoiceEntry() return = %+v", ret)
+	}
+	if payout := invoice.Payouts[0]; payout.Amount != 700 || payout.TrxHistoryID == nil || *payout.TrxHistoryID != trxHistoryID {
+		t.Errorf("addInvoiceEntry() payout = %+v", payout)
+	}
+	totals := []struct {
+		name      string
+		got, want float64
+	}{
+		{"collected", invoice.TotalCollected, 1060.5},
+		{"charge", invoice.TotalCharge, 60},
+		{"cod charge", invoice.TotalCODCharge, 10},
+		{"return", invoice.TotalReturn, 30},
+		{"payout", invoice.TotalPayout, 700},
+		{"net payable", invoice.NetPayable, 960.5},
+	}
+	for _, tt := range totals {
+		if tt.got != tt.want {
+			t.Errorf("total %s = %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+}
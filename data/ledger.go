@@ -0,0 +1,321 @@
+package data
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/techartificer/swiftex/constants/codes"
+	"github.com/techartificer/swiftex/lib/errors"
+	"github.com/techartificer/swiftex/models"
+	"github.com/techartificer/swiftex/serializer"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type LedgerRepository interface {
+	Statement(db *mongo.Database, shopID, lastID string, startDate, endDate *time.Time) (*map[string]interface{}, error)
+	Accounts(db *mongo.Database, accountType, lastID string) (*[]models.LedgerAccount, error)
+	Verify(db *mongo.Database, fix bool) (*[]serializer.LedgerMismatch, error)
+}
+
+type ledgerRepoImpl struct{}
+
+var ledgerRepo LedgerRepository
+
+func NewLedgerRepo() LedgerRepository {
+	if ledgerRepo == nil {
+		ledgerRepo = &ledgerRepoImpl{}
+	}
+	return ledgerRepo
+}
+
+func roundAmount(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// accountBalance returns balance of account type from debit and credit
+func accountBalance(accountType models.AccountType, debit, credit float64) float64 {
+	if accountType.IsDebitNormal() {
+		return roundAmount(debit - credit)
+	}
+	return roundAmount(credit - debit)
+}
+
+// ledgerLine returns a journal line, negative amount is posted on the opposite side
+func ledgerLine(accountType models.AccountType, ownerID *primitive.ObjectID, debit float64, memo string) models.JournalLine {
+	line := models.JournalLine{AccountType: accountType, OwnerID: ownerID, Memo: memo}
+	if debit < 0 {
+		line.Credit = roundAmount(-debit)
+	} else {
+		line.Debit = roundAmount(debit)
+	}
+	return line
+}
+
+// debitLine returns a journal line which debits the account
+func debitLine(accountType models.AccountType, ownerID *primitive.ObjectID, amount float64, memo string) models.JournalLine {
+	return ledgerLine(accountType, ownerID, amount, memo)
+}
+
+// creditLine returns a journal line which credits the account
+func creditLine(accountType models.AccountType, ownerID *primitive.ObjectID, amount float64, memo string) models.JournalLine {
+	return ledgerLine(accountType, ownerID, -amount, memo)
+}
+
+// postJournal writes a balanced journal entry and updates account balances,
+// ctx should be a session context to keep the entry in caller's transaction
+func postJournal(ctx context.Context, db *mongo.Database, entry *models.JournalEntry) error {
+	var lines []models.JournalLine
+	var debit, credit float64
+	for _, line := range entry.Lines {
+		if line.Debit == 0 && line.Credit == 0 {
+			continue
+		}
+		debit += line.Debit
+		credit += line.Credit
+		lines = append(lines, line)
+	}
+	if roundAmount(debit) != roundAmount(credit) {
+		return errors.NewError(string(codes.UnbalancedJournal))
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	entry.Lines = lines
+	if entry.ID.IsZero() {
+		entry.ID = primitive.NewObjectID()
+	}
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now().UTC()
+	}
+	accountCollection := db.Collection(models.LedgerAccount{}.CollectionName())
+	for _, line := range lines {
+		filter := bson.M{"type": line.AccountType, "ownerId": line.OwnerID}
+		update := bson.M{
+			"$inc": bson.M{
+				"debit":   line.Debit,
+				"credit":  line.Credit,
+				"balance": accountBalance(line.AccountType, line.Debit, line.Credit),
+			},
+			"$set":         bson.M{"updatedAt": entry.CreatedAt},
+			"$setOnInsert": bson.M{"createdAt": entry.CreatedAt},
+		}
+		opts := options.Update().SetUpsert(true)
+		if _, err := accountCollection.UpdateOne(ctx, filter, update, opts); err != nil {
+			return err
+		}
+	}
+	journalCollection := db.Collection(entry.CollectionName())
+	_, err := journalCollection.InsertOne(ctx, entry)
+	return err
+}
+
+// ensureShopAccount posts opening balance of a shop which existed before the ledger
+func ensureShopAccount(ctx context.Context, db *mongo.Database, trx *models.Transaction) error {
+	accountCollection := db.Collection(models.LedgerAccount{}.CollectionName())
+	cnt, err := accountCollection.CountDocuments(ctx, bson.M{"type": models.ShopReceivable, "ownerId": trx.ShopID})
+	if err != nil || cnt > 0 {
+		return err
+	}
+	return postJournal(ctx, db, openingEntry(trx))
+}
+
+func openingEntry(trx *models.Transaction) *models.JournalEntry {
+	return &models.JournalEntry{
+		ShopID:      &trx.ShopID,
+		Description: "Opening balance",
+		Lines: []models.JournalLine{
+			debitLine(models.OpeningBalance, nil, trx.Balance, "Balance before ledger"),
+			creditLine(models.ShopReceivable, &trx.ShopID, trx.Balance, "Balance before ledger"),
+		},
+	}
+}
+
+func (l *ledgerRepoImpl) Statement(db *mongo.Database, shopID, lastID string, startDate, endDate *time.Time) (*map[string]interface{}, error) {
+	_shopID, err := primitive.ObjectIDFromHex(shopID)
+	if err != nil {
+		return nil, err
+	}
+	query := bson.M{"shopId": _shopID}
+	if lastID != "" {
+		_lastID, err := primitive.ObjectIDFromHex(lastID)
+		if err != nil {
+			return nil, err
+		}
+		query["_id"] = bson.M{"$lt": _lastID}
+	}
+	if startDate != nil && endDate != nil && !startDate.IsZero() && !endDate.IsZero() {
+		query["$and"] = []bson.M{{"createdAt": bson.M{"$gte": startDate}}, {"createdAt": bson.M{"$lte": endDate}}}
+	}
+	journalCollection := db.Collection(models.JournalEntry{}.CollectionName())
+	opts := options.Find().SetSort(bson.M{"_id": -1}).SetLimit(30)
+	cursor, err := journalCollection.Find(context.Background(), query, opts)
+	if err != nil {
+		return nil, err
+	}
+	entries := []models.JournalEntry{}
+	if err = cursor.All(context.Background(), &entries); err != nil {
+		return nil, err
+	}
+	account := &models.LedgerAccount{}
+	accountCollection := db.Collection(account.CollectionName())
+	err = accountCollection.FindOne(context.Background(), bson.M{"type": models.ShopReceivable, "ownerId": _shopID}).Decode(account)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+	result := map[string]interface{}{
+		"balance": account.Balance,
+		"entries": entries,
+	}
+	return &result, nil
+}
+
+func (l *ledgerRepoImpl) Accounts(db *mongo.Database, accountType, lastID string) (*[]models.LedgerAccount, error) {
+	accountCollection := db.Collection(models.LedgerAccount{}.CollectionName())
+	query := make(bson.M)
+	if accountType != "" {
+		query["type"] = accountType
+	}
+	if lastID != "" {
+		_lastID, err := primitive.ObjectIDFromHex(lastID)
+		if err != nil {
+			return nil, err
+		}
+		query["_id"] = bson.M{"$lt": _lastID}
+	}
+	opts := options.Find().SetSort(bson.M{"_id": -1}).SetLimit(30)
+	cursor, err := accountCollection.Find(context.Background(), query, opts)
+	if err != nil {
+		return nil, err
+	}
+	var accounts []models.LedgerAccount
+	if err = cursor.All(context.Background(), &accounts); err != nil {
+		return nil, err
+	}
+	return &accounts, nil
+}
+
+type journalTotal struct {
+	ID struct {
+		AccountType models.AccountType  `bson:"accountType"`
+		OwnerID     *primitive.ObjectID `bson:"ownerId"`
+	} `bson:"_id"`
+	Debit  float64 `bson:"debit"`
+	Credit float64 `bson:"credit"`
+}
+
+// Verify recomputes every account balance from journal and compares with stored balances,
+// stored balances are corrected from journal if fix is true
+func (l *ledgerRepoImpl) Verify(db *mongo.Database, fix bool) (*[]serializer.LedgerMismatch, error) {
+	mismatches := []serializer.LedgerMismatch{}
+	journalCollection := db.Collection(models.JournalEntry{}.CollectionName())
+	accountCollection := db.Collection(models.LedgerAccount{}.CollectionName())
+	trxCollection := db.Collection(models.Transaction{}.CollectionName())
+
+	if fix {
+		// shops existed before ledger get an opening balance
+		cursor, err := trxCollection.Find(context.Background(), bson.M{})
+		if err != nil {
+			return nil, err
+		}
+		var trxs []models.Transaction
+		if err = cursor.All(context.Background(), &trxs); err != nil {
+			return nil, err
+		}
+		for i := range trxs {
+			if err := ensureShopAccount(context.Background(), db, &trxs[i]); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	pipeline := []bson.M{
+		{"$unwind": "$lines"},
+		{"$group": bson.M{
+			"_id":    bson.M{"accountType": "$lines.accountType", "ownerId": "$lines.ownerId"},
+			"debit":  bson.M{"$sum": "$lines.debit"},
+			"credit": bson.M{"$sum": "$lines.credit"},
+		}},
+	}
+	cursor, err := journalCollection.Aggregate(context.Background(), pipeline)
+	if err != nil {
+		return nil, err
+	}
+	var totals []journalTotal
+	if err = cursor.All(context.Background(), &totals); err != nil {
+		return nil, err
+	}
+	expected := make(map[string]float64)
+	for _, total := range totals {
+		accountType, ownerID := total.ID.AccountType, total.ID.OwnerID
+		balance := accountBalance(accountType, total.Debit, total.Credit)
+		key := string(accountType)
+		if ownerID != nil {
+			key += ownerID.Hex()
+		}
+		expected[key] = balance
+
+		account := models.LedgerAccount{}
+		filter := bson.M{"type": accountType, "ownerId": ownerID}
+		if err := accountCollection.FindOne(context.Background(), filter).Decode(&account); err != nil && err != mongo.ErrNoDocuments {
+			return nil, err
+		}
+		if roundAmount(account.Balance) == balance {
+			continue
+		}
+		mismatch := serializer.LedgerMismatch{
+			AccountType: string(accountType),
+			OwnerID:     ownerID,
+			Stored:      account.Balance,
+			Expected:    balance,
+		}
+		if fix {
+			update := bson.M{"$set": bson.M{
+				"debit":     total.Debit,
+				"credit":    total.Credit,
+				"balance":   balance,
+				"updatedAt": time.Now().UTC(),
+			}}
+			if _, err := accountCollection.UpdateOne(context.Background(), filter, update, options.Update().SetUpsert(true)); err != nil {
+				return nil, err
+			}
+			mismatch.Fixed = true
+		}
+		mismatches = append(mismatches, mismatch)
+	}
+
+	// shop balance of transaction must match shop receivable account
+	cursor, err = trxCollection.Find(context.Background(), bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	var trxs []models.Transaction
+	if err = cursor.All(context.Background(), &trxs); err != nil {
+		return nil, err
+	}
+	for _, trx := range trxs {
+		shopID := trx.ShopID
+		balance := expected[string(models.ShopReceivable)+shopID.Hex()]
+		if roundAmount(trx.Balance) == balance {
+			continue
+		}
+		mismatch := serializer.LedgerMismatch{
+			AccountType: "Transaction",
+			OwnerID:     &shopID,
+			Stored:      trx.Balance,
+			Expected:    balance,
+		}
+		if fix {
+			update := bson.M{"$set": bson.M{"balance": balance, "updatedAt": time.Now().UTC()}}
+			if _, err := trxCollection.UpdateOne(context.Background(), bson.M{"_id": trx.ID}, update); err != nil {
+				return nil, err
+			}
+			mismatch.Fixed = true
+		}
+		mismatches = append(mismatches, mismatch)
+	}
+	return &mismatches, nil
+}
@@ -0,0 +1,82 @@
+package data
+
+import (
+	"context"
+	"testing"
+
+	"github.com/techartificer/swiftex/constants/codes"
+	"github.com/techartificer/swiftex/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestLedgerLine(t *testing.T) {
+	tests := []struct {
+		name   string
+		line   models.JournalLine
+		debit  float64
+		credit float64
+	}{
+		{"debit", debitLine(models.RiderCashInHand, nil, 100.004, ""), 100, 0},
+		{"credit", creditLine(models.ShopReceivable, nil, 49.996, ""), 0, 50},
+		{"negative debit", debitLine(models.OpeningBalance, nil, -20, ""), 0, 20},
+		{"negative credit", creditLine(models.ShopReceivable, nil, -20, ""), 20, 0},
+	}
+	for _, tt := range tests {
+		if tt.line.Debit != tt.debit || tt.line.Credit != tt.credit {
+			t.Errorf("%s: got debit %v credit %v, want debit %v credit %v", tt.name, tt.line.Debit, tt.line.Credit, tt.debit, tt.credit)
+		}
+	}
+}
+
+func TestAccountBalance(t *testing.T) {
+	tests := []struct {
+		accountType   models.AccountType
+		debit, credit float64
+		want          float64
+	}{
+		{models.RiderCashInHand, 100, 30, 70},
+		{models.PlatformCash, 0, 50, -50},
+		{models.ShopReceivable, 30, 100, 70},
+		{models.PlatformRevenue, 0, 60, 60},
+	}
+	for _, tt := range tests {
+		if got := accountBalance(tt.accountType, tt.debit, tt.credit); got != tt.want {
+			t.Errorf("accountBalance(%s, %v, %v) = %v, want %v", tt.accountType, tt.debit, tt.credit, got, tt.want)
+		}
+	}
+}
+
+func TestOpeningEntry(t *testing.T) {
+	for _, balance := range []float64{250.5, -40, 0} {
+		trx := &models.Transaction{ShopID: primitive.NewObjectID(), Balance: balance}
+		entry := openingEntry(trx)
+		var debit, credit float64
+		for _, line := range entry.Lines {
+			debit += line.Debit
+			credit += line.Credit
+		}
+		if debit != credit {
+			t.Errorf("openingEntry() of balance %v is unbalanced, debit %v credit %v", balance, debit, credit)
+		}
+		receivable := entry.Lines[1]
+		if got := accountBalance(receivable.AccountType, receivable.Debit, receivable.Credit); got != balance {
+			t.Errorf("openingEntry() credits shop %v, want %v", got, balance)
+		}
+	}
+}
+
+func TestPostJournalUnbalanced(t *testing.T) {
+	entry := &models.JournalEntry{Lines: []models.JournalLine{
+		debitLine(models.RiderCashInHand, nil, 100, ""),
+		creditLine(models.PlatformRevenue, nil, 60, ""),
+		creditLine(models.ShopReceivable, nil, 30, ""),
+	}}
+	err := postJournal(context.Background(), nil, entry)
+	if err == nil || err.Error() != string(codes.UnbalancedJournal) {
+		t.Errorf("postJournal() = %v, want %s", err, codes.UnbalancedJournal)
+	}
+	empty := &models.JournalEntry{Lines: []models.JournalLine{creditLine(models.CODFee, nil, 0, "")}}
+	if err := postJournal(context.Background(), nil, empty); err != nil {
+		t.Errorf("postJournal() of an empty entry = %v, want nil", err)
+	}
+}
@@ -0,0 +1,453 @@
+package data
+
+import (
+	"context"
+	"time"
+
+	"github.com/gosimple/slug"
+	"github.com/techartificer/swiftex/constants"
+	"github.com/techartificer/swiftex/constants/codes"
+	"github.com/techartificer/swiftex/lib/errors"
+	"github.com/techartificer/swiftex/models"
+	"github.com/techartificer/swiftex/serializer"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type LocationRepository interface {
+	CreateHub(db *mongo.Database, hub *models.Hub) error
+	Hubs(db *mongo.Database, status string) (*[]models.Hub, error)
+	HubByID(db *mongo.Database, ID string) (*models.Hub, error)
+	UpdateHub(db *mongo.Database, ID string, hub *models.Hub) (*models.Hub, error)
+	CreateCity(db *mongo.Database, city *models.City) error
+	Cities(db *mongo.Database, status string) (*[]models.City, error)
+	UpdateCity(db *mongo.Database, ID string, city *models.City) (*models.City, error)
+	CreateThana(db *mongo.Database, thana *models.Thana) error
+	Thanas(db *mongo.Database, cityID, status string) (*[]models.Thana, error)
+	UpdateThana(db *mongo.Database, ID string, thana *models.Thana) (*models.Thana, error)
+	CreateArea(db *mongo.Database, area *models.Area) error
+	Areas(db *mongo.Database, query bson.M) (*[]models.Area, error)
+	UpdateArea(db *mongo.Database, ID string, area *models.Area) (*models.Area, error)
+	SetCoverage(db *mongo.Database, hubID string, add, remove []primitive.ObjectID) (*[]models.Area, error)
+	HubsExist(db *mongo.Database, hubs []primitive.ObjectID) error
+	ShopsInHubs(db *mongo.Database, hubs []primitive.ObjectID) ([]primitive.ObjectID, error)
+	IsShopInHubs(db *mongo.Database, shop *models.Shop, hubs []primitive.ObjectID) (bool, error)
+	ResolveHub(db *mongo.Database, hub string) (*models.Hub, error)
+	ResolveOrderLocation(db *mongo.Database, pickHub, city, thana, area string) (*models.OrderLocation, error)
+	Migrate(db *mongo.Database, dryRun, create bool) (*serializer.LocationMigration, error)
+}
+
+type locationRepoImpl struct{}
+
+var locationRepo LocationRepository
+
+func NewLocationRepo() LocationRepository {
+	if locationRepo == nil {
+		locationRepo = &locationRepoImpl{}
+	}
+	return locationRepo
+}
+
+// findLocation finds an active registry entry by id or by slug of its name
+func findLocation(col *mongo.Collection, value string, scope bson.M, v interface{}) error {
+	query := bson.M{"status": constants.Active}
+	for key, val := range scope {
+		query[key] = val
+	}
+	if _id, err := primitive.ObjectIDFromHex(value); err == nil {
+		query["_id"] = _id
+	} else {
+		query["slug"] = slug.Make(value)
+	}
+	return col.FindOne(context.Background(), query).Decode(v)
+}
+
+func listLocations(col *mongo.Collection, query bson.M, v interface{}) error {
+	opts := options.Find().SetSort(bson.M{"name": 1})
+	cursor, err := col.Find(context.Background(), query, opts)
+	if err != nil {
+		return err
+	}
+	return cursor.All(context.Background(), v)
+}
+
+func updateLocation(col *mongo.Collection, ID string, set bson.M, v interface{}) error {
+	_id, err := primitive.ObjectIDFromHex(ID)
+	if err != nil {
+		return err
+	}
+	after := options.After
+	opt := options.FindOneAndUpdateOptions{
+		ReturnDocument: &after,
+	}
+	set["updatedAt"] = time.Now().UTC()
+	return col.FindOneAndUpdate(context.Background(), bson.M{"_id": _id}, bson.M{"$set": set}, &opt).Decode(v)
+}
+
+func statusQuery(status string) bson.M {
+	query := make(bson.M)
+	if status != "" {
+		query["status"] = status
+	}
+	return query
+}
+
+func (l *locationRepoImpl) CreateHub(db *mongo.Database, hub *models.Hub) error {
+	hubCollection := db.Collection(hub.CollectionName())
+	hub.Slug = slug.Make(hub.Name)
+	_, err := hubCollection.InsertOne(context.Background(), hub)
+	return err
+}
+
+func (l *locationRepoImpl) Hubs(db *mongo.Database, status string) (*[]models.Hub, error) {
+	hubCollection := db.Collection(models.Hub{}.CollectionName())
+	var hubs []models.Hub
+	if err := listLocations(hubCollection, statusQuery(status), &hubs); err != nil {
+		return nil, err
+	}
+	return &hubs, nil
+}
+
+func (l *locationRepoImpl) HubByID(db *mongo.Database, ID string) (*models.Hub, error) {
+	_id, err := primitive.ObjectIDFromHex(ID)
+	if err != nil {
+		return nil, err
+	}
+	hub := &models.Hub{}
+	hubCollection := db.Collection(hub.CollectionName())
+	if err := hubCollection.FindOne(context.Background(), bson.M{"_id": _id}).Decode(hub); err != nil {
+		return nil, err
+	}
+	return hub, nil
+}
+
+func (l *locationRepoImpl) UpdateHub(db *mongo.Database, ID string, hub *models.Hub) (*models.Hub, error) {
+	set := bson.M{}
+	if hub.Name != "" {
+		set["name"] = hub.Name
+		set["slug"] = slug.Make(hub.Name)
+	}
+	if hub.CityID != nil {
+		set["cityId"] = hub.CityID
+	}
+	if hub.Address != "" {
+		set["address"] = hub.Address
+	}
+	if hub.Phone != "" {
+		set["phone"] = hub.Phone
+	}
+	if hub.Status != "" {
+		set["status"] = hub.Status
+	}
+	updatedHub := &models.Hub{}
+	if err := updateLocation(db.Collection(hub.CollectionName()), ID, set, updatedHub); err != nil {
+		return nil, err
+	}
+	return updatedHub, nil
+}
+
+func (l *locationRepoImpl) CreateCity(db *mongo.Database, city *models.City) error {
+	cityCollection := db.Collection(city.CollectionName())
+	city.Slug = slug.Make(city.Name)
+	_, err := cityCollection.InsertOne(context.Background(), city)
+	return err
+}
+
+func (l *locationRepoImpl) Cities(db *mongo.Database, status string) (*[]models.City, error) {
+	cityCollection := db.Collection(models.City{}.CollectionName())
+	var cities []models.City
+	if err := listLocations(cityCollection, statusQuery(status), &cities); err != nil {
+		return nil, err
+	}
+	return &cities, nil
+}
+
+func (l *locationRepoImpl) UpdateCity(db *mongo.Database, ID string, city *models.City) (*models.City, error) {
+	set := bson.M{}
+	if city.Name != "" {
+		set["name"] = city.Name
+		set["slug"] = slug.Make(city.Name)
+	}
+	if city.Status != "" {
+		set["status"] = city.Status
+	}
+	updatedCity := &models.City{}
+	if err := updateLocation(db.Collection(city.CollectionName()), ID, set, updatedCity); err != nil {
+		return nil, err
+	}
+	return updatedCity, nil
+}
+
+func (l *locationRepoImpl) CreateThana(db *mongo.Database, thana *models.Thana) error {
+	cityCollection := db.Collection(models.City{}.CollectionName())
+	if err := cityCollection.FindOne(context.Background(), bson.M{"_id": thana.CityID}).Err(); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return errors.NewError(string(codes.CityNotFound))
+		}
+		return err
+	}
+	thanaCollection := db.Collection(thana.CollectionName())
+	thana.Slug = slug.Make(thana.Name)
+	_, err := thanaCollection.InsertOne(context.Background(), thana)
+	return err
+}
+
+func (l *locationRepoImpl) Thanas(db *mongo.Database, cityID, status string) (*[]models.Thana, error) {
+	query := statusQuery(status)
+	if cityID != "" {
+		_cityID, err := primitive.ObjectIDFromHex(cityID)
+		if err != nil {
+			return nil, err
+		}
+		query["cityId"] = _cityID
+	}
+	thanaCollection := db.Collection(models.Thana{}.CollectionName())
+	var thanas []models.Thana
+	if err := listLocations(thanaCollection, query, &thanas); err != nil {
+		return nil, err
+	}
+	return &thanas, nil
+}
+
+func (l *locationRepoImpl) UpdateThana(db *mongo.Database, ID string, thana *models.Thana) (*models.Thana, error) {
+	set := bson.M{}
+	if thana.Name != "" {
+		set["name"] = thana.Name
+		set["slug"] = slug.Make(thana.Name)
+	}
+	if thana.Status != "" {
+		set["status"] = thana.Status
+	}
+	updatedThana := &models.Thana{}
+	if err := updateLocation(db.Collection(thana.CollectionName()), ID, set, updatedThana); err != nil {
+		return nil, err
+	}
+	return updatedThana, nil
+}
+
+// checkAreaRefs returns error if city, thana or hub of an area does not exist
+func checkAreaRefs(db *mongo.Database, area *models.Area) error {
+	if !area.CityID.IsZero() {
+		cityCollection := db.Collection(models.City{}.CollectionName())
+		if err := cityCollection.FindOne(context.Background(), bson.M{"_id": area.CityID}).Err(); err != nil {
+			if err == mongo.ErrNoDocuments {
+				return errors.NewError(string(codes.CityNotFound))
+			}
+			return err
+		}
+	}
+	if area.ThanaID != nil {
+		thanaCollection := db.Collection(models.Thana{}.CollectionName())
+		query := bson.M{"_id": area.ThanaID}
+		if !area.CityID.IsZero() {
+			query["cityId"] = area.CityID
+		}
+		if err := thanaCollection.FindOne(context.Background(), query).Err(); err != nil {
+			if err == mongo.ErrNoDocuments {
+				return errors.NewError(string(codes.ThanaNotFound))
+			}
+			return err
+		}
+	}
+	if area.HubID != nil {
+		hubCollection := db.Collection(models.Hub{}.CollectionName())
+		if err := hubCollection.FindOne(context.Background(), bson.M{"_id": area.HubID}).Err(); err != nil {
+			if err == mongo.ErrNoDocuments {
+				return errors.NewError(string(codes.HubNotFound))
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *locationRepoImpl) CreateArea(db *mongo.Database, area *models.Area) error {
+	if err := checkAreaRefs(db, area); err != nil {
+		return err
+	}
+	areaCollection := db.Collection(area.CollectionName())
+	area.Slug = slug.Make(area.Name)
+	_, err := areaCollection.InsertOne(context.Background(), area)
+	return err
+}
+
+func (l *locationRepoImpl) Areas(db *mongo.Database, query bson.M) (*[]models.Area, error) {
+	areaCollection := db.Collection(models.Area{}.CollectionName())
+	var areas []models.Area
+	if err := listLocations(areaCollection, query, &areas); err != nil {
+		return nil, err
+	}
+	return &areas, nil
+}
+
+func (l *locationRepoImpl) UpdateArea(db *mongo.Database, ID string, area *models.Area) (*models.Area, error) {
+	set := bson.M{}
+	if area.Name != "" {
+		set["name"] = area.Name
+		set["slug"] = slug.Make(area.Name)
+	}
+	if area.ThanaID != nil {
+		_id, err := primitive.ObjectIDFromHex(ID)
+		if err != nil {
+			return nil, err
+		}
+		current := &models.Area{}
+		if err := db.Collection(area.CollectionName()).FindOne(context.Background(), bson.M{"_id": _id}).Decode(current); err != nil {
+			return nil, err
+		}
+		if err := checkAreaRefs(db, &models.Area{CityID: current.CityID, ThanaID: area.ThanaID}); err != nil {
+			return nil, err
+		}
+		set["thanaId"] = area.ThanaID
+	}
+	if area.Status != "" {
+		set["status"] = area.Status
+	}
+	updatedArea := &models.Area{}
+	if err := updateLocation(db.Collection(area.CollectionName()), ID, set, updatedArea); err != nil {
+		return nil, err
+	}
+	return updatedArea, nil
+}
+
+// SetCoverage makes the hub cover added areas and uncovers removed areas,
+// returns all areas covered by the hub
+func (l *locationRepoImpl) SetCoverage(db *mongo.Database, hubID string, add, remove []primitive.ObjectID) (*[]models.Area, error) {
+	hub, err := l.HubByID(db, hubID)
+	if err != nil {
+		return nil, err
+	}
+	areaCollection := db.Collection(models.Area{}.CollectionName())
+	now := time.Now().UTC()
+	if len(add) > 0 {
+		count, err := areaCollection.CountDocuments(context.Background(), bson.M{"_id": bson.M{"$in": add}})
+		if err != nil {
+			return nil, err
+		}
+		if count != int64(len(add)) {
+			return nil, errors.NewError(string(codes.AreaNotFound))
+		}
+		filter := bson.M{"_id": bson.M{"$in": add}}
+		update := bson.M{"$set": bson.M{"hubId": hub.ID, "updatedAt": now}}
+		if _, err := areaCollection.UpdateMany(context.Background(), filter, update); err != nil {
+			return nil, err
+		}
+	}
+	if len(remove) > 0 {
+		filter := bson.M{"_id": bson.M{"$in": remove}, "hubId": hub.ID}
+		update := bson.M{"$unset": bson.M{"hubId": ""}, "$set": bson.M{"updatedAt": now}}
+		if _, err := areaCollection.UpdateMany(context.Background(), filter, update); err != nil {
+			return nil, err
+		}
+	}
+	return l.Areas(db, bson.M{"hubId": hub.ID})
+}
+
+// HubsExist returns HubNotFound error if any of the hubs does not exist
+func (l *locationRepoImpl) HubsExist(db *mongo.Database, hubs []primitive.ObjectID) error {
+	if len(hubs) == 0 {
+		return nil
+	}
+	hubCollection := db.Collection(models.Hub{}.CollectionName())
+	count, err := hubCollection.CountDocuments(context.Background(), bson.M{"_id": bson.M{"$in": hubs}})
+	if err != nil {
+		return err
+	}
+	if count != int64(len(hubs)) {
+		return errors.NewError(string(codes.HubNotFound))
+	}
+	return nil
+}
+
+// ShopsInHubs returns ids of shops whose pickup area is covered by the hubs
+func (l *locationRepoImpl) ShopsInHubs(db *mongo.Database, hubs []primitive.ObjectID) ([]primitive.ObjectID, error) {
+	areaCollection := db.Collection(models.Area{}.CollectionName())
+	areaIDs, err := areaCollection.Distinct(context.Background(), "_id", bson.M{"hubId": bson.M{"$in": hubs}})
+	if err != nil {
+		return nil, err
+	}
+	shopIDs := []primitive.ObjectID{}
+	if len(areaIDs) == 0 {
+		return shopIDs, nil
+	}
+	shopCollection := db.Collection(models.Shop{}.CollectionName())
+	ids, err := shopCollection.Distinct(context.Background(), "_id", bson.M{"pickupAreaId": bson.M{"$in": areaIDs}})
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		if _id, ok := id.(primitive.ObjectID); ok {
+			shopIDs = append(shopIDs, _id)
+		}
+	}
+	return shopIDs, nil
+}
+
+// IsShopInHubs reports whether pickup area of the shop is covered by one of the hubs
+func (l *locationRepoImpl) IsShopInHubs(db *mongo.Database, shop *models.Shop, hubs []primitive.ObjectID) (bool, error) {
+	if shop.PickupAreaID == nil || len(hubs) == 0 {
+		return false, nil
+	}
+	areaCollection := db.Collection(models.Area{}.CollectionName())
+	query := bson.M{"_id": shop.PickupAreaID, "hubId": bson.M{"$in": hubs}}
+	count, err := areaCollection.CountDocuments(context.Background(), query)
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (l *locationRepoImpl) ResolveHub(db *mongo.Database, hub string) (*models.Hub, error) {
+	result := &models.Hub{}
+	if err := findLocation(db.Collection(result.CollectionName()), hub, nil, result); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.NewError(string(codes.HubNotFound))
+		}
+		return nil, err
+	}
+	return result, nil
+}
+
+// ResolveOrderLocation maps pickup hub and recipient address of an order to registry,
+// values can be either id or name of the entries, thana is optional
+func (l *locationRepoImpl) ResolveOrderLocation(db *mongo.Database, pickHub, city, thana, area string) (*models.OrderLocation, error) {
+	location := &models.OrderLocation{}
+	hub, err := l.ResolveHub(db, pickHub)
+	if err != nil {
+		return nil, err
+	}
+	location.PickHub = hub
+
+	location.City = &models.City{}
+	if err := findLocation(db.Collection(models.City{}.CollectionName()), city, nil, location.City); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.NewError(string(codes.CityNotFound))
+		}
+		return nil, err
+	}
+	scope := bson.M{"cityId": location.City.ID}
+	if thana != "" {
+		location.Thana = &models.Thana{}
+		if err := findLocation(db.Collection(models.Thana{}.CollectionName()), thana, scope, location.Thana); err != nil {
+			if err == mongo.ErrNoDocuments {
+				return nil, errors.NewError(string(codes.ThanaNotFound))
+			}
+			return nil, err
+		}
+	}
+
+	location.Area = &models.Area{}
+	if err := findLocation(db.Collection(models.Area{}.CollectionName()), area, scope, location.Area); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.NewError(string(codes.AreaNotFound))
+		}
+		return nil, err
+	}
+	if location.Thana != nil && location.Area.ThanaID != nil && *location.Area.ThanaID != location.Thana.ID {
+		return nil, errors.NewError(string(codes.AreaNotFound))
+	}
+	location.DeliveryHubID = location.Area.HubID
+	return location, nil
+}
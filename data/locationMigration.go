@@ -0,0 +1,401 @@
+package data
+
+import (
+	"context"
+	"time"
+
+	"github.com/gosimple/slug"
+	"github.com/techartificer/swiftex/constants"
+	"github.com/techartificer/swiftex/models"
+	"github.com/techartificer/swiftex/serializer"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// locationMigrator maps free text locations to registry entries,
+// missing entries are created only if create is set
+type locationMigrator struct {
+	db     *mongo.Database
+	dryRun bool
+	create bool
+	report *serializer.LocationMigration
+	hubs   map[string]*models.Hub
+	cities map[string]*models.City
+	thanas map[string]*models.Thana
+	areas  map[string]*models.Area
+}
+
+type locationValue struct {
+	ID    string `bson:"_id"`
+	Count int64  `bson:"count"`
+}
+
+type addressValue struct {
+	ID struct {
+		City  string `bson:"city"`
+		Thana string `bson:"thana"`
+		Area  string `bson:"area"`
+	} `bson:"_id"`
+	Count int64 `bson:"count"`
+}
+
+func (m *locationMigrator) unresolved(collection, field, value string, count int64) {
+	m.report.Unresolved = append(m.report.Unresolved, serializer.UnresolvedLocation{
+		Collection: collection,
+		Field:      field,
+		Value:      value,
+		Count:      count,
+	})
+}
+
+func (m *locationMigrator) insert(col *mongo.Collection, doc interface{}) error {
+	if m.dryRun {
+		return nil
+	}
+	_, err := col.InsertOne(context.Background(), doc)
+	return err
+}
+
+// apply updates matched documents, only counts them on dry run
+func (m *locationMigrator) apply(col *mongo.Collection, filter, set bson.M) (int64, error) {
+	if m.dryRun {
+		return col.CountDocuments(context.Background(), filter)
+	}
+	result, err := col.UpdateMany(context.Background(), filter, bson.M{"$set": set})
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+func (m *locationMigrator) hub(name string) (*models.Hub, error) {
+	key := slug.Make(name)
+	if hub, ok := m.hubs[key]; ok {
+		return hub, nil
+	}
+	hub := &models.Hub{}
+	hubCollection := m.db.Collection(hub.CollectionName())
+	err := hubCollection.FindOne(context.Background(), bson.M{"slug": key}).Decode(hub)
+	if err == mongo.ErrNoDocuments && m.create {
+		hub = &models.Hub{
+			ID:        primitive.NewObjectID(),
+			Name:      name,
+			Slug:      key,
+			Status:    constants.Active,
+			CreatedAt: time.Now().UTC(),
+			UpdatedAt: time.Now().UTC(),
+		}
+		if err = m.insert(hubCollection, hub); err == nil {
+			m.report.CreatedHubs++
+		}
+	}
+	if err == mongo.ErrNoDocuments {
+		hub, err = nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	m.hubs[key] = hub
+	return hub, nil
+}
+
+func (m *locationMigrator) city(name string) (*models.City, error) {
+	key := slug.Make(name)
+	if city, ok := m.cities[key]; ok {
+		return city, nil
+	}
+	city := &models.City{}
+	cityCollection := m.db.Collection(city.CollectionName())
+	err := cityCollection.FindOne(context.Background(), bson.M{"slug": key}).Decode(city)
+	if err == mongo.ErrNoDocuments && m.create {
+		city = &models.City{
+			ID:        primitive.NewObjectID(),
+			Name:      name,
+			Slug:      key,
+			Status:    constants.Active,
+			CreatedAt: time.Now().UTC(),
+			UpdatedAt: time.Now().UTC(),
+		}
+		if err = m.insert(cityCollection, city); err == nil {
+			m.report.CreatedCities++
+		}
+	}
+	if err == mongo.ErrNoDocuments {
+		city, err = nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	m.cities[key] = city
+	return city, nil
+}
+
+func (m *locationMigrator) thana(city *models.City, name string) (*models.Thana, error) {
+	key := slug.Make(name)
+	cacheKey := city.ID.Hex() + "/" + key
+	if thana, ok := m.thanas[cacheKey]; ok {
+		return thana, nil
+	}
+	thana := &models.Thana{}
+	thanaCollection := m.db.Collection(thana.CollectionName())
+	err := thanaCollection.FindOne(context.Background(), bson.M{"cityId": city.ID, "slug": key}).Decode(thana)
+	if err == mongo.ErrNoDocuments && m.create {
+		thana = &models.Thana{
+			ID:        primitive.NewObjectID(),
+			CityID:    city.ID,
+			Name:      name,
+			Slug:      key,
+			Status:    constants.Active,
+			CreatedAt: time.Now().UTC(),
+			UpdatedAt: time.Now().UTC(),
+		}
+		if err = m.insert(thanaCollection, thana); err == nil {
+			m.report.CreatedThanas++
+		}
+	}
+	if err == mongo.ErrNoDocuments {
+		thana, err = nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	m.thanas[cacheKey] = thana
+	return thana, nil
+}
+
+func (m *locationMigrator) area(city *models.City, thana *models.Thana, name string) (*models.Area, error) {
+	key := slug.Make(name)
+	cacheKey := city.ID.Hex() + "/" + key
+	if area, ok := m.areas[cacheKey]; ok {
+		return area, nil
+	}
+	area := &models.Area{}
+	areaCollection := m.db.Collection(area.CollectionName())
+	err := areaCollection.FindOne(context.Background(), bson.M{"cityId": city.ID, "slug": key}).Decode(area)
+	if err == mongo.ErrNoDocuments && m.create {
+		area = &models.Area{
+			ID:        primitive.NewObjectID(),
+			CityID:    city.ID,
+			Name:      name,
+			Slug:      key,
+			Status:    constants.Active,
+			CreatedAt: time.Now().UTC(),
+			UpdatedAt: time.Now().UTC(),
+		}
+		if thana != nil {
+			area.ThanaID = &thana.ID
+		}
+		if err = m.insert(areaCollection, area); err == nil {
+			m.report.CreatedAreas++
+		}
+	}
+	if err == mongo.ErrNoDocuments {
+		area, err = nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	m.areas[cacheKey] = area
+	return area, nil
+}
+
+// distinct returns distinct non empty values of field with number of documents
+func distinct(col *mongo.Collection, field string, match bson.M) ([]locationValue, error) {
+	match[field] = bson.M{"$nin": []interface{}{"", nil}}
+	pipeline := []bson.M{
+		{"$match": match},
+		{"$group": bson.M{"_id": "$" + field, "count": bson.M{"$sum": 1}}},
+	}
+	cursor, err := col.Aggregate(context.Background(), pipeline)
+	if err != nil {
+		return nil, err
+	}
+	var values []locationValue
+	if err := cursor.All(context.Background(), &values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func (m *locationMigrator) migrateOrderHubs() error {
+	orderCollection := m.db.Collection(models.Order{}.CollectionName())
+	values, err := distinct(orderCollection, "pickHub", bson.M{"pickHubId": bson.M{"$exists": false}})
+	if err != nil {
+		return err
+	}
+	for _, value := range values {
+		hub, err := m.hub(value.ID)
+		if err != nil {
+			return err
+		}
+		if hub == nil {
+			m.unresolved("orders", "pickHub", value.ID, value.Count)
+			continue
+		}
+		filter := bson.M{"pickHub": value.ID, "pickHubId": bson.M{"$exists": false}}
+		n, err := m.apply(orderCollection, filter, bson.M{"pickHubId": hub.ID})
+		if err != nil {
+			return err
+		}
+		m.report.Orders += n
+	}
+	return nil
+}
+
+func (m *locationMigrator) migrateOrderAddresses() error {
+	orderCollection := m.db.Collection(models.Order{}.CollectionName())
+	pipeline := []bson.M{
+		{"$match": bson.M{"recipientAreaId": bson.M{"$exists": false}}},
+		{"$group": bson.M{
+			"_id":   bson.M{"city": "$recipientCity", "thana": "$recipientThana", "area": "$recipientArea"},
+			"count": bson.M{"$sum": 1},
+		}},
+	}
+	cursor, err := orderCollection.Aggregate(context.Background(), pipeline)
+	if err != nil {
+		return err
+	}
+	var values []addressValue
+	if err := cursor.All(context.Background(), &values); err != nil {
+		return err
+	}
+	for _, value := range values {
+		address := value.ID.City + " / " + value.ID.Thana + " / " + value.ID.Area
+		if value.ID.City == "" || value.ID.Area == "" {
+			m.unresolved("orders", "recipientArea", address, value.Count)
+			continue
+		}
+		city, err := m.city(value.ID.City)
+		if err != nil {
+			return err
+		}
+		if city == nil {
+			m.unresolved("orders", "recipientCity", address, value.Count)
+			continue
+		}
+		var thana *models.Thana
+		if value.ID.Thana != "" {
+			if thana, err = m.thana(city, value.ID.Thana); err != nil {
+				return err
+			}
+		}
+		area, err := m.area(city, thana, value.ID.Area)
+		if err != nil {
+			return err
+		}
+		if area == nil {
+			m.unresolved("orders", "recipientArea", address, value.Count)
+			continue
+		}
+		set := bson.M{"recipientCityId": city.ID, "recipientAreaId": area.ID}
+		if thana != nil {
+			set["recipientThanaId"] = thana.ID
+		}
+		if area.HubID != nil {
+			set["deliveryHubId"] = area.HubID
+		}
+		filter := bson.M{
+			"recipientCity":   value.ID.City,
+			"recipientThana":  value.ID.Thana,
+			"recipientArea":   value.ID.Area,
+			"recipientAreaId": bson.M{"$exists": false},
+		}
+		if value.ID.Thana == "" {
+			filter["recipientThana"] = bson.M{"$in": []interface{}{"", nil}}
+		}
+		n, err := m.apply(orderCollection, filter, set)
+		if err != nil {
+			return err
+		}
+		m.report.Orders += n
+	}
+	return nil
+}
+
+func (m *locationMigrator) migrateRiders() error {
+	riderCollection := m.db.Collection(models.Rider{}.CollectionName())
+	values, err := distinct(riderCollection, "hub", bson.M{"hubId": bson.M{"$exists": false}})
+	if err != nil {
+		return err
+	}
+	for _, value := range values {
+		hub, err := m.hub(value.ID)
+		if err != nil {
+			return err
+		}
+		if hub == nil {
+			m.unresolved("riders", "hub", value.ID, value.Count)
+			continue
+		}
+		filter := bson.M{"hub": value.ID, "hubId": bson.M{"$exists": false}}
+		n, err := m.apply(riderCollection, filter, bson.M{"hubId": hub.ID})
+		if err != nil {
+			return err
+		}
+		m.report.Riders += n
+	}
+	return nil
+}
+
+// migrateShopAreas maps shop areas which has no city, so an area is matched only if its name is unique
+func (m *locationMigrator) migrateShopAreas(field string) error {
+	shopCollection := m.db.Collection(models.Shop{}.CollectionName())
+	areaCollection := m.db.Collection(models.Area{}.CollectionName())
+	values, err := distinct(shopCollection, field, bson.M{field + "Id": bson.M{"$exists": false}})
+	if err != nil {
+		return err
+	}
+	for _, value := range values {
+		var areas []models.Area
+		cursor, err := areaCollection.Find(context.Background(), bson.M{"slug": slug.Make(value.ID)})
+		if err != nil {
+			return err
+		}
+		if err := cursor.All(context.Background(), &areas); err != nil {
+			return err
+		}
+		if len(areas) != 1 {
+			m.unresolved("shops", field, value.ID, value.Count)
+			continue
+		}
+		filter := bson.M{field: value.ID, field + "Id": bson.M{"$exists": false}}
+		n, err := m.apply(shopCollection, filter, bson.M{field + "Id": areas[0].ID})
+		if err != nil {
+			return err
+		}
+		m.report.Shops += n
+	}
+	return nil
+}
+
+// Migrate maps free text hubs and zones of orders, riders and shops to registry ids,
+// documents which already have ids are not touched so it is safe to run again
+func (l *locationRepoImpl) Migrate(db *mongo.Database, dryRun, create bool) (*serializer.LocationMigration, error) {
+	m := &locationMigrator{
+		db:     db,
+		dryRun: dryRun,
+		create: create,
+		report: &serializer.LocationMigration{DryRun: dryRun, Unresolved: []serializer.UnresolvedLocation{}},
+		hubs:   make(map[string]*models.Hub),
+		cities: make(map[string]*models.City),
+		thanas: make(map[string]*models.Thana),
+		areas:  make(map[string]*models.Area),
+	}
+	if err := m.migrateOrderHubs(); err != nil {
+		return nil, err
+	}
+	if err := m.migrateOrderAddresses(); err != nil {
+		return nil, err
+	}
+	if err := m.migrateRiders(); err != nil {
+		return nil, err
+	}
+	if err := m.migrateShopAreas("pickupArea"); err != nil {
+		return nil, err
+	}
+	if err := m.migrateShopAreas("deliveryZone"); err != nil {
+		return nil, err
+	}
+	return m.report, nil
+}
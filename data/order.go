@@ -5,12 +5,17 @@ import (
 	"time"
 
 	"github.com/techartificer/swiftex/constants"
+	"github.com/techartificer/swiftex/constants/codes"
+	"github.com/techartificer/swiftex/lib/errors"
 	"github.com/techartificer/swiftex/lib/helper"
+	"github.com/techartificer/swiftex/lib/orderstatus"
 	"github.com/techartificer/swiftex/models"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
 )
 
 type OrderRepository interface {
@@ -22,6 +27,11 @@ type OrderRepository interface {
 	TrackOrder(db *mongo.Database, trackID string) (*models.Order, error)
 	Dashboard(db *mongo.Database, shopID string, startDate, endDate *time.Time) (*map[string]int64, error)
 	CreateMultiple(db *mongo.Database, orders []interface{}) error
+	ImportMultiple(db *mongo.Database, orders []interface{}) (map[int]error, error)
+	OrdersForLabel(db *mongo.Database, query primitive.M, limit int64) (*[]models.Order, error)
+	CountOrders(db *mongo.Database, query primitive.M) (int64, error)
+	SetDeliveryOTP(db *mongo.Database, ID primitive.ObjectID, riderID *primitive.ObjectID, hash string, expiresAt int64) (*models.Order, error)
+	VerifyDeliveryOTP(db *mongo.Database, ID, riderID primitive.ObjectID, otp string) (string, error)
 }
 
 type orderRepositoryImpl struct{}
@@ -189,6 +199,10 @@ func (o *orderRepositoryImpl) UpdateOrder(db *mongo.Database, order *models.Orde
 	opt := options.FindOneAndUpdateOptions{
 		ReturnDocument: &after,
 	}
+	if order.CurrentStatus != nil || order.Status != nil {
+		// status can only be changed through AddOrderStatus
+		return nil, errors.NewError(string(codes.InvalidStatusTransition))
+	}
 	update := bson.D{{"$set", order}}
 	updatedOrder := &models.Order{}
 	err = orderCollection.FindOneAndUpdate(context.Background(), filter, update, &opt).Decode(&updatedOrder)
@@ -198,44 +212,90 @@ func (o *orderRepositoryImpl) UpdateOrder(db *mongo.Database, order *models.Orde
 func (o *orderRepositoryImpl) AddOrderStatus(db *mongo.Database, orderStatus *models.OrderStatus, ID string) (*models.Order, error) {
 	// TODO: have to add charge update [admin can change charge]
 
-	updatedOrder := &models.Order{}
-	orderCollection := db.Collection(updatedOrder.CollectionName())
+	orderCollection := db.Collection(models.Order{}.CollectionName())
 	_id, err := primitive.ObjectIDFromHex(ID)
 	if err != nil {
 		return nil, err
 	}
-	filter := bson.D{{"_id", _id}}
-	after := options.After
-	opt := options.FindOneAndUpdateOptions{
-		ReturnDocument: &after,
+	wc := writeconcern.New(writeconcern.WMajority())
+	rc := readconcern.Snapshot()
+	txnOpts := options.Transaction().SetWriteConcern(wc).SetReadConcern(rc)
+	session, err := db.Client().StartSession()
+	if err != nil {
+		return nil, err
 	}
-	query := make(bson.M)
-	if orderStatus.Status == constants.Accepted {
-		query["isAccepted"] = true
-		orderStatus.Text = constants.AcceptedMsg
-	}
-	if orderStatus.Status == constants.Picked {
-		query["isPicked"] = true
-		orderStatus.Text = constants.PickedMsg
-	}
-	if orderStatus.Status == constants.Declined {
-		query["isCancelled"] = true
-		if orderStatus.Text == "" {
-			orderStatus.Text = constants.CancelledMsg
+	defer session.EndSession(context.Background())
+
+	callBack := func(sessionCtx mongo.SessionContext) (interface{}, error) {
+		order := models.Order{}
+		if err := orderCollection.FindOne(sessionCtx, bson.M{"_id": _id}).Decode(&order); err != nil {
+			return nil, err
 		}
+		if err := checkTransition(&order, orderStatus.Status); err != nil {
+			return nil, err
+		}
+		// matching current status guards against a concurrent status change
+		filter := bson.M{"_id": _id, "currentStatus": order.CurrentStatus}
+		after := options.After
+		opt := options.FindOneAndUpdateOptions{
+			ReturnDocument: &after,
+		}
+		query := make(bson.M)
+		if orderStatus.Status == constants.Accepted {
+			query["isAccepted"] = true
+			orderStatus.Text = constants.AcceptedMsg
+		}
+		if orderStatus.Status == constants.Picked {
+			query["isPicked"] = true
+			orderStatus.Text = constants.PickedMsg
+		}
+		if orderStatus.Status == constants.Declined || orderStatus.Status == constants.Cancelled {
+			query["isCancelled"] = true
+			if orderStatus.Text == "" {
+				orderStatus.Text = constants.CancelledMsg
+			}
+		}
+		if orderStatus.Status == constants.Returned && orderStatus.Text == "" {
+			orderStatus.Text = constants.ReturnedMsg
+		}
+		if orderStatus.Status == constants.Rescheduled && orderStatus.Text == "" {
+			orderStatus.Text = constants.RescheduleMsg
+		}
+
+		query["currentStatus"] = orderStatus.Status
+		query["updatedAt"] = time.Now().UTC()
+		orderStatusArray := []models.OrderStatus{*orderStatus}
+		push := bson.M{"status": bson.M{"$each": orderStatusArray, "$position": 0}}
+		updatedOrder := &models.Order{}
+		err := orderCollection.FindOneAndUpdate(sessionCtx, filter, bson.M{"$set": query, "$push": push}, &opt).Decode(updatedOrder)
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.NewError(string(codes.InvalidStatusTransition))
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := publishOrderStatus(sessionCtx, db, updatedOrder, orderStatus); err != nil {
+			return nil, err
+		}
+		if orderStatus.Status == constants.Returned {
+			return startReturn(sessionCtx, db, updatedOrder, orderStatus, returnCharge(updatedOrder))
+		}
+		return updatedOrder, nil
 	}
-	if orderStatus.Status == constants.Returned && orderStatus.Text == "" {
-		orderStatus.Text = constants.ReturnedMsg
-	}
-	if orderStatus.Status == constants.Rescheduled && orderStatus.Text == "" {
-		orderStatus.Text = constants.RescheduleMsg
+	result, err := session.WithTransaction(context.Background(), callBack, txnOpts)
+	if err != nil {
+		return nil, err
 	}
+	return result.(*models.Order), nil
+}
 
-	query["currentStatus"] = orderStatus.Status
-	orderStatusArray := []models.OrderStatus{*orderStatus}
-	push := bson.M{"status": bson.M{"$each": orderStatusArray, "$position": 0}}
-	err = orderCollection.FindOneAndUpdate(context.Background(), filter, bson.M{"$set": query, "$push": push}, &opt).Decode(updatedOrder)
-	return updatedOrder, err
+// checkTransition returns error if order is not allowed to move to next status
+func checkTransition(order *models.Order, next string) error {
+	current := orderstatus.Current(order.CurrentStatus)
+	if !orderstatus.CanTransition(current, next) {
+		return errors.NewError(string(codes.InvalidStatusTransition))
+	}
+	return nil
 }
 
 func (o *orderRepositoryImpl) CreateMultiple(db *mongo.Database, orders []interface{}) error {
@@ -244,3 +304,42 @@ func (o *orderRepositoryImpl) CreateMultiple(db *mongo.Database, orders []interf
 	_, err := orderCollection.InsertMany(context.Background(), orders)
 	return err
 }
+
+// ImportMultiple inserts orders without stopping at a failed one,
+// returns errors of failed orders by their index
+func (o *orderRepositoryImpl) ImportMultiple(db *mongo.Database, orders []interface{}) (map[int]error, error) {
+	failed := make(map[int]error)
+	if len(orders) == 0 {
+		return failed, nil
+	}
+	orderCollection := db.Collection(models.Order{}.CollectionName())
+	opts := options.InsertMany().SetOrdered(false)
+	_, err := orderCollection.InsertMany(context.Background(), orders, opts)
+	if bwe, ok := err.(mongo.BulkWriteException); ok && bwe.WriteConcernError == nil {
+		for _, we := range bwe.WriteErrors {
+			failed[we.Index] = we
+		}
+		return failed, nil
+	}
+	return failed, err
+}
+
+// OrdersForLabel returns orders matching query in the order they were created
+func (o *orderRepositoryImpl) OrdersForLabel(db *mongo.Database, query primitive.M, limit int64) (*[]models.Order, error) {
+	orderCollection := db.Collection(models.Order{}.CollectionName())
+	opts := options.Find().SetSort(bson.M{"_id": 1}).SetLimit(limit)
+	cursor, err := orderCollection.Find(context.Background(), query, opts)
+	if err != nil {
+		return nil, err
+	}
+	var orders []models.Order
+	if err = cursor.All(context.Background(), &orders); err != nil {
+		return nil, err
+	}
+	return &orders, nil
+}
+
+func (o *orderRepositoryImpl) CountOrders(db *mongo.Database, query primitive.M) (int64, error) {
+	orderCollection := db.Collection(models.Order{}.CollectionName())
+	return orderCollection.CountDocuments(context.Background(), query)
+}
@@ -0,0 +1,328 @@
+package data
+
+import (
+	"context"
+	"time"
+
+	"github.com/techartificer/swiftex/config"
+	"github.com/techartificer/swiftex/constants"
+	"github.com/techartificer/swiftex/constants/codes"
+	"github.com/techartificer/swiftex/lib/charge"
+	"github.com/techartificer/swiftex/lib/errors"
+	"github.com/techartificer/swiftex/lib/random"
+	"github.com/techartificer/swiftex/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+)
+
+type ReturnRepository interface {
+	Returns(db *mongo.Database, query bson.M, limit int64) (*[]models.Order, error)
+	ReceiveAtHub(db *mongo.Database, ID, adminID primitive.ObjectID) (*models.Order, error)
+	HandOver(db *mongo.Database, ID, adminID primitive.ObjectID, receiverName, receiverPhone string) (*models.Order, error)
+	Confirm(db *mongo.Database, shopID, ID, merchantID primitive.ObjectID) (*models.Order, error)
+}
+
+type returnRepoImpl struct{}
+
+var returnRepo ReturnRepository
+
+func NewReturnRepo() ReturnRepository {
+	if returnRepo == nil {
+		returnRepo = &returnRepoImpl{}
+	}
+	return returnRepo
+}
+
+// statusActor returns who added the order status
+func statusActor(status *models.OrderStatus) *primitive.ObjectID {
+	for _, ID := range []*primitive.ObjectID{status.AdminID, status.MerchantID, status.ShopModeratorID, status.DeleveryBoyID} {
+		if ID != nil {
+			return ID
+		}
+	}
+	return nil
+}
+
+// returnCharge returns the configured charge of returning an order to its shop
+func returnCharge(order *models.Order) float64 {
+	cfg := config.GetReturn()
+	return roundAmount(charge.ReturnCharge(order.Charge, cfg.Charge, cfg.ChargePercent))
+}
+
+// startReturn opens the return of an order which has just moved to Returned, the parcel is routed back
+// to its pickup hub and returnCharge is debited from the shop. ctx should be a session context
+func startReturn(ctx context.Context, db *mongo.Database, order *models.Order, status *models.OrderStatus, returnCharge float64) (*models.Order, error) {
+	orderReturn := &models.OrderReturn{
+		Reason:      status.Text,
+		Status:      constants.Initiated,
+		Hub:         order.PickHub,
+		HubID:       order.PickHubID,
+		Charge:      returnCharge,
+		InitiatedBy: statusActor(status),
+		InitiatedAt: status.Time,
+	}
+	if orderReturn.Charge > 0 {
+		trx := &models.Transaction{}
+		trxCollection := db.Collection(trx.CollectionName())
+		filter := bson.M{"shopId": order.ShopID}
+		if err := trxCollection.FindOne(ctx, filter).Decode(trx); err != nil {
+			if err == mongo.ErrNoDocuments {
+				return nil, errors.NewError(string(codes.TransactionNotFound))
+			}
+			return nil, err
+		}
+		if err := ensureShopAccount(ctx, db, trx); err != nil {
+			return nil, err
+		}
+		update := bson.M{
+			"$inc": bson.M{"balance": -orderReturn.Charge},
+			"$set": bson.M{"updatedAt": status.Time},
+		}
+		after := options.After
+		opt := options.FindOneAndUpdateOptions{ReturnDocument: &after}
+		if err := trxCollection.FindOneAndUpdate(ctx, filter, update, &opt).Decode(trx); err != nil {
+			return nil, err
+		}
+		trxHistory := models.TrxHistory{
+			ID:          primitive.NewObjectID(),
+			PaymentType: models.OUT,
+			Payment:     orderReturn.Charge,
+			TrxID:       trx.ID,
+			OrderID:     &order.ID,
+			ShopID:      order.ShopID,
+			Remarks:     "Return charge of " + order.TrackID,
+			CreatedAt:   status.Time,
+		}
+		if orderReturn.InitiatedBy != nil {
+			trxHistory.CreatedBy = *orderReturn.InitiatedBy
+		}
+		trxHistoryCollection := db.Collection(trxHistory.CollectionName())
+		if _, err := trxHistoryCollection.InsertOne(ctx, trxHistory); err != nil {
+			return nil, err
+		}
+		entry := &models.JournalEntry{
+			ShopID:       &order.ShopID,
+			OrderID:      &order.ID,
+			TrxHistoryID: &trxHistory.ID,
+			Description:  "Parcel returned",
+			CreatedBy:    orderReturn.InitiatedBy,
+			CreatedAt:    status.Time,
+			Lines: []models.JournalLine{
+				debitLine(models.ShopReceivable, &order.ShopID, orderReturn.Charge, "Return charge"),
+				creditLine(models.ReturnFee, nil, orderReturn.Charge, "Return charge"),
+			},
+		}
+		if err := postJournal(ctx, db, entry); err != nil {
+			return nil, err
+		}
+		debited := models.BalanceDebitedPayload{
+			TransactionID: trx.ID,
+			TrxHistoryID:  trxHistory.ID,
+			ShopID:        order.ShopID,
+			OrderID:       &order.ID,
+			Amount:        orderReturn.Charge,
+			Balance:       trx.Balance,
+			Remarks:       trxHistory.Remarks,
+			Time:          status.Time,
+		}
+		if err := publishEvent(ctx, db, models.BalanceDebited, &order.ShopID, &order.ID, debited); err != nil {
+			return nil, err
+		}
+		orderReturn.TrxHistoryID = &trxHistory.ID
+	}
+	orderCollection := db.Collection(models.Order{}.CollectionName())
+	after := options.After
+	opt := options.FindOneAndUpdateOptions{ReturnDocument: &after}
+	updatedOrder := &models.Order{}
+	update := bson.M{"$set": bson.M{"return": orderReturn}}
+	if err := orderCollection.FindOneAndUpdate(ctx, bson.M{"_id": order.ID}, update, &opt).Decode(updatedOrder); err != nil {
+		return nil, err
+	}
+	return updatedOrder, nil
+}
+
+// spawnReturnOrder creates the return order of items a rider brought back from a delivery, the order
+// starts returned and travels back to the pickup hub free of charge as its trip is paid by the delivery.
+// ctx should be a session context
+func spawnReturnOrder(ctx context.Context, db *mongo.Database, ID primitive.ObjectID, order *models.Order, items int, reason string, riderID primitive.ObjectID) (*models.Order, error) {
+	trackID, err := random.GenerateRandomString(constants.TrackIDSize)
+	if err != nil {
+		return nil, err
+	}
+	t := time.Now().UTC()
+	returned := constants.Returned
+	status := models.OrderStatus{
+		ID:            primitive.NewObjectID(),
+		DeleveryBoyID: &riderID,
+		Status:        constants.Returned,
+		Text:          reason,
+		Time:          t,
+	}
+	returnOrder := &models.Order{
+		ID:               ID,
+		ShopID:           order.ShopID,
+		RiderID:          &riderID,
+		ShopModeratorID:  order.ShopModeratorID,
+		MerchantID:       order.MerchantID,
+		RecipientName:    order.RecipientName,
+		RecipientPhone:   order.RecipientPhone,
+		RecipientCity:    order.RecipientCity,
+		RecipientThana:   order.RecipientThana,
+		RecipientArea:    order.RecipientArea,
+		RecipientZip:     order.RecipientZip,
+		RecipientAddress: order.RecipientAddress,
+		RecipientCityID:  order.RecipientCityID,
+		RecipientThanaID: order.RecipientThanaID,
+		RecipientAreaID:  order.RecipientAreaID,
+		PackageCode:      order.PackageCode,
+		PaymentStatus:    constants.PAID,
+		PercelType:       order.PercelType,
+		PickAddress:      order.PickAddress,
+		PickHub:          order.PickHub,
+		PickHubID:        order.PickHubID,
+		DeliveryHubID:    order.DeliveryHubID,
+		Comments:         reason,
+		NumberOfItems:    items,
+		TrackID:          trackID,
+		DeliveryType:     order.DeliveryType,
+		CurrentStatus:    &returned,
+		IsAccepted:       true,
+		IsPicked:         true,
+		ParentOrderID:    &order.ID,
+		Status: []models.OrderStatus{
+			status,
+			{ID: primitive.NewObjectID(), Status: constants.Created, Text: constants.CreatedMsg, Time: t},
+		},
+		CreatedAt: t,
+		UpdatedAt: t,
+	}
+	orderCollection := db.Collection(returnOrder.CollectionName())
+	if _, err := orderCollection.InsertOne(ctx, returnOrder); err != nil {
+		return nil, err
+	}
+	if err := publishOrderStatus(ctx, db, returnOrder, &status); err != nil {
+		return nil, err
+	}
+	return startReturn(ctx, db, returnOrder, &status, 0)
+}
+
+// Returns returns returned orders matching query, newest first
+func (r *returnRepoImpl) Returns(db *mongo.Database, query bson.M, limit int64) (*[]models.Order, error) {
+	orderCollection := db.Collection(models.Order{}.CollectionName())
+	query["return"] = bson.M{"$exists": true}
+	opts := options.Find().SetSort(bson.M{"_id": -1}).SetLimit(limit)
+	cursor, err := orderCollection.Find(context.Background(), query, opts)
+	if err != nil {
+		return nil, err
+	}
+	var orders []models.Order
+	if err = cursor.All(context.Background(), &orders); err != nil {
+		return nil, err
+	}
+	return &orders, nil
+}
+
+// moveReturn moves return of a returned order from one status to another and logs it on the order,
+// a return which exists but is not in from status can not be moved
+func moveReturn(db *mongo.Database, query bson.M, from string, set bson.M, status *models.OrderStatus) (*models.Order, error) {
+	wc := writeconcern.New(writeconcern.WMajority())
+	rc := readconcern.Snapshot()
+	txnOpts := options.Transaction().SetWriteConcern(wc).SetReadConcern(rc)
+	session, err := db.Client().StartSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.EndSession(context.Background())
+
+	callBack := func(sessionCtx mongo.SessionContext) (interface{}, error) {
+		orderCollection := db.Collection(models.Order{}.CollectionName())
+		filter := bson.M{"currentStatus": constants.Returned, "return.status": from}
+		for k, v := range query {
+			filter[k] = v
+		}
+		set["updatedAt"] = status.Time
+		push := bson.M{"status": bson.M{"$each": []models.OrderStatus{*status}, "$position": 0}}
+		after := options.After
+		opt := options.FindOneAndUpdateOptions{ReturnDocument: &after}
+		order := &models.Order{}
+		err := orderCollection.FindOneAndUpdate(sessionCtx, filter, bson.M{"$set": set, "$push": push}, &opt).Decode(order)
+		if err == mongo.ErrNoDocuments {
+			exists := bson.M{"return": bson.M{"$exists": true}}
+			for k, v := range query {
+				exists[k] = v
+			}
+			count, err := orderCollection.CountDocuments(sessionCtx, exists)
+			if err != nil {
+				return nil, err
+			}
+			if count > 0 {
+				return nil, errors.NewError(string(codes.InvalidReturnTransition))
+			}
+			return nil, mongo.ErrNoDocuments
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := publishOrderStatus(sessionCtx, db, order, status); err != nil {
+			return nil, err
+		}
+		return order, nil
+	}
+	result, err := session.WithTransaction(context.Background(), callBack, txnOpts)
+	if err != nil {
+		return nil, err
+	}
+	return result.(*models.Order), nil
+}
+
+// ReceiveAtHub records the returned parcel has reached its origin hub
+func (r *returnRepoImpl) ReceiveAtHub(db *mongo.Database, ID, adminID primitive.ObjectID) (*models.Order, error) {
+	t := time.Now().UTC()
+	status := &models.OrderStatus{
+		ID:      primitive.NewObjectID(),
+		AdminID: &adminID,
+		Status:  constants.Returned,
+		Text:    constants.ReturnHubMsg,
+		Time:    t,
+	}
+	set := bson.M{"return.status": constants.AtHub, "return.receivedBy": adminID, "return.receivedAt": t}
+	return moveReturn(db, bson.M{"_id": ID}, constants.Initiated, set, status)
+}
+
+// HandOver records the parcel has been handed over to the receiver of the shop at origin hub
+func (r *returnRepoImpl) HandOver(db *mongo.Database, ID, adminID primitive.ObjectID, receiverName, receiverPhone string) (*models.Order, error) {
+	t := time.Now().UTC()
+	status := &models.OrderStatus{
+		ID:      primitive.NewObjectID(),
+		AdminID: &adminID,
+		Status:  constants.Returned,
+		Text:    constants.HandOverMsg,
+		Time:    t,
+	}
+	set := bson.M{
+		"return.status":        constants.HandedOver,
+		"return.receiverName":  receiverName,
+		"return.receiverPhone": receiverPhone,
+		"return.handedOverBy":  adminID,
+		"return.handedOverAt":  t,
+	}
+	return moveReturn(db, bson.M{"_id": ID}, constants.AtHub, set, status)
+}
+
+// Confirm lets the shop confirm it has received the handed over parcel
+func (r *returnRepoImpl) Confirm(db *mongo.Database, shopID, ID, merchantID primitive.ObjectID) (*models.Order, error) {
+	t := time.Now().UTC()
+	status := &models.OrderStatus{
+		ID:         primitive.NewObjectID(),
+		MerchantID: &merchantID,
+		Status:     constants.Returned,
+		Text:       constants.ReturnConfMsg,
+		Time:       t,
+	}
+	set := bson.M{"return.status": constants.Confirmed, "return.confirmedBy": merchantID, "return.confirmedAt": t}
+	return moveReturn(db, bson.M{"_id": ID, "shopId": shopID}, constants.HandedOver, set, status)
+}
@@ -0,0 +1,395 @@
+package data
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/techartificer/swiftex/constants"
+	"github.com/techartificer/swiftex/constants/codes"
+	"github.com/techartificer/swiftex/lib/errors"
+	"github.com/techartificer/swiftex/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+)
+
+type PayoutRepository interface {
+	SaveSchedule(db *mongo.Database, schedule *models.PayoutSchedule) (*models.PayoutSchedule, error)
+	ScheduleByShop(db *mongo.Database, shopID primitive.ObjectID) (*models.PayoutSchedule, error)
+	CreateBatch(db *mongo.Database, now time.Time) (*models.PayoutBatch, error)
+	Batches(db *mongo.Database, query bson.M, limit int64) (*[]models.PayoutBatch, error)
+	BatchByID(db *mongo.Database, ID primitive.ObjectID) (*models.PayoutBatch, error)
+	ApproveBatch(db *mongo.Database, ID, adminID primitive.ObjectID) (*models.PayoutBatch, error)
+	RejectBatch(db *mongo.Database, ID, adminID primitive.ObjectID, reason string) (*models.PayoutBatch, error)
+}
+
+type payoutRepoImpl struct{}
+
+var payoutRepo PayoutRepository
+
+func NewPayoutRepo() PayoutRepository {
+	if payoutRepo == nil {
+		payoutRepo = &payoutRepoImpl{}
+	}
+	return payoutRepo
+}
+
+// nextPayoutRun returns start of the next UTC day after t, weekly schedules run on their weekday
+func nextPayoutRun(schedule *models.PayoutSchedule, t time.Time) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	if schedule.Frequency != constants.Weekly {
+		return day.AddDate(0, 0, 1)
+	}
+	days := (int(schedule.Weekday) - int(day.Weekday()) + 7) % 7
+	if days == 0 {
+		days = 7
+	}
+	return day.AddDate(0, 0, days)
+}
+
+// SaveSchedule creates or replaces payout schedule of the shop, next run is counted from now
+func (p *payoutRepoImpl) SaveSchedule(db *mongo.Database, schedule *models.PayoutSchedule) (*models.PayoutSchedule, error) {
+	payoutCollection := db.Collection(schedule.CollectionName())
+	now := time.Now().UTC()
+	update := bson.M{
+		"$set": bson.M{
+			"frequency":     schedule.Frequency,
+			"weekday":       schedule.Weekday,
+			"minimumAmount": schedule.MinimumAmount,
+			"status":        schedule.Status,
+			"nextRunAt":     nextPayoutRun(schedule, now),
+			"updatedBy":     schedule.UpdatedBy,
+			"updatedAt":     now,
+		},
+		"$unset":       bson.M{"account": ""},
+		"$setOnInsert": bson.M{"createdAt": now},
+	}
+	after := options.After
+	upsert := true
+	opts := options.FindOneAndUpdateOptions{ReturnDocument: &after, Upsert: &upsert}
+	saved := &models.PayoutSchedule{}
+	err := payoutCollection.FindOneAndUpdate(context.Background(), bson.M{"shopId": schedule.ShopID}, update, &opts).Decode(saved)
+	return saved, err
+}
+
+// ScheduleByShop returns payout schedule of the shop
+func (p *payoutRepoImpl) ScheduleByShop(db *mongo.Database, shopID primitive.ObjectID) (*models.PayoutSchedule, error) {
+	schedule := &models.PayoutSchedule{}
+	payoutCollection := db.Collection(schedule.CollectionName())
+	if err := payoutCollection.FindOne(context.Background(), bson.M{"shopId": shopID}).Decode(schedule); err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// CreateBatch creates a pending batch of due schedules, a shop is paid its whole balance if it
+// reaches the minimum amount and the shop has no payout waiting in another batch. Payouts go to the
+// verified active payout method of the shop, shops without one are skipped. Due schedules
+// move to their next run even if nothing is paid, ErrNoDocuments is returned if the batch is empty
+func (p *payoutRepoImpl) CreateBatch(db *mongo.Database, now time.Time) (*models.PayoutBatch, error) {
+	wc := writeconcern.New(writeconcern.WMajority())
+	rc := readconcern.Snapshot()
+	txnOpts := options.Transaction().SetWriteConcern(wc).SetReadConcern(rc)
+	session, err := db.Client().StartSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.EndSession(context.Background())
+
+	callBack := func(sessionCtx mongo.SessionContext) (interface{}, error) {
+		scheduleCollection := db.Collection(models.PayoutSchedule{}.CollectionName())
+		query := bson.M{"status": constants.Active, "nextRunAt": bson.M{"$lte": now}}
+		cursor, err := scheduleCollection.Find(sessionCtx, query)
+		if err != nil {
+			return nil, err
+		}
+		schedules := []models.PayoutSchedule{}
+		if err := cursor.All(sessionCtx, &schedules); err != nil {
+			return nil, err
+		}
+		if len(schedules) == 0 {
+			return nil, nil
+		}
+
+		batchCollection := db.Collection(models.PayoutBatch{}.CollectionName())
+		shopIDs := []primitive.ObjectID{}
+		for _, schedule := range schedules {
+			shopIDs = append(shopIDs, schedule.ShopID)
+		}
+		waiting := make(map[primitive.ObjectID]bool)
+		cursor, err = batchCollection.Find(sessionCtx, bson.M{"status": constants.Pending, "items.shopId": bson.M{"$in": shopIDs}})
+		if err != nil {
+			return nil, err
+		}
+		pending := []models.PayoutBatch{}
+		if err := cursor.All(sessionCtx, &pending); err != nil {
+			return nil, err
+		}
+		for _, batch := range pending {
+			for _, item := range batch.Items {
+				waiting[item.ShopID] = true
+			}
+		}
+
+		trxCollection := db.Collection(models.Transaction{}.CollectionName())
+		shopCollection := db.Collection(models.Shop{}.CollectionName())
+		batch := &models.PayoutBatch{
+			ID:        primitive.NewObjectID(),
+			Items:     []models.PayoutItem{},
+			Status:    constants.Pending,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		for i := range schedules {
+			schedule := &schedules[i]
+			update := bson.M{"$set": bson.M{"nextRunAt": nextPayoutRun(schedule, now), "lastRunAt": now}}
+			if _, err := scheduleCollection.UpdateOne(sessionCtx, bson.M{"_id": schedule.ID}, update); err != nil {
+				return nil, err
+			}
+			if waiting[schedule.ShopID] {
+				continue
+			}
+			trx := &models.Transaction{}
+			if err := trxCollection.FindOne(sessionCtx, bson.M{"shopId": schedule.ShopID}).Decode(trx); err != nil {
+				if err == mongo.ErrNoDocuments {
+					continue
+				}
+				return nil, err
+			}
+			amount := int64(math.Floor(trx.Balance))
+			if amount < 1 || float64(amount) < schedule.MinimumAmount {
+				continue
+			}
+			method, err := activePayoutMethod(sessionCtx, db, schedule.ShopID)
+			if err != nil {
+				return nil, err
+			}
+			if method == nil {
+				continue
+			}
+			shop := &models.Shop{}
+			opts := options.FindOne().SetProjection(bson.M{"name": 1})
+			if err := shopCollection.FindOne(sessionCtx, bson.M{"_id": schedule.ShopID}, opts).Decode(shop); err != nil {
+				return nil, err
+			}
+			batch.Items = append(batch.Items, models.PayoutItem{
+				ShopID:        schedule.ShopID,
+				ShopName:      shop.Name,
+				TransactionID: trx.ID,
+				Amount:        amount,
+				Account:       method.Account,
+				Status:        constants.Pending,
+			})
+			batch.TotalAmount += amount
+		}
+		batch.Count = len(batch.Items)
+		if batch.Count == 0 {
+			return nil, nil
+		}
+		if _, err := batchCollection.InsertOne(sessionCtx, batch); err != nil {
+			return nil, err
+		}
+		return batch, nil
+	}
+	result, err := session.WithTransaction(context.Background(), callBack, txnOpts)
+	if err != nil {
+		return nil, err
+	}
+	batch, ok := result.(*models.PayoutBatch)
+	if !ok || batch == nil {
+		return nil, mongo.ErrNoDocuments
+	}
+	return batch, nil
+}
+
+// Batches returns payout batches matching query, newest first
+func (p *payoutRepoImpl) Batches(db *mongo.Database, query bson.M, limit int64) (*[]models.PayoutBatch, error) {
+	batchCollection := db.Collection(models.PayoutBatch{}.CollectionName())
+	opts := options.Find().SetSort(bson.M{"_id": -1}).SetLimit(limit)
+	cursor, err := batchCollection.Find(context.Background(), query, opts)
+	if err != nil {
+		return nil, err
+	}
+	var batches []models.PayoutBatch
+	if err = cursor.All(context.Background(), &batches); err != nil {
+		return nil, err
+	}
+	return &batches, nil
+}
+
+// BatchByID returns a payout batch
+func (p *payoutRepoImpl) BatchByID(db *mongo.Database, ID primitive.ObjectID) (*models.PayoutBatch, error) {
+	batch := &models.PayoutBatch{}
+	batchCollection := db.Collection(batch.CollectionName())
+	if err := batchCollection.FindOne(context.Background(), bson.M{"_id": ID}).Decode(batch); err != nil {
+		return nil, err
+	}
+	return batch, nil
+}
+
+// pendingBatch returns the batch if it is still pending, InvalidPayoutTransition if it is reviewed already
+func pendingBatch(ctx context.Context, db *mongo.Database, ID primitive.ObjectID) (*models.PayoutBatch, error) {
+	batch := &models.PayoutBatch{}
+	batchCollection := db.Collection(batch.CollectionName())
+	if err := batchCollection.FindOne(ctx, bson.M{"_id": ID}).Decode(batch); err != nil {
+		return nil, err
+	}
+	if batch.Status != constants.Pending {
+		return nil, errors.NewError(string(codes.InvalidPayoutTransition))
+	}
+	return batch, nil
+}
+
+// ApproveBatch pays out items of a pending batch, every paid item is debited from the shop balance
+// and recorded in trx history and the journal. Items whose shop balance dropped below the amount or
+// whose account is no longer the active payout method of the shop are marked failed and left for the next run
+func (p *payoutRepoImpl) ApproveBatch(db *mongo.Database, ID, adminID primitive.ObjectID) (*models.PayoutBatch, error) {
+	wc := writeconcern.New(writeconcern.WMajority())
+	rc := readconcern.Snapshot()
+	txnOpts := options.Transaction().SetWriteConcern(wc).SetReadConcern(rc)
+	session, err := db.Client().StartSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.EndSession(context.Background())
+
+	callBack := func(sessionCtx mongo.SessionContext) (interface{}, error) {
+		batch, err := pendingBatch(sessionCtx, db, ID)
+		if err != nil {
+			return nil, err
+		}
+		now := time.Now().UTC()
+		trxCollection := db.Collection(models.Transaction{}.CollectionName())
+		trxHistoryCollection := db.Collection(models.TrxHistory{}.CollectionName())
+		after := options.After
+		opt := options.FindOneAndUpdateOptions{ReturnDocument: &after}
+		var paid int64
+		for i := range batch.Items {
+			item := &batch.Items[i]
+			trx := &models.Transaction{}
+			if err := trxCollection.FindOne(sessionCtx, bson.M{"_id": item.TransactionID}).Decode(trx); err != nil {
+				return nil, err
+			}
+			if trx.Balance < float64(item.Amount) {
+				item.Status = constants.Failed
+				item.Remarks = "Insufficient balance"
+				continue
+			}
+			method, err := activePayoutMethod(sessionCtx, db, item.ShopID)
+			if err != nil {
+				return nil, err
+			}
+			if method == nil || method.Account != item.Account {
+				item.Status = constants.Failed
+				item.Remarks = "Payout method changed"
+				continue
+			}
+			if err := ensureShopAccount(sessionCtx, db, trx); err != nil {
+				return nil, err
+			}
+			update := bson.M{
+				"$inc": bson.M{"balance": -item.Amount},
+				"$set": bson.M{"updatedAt": now},
+			}
+			if err := trxCollection.FindOneAndUpdate(sessionCtx, bson.M{"_id": trx.ID}, update, &opt).Decode(trx); err != nil {
+				return nil, err
+			}
+			trxHistory := models.TrxHistory{
+				ID:          primitive.NewObjectID(),
+				PaymentType: models.OUT,
+				Payment:     float64(item.Amount),
+				TrxID:       trx.ID,
+				ShopID:      trx.ShopID,
+				Remarks:     "Payout batch " + batch.ID.Hex(),
+				CreatedBy:   adminID,
+				CreatedAt:   now,
+			}
+			if _, err := trxHistoryCollection.InsertOne(sessionCtx, trxHistory); err != nil {
+				return nil, err
+			}
+			entry := &models.JournalEntry{
+				ShopID:       &trx.ShopID,
+				TrxHistoryID: &trxHistory.ID,
+				Description:  "Scheduled payout",
+				CreatedBy:    &adminID,
+				CreatedAt:    now,
+				Lines: []models.JournalLine{
+					debitLine(models.ShopReceivable, &trx.ShopID, float64(item.Amount), "Paid to shop"),
+					creditLine(models.PlatformCash, nil, float64(item.Amount), "Paid to shop"),
+				},
+			}
+			if err := postJournal(sessionCtx, db, entry); err != nil {
+				return nil, err
+			}
+			cashOut := models.CashOutCompletedPayload{
+				TransactionID: trx.ID,
+				TrxHistoryID:  trxHistory.ID,
+				ShopID:        trx.ShopID,
+				Amount:        item.Amount,
+				Balance:       trx.Balance,
+				Time:          now,
+			}
+			if err := publishEvent(sessionCtx, db, models.CashOutCompleted, &trx.ShopID, nil, cashOut); err != nil {
+				return nil, err
+			}
+			item.Status = constants.Paid
+			item.TrxHistoryID = &trxHistory.ID
+			paid += item.Amount
+		}
+		batchCollection := db.Collection(batch.CollectionName())
+		update := bson.M{
+			"$set": bson.M{
+				"items":      batch.Items,
+				"paidAmount": paid,
+				"status":     constants.Apporved,
+				"reviewedBy": adminID,
+				"reviewedAt": now,
+				"updatedAt":  now,
+			},
+		}
+		if err := batchCollection.FindOneAndUpdate(sessionCtx, bson.M{"_id": batch.ID}, update, &opt).Decode(batch); err != nil {
+			return nil, err
+		}
+		return batch, nil
+	}
+	result, err := session.WithTransaction(context.Background(), callBack, txnOpts)
+	if err != nil {
+		return nil, err
+	}
+	return result.(*models.PayoutBatch), nil
+}
+
+// RejectBatch rejects a pending batch, balances are left untouched and shops are paid on their next run
+func (p *payoutRepoImpl) RejectBatch(db *mongo.Database, ID, adminID primitive.ObjectID, reason string) (*models.PayoutBatch, error) {
+	ctx := context.Background()
+	if _, err := pendingBatch(ctx, db, ID); err != nil {
+		return nil, err
+	}
+	batch := &models.PayoutBatch{}
+	batchCollection := db.Collection(batch.CollectionName())
+	now := time.Now().UTC()
+	update := bson.M{
+		"$set": bson.M{
+			"status":       constants.Rejected,
+			"rejectReason": reason,
+			"reviewedBy":   adminID,
+			"reviewedAt":   now,
+			"updatedAt":    now,
+		},
+	}
+	after := options.After
+	opt := options.FindOneAndUpdateOptions{ReturnDocument: &after}
+	query := bson.M{"_id": ID, "status": constants.Pending}
+	if err := batchCollection.FindOneAndUpdate(ctx, query, update, &opt).Decode(batch); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.NewError(string(codes.InvalidPayoutTransition))
+		}
+		return nil, err
+	}
+	return batch, nil
+}
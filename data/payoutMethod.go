@@ -0,0 +1,171 @@
+package data
+
+import (
+	"context"
+	"time"
+
+	"github.com/techartificer/swiftex/constants"
+	"github.com/techartificer/swiftex/constants/codes"
+	"github.com/techartificer/swiftex/lib/errors"
+	"github.com/techartificer/swiftex/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+)
+
+type PayoutMethodRepository interface {
+	Create(db *mongo.Database, method *models.PayoutMethod) (*models.PayoutMethod, error)
+	Methods(db *mongo.Database, shopID primitive.ObjectID) (*[]models.PayoutMethod, error)
+	MethodByID(db *mongo.Database, shopID, ID primitive.ObjectID) (*models.PayoutMethod, error)
+	Update(db *mongo.Database, shopID, ID primitive.ObjectID, account models.PayoutAccount, phone string) (*models.PayoutMethod, error)
+	Delete(db *mongo.Database, shopID, ID primitive.ObjectID) (*models.PayoutMethod, error)
+	Activate(db *mongo.Database, verified *models.PayoutMethod, verifiedBy primitive.ObjectID) (*models.PayoutMethod, error)
+}
+
+type payoutMethodRepoImpl struct{}
+
+var payoutMethodRepo PayoutMethodRepository
+
+func NewPayoutMethodRepo() PayoutMethodRepository {
+	if payoutMethodRepo == nil {
+		payoutMethodRepo = &payoutMethodRepoImpl{}
+	}
+	return payoutMethodRepo
+}
+
+// activePayoutMethod returns the verified method a shop is paid to, nil if the shop has none
+func activePayoutMethod(ctx context.Context, db *mongo.Database, shopID primitive.ObjectID) (*models.PayoutMethod, error) {
+	method := &models.PayoutMethod{}
+	methodCollection := db.Collection(method.CollectionName())
+	query := bson.M{"shopId": shopID, "status": constants.Active}
+	if err := methodCollection.FindOne(ctx, query).Decode(method); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return method, nil
+}
+
+// Create adds a pending method to the shop, it is not used for payouts until verified
+func (p *payoutMethodRepoImpl) Create(db *mongo.Database, method *models.PayoutMethod) (*models.PayoutMethod, error) {
+	methodCollection := db.Collection(method.CollectionName())
+	if _, err := methodCollection.InsertOne(context.Background(), method); err != nil {
+		return nil, err
+	}
+	return method, nil
+}
+
+// Methods returns payout methods of the shop, newest first
+func (p *payoutMethodRepoImpl) Methods(db *mongo.Database, shopID primitive.ObjectID) (*[]models.PayoutMethod, error) {
+	methodCollection := db.Collection(models.PayoutMethod{}.CollectionName())
+	opts := options.Find().SetSort(bson.M{"_id": -1})
+	cursor, err := methodCollection.Find(context.Background(), bson.M{"shopId": shopID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	var methods []models.PayoutMethod
+	if err = cursor.All(context.Background(), &methods); err != nil {
+		return nil, err
+	}
+	return &methods, nil
+}
+
+func (p *payoutMethodRepoImpl) MethodByID(db *mongo.Database, shopID, ID primitive.ObjectID) (*models.PayoutMethod, error) {
+	method := &models.PayoutMethod{}
+	methodCollection := db.Collection(method.CollectionName())
+	if err := methodCollection.FindOne(context.Background(), bson.M{"_id": ID, "shopId": shopID}).Decode(method); err != nil {
+		return nil, err
+	}
+	return method, nil
+}
+
+// Update replaces account details of a method, the method goes back to Pending and
+// has to be verified again before it is used
+func (p *payoutMethodRepoImpl) Update(db *mongo.Database, shopID, ID primitive.ObjectID, account models.PayoutAccount, phone string) (*models.PayoutMethod, error) {
+	method := &models.PayoutMethod{}
+	methodCollection := db.Collection(method.CollectionName())
+	update := bson.M{
+		"$set": bson.M{
+			"account":   account,
+			"phone":     phone,
+			"status":    constants.Pending,
+			"updatedAt": time.Now().UTC(),
+		},
+		"$unset": bson.M{"verifiedAt": "", "verifiedBy": ""},
+	}
+	after := options.After
+	opt := options.FindOneAndUpdateOptions{ReturnDocument: &after}
+	if err := methodCollection.FindOneAndUpdate(context.Background(), bson.M{"_id": ID, "shopId": shopID}, update, &opt).Decode(method); err != nil {
+		return nil, err
+	}
+	return method, nil
+}
+
+// Delete removes a method of the shop, shop is left without an active method if it was active
+func (p *payoutMethodRepoImpl) Delete(db *mongo.Database, shopID, ID primitive.ObjectID) (*models.PayoutMethod, error) {
+	method := &models.PayoutMethod{}
+	methodCollection := db.Collection(method.CollectionName())
+	if err := methodCollection.FindOneAndDelete(context.Background(), bson.M{"_id": ID, "shopId": shopID}).Decode(method); err != nil {
+		return nil, err
+	}
+	return method, nil
+}
+
+// Activate marks a verified method active, the previously active method of the shop is deactivated.
+// PayoutMethodChanged is returned if the method was changed or removed after it was verified
+func (p *payoutMethodRepoImpl) Activate(db *mongo.Database, verified *models.PayoutMethod, verifiedBy primitive.ObjectID) (*models.PayoutMethod, error) {
+	wc := writeconcern.New(writeconcern.WMajority())
+	rc := readconcern.Snapshot()
+	txnOpts := options.Transaction().SetWriteConcern(wc).SetReadConcern(rc)
+	session, err := db.Client().StartSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.EndSession(context.Background())
+
+	callBack := func(sessionCtx mongo.SessionContext) (interface{}, error) {
+		now := time.Now().UTC()
+		method := &models.PayoutMethod{}
+		methodCollection := db.Collection(method.CollectionName())
+		query := bson.M{"shopId": verified.ShopID, "status": constants.Active, "_id": bson.M{"$ne": verified.ID}}
+		deactivate := bson.M{"$set": bson.M{"status": constants.Deactive, "updatedAt": now}}
+		if _, err := methodCollection.UpdateMany(sessionCtx, query, deactivate); err != nil {
+			return nil, err
+		}
+		update := bson.M{
+			"$set": bson.M{
+				"status":     constants.Active,
+				"verifiedAt": now,
+				"verifiedBy": verifiedBy,
+				"updatedAt":  now,
+			},
+		}
+		after := options.After
+		opt := options.FindOneAndUpdateOptions{ReturnDocument: &after}
+		filter := bson.M{
+			"_id":       verified.ID,
+			"shopId":    verified.ShopID,
+			"phone":     verified.Phone,
+			"updatedAt": verified.UpdatedAt,
+		}
+		if verified.Phone == "" {
+			filter["phone"] = bson.M{"$in": bson.A{nil, ""}}
+		}
+		if err := methodCollection.FindOneAndUpdate(sessionCtx, filter, update, &opt).Decode(method); err != nil {
+			if err == mongo.ErrNoDocuments {
+				return nil, errors.NewError(string(codes.PayoutMethodChanged))
+			}
+			return nil, err
+		}
+		return method, nil
+	}
+	result, err := session.WithTransaction(context.Background(), callBack, txnOpts)
+	if err != nil {
+		return nil, err
+	}
+	return result.(*models.PayoutMethod), nil
+}
@@ -0,0 +1,34 @@
+package data
+
+import (
+	"testing"
+	"time"
+
+	"github.com/techartificer/swiftex/constants"
+	"github.com/techartificer/swiftex/models"
+)
+
+func TestNextPayoutRun(t *testing.T) {
+	// 2026-10-14 is a Wednesday
+	wednesday := time.Date(2026, 10, 14, 15, 30, 0, 0, time.UTC)
+	dhaka := time.FixedZone("Asia/Dhaka", 6*60*60)
+	tests := []struct {
+		name     string
+		schedule models.PayoutSchedule
+		at       time.Time
+		want     time.Time
+	}{
+		{"daily", models.PayoutSchedule{Frequency: constants.Daily}, wednesday, time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC)},
+		{"daily at midnight", models.PayoutSchedule{Frequency: constants.Daily}, time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC), time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC)},
+		{"daily across month", models.PayoutSchedule{Frequency: constants.Daily}, time.Date(2026, 10, 31, 23, 0, 0, 0, time.UTC), time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)},
+		{"daily in another zone", models.PayoutSchedule{Frequency: constants.Daily}, time.Date(2026, 10, 15, 2, 0, 0, 0, dhaka), time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC)},
+		{"weekly later this week", models.PayoutSchedule{Frequency: constants.Weekly, Weekday: time.Friday}, wednesday, time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)},
+		{"weekly earlier in week", models.PayoutSchedule{Frequency: constants.Weekly, Weekday: time.Sunday}, wednesday, time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)},
+		{"weekly same day", models.PayoutSchedule{Frequency: constants.Weekly, Weekday: time.Wednesday}, wednesday, time.Date(2026, 10, 21, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		if got := nextPayoutRun(&tt.schedule, tt.at); !got.Equal(tt.want) {
+			t.Errorf("%s: nextPayoutRun() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package data
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/techartificer/swiftex/constants"
+	"github.com/techartificer/swiftex/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// permissionCacheTTL bounds how long a role mapping change takes to reach other instances
+const permissionCacheTTL = time.Minute
+
+type PermissionRepository interface {
+	RolePermissions(db *mongo.Database, role constants.AdminRole) (*models.RolePermission, error)
+	AllRolePermissions(db *mongo.Database) (*[]models.RolePermission, error)
+	SetRolePermissions(db *mongo.Database, rolePermission *models.RolePermission) (*models.RolePermission, error)
+}
+
+type cachedRolePermission struct {
+	rolePermission *models.RolePermission
+	expiresAt      time.Time
+}
+
+type permissionRepoImpl struct {
+	mu    sync.RWMutex
+	cache map[constants.AdminRole]cachedRolePermission
+}
+
+var permissionRepo PermissionRepository
+
+func NewPermissionRepo() PermissionRepository {
+	if permissionRepo == nil {
+		permissionRepo = &permissionRepoImpl{cache: make(map[constants.AdminRole]cachedRolePermission)}
+	}
+	return permissionRepo
+}
+
+func defaultRolePermission(role constants.AdminRole) *models.RolePermission {
+	permissions := constants.DefaultRolePermissions[role]
+	if permissions == nil {
+		permissions = []constants.Permission{}
+	}
+	return &models.RolePermission{Role: role, Permissions: permissions, IsDefault: true}
+}
+
+// RolePermissions returns permissions of a role, default permissions are returned
+// if the role is not configured, super admin always has all permissions
+func (p *permissionRepoImpl) RolePermissions(db *mongo.Database, role constants.AdminRole) (*models.RolePermission, error) {
+	if role == constants.SuperAdmin {
+		return defaultRolePermission(role), nil
+	}
+	p.mu.RLock()
+	cached, ok := p.cache[role]
+	p.mu.RUnlock()
+	if ok && time.Now().Before(cached.expiresAt) {
+		return cached.rolePermission, nil
+	}
+	rolePermission := &models.RolePermission{}
+	rolePermissionCollection := db.Collection(rolePermission.CollectionName())
+	err := rolePermissionCollection.FindOne(context.Background(), bson.M{"role": role}).Decode(rolePermission)
+	if err == mongo.ErrNoDocuments {
+		rolePermission, err = defaultRolePermission(role), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	p.cache[role] = cachedRolePermission{rolePermission: rolePermission, expiresAt: time.Now().Add(permissionCacheTTL)}
+	p.mu.Unlock()
+	return rolePermission, nil
+}
+
+func (p *permissionRepoImpl) AllRolePermissions(db *mongo.Database) (*[]models.RolePermission, error) {
+	var rolePermissions []models.RolePermission
+	for _, role := range constants.Roles {
+		rolePermission, err := p.RolePermissions(db, role)
+		if err != nil {
+			return nil, err
+		}
+		rolePermissions = append(rolePermissions, *rolePermission)
+	}
+	return &rolePermissions, nil
+}
+
+func (p *permissionRepoImpl) SetRolePermissions(db *mongo.Database, rolePermission *models.RolePermission) (*models.RolePermission, error) {
+	rolePermissionCollection := db.Collection(rolePermission.CollectionName())
+	after := options.After
+	upsert := true
+	opt := options.FindOneAndUpdateOptions{
+		ReturnDocument: &after,
+		Upsert:         &upsert,
+	}
+	update := bson.M{"$set": bson.M{
+		"permissions": rolePermission.Permissions,
+		"updatedBy":   rolePermission.UpdatedBy,
+		"updatedAt":   time.Now().UTC(),
+	}}
+	updated := &models.RolePermission{}
+	filter := bson.M{"role": rolePermission.Role}
+	if err := rolePermissionCollection.FindOneAndUpdate(context.Background(), filter, update, &opt).Decode(updated); err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	delete(p.cache, rolePermission.Role)
+	p.mu.Unlock()
+	return updated, nil
+}
@@ -0,0 +1,267 @@
+package data
+
+import (
+	"context"
+	"time"
+
+	"github.com/techartificer/swiftex/constants"
+	"github.com/techartificer/swiftex/constants/codes"
+	"github.com/techartificer/swiftex/lib/errors"
+	"github.com/techartificer/swiftex/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+)
+
+type PickupRepository interface {
+	Create(db *mongo.Database, pickup *models.PickupRequest) (*models.PickupRequest, error)
+	PickupByID(db *mongo.Database, ID primitive.ObjectID) (*models.PickupRequest, error)
+	Pickups(db *mongo.Database, query bson.M, limit int64) (*[]models.PickupRequest, error)
+	Cancel(db *mongo.Database, shopID, ID primitive.ObjectID) (*models.PickupRequest, error)
+	Assign(db *mongo.Database, ID, riderID, assignedBy primitive.ObjectID) (*models.PickupRequest, error)
+	ConfirmPicked(db *mongo.Database, ID, riderID primitive.ObjectID, orderIDs []primitive.ObjectID, complete bool) (*models.PickupRequest, error)
+}
+
+type pickupRepoImpl struct{}
+
+var pickupRepo PickupRepository
+
+func NewPickupRepo() PickupRepository {
+	if pickupRepo == nil {
+		pickupRepo = &pickupRepoImpl{}
+	}
+	return pickupRepo
+}
+
+// livePickup matches pickup requests which are not picked or cancelled yet
+var livePickup = bson.M{"$in": []string{constants.Pending, constants.Assigned}}
+
+// Create links orders of the shop to a pickup request, orders must be waiting for
+// pickup and can not be linked to another live request
+func (p *pickupRepoImpl) Create(db *mongo.Database, pickup *models.PickupRequest) (*models.PickupRequest, error) {
+	wc := writeconcern.New(writeconcern.WMajority())
+	rc := readconcern.Snapshot()
+	txnOpts := options.Transaction().SetWriteConcern(wc).SetReadConcern(rc)
+	session, err := db.Client().StartSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.EndSession(context.Background())
+
+	callBack := func(sessionCtx mongo.SessionContext) (interface{}, error) {
+		if len(pickup.Items) == 0 {
+			pickup.Items = []models.PickupItem{}
+		}
+		orderIDs := []primitive.ObjectID{}
+		for _, item := range pickup.Items {
+			orderIDs = append(orderIDs, item.OrderID)
+		}
+		if len(orderIDs) > 0 {
+			orderCollection := db.Collection(models.Order{}.CollectionName())
+			query := bson.M{
+				"_id":         bson.M{"$in": orderIDs},
+				"shopId":      pickup.ShopID,
+				"isPicked":    bson.M{"$ne": true},
+				"isCancelled": bson.M{"$ne": true},
+				"deliveredAt": bson.M{"$exists": false},
+			}
+			opts := options.Find().SetProjection(bson.M{"trackId": 1})
+			cursor, err := orderCollection.Find(sessionCtx, query, opts)
+			if err != nil {
+				return nil, err
+			}
+			var orders []models.Order
+			if err := cursor.All(sessionCtx, &orders); err != nil {
+				return nil, err
+			}
+			if len(orders) != len(orderIDs) {
+				return nil, errors.NewError(string(codes.InvalidPickupData))
+			}
+			trackIDs := make(map[primitive.ObjectID]string)
+			for _, order := range orders {
+				trackIDs[order.ID] = order.TrackID
+			}
+			for i := range pickup.Items {
+				pickup.Items[i].TrackID = trackIDs[pickup.Items[i].OrderID]
+			}
+			pickupCollection := db.Collection(pickup.CollectionName())
+			linked, err := pickupCollection.CountDocuments(sessionCtx, bson.M{"items.orderId": bson.M{"$in": orderIDs}, "status": livePickup})
+			if err != nil {
+				return nil, err
+			}
+			if linked > 0 {
+				return nil, errors.NewError(string(codes.OrderAlreadyInPickup))
+			}
+		}
+		if _, err := db.Collection(pickup.CollectionName()).InsertOne(sessionCtx, pickup); err != nil {
+			return nil, err
+		}
+		return pickup, nil
+	}
+	result, err := session.WithTransaction(context.Background(), callBack, txnOpts)
+	if err != nil {
+		return nil, err
+	}
+	return result.(*models.PickupRequest), nil
+}
+
+func (p *pickupRepoImpl) PickupByID(db *mongo.Database, ID primitive.ObjectID) (*models.PickupRequest, error) {
+	pickup := &models.PickupRequest{}
+	pickupCollection := db.Collection(pickup.CollectionName())
+	if err := pickupCollection.FindOne(context.Background(), bson.M{"_id": ID}).Decode(pickup); err != nil {
+		return nil, err
+	}
+	return pickup, nil
+}
+
+// Pickups returns pickup requests matching query, newest first
+func (p *pickupRepoImpl) Pickups(db *mongo.Database, query bson.M, limit int64) (*[]models.PickupRequest, error) {
+	pickupCollection := db.Collection(models.PickupRequest{}.CollectionName())
+	opts := options.Find().SetSort(bson.M{"_id": -1}).SetLimit(limit)
+	cursor, err := pickupCollection.Find(context.Background(), query, opts)
+	if err != nil {
+		return nil, err
+	}
+	var pickups []models.PickupRequest
+	if err = cursor.All(context.Background(), &pickups); err != nil {
+		return nil, err
+	}
+	return &pickups, nil
+}
+
+// updatePickup applies update to a pickup request matching query, a request which exists
+// but does not match is not in a status allowing the update
+func updatePickup(ctx context.Context, db *mongo.Database, ID primitive.ObjectID, query, update bson.M) (*models.PickupRequest, error) {
+	pickupCollection := db.Collection(models.PickupRequest{}.CollectionName())
+	after := options.After
+	opts := options.FindOneAndUpdateOptions{ReturnDocument: &after}
+	pickup := &models.PickupRequest{}
+	err := pickupCollection.FindOneAndUpdate(ctx, query, update, &opts).Decode(pickup)
+	if err != mongo.ErrNoDocuments {
+		if err != nil {
+			return nil, err
+		}
+		return pickup, nil
+	}
+	count, err := pickupCollection.CountDocuments(ctx, bson.M{"_id": ID})
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, errors.NewError(string(codes.InvalidPickupTransition))
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+// Cancel cancels a pending request of the shop
+func (p *pickupRepoImpl) Cancel(db *mongo.Database, shopID, ID primitive.ObjectID) (*models.PickupRequest, error) {
+	query := bson.M{"_id": ID, "shopId": shopID, "status": constants.Pending}
+	update := bson.M{"$set": bson.M{"status": constants.Cancelled, "updatedAt": time.Now().UTC()}}
+	return updatePickup(context.Background(), db, ID, query, update)
+}
+
+// Assign hands a pending request over to a rider, assigned requests can be reassigned until picked
+func (p *pickupRepoImpl) Assign(db *mongo.Database, ID, riderID, assignedBy primitive.ObjectID) (*models.PickupRequest, error) {
+	t := time.Now().UTC()
+	query := bson.M{"_id": ID, "status": livePickup, "items.picked": bson.M{"$ne": true}}
+	update := bson.M{"$set": bson.M{
+		"status":     constants.Assigned,
+		"riderId":    riderID,
+		"assignedBy": assignedBy,
+		"assignedAt": t,
+		"updatedAt":  t,
+	}}
+	return updatePickup(context.Background(), db, ID, query, update)
+}
+
+// ConfirmPicked marks orders of the request picked by the rider, orders move to Picked status.
+// The request is picked once all of its orders are or the rider completes it, orders left
+// unpicked can be linked to another request
+func (p *pickupRepoImpl) ConfirmPicked(db *mongo.Database, ID, riderID primitive.ObjectID, orderIDs []primitive.ObjectID, complete bool) (*models.PickupRequest, error) {
+	wc := writeconcern.New(writeconcern.WMajority())
+	rc := readconcern.Snapshot()
+	txnOpts := options.Transaction().SetWriteConcern(wc).SetReadConcern(rc)
+	session, err := db.Client().StartSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.EndSession(context.Background())
+
+	callBack := func(sessionCtx mongo.SessionContext) (interface{}, error) {
+		pickup := &models.PickupRequest{}
+		pickupCollection := db.Collection(pickup.CollectionName())
+		if err := pickupCollection.FindOne(sessionCtx, bson.M{"_id": ID, "riderId": riderID}).Decode(pickup); err != nil {
+			return nil, err
+		}
+		if pickup.Status != constants.Assigned {
+			return nil, errors.NewError(string(codes.InvalidPickupTransition))
+		}
+		items := make(map[primitive.ObjectID]*models.PickupItem)
+		for i := range pickup.Items {
+			items[pickup.Items[i].OrderID] = &pickup.Items[i]
+		}
+		t := time.Now().UTC()
+		orderCollection := db.Collection(models.Order{}.CollectionName())
+		for _, orderID := range orderIDs {
+			item, ok := items[orderID]
+			if !ok {
+				return nil, errors.NewError(string(codes.InvalidPickupData))
+			}
+			if item.Picked {
+				continue
+			}
+			order := models.Order{}
+			if err := orderCollection.FindOne(sessionCtx, bson.M{"_id": orderID}).Decode(&order); err != nil {
+				return nil, err
+			}
+			if err := checkTransition(&order, constants.Picked); err != nil {
+				return nil, err
+			}
+			status := models.OrderStatus{
+				ID:            primitive.NewObjectID(),
+				DeleveryBoyID: &riderID,
+				Status:        constants.Picked,
+				Text:          constants.PickedMsg,
+				Time:          t,
+			}
+			push := bson.M{"status": bson.M{"$each": []models.OrderStatus{status}, "$position": 0}}
+			set := bson.M{"currentStatus": constants.Picked, "isPicked": true, "updatedAt": t}
+			// matching current status guards against a concurrent status change
+			filter := bson.M{"_id": orderID, "currentStatus": order.CurrentStatus}
+			after := options.After
+			opt := options.FindOneAndUpdateOptions{ReturnDocument: &after}
+			updatedOrder := &models.Order{}
+			err := orderCollection.FindOneAndUpdate(sessionCtx, filter, bson.M{"$set": set, "$push": push}, &opt).Decode(updatedOrder)
+			if err == mongo.ErrNoDocuments {
+				return nil, errors.NewError(string(codes.InvalidStatusTransition))
+			}
+			if err != nil {
+				return nil, err
+			}
+			if err := publishOrderStatus(sessionCtx, db, updatedOrder, &status); err != nil {
+				return nil, err
+			}
+			item.Picked = true
+			item.PickedAt = &t
+		}
+		set := bson.M{"items": pickup.Items, "updatedAt": t}
+		picked := true
+		for _, item := range pickup.Items {
+			picked = picked && item.Picked
+		}
+		if picked || complete {
+			set["status"] = constants.Picked
+			set["pickedAt"] = t
+		}
+		query := bson.M{"_id": ID, "riderId": riderID, "status": constants.Assigned}
+		return updatePickup(sessionCtx, db, ID, query, bson.M{"$set": set})
+	}
+	result, err := session.WithTransaction(context.Background(), callBack, txnOpts)
+	if err != nil {
+		return nil, err
+	}
+	return result.(*models.PickupRequest), nil
+}
@@ -0,0 +1,190 @@
+package data
+
+import (
+	"context"
+	"time"
+
+	"github.com/techartificer/swiftex/constants/codes"
+	"github.com/techartificer/swiftex/lib/errors"
+	"github.com/techartificer/swiftex/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type RateCardRepository interface {
+	Create(db *mongo.Database, card *models.RateCard) error
+	CreateVersion(db *mongo.Database, card *models.RateCard) error
+	RateCards(db *mongo.Database, lastID string) (*[]models.RateCard, error)
+	RateCardByID(db *mongo.Database, ID string) (*models.RateCard, error)
+	Versions(db *mongo.Database, code string) (*[]models.RateCard, error)
+	ActiveRateCard(db *mongo.Database, code string, at time.Time) (*models.RateCard, error)
+	Delete(db *mongo.Database, ID string) error
+	AssignToShop(db *mongo.Database, shopID, code string) (*models.Shop, error)
+}
+
+type rateCardRepoImpl struct{}
+
+var rateCardRepo RateCardRepository
+
+func NewRateCardRepo() RateCardRepository {
+	if rateCardRepo == nil {
+		rateCardRepo = &rateCardRepoImpl{}
+	}
+	return rateCardRepo
+}
+
+// unsetDefault clears default flag of rate cards superseded by the default in effect now, older versions
+// of the same code included. Defaults which are not effective yet keep the flag until they take over
+func (r *rateCardRepoImpl) unsetDefault(db *mongo.Database) error {
+	current, err := r.ActiveRateCard(db, "", time.Now().UTC())
+	if err == mongo.ErrNoDocuments {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	rateCardCollection := db.Collection(current.CollectionName())
+	filter := bson.M{
+		"_id":           bson.M{"$ne": current.ID},
+		"isDefault":     true,
+		"effectiveFrom": bson.M{"$lte": current.EffectiveFrom},
+	}
+	_, err = rateCardCollection.UpdateMany(context.Background(), filter, bson.M{"$set": bson.M{"isDefault": false}})
+	return err
+}
+
+func (r *rateCardRepoImpl) Create(db *mongo.Database, card *models.RateCard) error {
+	rateCardCollection := db.Collection(card.CollectionName())
+	card.Version = 1
+	if _, err := rateCardCollection.InsertOne(context.Background(), card); err != nil {
+		return err
+	}
+	if card.IsDefault {
+		return r.unsetDefault(db)
+	}
+	return nil
+}
+
+func (r *rateCardRepoImpl) CreateVersion(db *mongo.Database, card *models.RateCard) error {
+	rateCardCollection := db.Collection(card.CollectionName())
+	latest := models.RateCard{}
+	opts := options.FindOne().SetSort(bson.M{"version": -1})
+	if err := rateCardCollection.FindOne(context.Background(), bson.M{"code": card.Code}, opts).Decode(&latest); err != nil {
+		return err
+	}
+	card.Version = latest.Version + 1
+	if _, err := rateCardCollection.InsertOne(context.Background(), card); err != nil {
+		return err
+	}
+	if card.IsDefault {
+		return r.unsetDefault(db)
+	}
+	return nil
+}
+
+func (r *rateCardRepoImpl) RateCards(db *mongo.Database, lastID string) (*[]models.RateCard, error) {
+	rateCardCollection := db.Collection(models.RateCard{}.CollectionName())
+	query := make(bson.M)
+	if lastID != "" {
+		_lastID, err := primitive.ObjectIDFromHex(lastID)
+		if err != nil {
+			return nil, err
+		}
+		query["_id"] = bson.M{"$lt": _lastID}
+	}
+	opts := options.Find().SetSort(bson.M{"_id": -1}).SetLimit(15)
+	cursor, err := rateCardCollection.Find(context.Background(), query, opts)
+	if err != nil {
+		return nil, err
+	}
+	var cards []models.RateCard
+	if err = cursor.All(context.Background(), &cards); err != nil {
+		return nil, err
+	}
+	return &cards, nil
+}
+
+func (r *rateCardRepoImpl) RateCardByID(db *mongo.Database, ID string) (*models.RateCard, error) {
+	_id, err := primitive.ObjectIDFromHex(ID)
+	if err != nil {
+		return nil, err
+	}
+	card := &models.RateCard{}
+	rateCardCollection := db.Collection(card.CollectionName())
+	if err := rateCardCollection.FindOne(context.Background(), bson.M{"_id": _id}).Decode(card); err != nil {
+		return nil, err
+	}
+	return card, nil
+}
+
+func (r *rateCardRepoImpl) Versions(db *mongo.Database, code string) (*[]models.RateCard, error) {
+	rateCardCollection := db.Collection(models.RateCard{}.CollectionName())
+	opts := options.Find().SetSort(bson.M{"version": -1})
+	cursor, err := rateCardCollection.Find(context.Background(), bson.M{"code": code}, opts)
+	if err != nil {
+		return nil, err
+	}
+	var cards []models.RateCard
+	if err = cursor.All(context.Background(), &cards); err != nil {
+		return nil, err
+	}
+	return &cards, nil
+}
+
+// ActiveRateCard returns the version of rate card effective at given time,
+// default rate card is used if code is empty
+func (r *rateCardRepoImpl) ActiveRateCard(db *mongo.Database, code string, at time.Time) (*models.RateCard, error) {
+	rateCardCollection := db.Collection(models.RateCard{}.CollectionName())
+	query := bson.M{"effectiveFrom": bson.M{"$lte": at}}
+	if code != "" {
+		query["code"] = code
+	} else {
+		query["isDefault"] = true
+	}
+	opts := options.FindOne().SetSort(bson.D{{Key: "effectiveFrom", Value: -1}, {Key: "version", Value: -1}})
+	card := &models.RateCard{}
+	if err := rateCardCollection.FindOne(context.Background(), query, opts).Decode(card); err != nil {
+		return nil, err
+	}
+	return card, nil
+}
+
+// Delete removes a rate card version which is not effective yet
+func (r *rateCardRepoImpl) Delete(db *mongo.Database, ID string) error {
+	card, err := r.RateCardByID(db, ID)
+	if err != nil {
+		return err
+	}
+	if !card.EffectiveFrom.After(time.Now().UTC()) {
+		return errors.NewError(string(codes.RateCardNotDeletable))
+	}
+	rateCardCollection := db.Collection(card.CollectionName())
+	_, err = rateCardCollection.DeleteOne(context.Background(), bson.M{"_id": card.ID})
+	return err
+}
+
+func (r *rateCardRepoImpl) AssignToShop(db *mongo.Database, shopID, code string) (*models.Shop, error) {
+	_shopID, err := primitive.ObjectIDFromHex(shopID)
+	if err != nil {
+		return nil, err
+	}
+	rateCardCollection := db.Collection(models.RateCard{}.CollectionName())
+	cnt, err := rateCardCollection.CountDocuments(context.Background(), bson.M{"code": code})
+	if err != nil {
+		return nil, err
+	}
+	if cnt == 0 {
+		return nil, errors.NewError(string(codes.RateCardNotFound))
+	}
+	shop := &models.Shop{}
+	shopCollection := db.Collection(shop.CollectionName())
+	after := options.After
+	opt := options.FindOneAndUpdateOptions{
+		ReturnDocument: &after,
+	}
+	update := bson.M{"$set": bson.M{"rateCard": code, "updatedAt": time.Now().UTC()}}
+	err = shopCollection.FindOneAndUpdate(context.Background(), bson.M{"_id": _shopID}, update, &opt).Decode(shop)
+	return shop, err
+}
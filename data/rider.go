@@ -14,8 +14,9 @@ type RiderRepository interface {
 	Create(db *mongo.Database, rider *models.Rider) error
 	FindByPhone(db *mongo.Database, phone string) (*models.Rider, error)
 	FindByID(db *mongo.Database, ID string) (*models.Rider, error)
-	Riders(db *mongo.Database, lastID string) (*[]models.Rider, error)
-	RidersByHub(db *mongo.Database, hub string) (*[]models.Rider, error)
+	Riders(db *mongo.Database, lastID string, hubs []primitive.ObjectID) (*[]models.Rider, error)
+	RidersByHub(db *mongo.Database, hub string, hubs []primitive.ObjectID) (*[]models.Rider, error)
+	SetCapacity(db *mongo.Database, ID primitive.ObjectID, capacity int) (*models.Rider, error)
 }
 
 type riderRepoImpl struct{}
@@ -60,7 +61,8 @@ func (r *riderRepoImpl) FindByID(db *mongo.Database, ID string) (*models.Rider,
 	return rider, nil
 }
 
-func (r *riderRepoImpl) Riders(db *mongo.Database, lastID string) (*[]models.Rider, error) {
+// Riders returns riders page, riders are restricted to hubs if hubs is not nil
+func (r *riderRepoImpl) Riders(db *mongo.Database, lastID string, hubs []primitive.ObjectID) (*[]models.Rider, error) {
 	rider := models.Rider{}
 	riderCollection := db.Collection(rider.CollectionName())
 	opts := options.Find().SetSort(bson.M{"_id": -1}).SetLimit(15)
@@ -72,6 +74,9 @@ func (r *riderRepoImpl) Riders(db *mongo.Database, lastID string) (*[]models.Rid
 		}
 		query["_id"] = bson.M{"$lt": _lastID}
 	}
+	if hubs != nil {
+		query["hubId"] = bson.M{"$in": hubs}
+	}
 	cursor, err := riderCollection.Find(context.Background(), query, opts)
 	if err != nil {
 		return nil, err
@@ -83,12 +88,15 @@ func (r *riderRepoImpl) Riders(db *mongo.Database, lastID string) (*[]models.Rid
 	return &riders, nil
 }
 
-func (r *riderRepoImpl) RidersByHub(db *mongo.Database, hub string) (*[]models.Rider, error) {
+func (r *riderRepoImpl) RidersByHub(db *mongo.Database, hub string, hubs []primitive.ObjectID) (*[]models.Rider, error) {
 	rider := models.Rider{}
 	riderCollection := db.Collection(rider.CollectionName())
 	opts := options.Find().SetSort(bson.M{"_id": -1}).SetLimit(15)
 	query := make(bson.M)
 	query["hub"] = hub
+	if hubs != nil {
+		query["hubId"] = bson.M{"$in": hubs}
+	}
 	cursor, err := riderCollection.Find(context.Background(), query, opts)
 	if err != nil {
 		return nil, err
@@ -99,3 +107,19 @@ func (r *riderRepoImpl) RidersByHub(db *mongo.Database, hub string) (*[]models.R
 	}
 	return &riders, nil
 }
+
+// SetCapacity sets parcels the rider can be assigned a day, 0 falls back to the default capacity
+func (r *riderRepoImpl) SetCapacity(db *mongo.Database, ID primitive.ObjectID, capacity int) (*models.Rider, error) {
+	riderCol := db.Collection(models.Rider{}.CollectionName())
+	update := bson.M{"$set": bson.M{"dailyCapacity": capacity}}
+	if capacity == 0 {
+		update = bson.M{"$unset": bson.M{"dailyCapacity": ""}}
+	}
+	after := options.After
+	opts := options.FindOneAndUpdateOptions{ReturnDocument: &after}
+	rider := &models.Rider{}
+	if err := riderCol.FindOneAndUpdate(context.Background(), bson.M{"_id": ID}, update, &opts).Decode(rider); err != nil {
+		return nil, err
+	}
+	return rider, nil
+}
@@ -0,0 +1,223 @@
+package data
+
+import (
+	"context"
+	"time"
+
+	"github.com/techartificer/swiftex/config"
+	"github.com/techartificer/swiftex/constants/codes"
+	"github.com/techartificer/swiftex/lib/errors"
+	"github.com/techartificer/swiftex/models"
+	"github.com/techartificer/swiftex/serializer"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+)
+
+type RiderCashRepository interface {
+	CashInHand(db *mongo.Database, riderID primitive.ObjectID) (float64, error)
+	Deposit(db *mongo.Database, deposit *models.CashDeposit) (*models.CashDeposit, error)
+	Deposits(db *mongo.Database, riderID, hub, lastID string, startDate, endDate *time.Time) (*[]models.CashDeposit, error)
+	OutstandingByRider(db *mongo.Database, hub string) (*[]serializer.RiderCash, error)
+	OutstandingByHub(db *mongo.Database) (*[]serializer.HubCash, error)
+}
+
+type riderCashRepoImpl struct{}
+
+var riderCashRepo RiderCashRepository
+
+func NewRiderCashRepo() RiderCashRepository {
+	if riderCashRepo == nil {
+		riderCashRepo = &riderCashRepoImpl{}
+	}
+	return riderCashRepo
+}
+
+// riderCashInHand returns balance of rider's cash in hand account
+func riderCashInHand(ctx context.Context, db *mongo.Database, riderID primitive.ObjectID) (float64, error) {
+	account := models.LedgerAccount{}
+	accountCollection := db.Collection(account.CollectionName())
+	err := accountCollection.FindOne(ctx, bson.M{"type": models.RiderCashInHand, "ownerId": riderID}).Decode(&account)
+	if err == mongo.ErrNoDocuments {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return account.Balance, nil
+}
+
+// checkRiderCashLimit returns error if rider is holding more cash than the configured limit
+func checkRiderCashLimit(ctx context.Context, db *mongo.Database, riderID primitive.ObjectID) error {
+	limit := config.GetCash().RiderLimit
+	if limit <= 0 {
+		return nil
+	}
+	cashInHand, err := riderCashInHand(ctx, db, riderID)
+	if err != nil {
+		return err
+	}
+	if cashInHand >= limit {
+		return errors.NewError(string(codes.RiderCashLimitExceeded))
+	}
+	return nil
+}
+
+func (r *riderCashRepoImpl) CashInHand(db *mongo.Database, riderID primitive.ObjectID) (float64, error) {
+	return riderCashInHand(context.Background(), db, riderID)
+}
+
+func (r *riderCashRepoImpl) Deposit(db *mongo.Database, deposit *models.CashDeposit) (*models.CashDeposit, error) {
+	wc := writeconcern.New(writeconcern.WMajority())
+	rc := readconcern.Snapshot()
+	txnOpts := options.Transaction().SetWriteConcern(wc).SetReadConcern(rc)
+	session, err := db.Client().StartSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.EndSession(context.Background())
+
+	callBack := func(sessionCtx mongo.SessionContext) (interface{}, error) {
+		rider := models.Rider{}
+		riderCollection := db.Collection(rider.CollectionName())
+		if err := riderCollection.FindOne(sessionCtx, bson.M{"_id": deposit.RiderID}).Decode(&rider); err != nil {
+			return nil, err
+		}
+		cashInHand, err := riderCashInHand(sessionCtx, db, deposit.RiderID)
+		if err != nil {
+			return nil, err
+		}
+		if roundAmount(deposit.Amount) > roundAmount(cashInHand) {
+			return nil, errors.NewError(string(codes.DepositExceedsCashInHand))
+		}
+		entry := &models.JournalEntry{
+			ID:          primitive.NewObjectID(),
+			Description: "Rider cash deposit",
+			CreatedBy:   &deposit.ReceivedBy,
+			CreatedAt:   deposit.CreatedAt,
+			Lines: []models.JournalLine{
+				debitLine(models.PlatformCash, nil, deposit.Amount, "Deposited at "+rider.Hub),
+				creditLine(models.RiderCashInHand, &deposit.RiderID, deposit.Amount, "Deposited at "+rider.Hub),
+			},
+		}
+		if err := postJournal(sessionCtx, db, entry); err != nil {
+			return nil, err
+		}
+		deposit.Hub = rider.Hub
+		deposit.CashInHand = roundAmount(cashInHand - deposit.Amount)
+		deposit.JournalEntryID = entry.ID
+		depositCollection := db.Collection(deposit.CollectionName())
+		if _, err := depositCollection.InsertOne(sessionCtx, deposit); err != nil {
+			return nil, err
+		}
+		return deposit, nil
+	}
+	if _, err := session.WithTransaction(context.Background(), callBack, txnOpts); err != nil {
+		return nil, err
+	}
+	return deposit, nil
+}
+
+func (r *riderCashRepoImpl) Deposits(db *mongo.Database, riderID, hub, lastID string, startDate, endDate *time.Time) (*[]models.CashDeposit, error) {
+	query := make(bson.M)
+	if riderID != "" {
+		_riderID, err := primitive.ObjectIDFromHex(riderID)
+		if err != nil {
+			return nil, err
+		}
+		query["riderId"] = _riderID
+	}
+	if hub != "" {
+		query["hub"] = hub
+	}
+	if lastID != "" {
+		_lastID, err := primitive.ObjectIDFromHex(lastID)
+		if err != nil {
+			return nil, err
+		}
+		query["_id"] = bson.M{"$lt": _lastID}
+	}
+	if startDate != nil && endDate != nil && !startDate.IsZero() && !endDate.IsZero() {
+		query["$and"] = []bson.M{{"createdAt": bson.M{"$gte": startDate}}, {"createdAt": bson.M{"$lte": endDate}}}
+	}
+	depositCollection := db.Collection(models.CashDeposit{}.CollectionName())
+	opts := options.Find().SetSort(bson.M{"_id": -1}).SetLimit(15)
+	cursor, err := depositCollection.Find(context.Background(), query, opts)
+	if err != nil {
+		return nil, err
+	}
+	var deposits []models.CashDeposit
+	if err = cursor.All(context.Background(), &deposits); err != nil {
+		return nil, err
+	}
+	return &deposits, nil
+}
+
+// outstandingPipeline returns riders who are holding cash with their hub
+func outstandingPipeline(hub string) []bson.M {
+	pipeline := []bson.M{
+		{"$match": bson.M{"type": models.RiderCashInHand, "balance": bson.M{"$gt": 0}}},
+		{"$lookup": bson.M{
+			"from":         models.Rider{}.CollectionName(),
+			"localField":   "ownerId",
+			"foreignField": "_id",
+			"as":           "rider",
+		}},
+		{"$unwind": "$rider"},
+	}
+	if hub != "" {
+		pipeline = append(pipeline, bson.M{"$match": bson.M{"rider.hub": hub}})
+	}
+	return pipeline
+}
+
+func (r *riderCashRepoImpl) OutstandingByRider(db *mongo.Database, hub string) (*[]serializer.RiderCash, error) {
+	pipeline := append(outstandingPipeline(hub),
+		bson.M{"$project": bson.M{
+			"name":       "$rider.name",
+			"phone":      "$rider.phone",
+			"hub":        "$rider.hub",
+			"cashInHand": "$balance",
+			"_id":        "$ownerId",
+		}},
+		bson.M{"$sort": bson.M{"cashInHand": -1}},
+	)
+	accountCollection := db.Collection(models.LedgerAccount{}.CollectionName())
+	cursor, err := accountCollection.Aggregate(context.Background(), pipeline)
+	if err != nil {
+		return nil, err
+	}
+	var riders []serializer.RiderCash
+	if err = cursor.All(context.Background(), &riders); err != nil {
+		return nil, err
+	}
+	limit := config.GetCash().RiderLimit
+	for i := range riders {
+		riders[i].OverLimit = limit > 0 && riders[i].CashInHand >= limit
+	}
+	return &riders, nil
+}
+
+func (r *riderCashRepoImpl) OutstandingByHub(db *mongo.Database) (*[]serializer.HubCash, error) {
+	pipeline := append(outstandingPipeline(""),
+		bson.M{"$group": bson.M{
+			"_id":        "$rider.hub",
+			"riders":     bson.M{"$sum": 1},
+			"cashInHand": bson.M{"$sum": "$balance"},
+		}},
+		bson.M{"$sort": bson.M{"cashInHand": -1}},
+	)
+	accountCollection := db.Collection(models.LedgerAccount{}.CollectionName())
+	cursor, err := accountCollection.Aggregate(context.Background(), pipeline)
+	if err != nil {
+		return nil, err
+	}
+	var hubs []serializer.HubCash
+	if err = cursor.All(context.Background(), &hubs); err != nil {
+		return nil, err
+	}
+	return &hubs, nil
+}
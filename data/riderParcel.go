@@ -8,6 +8,7 @@ import (
 	"github.com/mitchellh/mapstructure"
 	"github.com/techartificer/swiftex/constants"
 	"github.com/techartificer/swiftex/constants/codes"
+	"github.com/techartificer/swiftex/lib/orderstatus"
 	"github.com/techartificer/swiftex/models"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -20,6 +21,10 @@ import (
 type RiderParcelRepository interface {
 	Create(db *mongo.Database, parcel *models.RiderParcel) (*models.Order, error)
 	ParcelsByRiderId(db *mongo.Database, riderID, lastID string) (*[]bson.M, error)
+	Accept(db *mongo.Database, riderID, parcelID primitive.ObjectID) (*models.RiderParcel, error)
+	Pickup(db *mongo.Database, riderID, parcelID primitive.ObjectID) (*models.RiderParcel, error)
+	FailAttempt(db *mongo.Database, riderID, parcelID primitive.ObjectID, attempt *models.DeliveryAttempt) (*models.RiderParcel, error)
+	ReturnToHub(db *mongo.Database, riderID, parcelID primitive.ObjectID) (*models.RiderParcel, error)
 }
 
 type riderParcelImpl struct{}
@@ -34,7 +39,6 @@ func NewRiderParcelRepo() RiderParcelRepository {
 }
 
 func (p riderParcelImpl) Create(db *mongo.Database, parcel *models.RiderParcel) (*models.Order, error) {
-	riderParcelCollection := db.Collection(parcel.CollectionName())
 	wc := writeconcern.New(writeconcern.WMajority())
 	rc := readconcern.Snapshot()
 	txnOpts := options.Transaction().SetWriteConcern(wc).SetReadConcern(rc)
@@ -45,50 +49,7 @@ func (p riderParcelImpl) Create(db *mongo.Database, parcel *models.RiderParcel)
 	defer session.EndSession(context.Background())
 
 	callBack := func(sessionCtx mongo.SessionContext) (interface{}, error) {
-		order := models.Order{}
-		orderCollection := db.Collection(order.CollectionName())
-		err := orderCollection.FindOne(context.Background(), bson.M{"_id": parcel.OrderID}).Decode(&order)
-		if err != nil {
-			return nil, err
-		}
-		if *order.CurrentStatus == constants.InTransit {
-			return nil, errors.New(string(codes.OrderAlreadyInTransit))
-		}
-		if *order.CurrentStatus == constants.Delivered || order.DeliveredAt != nil {
-			return nil, errors.New(string(codes.OrderAlreadyDelevired))
-		}
-		if _, err := riderParcelCollection.InsertOne(sessionCtx, parcel); err != nil {
-			return nil, err
-		}
-		status := models.OrderStatus{
-			ID:              primitive.NewObjectID(),
-			DeleveryBoyID:   &parcel.RiderID,
-			ShopModeratorID: nil,
-			MerchantID:      nil,
-			AdminID:         &parcel.AssignedBy,
-			Status:          constants.InTransit,
-			Text:            "Rider have picked your parcel",
-			Time:            time.Now().UTC(),
-		}
-		orderStatusArray := []models.OrderStatus{status}
-		push := bson.M{"status": bson.M{"$each": orderStatusArray, "$position": 0}}
-		filter := bson.M{"_id": parcel.OrderID}
-		after := options.After
-		opt := options.FindOneAndUpdateOptions{
-			ReturnDocument: &after,
-		}
-
-		updatedOrder := models.Order{}
-		query := bson.M{"$set": bson.M{
-			"currentStatus": constants.InTransit,
-			"riderId":       &parcel.RiderID,
-		}, "$push": push}
-
-		err = orderCollection.FindOneAndUpdate(context.Background(), filter, query, &opt).Decode(&updatedOrder)
-		if err != nil {
-			return nil, err
-		}
-		return &updatedOrder, nil
+		return assignParcel(sessionCtx, db, parcel)
 	}
 
 	result, err := session.WithTransaction(context.Background(), callBack, txnOpts)
@@ -101,6 +62,86 @@ func (p riderParcelImpl) Create(db *mongo.Database, parcel *models.RiderParcel)
 	return &order, nil
 }
 
+// assignParcel hands an order over to the rider of parcel, it has to run in a transaction
+func assignParcel(sessionCtx mongo.SessionContext, db *mongo.Database, parcel *models.RiderParcel) (*models.Order, error) {
+	riderParcelCollection := db.Collection(parcel.CollectionName())
+	order := models.Order{}
+	orderCollection := db.Collection(order.CollectionName())
+	err := orderCollection.FindOne(sessionCtx, bson.M{"_id": parcel.OrderID}).Decode(&order)
+	if err != nil {
+		return nil, err
+	}
+	currentStatus := orderstatus.Current(order.CurrentStatus)
+	if currentStatus == constants.InTransit || currentStatus == constants.Assigned {
+		return nil, errors.New(string(codes.OrderAlreadyInTransit))
+	}
+	if currentStatus == constants.Delivered || order.DeliveredAt != nil {
+		return nil, errors.New(string(codes.OrderAlreadyDelevired))
+	}
+	if err := checkTransition(&order, constants.Assigned); err != nil {
+		return nil, err
+	}
+	if err := checkRiderCashLimit(sessionCtx, db, parcel.RiderID); err != nil {
+		return nil, err
+	}
+	if _, err := riderParcelCollection.InsertOne(sessionCtx, parcel); err != nil {
+		return nil, err
+	}
+	status := models.OrderStatus{
+		ID:              primitive.NewObjectID(),
+		DeleveryBoyID:   &parcel.RiderID,
+		ShopModeratorID: nil,
+		MerchantID:      nil,
+		AdminID:         &parcel.AssignedBy,
+		Status:          constants.Assigned,
+		Text:            constants.AssignedMsg,
+		Time:            time.Now().UTC(),
+	}
+	orderStatusArray := []models.OrderStatus{status}
+	push := bson.M{"status": bson.M{"$each": orderStatusArray, "$position": 0}}
+	filter := bson.M{"_id": parcel.OrderID, "currentStatus": order.CurrentStatus}
+	after := options.After
+	opt := options.FindOneAndUpdateOptions{
+		ReturnDocument: &after,
+	}
+
+	updatedOrder := models.Order{}
+	query := bson.M{"$set": bson.M{
+		"currentStatus": constants.Assigned,
+		"riderId":       &parcel.RiderID,
+	}, "$push": push}
+
+	err = orderCollection.FindOneAndUpdate(sessionCtx, filter, query, &opt).Decode(&updatedOrder)
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.New(string(codes.InvalidStatusTransition))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := publishOrderStatus(sessionCtx, db, &updatedOrder, &status); err != nil {
+		return nil, err
+	}
+	assigned := models.ParcelAssignedPayload{
+		OrderID:    updatedOrder.ID,
+		TrackID:    updatedOrder.TrackID,
+		ShopID:     updatedOrder.ShopID,
+		RiderID:    parcel.RiderID,
+		AssignedBy: parcel.AssignedBy,
+		Time:       status.Time,
+	}
+	if err := publishEvent(sessionCtx, db, models.ParcelAssigned, &updatedOrder.ShopID, &updatedOrder.ID, assigned); err != nil {
+		return nil, err
+	}
+	return &updatedOrder, nil
+}
+
+// parcelOrderStages returns stages joining rider parcels with their order as field order
+func parcelOrderStages() (bson.D, bson.D) {
+	lookupStage := bson.D{{"$lookup", bson.D{{"from", "orders"}, {"localField", "orderId"}, {"foreignField", "_id"}, {"as", "order"}}}}
+	unwindStage := bson.D{{"$unwind", bson.D{{"path", "$order"}, {"preserveNullAndEmptyArrays", false}}}}
+	return lookupStage, unwindStage
+}
+
 func (p riderParcelImpl) ParcelsByRiderId(db *mongo.Database, riderID, lastID string) (*[]bson.M, error) {
 	query := make(bson.M)
 	_riderID, err := primitive.ObjectIDFromHex(riderID)
@@ -121,8 +162,7 @@ func (p riderParcelImpl) ParcelsByRiderId(db *mongo.Database, riderID, lastID st
 
 	matchStage := bson.D{{"$match", query}}
 	limitStage := bson.D{{"$limit", 10}}
-	lookupStage := bson.D{{"$lookup", bson.D{{"from", "orders"}, {"localField", "orderId"}, {"foreignField", "_id"}, {"as", "order"}}}}
-	unwindStage := bson.D{{"$unwind", bson.D{{"path", "$order"}, {"preserveNullAndEmptyArrays", false}}}}
+	lookupStage, unwindStage := parcelOrderStages()
 	sortStage := bson.D{{"$sort", bson.D{{"_id", -1}}}}
 
 	cursor, err := riderParcelCollection.Aggregate(context.Background(), mongo.Pipeline{matchStage, limitStage, lookupStage, unwindStage, sortStage})
@@ -136,3 +176,160 @@ func (p riderParcelImpl) ParcelsByRiderId(db *mongo.Database, riderID, lastID st
 	}
 	return &parcels, nil
 }
+
+// parcelStep moves a rider parcel to next status and records it in order status history
+type parcelStep struct {
+	from []string
+	to   string
+	// orderStatus is the status the order moves to, order keeps its status if it is already there
+	orderStatus string
+	text        string
+	parcelSet   bson.M
+	parcelPush  bson.M
+	orderSet    bson.M
+	orderInc    bson.M
+}
+
+func (p riderParcelImpl) advance(db *mongo.Database, riderID, parcelID primitive.ObjectID, step parcelStep) (*models.RiderParcel, error) {
+	wc := writeconcern.New(writeconcern.WMajority())
+	rc := readconcern.Snapshot()
+	txnOpts := options.Transaction().SetWriteConcern(wc).SetReadConcern(rc)
+	session, err := db.Client().StartSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.EndSession(context.Background())
+
+	callBack := func(sessionCtx mongo.SessionContext) (interface{}, error) {
+		parcel := models.RiderParcel{}
+		riderParcelCollection := db.Collection(parcel.CollectionName())
+		if err := riderParcelCollection.FindOne(sessionCtx, bson.M{"_id": parcelID, "riderId": riderID}).Decode(&parcel); err != nil {
+			return nil, err
+		}
+		allowed := false
+		for _, s := range step.from {
+			if s == parcel.Status {
+				allowed = true
+			}
+		}
+		if !allowed {
+			return nil, errors.New(string(codes.InvalidParcelTransition))
+		}
+		order := models.Order{}
+		orderCollection := db.Collection(order.CollectionName())
+		if err := orderCollection.FindOne(sessionCtx, bson.M{"_id": parcel.OrderID}).Decode(&order); err != nil {
+			return nil, err
+		}
+		t := time.Now().UTC()
+		currentStatus := orderstatus.Current(order.CurrentStatus)
+		status := models.OrderStatus{
+			ID:            primitive.NewObjectID(),
+			DeleveryBoyID: &riderID,
+			Status:        currentStatus,
+			Text:          step.text,
+			Time:          t,
+		}
+		orderSet := bson.M{"updatedAt": t}
+		for k, v := range step.orderSet {
+			orderSet[k] = v
+		}
+		if step.orderStatus != "" && step.orderStatus != currentStatus {
+			if err := checkTransition(&order, step.orderStatus); err != nil {
+				return nil, err
+			}
+			status.Status = step.orderStatus
+			orderSet["currentStatus"] = step.orderStatus
+		}
+		push := bson.M{"status": bson.M{"$each": []models.OrderStatus{status}, "$position": 0}}
+		update := bson.M{"$set": orderSet, "$push": push}
+		if step.orderInc != nil {
+			update["$inc"] = step.orderInc
+		}
+		// matching current status guards against a concurrent status change
+		filter := bson.M{"_id": order.ID, "currentStatus": order.CurrentStatus}
+		after := options.After
+		opt := options.FindOneAndUpdateOptions{ReturnDocument: &after}
+		updatedOrder := models.Order{}
+		err := orderCollection.FindOneAndUpdate(sessionCtx, filter, update, &opt).Decode(&updatedOrder)
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New(string(codes.InvalidStatusTransition))
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := publishOrderStatus(sessionCtx, db, &updatedOrder, &status); err != nil {
+			return nil, err
+		}
+		parcelSet := bson.M{"status": step.to, "updatedAt": t}
+		for k, v := range step.parcelSet {
+			parcelSet[k] = v
+		}
+		parcelUpdate := bson.M{"$set": parcelSet}
+		if step.parcelPush != nil {
+			parcelUpdate["$push"] = step.parcelPush
+		}
+		updatedParcel := &models.RiderParcel{}
+		parcelFilter := bson.M{"_id": parcel.ID, "status": parcel.Status}
+		err = riderParcelCollection.FindOneAndUpdate(sessionCtx, parcelFilter, parcelUpdate, &opt).Decode(updatedParcel)
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New(string(codes.InvalidParcelTransition))
+		}
+		if err != nil {
+			return nil, err
+		}
+		return updatedParcel, nil
+	}
+	result, err := session.WithTransaction(context.Background(), callBack, txnOpts)
+	if err != nil {
+		return nil, err
+	}
+	return result.(*models.RiderParcel), nil
+}
+
+// Accept acknowledges an assignment, order keeps its status
+func (p riderParcelImpl) Accept(db *mongo.Database, riderID, parcelID primitive.ObjectID) (*models.RiderParcel, error) {
+	return p.advance(db, riderID, parcelID, parcelStep{
+		from:      []string{constants.Assigned},
+		to:        constants.Accepted,
+		text:      constants.RiderAccepted,
+		parcelSet: bson.M{"acceptedAt": time.Now().UTC()},
+	})
+}
+
+// Pickup confirms the rider has the parcel, order goes out for delivery
+func (p riderParcelImpl) Pickup(db *mongo.Database, riderID, parcelID primitive.ObjectID) (*models.RiderParcel, error) {
+	return p.advance(db, riderID, parcelID, parcelStep{
+		from:        []string{constants.Assigned, constants.Accepted},
+		to:          constants.Picked,
+		orderStatus: constants.InTransit,
+		text:        constants.InTransitMsg,
+		parcelSet:   bson.M{"pickedAt": time.Now().UTC()},
+	})
+}
+
+// FailAttempt records a failed delivery attempt and reschedules the order
+func (p riderParcelImpl) FailAttempt(db *mongo.Database, riderID, parcelID primitive.ObjectID, attempt *models.DeliveryAttempt) (*models.RiderParcel, error) {
+	orderSet := bson.M{}
+	if attempt.RescheduleAt != nil {
+		orderSet["rescheduledTo"] = attempt.RescheduleAt
+	}
+	return p.advance(db, riderID, parcelID, parcelStep{
+		from:        []string{constants.Picked},
+		to:          constants.Failed,
+		orderStatus: constants.Rescheduled,
+		text:        constants.AttemptMsg + ", " + attempt.Reason,
+		parcelPush:  bson.M{"attempts": attempt},
+		orderSet:    orderSet,
+		orderInc:    bson.M{"attempts": 1},
+	})
+}
+
+// ReturnToHub records the parcel of a failed attempt has been handed over to hub
+func (p riderParcelImpl) ReturnToHub(db *mongo.Database, riderID, parcelID primitive.ObjectID) (*models.RiderParcel, error) {
+	return p.advance(db, riderID, parcelID, parcelStep{
+		from:      []string{constants.Failed},
+		to:        constants.Returned,
+		text:      constants.HubReturnMsg,
+		parcelSet: bson.M{"returnedAt": time.Now().UTC()},
+	})
+}
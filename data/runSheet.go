@@ -0,0 +1,219 @@
+package data
+
+import (
+	"context"
+	"time"
+
+	"github.com/techartificer/swiftex/constants"
+	"github.com/techartificer/swiftex/constants/codes"
+	"github.com/techartificer/swiftex/lib/errors"
+	"github.com/techartificer/swiftex/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type RunSheetRepository interface {
+	Open(db *mongo.Database, sheet *models.RunSheet) (*models.RunSheet, error)
+	RunSheetByID(db *mongo.Database, ID primitive.ObjectID) (*models.RunSheet, error)
+	RunSheetByRider(db *mongo.Database, riderID primitive.ObjectID, date string) (*models.RunSheet, error)
+	RunSheets(db *mongo.Database, query bson.M, limit int64) (*[]models.RunSheet, error)
+	Close(db *mongo.Database, ID, closedBy primitive.ObjectID) (*models.RunSheet, error)
+}
+
+type runSheetRepoImpl struct{}
+
+var runSheetRepo RunSheetRepository
+
+func NewRunSheetRepo() RunSheetRepository {
+	if runSheetRepo == nil {
+		runSheetRepo = &runSheetRepoImpl{}
+	}
+	return runSheetRepo
+}
+
+// parcelWithOrder is a rider parcel joined with its order
+type parcelWithOrder struct {
+	models.RiderParcel `bson:",inline"`
+	Order              models.Order `bson:"order"`
+}
+
+// Open lists parcels the rider is carrying on the sheet, a rider has one sheet a date
+func (r *runSheetRepoImpl) Open(db *mongo.Database, sheet *models.RunSheet) (*models.RunSheet, error) {
+	riderParcelCollection := db.Collection(models.RiderParcel{}.CollectionName())
+	matchStage := bson.D{{Key: "$match", Value: bson.M{"riderId": sheet.RiderID, "status": bson.M{"$in": openParcelStatuses}}}}
+	lookupStage, unwindStage := parcelOrderStages()
+	sortStage := bson.D{{Key: "$sort", Value: bson.M{"_id": 1}}}
+	cursor, err := riderParcelCollection.Aggregate(context.Background(), mongo.Pipeline{matchStage, lookupStage, unwindStage, sortStage})
+	if err != nil {
+		return nil, err
+	}
+	var parcels []parcelWithOrder
+	if err := cursor.All(context.Background(), &parcels); err != nil {
+		return nil, err
+	}
+	sheet.Parcels = []models.RunSheetParcel{}
+	for _, parcel := range parcels {
+		cod := 0.0
+		if parcel.Order.PaymentStatus == constants.COD {
+			cod = parcel.Order.Price
+		}
+		sheet.TotalCOD += cod
+		sheet.Parcels = append(sheet.Parcels, models.RunSheetParcel{
+			ParcelID:         parcel.ID,
+			OrderID:          parcel.OrderID,
+			TrackID:          parcel.Order.TrackID,
+			RecipientName:    parcel.Order.RecipientName,
+			RecipientPhone:   parcel.Order.RecipientPhone,
+			RecipientAddress: parcel.Order.RecipientAddress,
+			RecipientArea:    parcel.Order.RecipientArea,
+			COD:              cod,
+			OpeningStatus:    parcel.Status,
+		})
+	}
+	runSheetCollection := db.Collection(sheet.CollectionName())
+	if _, err := runSheetCollection.InsertOne(context.Background(), sheet); err != nil {
+		if errors.IsMongoDupError(err) {
+			return nil, errors.NewError(string(codes.RunSheetAlreadyExist))
+		}
+		return nil, err
+	}
+	return sheet, nil
+}
+
+func (r *runSheetRepoImpl) RunSheetByID(db *mongo.Database, ID primitive.ObjectID) (*models.RunSheet, error) {
+	sheet := &models.RunSheet{}
+	runSheetCollection := db.Collection(sheet.CollectionName())
+	if err := runSheetCollection.FindOne(context.Background(), bson.M{"_id": ID}).Decode(sheet); err != nil {
+		return nil, err
+	}
+	return sheet, nil
+}
+
+func (r *runSheetRepoImpl) RunSheetByRider(db *mongo.Database, riderID primitive.ObjectID, date string) (*models.RunSheet, error) {
+	sheet := &models.RunSheet{}
+	runSheetCollection := db.Collection(sheet.CollectionName())
+	if err := runSheetCollection.FindOne(context.Background(), bson.M{"riderId": riderID, "date": date}).Decode(sheet); err != nil {
+		return nil, err
+	}
+	return sheet, nil
+}
+
+// RunSheets returns run sheets matching query without their parcels, newest first
+func (r *runSheetRepoImpl) RunSheets(db *mongo.Database, query bson.M, limit int64) (*[]models.RunSheet, error) {
+	runSheetCollection := db.Collection(models.RunSheet{}.CollectionName())
+	opts := options.Find().SetSort(bson.M{"_id": -1}).SetLimit(limit).SetProjection(bson.M{"parcels": 0})
+	cursor, err := runSheetCollection.Find(context.Background(), query, opts)
+	if err != nil {
+		return nil, err
+	}
+	var sheets []models.RunSheet
+	if err = cursor.All(context.Background(), &sheets); err != nil {
+		return nil, err
+	}
+	return &sheets, nil
+}
+
+// collectedAmounts returns the amounts riders collected from recipients of delivered orders
+func collectedAmounts(db *mongo.Database, orderIDs []primitive.ObjectID) (map[primitive.ObjectID]float64, error) {
+	amounts := make(map[primitive.ObjectID]float64)
+	if len(orderIDs) == 0 {
+		return amounts, nil
+	}
+	trxHistoryCollection := db.Collection(models.TrxHistory{}.CollectionName())
+	cursor, err := trxHistoryCollection.Find(context.Background(), bson.M{"orderId": bson.M{"$in": orderIDs}})
+	if err != nil {
+		return nil, err
+	}
+	var histories []models.TrxHistory
+	if err := cursor.All(context.Background(), &histories); err != nil {
+		return nil, err
+	}
+	for _, history := range histories {
+		if history.OrderID != nil {
+			amounts[*history.OrderID] += history.Collected
+		}
+	}
+	return amounts, nil
+}
+
+// Close reconciles parcels of an open sheet, delivered and returned parcels are final
+// and parcels the rider still holds are carried over to the next sheet, collected cod is the
+// amount the rider actually collected on delivery
+func (r *runSheetRepoImpl) Close(db *mongo.Database, ID, closedBy primitive.ObjectID) (*models.RunSheet, error) {
+	sheet, err := r.RunSheetByID(db, ID)
+	if err != nil {
+		return nil, err
+	}
+	if sheet.Status != constants.Open {
+		return nil, errors.NewError(string(codes.RunSheetClosed))
+	}
+	parcelIDs := []primitive.ObjectID{}
+	for _, parcel := range sheet.Parcels {
+		parcelIDs = append(parcelIDs, parcel.ParcelID)
+	}
+	riderParcelCollection := db.Collection(models.RiderParcel{}.CollectionName())
+	cursor, err := riderParcelCollection.Find(context.Background(), bson.M{"_id": bson.M{"$in": parcelIDs}})
+	if err != nil {
+		return nil, err
+	}
+	var parcels []models.RiderParcel
+	if err := cursor.All(context.Background(), &parcels); err != nil {
+		return nil, err
+	}
+	statuses := make(map[primitive.ObjectID]string)
+	deliveredOrderIDs := []primitive.ObjectID{}
+	for _, parcel := range parcels {
+		statuses[parcel.ID] = parcel.Status
+		if parcel.Status == constants.Delivered {
+			deliveredOrderIDs = append(deliveredOrderIDs, parcel.OrderID)
+		}
+	}
+	amounts, err := collectedAmounts(db, deliveredOrderIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	collected := 0.0
+	delivered, returned, carriedOver := 0, 0, 0
+	for i := range sheet.Parcels {
+		parcel := &sheet.Parcels[i]
+		switch statuses[parcel.ParcelID] {
+		case constants.Delivered:
+			parcel.ClosingStatus = constants.Delivered
+			collected += amounts[parcel.OrderID]
+			delivered++
+		case constants.Returned:
+			parcel.ClosingStatus = constants.Returned
+			returned++
+		default:
+			parcel.ClosingStatus = constants.CarriedOver
+			carriedOver++
+		}
+	}
+	t := time.Now().UTC()
+	update := bson.M{"$set": bson.M{
+		"status":       constants.Closed,
+		"parcels":      sheet.Parcels,
+		"collectedCod": collected,
+		"delivered":    delivered,
+		"returned":     returned,
+		"carriedOver":  carriedOver,
+		"closedBy":     closedBy,
+		"closedAt":     t,
+		"updatedAt":    t,
+	}}
+	after := options.After
+	opts := options.FindOneAndUpdateOptions{ReturnDocument: &after}
+	runSheetCollection := db.Collection(sheet.CollectionName())
+	closed := &models.RunSheet{}
+	err = runSheetCollection.FindOneAndUpdate(context.Background(), bson.M{"_id": ID, "status": constants.Open}, update, &opts).Decode(closed)
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.NewError(string(codes.RunSheetClosed))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return closed, nil
+}
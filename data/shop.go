@@ -23,6 +23,7 @@ type ShopRepository interface {
 	Shops(db *mongo.Database, lastID string, limit int64) (*[]models.Shop, error)
 	Search(db *mongo.Database, query primitive.M) (*[]models.Shop, error)
 	AllShopsName(db *mongo.Database) (*[]serializer.AllShops, error)
+	SetImage(db *mongo.Database, ID primitive.ObjectID, key string) (*models.Shop, error)
 }
 
 type shopRepositoryImpl struct{}
@@ -144,10 +145,11 @@ func (a *shopRepositoryImpl) Shops(db *mongo.Database, lastID string, limit int6
 	return &shops, nil
 }
 
+// ShopsByOwnerId returns shops owned or moderated by the merchant
 func (a *shopRepositoryImpl) ShopsByOwnerId(db *mongo.Database, owner primitive.ObjectID) (*[]models.Shop, error) {
 	shop := &models.Shop{}
 	shopCollection := db.Collection(shop.CollectionName())
-	query := bson.M{"owner": owner}
+	query := bson.M{"$or": []bson.M{{"owner": owner}, {"moderators": owner}}}
 	cursor, err := shopCollection.Find(context.Background(), query)
 	if err != nil {
 		return nil, err
@@ -170,3 +172,15 @@ func (a *shopRepositoryImpl) ShopByID(db *mongo.Database, ID string) (*models.Sh
 	err = shopCollection.FindOne(context.Background(), filter).Decode(shop)
 	return shop, err
 }
+
+func (s *shopRepositoryImpl) SetImage(db *mongo.Database, ID primitive.ObjectID, key string) (*models.Shop, error) {
+	shopCollection := db.Collection(models.Shop{}.CollectionName())
+	update := bson.M{"$set": bson.M{"image": key, "updatedAt": time.Now().UTC()}}
+	after := options.After
+	opt := options.FindOneAndUpdateOptions{ReturnDocument: &after}
+	shop := &models.Shop{}
+	if err := shopCollection.FindOneAndUpdate(context.Background(), bson.M{"_id": ID}, update, &opt).Decode(shop); err != nil {
+		return nil, err
+	}
+	return shop, nil
+}
@@ -0,0 +1,218 @@
+package data
+
+import (
+	"context"
+	"time"
+
+	"github.com/techartificer/swiftex/constants"
+	"github.com/techartificer/swiftex/constants/codes"
+	"github.com/techartificer/swiftex/lib/errors"
+	"github.com/techartificer/swiftex/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+)
+
+type ShopModeratorRepository interface {
+	Invite(db *mongo.Database, moderator *models.ShopModerator) (*models.ShopModerator, error)
+	Moderators(db *mongo.Database, shopID primitive.ObjectID) (*[]models.ShopModerator, error)
+	UpdatePermissions(db *mongo.Database, shopID, ID primitive.ObjectID, permissions []constants.ShopPermission) (*models.ShopModerator, error)
+	Revoke(db *mongo.Database, shopID, ID primitive.ObjectID) (*models.ShopModerator, error)
+	Invitations(db *mongo.Database, phone string) (*[]models.ShopModerator, error)
+	Respond(db *mongo.Database, ID, merchantID primitive.ObjectID, phone string, accept bool) (*models.ShopModerator, error)
+	ActiveModerator(db *mongo.Database, shopID, merchantID primitive.ObjectID) (*models.ShopModerator, error)
+	ModeratorByPhone(db *mongo.Database, shopID primitive.ObjectID, phone string) (*models.ShopModerator, error)
+}
+
+type shopModeratorRepoImpl struct{}
+
+var shopModeratorRepo ShopModeratorRepository
+
+func NewShopModeratorRepo() ShopModeratorRepository {
+	if shopModeratorRepo == nil {
+		shopModeratorRepo = &shopModeratorRepoImpl{}
+	}
+	return shopModeratorRepo
+}
+
+// liveModerator matches invited and active moderators, revoked and declined ones are kept for history
+var liveModerator = bson.M{"$in": []string{constants.Invited, constants.Active}}
+
+// Invite creates an invitation or renews a revoked or declined one, active moderators can not be invited again
+func (s *shopModeratorRepoImpl) Invite(db *mongo.Database, moderator *models.ShopModerator) (*models.ShopModerator, error) {
+	moderatorCollection := db.Collection(moderator.CollectionName())
+	query := bson.M{"shopId": moderator.ShopID, "phone": moderator.Phone}
+	existing := &models.ShopModerator{}
+	err := moderatorCollection.FindOne(context.Background(), query).Decode(existing)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+	if err == nil && existing.Status == constants.Active {
+		return nil, errors.NewError(string(codes.ModeratorAlreadyExist))
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"shopName":    moderator.ShopName,
+			"permissions": moderator.Permissions,
+			"status":      constants.Invited,
+			"invitedBy":   moderator.InvitedBy,
+			"updatedAt":   moderator.UpdatedAt,
+		},
+		"$unset":       bson.M{"merchantId": "", "acceptedAt": ""},
+		"$setOnInsert": bson.M{"_id": moderator.ID, "createdAt": moderator.CreatedAt},
+	}
+	after := options.After
+	upsert := true
+	opts := options.FindOneAndUpdateOptions{ReturnDocument: &after, Upsert: &upsert}
+	result := &models.ShopModerator{}
+	if err := moderatorCollection.FindOneAndUpdate(context.Background(), query, update, &opts).Decode(result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *shopModeratorRepoImpl) Moderators(db *mongo.Database, shopID primitive.ObjectID) (*[]models.ShopModerator, error) {
+	moderatorCollection := db.Collection(models.ShopModerator{}.CollectionName())
+	query := bson.M{"shopId": shopID, "status": liveModerator}
+	opts := options.Find().SetSort(bson.M{"_id": -1})
+	cursor, err := moderatorCollection.Find(context.Background(), query, opts)
+	if err != nil {
+		return nil, err
+	}
+	var moderators []models.ShopModerator
+	if err = cursor.All(context.Background(), &moderators); err != nil {
+		return nil, err
+	}
+	return &moderators, nil
+}
+
+func (s *shopModeratorRepoImpl) UpdatePermissions(db *mongo.Database, shopID, ID primitive.ObjectID, permissions []constants.ShopPermission) (*models.ShopModerator, error) {
+	moderatorCollection := db.Collection(models.ShopModerator{}.CollectionName())
+	query := bson.M{"_id": ID, "shopId": shopID, "status": liveModerator}
+	update := bson.M{"$set": bson.M{"permissions": permissions, "updatedAt": time.Now().UTC()}}
+	after := options.After
+	opts := options.FindOneAndUpdateOptions{ReturnDocument: &after}
+	moderator := &models.ShopModerator{}
+	if err := moderatorCollection.FindOneAndUpdate(context.Background(), query, update, &opts).Decode(moderator); err != nil {
+		return nil, err
+	}
+	return moderator, nil
+}
+
+// Revoke cancels an invitation or removes an active moderator from the shop
+func (s *shopModeratorRepoImpl) Revoke(db *mongo.Database, shopID, ID primitive.ObjectID) (*models.ShopModerator, error) {
+	wc := writeconcern.New(writeconcern.WMajority())
+	rc := readconcern.Snapshot()
+	txnOpts := options.Transaction().SetWriteConcern(wc).SetReadConcern(rc)
+	session, err := db.Client().StartSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.EndSession(context.Background())
+
+	callBack := func(sessionCtx mongo.SessionContext) (interface{}, error) {
+		moderatorCollection := db.Collection(models.ShopModerator{}.CollectionName())
+		query := bson.M{"_id": ID, "shopId": shopID, "status": liveModerator}
+		update := bson.M{"$set": bson.M{"status": constants.Revoked, "updatedAt": time.Now().UTC()}}
+		after := options.After
+		opts := options.FindOneAndUpdateOptions{ReturnDocument: &after}
+		moderator := &models.ShopModerator{}
+		if err := moderatorCollection.FindOneAndUpdate(sessionCtx, query, update, &opts).Decode(moderator); err != nil {
+			return nil, err
+		}
+		if moderator.MerchantID != nil {
+			shopCollection := db.Collection(models.Shop{}.CollectionName())
+			pull := bson.M{"$pull": bson.M{"moderators": *moderator.MerchantID}}
+			if _, err := shopCollection.UpdateOne(sessionCtx, bson.M{"_id": shopID}, pull); err != nil {
+				return nil, err
+			}
+		}
+		return moderator, nil
+	}
+	result, err := session.WithTransaction(context.Background(), callBack, txnOpts)
+	if err != nil {
+		return nil, err
+	}
+	return result.(*models.ShopModerator), nil
+}
+
+// Invitations returns pending invitations sent to the phone
+func (s *shopModeratorRepoImpl) Invitations(db *mongo.Database, phone string) (*[]models.ShopModerator, error) {
+	moderatorCollection := db.Collection(models.ShopModerator{}.CollectionName())
+	query := bson.M{"phone": phone, "status": constants.Invited}
+	cursor, err := moderatorCollection.Find(context.Background(), query)
+	if err != nil {
+		return nil, err
+	}
+	var invitations []models.ShopModerator
+	if err = cursor.All(context.Background(), &invitations); err != nil {
+		return nil, err
+	}
+	return &invitations, nil
+}
+
+// Respond accepts or declines a pending invitation of the phone, accepted moderators are added to the shop
+func (s *shopModeratorRepoImpl) Respond(db *mongo.Database, ID, merchantID primitive.ObjectID, phone string, accept bool) (*models.ShopModerator, error) {
+	wc := writeconcern.New(writeconcern.WMajority())
+	rc := readconcern.Snapshot()
+	txnOpts := options.Transaction().SetWriteConcern(wc).SetReadConcern(rc)
+	session, err := db.Client().StartSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.EndSession(context.Background())
+
+	callBack := func(sessionCtx mongo.SessionContext) (interface{}, error) {
+		moderatorCollection := db.Collection(models.ShopModerator{}.CollectionName())
+		query := bson.M{"_id": ID, "phone": phone, "status": constants.Invited}
+		set := bson.M{"status": constants.Declined, "updatedAt": time.Now().UTC()}
+		if accept {
+			set["status"] = constants.Active
+			set["merchantId"] = merchantID
+			set["acceptedAt"] = time.Now().UTC()
+		}
+		after := options.After
+		opts := options.FindOneAndUpdateOptions{ReturnDocument: &after}
+		moderator := &models.ShopModerator{}
+		if err := moderatorCollection.FindOneAndUpdate(sessionCtx, query, bson.M{"$set": set}, &opts).Decode(moderator); err != nil {
+			return nil, err
+		}
+		if accept {
+			shopCollection := db.Collection(models.Shop{}.CollectionName())
+			add := bson.M{"$addToSet": bson.M{"moderators": merchantID}}
+			if _, err := shopCollection.UpdateOne(sessionCtx, bson.M{"_id": moderator.ShopID}, add); err != nil {
+				return nil, err
+			}
+		}
+		return moderator, nil
+	}
+	result, err := session.WithTransaction(context.Background(), callBack, txnOpts)
+	if err != nil {
+		return nil, err
+	}
+	return result.(*models.ShopModerator), nil
+}
+
+func (s *shopModeratorRepoImpl) ActiveModerator(db *mongo.Database, shopID, merchantID primitive.ObjectID) (*models.ShopModerator, error) {
+	moderatorCollection := db.Collection(models.ShopModerator{}.CollectionName())
+	query := bson.M{"shopId": shopID, "merchantId": merchantID, "status": constants.Active}
+	moderator := &models.ShopModerator{}
+	if err := moderatorCollection.FindOne(context.Background(), query).Decode(moderator); err != nil {
+		return nil, err
+	}
+	return moderator, nil
+}
+
+// ModeratorByPhone returns moderator of the shop with the phone in any status
+func (s *shopModeratorRepoImpl) ModeratorByPhone(db *mongo.Database, shopID primitive.ObjectID, phone string) (*models.ShopModerator, error) {
+	moderatorCollection := db.Collection(models.ShopModerator{}.CollectionName())
+	query := bson.M{"shopId": shopID, "phone": phone}
+	moderator := &models.ShopModerator{}
+	if err := moderatorCollection.FindOne(context.Background(), query).Decode(moderator); err != nil {
+		return nil, err
+	}
+	return moderator, nil
+}
@@ -2,14 +2,17 @@ package data
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"math"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/mitchellh/mapstructure"
 	"github.com/techartificer/swiftex/constants"
 	"github.com/techartificer/swiftex/constants/codes"
+	"github.com/techartificer/swiftex/lib/charge"
 	"github.com/techartificer/swiftex/lib/errors"
 	"github.com/techartificer/swiftex/lib/password"
 	"github.com/techartificer/swiftex/lib/random"
@@ -25,9 +28,9 @@ import (
 
 type TransactionRepository interface {
 	TransactionByShopId(db *mongo.Database, shopID string) (*map[string]interface{}, error)
-	AddTrxHistory(db *mongo.Database, trxHistory *models.TrxHistory) (*map[string]interface{}, error)
+	AddTrxHistory(db *mongo.Database, trxHistory *models.TrxHistory, proof *models.DeliveryProof, deliveredItems int, otpHash string) (*map[string]interface{}, error)
 	GenerateTrxCode(db *mongo.Database, amount int64, shopID string) (*string, error)
-	CashOutRequests(db *mongo.Database, lastID string) (*[]serializer.CashOutRequests, error)
+	CashOutRequests(db *mongo.Database, lastID string, shopIDs []primitive.ObjectID) (*[]serializer.CashOutRequests, error)
 	CashOut(db *mongo.Database, _createdBy primitive.ObjectID, trxID, trxCode string) (*models.Transaction, error)
 }
 
@@ -88,6 +91,9 @@ func (t *transactionRepoImpl) CashOut(db *mongo.Database, _createdBy primitive.O
 		if trx.Balance < float64(cashOutAmount) {
 			return nil, errors.NewError(string(codes.InsufficientBalance))
 		}
+		if err := ensureShopAccount(sessionCtx, db, trx); err != nil {
+			return nil, err
+		}
 
 		filter := bson.M{"_id": _trxID}
 		update := bson.M{
@@ -112,6 +118,31 @@ func (t *transactionRepoImpl) CashOut(db *mongo.Database, _createdBy primitive.O
 		}
 		trxHistoryCollection := db.Collection(trxHistory.CollectionName())
 		if _, err1 := trxHistoryCollection.InsertOne(sessionCtx, trxHistory); err1 != nil {
+			return nil, err1
+		}
+		entry := &models.JournalEntry{
+			ShopID:       &trx.ShopID,
+			TrxHistoryID: &trxHistory.ID,
+			Description:  "Cash out",
+			CreatedBy:    &_createdBy,
+			CreatedAt:    trxHistory.CreatedAt,
+			Lines: []models.JournalLine{
+				debitLine(models.ShopReceivable, &trx.ShopID, float64(cashOutAmount), "Paid to shop"),
+				creditLine(models.PlatformCash, nil, float64(cashOutAmount), "Paid to shop"),
+			},
+		}
+		if err := postJournal(sessionCtx, db, entry); err != nil {
+			return nil, err
+		}
+		cashOut := models.CashOutCompletedPayload{
+			TransactionID: trx.ID,
+			TrxHistoryID:  trxHistory.ID,
+			ShopID:        trx.ShopID,
+			Amount:        cashOutAmount,
+			Balance:       trx.Balance,
+			Time:          trxHistory.CreatedAt,
+		}
+		if err := publishEvent(sessionCtx, db, models.CashOutCompleted, &trx.ShopID, nil, cashOut); err != nil {
 			return nil, err
 		}
 		return trx, nil
@@ -125,9 +156,14 @@ func (t *transactionRepoImpl) CashOut(db *mongo.Database, _createdBy primitive.O
 	return &trx, nil
 }
 
-func (t *transactionRepoImpl) CashOutRequests(db *mongo.Database, lastID string) (*[]serializer.CashOutRequests, error) {
+// CashOutRequests returns cash out requests with the active payout method of each shop,
+// requests are restricted to shops if shopIDs is not nil
+func (t *transactionRepoImpl) CashOutRequests(db *mongo.Database, lastID string, shopIDs []primitive.ObjectID) (*[]serializer.CashOutRequests, error) {
 	query := make(bson.M)
 	query["amount"] = bson.M{"$gt": 0}
+	if shopIDs != nil {
+		query["shopId"] = bson.M{"$in": shopIDs}
+	}
 	if lastID != "" {
 		_lastID, err := primitive.ObjectIDFromHex(lastID)
 		if err != nil {
@@ -140,9 +176,23 @@ func (t *transactionRepoImpl) CashOutRequests(db *mongo.Database, lastID string)
 	lookupStage := bson.D{{"$lookup", bson.D{{"from", "shops"}, {"localField", "shopId"}, {"foreignField", "_id"}, {"as", "shop"}}}}
 	unwindStage := bson.D{{"$unwind", bson.D{{"path", "$shop"}, {"preserveNullAndEmptyArrays", false}}}}
 	sortStage := bson.D{{"$sort", bson.D{{"updatedAt", -1}}}}
+	methodLookupStage := bson.D{{Key: "$lookup", Value: bson.M{
+		"from": models.PayoutMethod{}.CollectionName(),
+		"let":  bson.M{"shopId": "$shopId"},
+		"pipeline": mongo.Pipeline{
+			bson.D{{Key: "$match", Value: bson.M{"$expr": bson.M{"$and": bson.A{
+				bson.M{"$eq": bson.A{"$shopId", "$$shopId"}},
+				bson.M{"$eq": bson.A{"$status", constants.Active}},
+			}}}}},
+			bson.D{{Key: "$limit", Value: 1}},
+		},
+		"as": "payoutMethod",
+	}}}
+	methodUnwindStage := bson.D{{Key: "$unwind", Value: bson.M{"path": "$payoutMethod", "preserveNullAndEmptyArrays": true}}}
 
 	trxCollection := db.Collection(models.Transaction{}.CollectionName())
-	cursor, err := trxCollection.Aggregate(context.Background(), mongo.Pipeline{matchStage, limitStage, lookupStage, unwindStage, sortStage})
+	pipeline := mongo.Pipeline{matchStage, limitStage, lookupStage, unwindStage, methodLookupStage, methodUnwindStage, sortStage}
+	cursor, err := trxCollection.Aggregate(context.Background(), pipeline)
 	if err != nil {
 		return nil, err
 	}
@@ -153,7 +203,12 @@ func (t *transactionRepoImpl) CashOutRequests(db *mongo.Database, lastID string)
 	return &transactions, nil
 }
 
-func (t *transactionRepoImpl) AddTrxHistory(db *mongo.Database, trxHistory *models.TrxHistory) (*map[string]interface{}, error) {
+// AddTrxHistory delivers an order by its assigned rider and credits the collected amount to its shop, deliveredItems is the
+// number of items the recipient accepted, 0 means all. Delivery charge of a partial delivery is taken
+// for the delivered items only. Refused items of a partial delivery and items collected on an exchange
+// go back to the shop in a linked return order. otpHash is the delivery code verified for the delivery,
+// it must still be the code of the order and it is consumed with the delivery
+func (t *transactionRepoImpl) AddTrxHistory(db *mongo.Database, trxHistory *models.TrxHistory, proof *models.DeliveryProof, deliveredItems int, otpHash string) (*map[string]interface{}, error) {
 	// TODO: have to add charge update [admin can change charge]
 
 	wc := writeconcern.New(writeconcern.WMajority())
@@ -165,35 +220,76 @@ func (t *transactionRepoImpl) AddTrxHistory(db *mongo.Database, trxHistory *mode
 	}
 	defer session.EndSession(context.Background())
 	callBack := func(sessionCtx mongo.SessionContext) (interface{}, error) {
-		shop := models.Shop{}
-		shopCollection := db.Collection(shop.CollectionName())
-		if err := shopCollection.FindOne(sessionCtx, bson.M{"_id": trxHistory.ShopID}).Decode(&shop); err != nil {
-			if mongo.ErrNoDocuments == err {
-				return nil, errors.NewError(string(codes.ShopNotFound))
-			}
-			return nil, err
-		}
 		after := options.After
 		opt := options.FindOneAndUpdateOptions{
 			ReturnDocument: &after,
 		}
 		order := models.Order{}
 		orderCollection := db.Collection(order.CollectionName())
-		query := bson.M{"_id": trxHistory.OrderID}
+		// the shop credited is the shop of the order, an order of another shop is not found
+		query := bson.M{"_id": trxHistory.OrderID, "shopId": trxHistory.ShopID}
 		if err := orderCollection.FindOne(sessionCtx, query).Decode(&order); err != nil {
 			return nil, err
 		}
+		// collected cash is booked to the rider delivering, only the assigned rider can deliver
+		if order.RiderID == nil || *order.RiderID != trxHistory.CreatedBy {
+			return nil, errors.NewError(string(codes.OrderNotAssignedToRider))
+		}
+		shop := models.Shop{}
+		shopCollection := db.Collection(shop.CollectionName())
+		if err := shopCollection.FindOne(sessionCtx, bson.M{"_id": order.ShopID}).Decode(&shop); err != nil {
+			if mongo.ErrNoDocuments == err {
+				return nil, errors.NewError(string(codes.ShopNotFound))
+			}
+			return nil, err
+		}
 		if order.DeliveredAt != nil {
 			return nil, errors.NewError(string(codes.OrderAlreadyDelevired))
 		}
 		if !order.IsAccepted {
 			return nil, errors.NewError(string(codes.OrderNotAcceptedYet))
 		}
+		if err := checkTransition(&order, constants.Delivered); err != nil {
+			return nil, err
+		}
+		if proof.OTPVerified && (otpHash == "" || order.DeliveryOTP != otpHash) {
+			return nil, errors.NewError(string(codes.InvalidDeliveryOTP))
+		}
+		if err := checkDeliveryProof(shop.ProofPolicy, proof); err != nil {
+			return nil, err
+		}
+		items := order.NumberOfItems
+		if items <= 0 {
+			items = 1
+		}
+		delivered := deliveredItems
+		if delivered == 0 {
+			delivered = items
+		}
+		if delivered > items {
+			return nil, errors.NewError(string(codes.InvalidDeliveredItems))
+		}
+		refused := items - delivered
+		if order.PaymentStatus == constants.COD && trxHistory.Payment > order.Price {
+			return nil, errors.NewError(string(codes.InvalidCollectedAmount))
+		}
+		var returnOrderID *primitive.ObjectID
+		if refused > 0 || order.ExchangeItems > 0 {
+			ID := primitive.NewObjectID()
+			returnOrderID = &ID
+		}
 
 		t := time.Now().UTC() // time
+		text := constants.DeleveredMsg
+		if refused > 0 {
+			text = constants.PartialMsg
+			if trxHistory.Remarks == "" {
+				trxHistory.Remarks = fmt.Sprintf("Delivered %d of %d items", delivered, items)
+			}
+		}
 		OrderStatus := models.OrderStatus{
 			ID:            primitive.NewObjectID(),
-			Text:          constants.DeleveredMsg,
+			Text:          text,
 			Status:        constants.Delivered,
 			DeleveryBoyID: &trxHistory.CreatedBy,
 			AdminID:       &trxHistory.CreatedBy,
@@ -201,26 +297,69 @@ func (t *transactionRepoImpl) AddTrxHistory(db *mongo.Database, trxHistory *mode
 		}
 		orderStatusArray := []models.OrderStatus{OrderStatus}
 		push := bson.M{"status": bson.M{"$each": orderStatusArray, "$position": 0}}
+		set := bson.M{
+			"deliveredAt":    &t,
+			"currentStatus":  constants.Delivered,
+			"proof":          proof,
+			"deliveredItems": delivered,
+		}
+		if returnOrderID != nil {
+			set["returnOrderId"] = returnOrderID
+		}
 		update := bson.M{
-			"$set": bson.M{
-				"deliveredAt":   &t,
-				"currentStatus": constants.Delivered,
-			},
-			"$push": push,
+			"$set":   set,
+			"$unset": bson.M{"deliveryOtp": "", "deliveryOtpExpiresAt": "", "deliveryOtpAttempts": ""},
+			"$push":  push,
 		}
-		if err := orderCollection.FindOneAndUpdate(sessionCtx, query, update, &opt).Decode(&order); err != nil {
+		filter := bson.M{"_id": trxHistory.OrderID, "currentStatus": order.CurrentStatus}
+		if proof.OTPVerified {
+			filter["deliveryOtp"] = otpHash
+		}
+		if err := orderCollection.FindOneAndUpdate(sessionCtx, filter, update, &opt).Decode(&order); err != nil {
+			if mongo.ErrNoDocuments == err {
+				return nil, errors.NewError(string(codes.InvalidStatusTransition))
+			}
+			return nil, err
+		}
+		if err := publishOrderStatus(sessionCtx, db, &order, &OrderStatus); err != nil {
 			return nil, err
 		}
-		trxHistory.Payment -= order.Charge
+		riderParcelCollection := db.Collection(models.RiderParcel{}.CollectionName())
+		parcelFilter := bson.M{
+			"orderId": order.ID,
+			"riderId": trxHistory.CreatedBy,
+			"status":  bson.M{"$in": []string{constants.Assigned, constants.Accepted, constants.Picked}},
+		}
+		parcelUpdate := bson.M{"$set": bson.M{"status": constants.Delivered, "updatedAt": t}}
+		if _, err := riderParcelCollection.UpdateMany(sessionCtx, parcelFilter, parcelUpdate); err != nil {
+			return nil, err
+		}
+		collected := trxHistory.Payment
+		deliveryCharge := partialDeliveryCharge(order.Charge, delivered, items)
+		codFee := 0.0
 		if order.PaymentStatus == constants.COD {
-			// order.Price (aka total payable) is sum of product price and delivery charge
-			// subtracting delivery charge from total payable, cause total payable only applicable on product price
-			percent := math.Floor((order.Price-order.Charge)/100) * shop.COD
-			trxHistory.Payment -= percent
+			if refused > 0 {
+				codFee = partialCODCharge(&order, &shop, collected, deliveryCharge)
+			} else if order.RateCardID != nil {
+				// cod charge has been calculated from rate card while creating order
+				codFee = order.CODCharge
+			} else {
+				codFee = charge.CODCharge(order.Price, order.Charge, shop.COD)
+			}
 		}
+		trxHistory.Payment = collected - deliveryCharge - codFee
 		trx := &models.Transaction{}
 		trxCollection := db.Collection(trx.CollectionName())
-		filter := bson.M{"shopId": trxHistory.ShopID}
+		filter = bson.M{"shopId": trxHistory.ShopID}
+		if err := trxCollection.FindOne(sessionCtx, filter).Decode(trx); err != nil {
+			if mongo.ErrNoDocuments == err {
+				return nil, errors.NewError(string(codes.TransactionNotFound))
+			}
+			return nil, err
+		}
+		if err := ensureShopAccount(sessionCtx, db, trx); err != nil {
+			return nil, err
+		}
 		update = bson.M{
 			"$inc": bson.M{"balance": trxHistory.Payment},
 			"$set": bson.M{"updatedAt": t},
@@ -233,10 +372,65 @@ func (t *transactionRepoImpl) AddTrxHistory(db *mongo.Database, trxHistory *mode
 			return nil, err
 		}
 		trxHistory.TrxID = trx.ID
+		trxHistory.Collected = collected
 		trxHistory.CreatedAt = t
 
 		trxHistoryCollection := db.Collection(trxHistory.CollectionName())
 		if _, err1 := trxHistoryCollection.InsertOne(sessionCtx, trxHistory); err1 != nil {
+			return nil, err1
+		}
+		entry := &models.JournalEntry{
+			ShopID:       &trxHistory.ShopID,
+			OrderID:      trxHistory.OrderID,
+			TrxHistoryID: &trxHistory.ID,
+			Description:  "Parcel delivered",
+			CreatedBy:    &trxHistory.CreatedBy,
+			CreatedAt:    t,
+			Lines: []models.JournalLine{
+				debitLine(models.RiderCashInHand, &trxHistory.CreatedBy, collected, "Collected from recipient"),
+				creditLine(models.PlatformRevenue, nil, deliveryCharge, "Delivery charge"),
+				creditLine(models.CODFee, nil, codFee, "COD fee"),
+				creditLine(models.ShopReceivable, &trxHistory.ShopID, trxHistory.Payment, "Payable to shop"),
+			},
+		}
+		if err := postJournal(sessionCtx, db, entry); err != nil {
+			return nil, err
+		}
+		if returnOrderID != nil {
+			reasons := []string{}
+			if refused > 0 {
+				reasons = append(reasons, constants.RefusedMsg)
+			}
+			if order.ExchangeItems > 0 {
+				reasons = append(reasons, constants.ExchangeMsg)
+			}
+			if _, err := spawnReturnOrder(sessionCtx, db, *returnOrderID, &order, refused+order.ExchangeItems, strings.Join(reasons, ", "), trxHistory.CreatedBy); err != nil {
+				return nil, err
+			}
+		}
+		deliveredPayload := models.ParcelDeliveredPayload{
+			OrderID:   order.ID,
+			TrackID:   order.TrackID,
+			ShopID:    order.ShopID,
+			RiderID:   trxHistory.CreatedBy,
+			Collected: collected,
+			Charge:    deliveryCharge,
+			CODCharge: codFee,
+			Time:      t,
+		}
+		if err := publishEvent(sessionCtx, db, models.ParcelDelivered, &order.ShopID, &order.ID, deliveredPayload); err != nil {
+			return nil, err
+		}
+		credited := models.BalanceCreditedPayload{
+			TransactionID: trx.ID,
+			TrxHistoryID:  trxHistory.ID,
+			ShopID:        trxHistory.ShopID,
+			OrderID:       trxHistory.OrderID,
+			Amount:        trxHistory.Payment,
+			Balance:       trx.Balance,
+			Time:          t,
+		}
+		if err := publishEvent(sessionCtx, db, models.BalanceCredited, &trxHistory.ShopID, trxHistory.OrderID, credited); err != nil {
 			return nil, err
 		}
 		ret := trxOrder{Trx: trx, Order: &order}
@@ -259,6 +453,27 @@ func (t *transactionRepoImpl) AddTrxHistory(db *mongo.Database, trxHistory *mode
 	return &ret, nil
 }
 
+// partialDeliveryCharge returns delivery charge of the delivered items of a parcel
+func partialDeliveryCharge(charge float64, delivered, items int) float64 {
+	if delivered >= items {
+		return charge
+	}
+	return roundAmount(charge * float64(delivered) / float64(items))
+}
+
+// partialCODCharge returns cash on delivery fee of a partially delivered parcel,
+// the fee is taken on the collected amount less delivery charge of the delivered items
+func partialCODCharge(order *models.Order, shop *models.Shop, collected, deliveryCharge float64) float64 {
+	if order.RateCardID != nil {
+		if order.Price <= order.Charge {
+			return 0
+		}
+		// cod charge of a rate card is a percent of product price
+		return roundAmount(order.CODCharge * math.Max(collected-deliveryCharge, 0) / (order.Price - order.Charge))
+	}
+	return math.Max(charge.CODCharge(collected, deliveryCharge, shop.COD), 0)
+}
+
 func (t *transactionRepoImpl) TransactionByShopId(db *mongo.Database, shopID string) (*map[string]interface{}, error) {
 	_shopID, err := primitive.ObjectIDFromHex(shopID)
 	if err != nil {
@@ -351,9 +566,18 @@ func (t *transactionRepoImpl) GenerateTrxCode(db *mongo.Database, amount int64,
 			"amount":           amount,
 			"updatedAt":        time.Now().UTC(),
 		}}
-		if err1 := trxCollection.FindOneAndUpdate(sessionCtx, query, update, &opt).Decode(trx); err != nil {
+		if err1 := trxCollection.FindOneAndUpdate(sessionCtx, query, update, &opt).Decode(trx); err1 != nil {
 			return nil, err1
 		}
+		requested := models.CashOutRequestedPayload{
+			TransactionID: trx.ID,
+			ShopID:        trx.ShopID,
+			Amount:        amount,
+			ExpiresAt:     expiresAt,
+		}
+		if err := publishEvent(sessionCtx, db, models.CashOutRequested, &trx.ShopID, nil, requested); err != nil {
+			return nil, err
+		}
 		return trxCode, nil
 	}
 	result, err := session.WithTransaction(context.Background(), callBack, txnOpts)
@@ -0,0 +1,51 @@
+package data
+
+import (
+	"testing"
+
+	"github.com/techartificer/swiftex/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestPartialDeliveryCharge(t *testing.T) {
+	tests := []struct {
+		charge           float64
+		delivered, items int
+		want             float64
+	}{
+		{60, 3, 3, 60},
+		{60, 1, 1, 60},
+		{60, 1, 3, 20},
+		{100, 2, 3, 66.67},
+		{60, 0, 3, 0},
+	}
+	for _, tt := range tests {
+		if got := partialDeliveryCharge(tt.charge, tt.delivered, tt.items); got != tt.want {
+			t.Errorf("partialDeliveryCharge(%v, %d, %d) = %v, want %v", tt.charge, tt.delivered, tt.items, got, tt.want)
+		}
+	}
+}
+
+func TestPartialCODCharge(t *testing.T) {
+	rateCardID := primitive.NewObjectID()
+	shop := &models.Shop{COD: 1}
+	tests := []struct {
+		name           string
+		order          models.Order
+		collected      float64
+		deliveryCharge float64
+		want           float64
+	}{
+		{"legacy half collected", models.Order{Price: 2060, Charge: 60}, 1040, 40, 10},
+		{"legacy nothing collected", models.Order{Price: 2060, Charge: 60}, 0, 20, 0},
+		{"rate card half collected", models.Order{Price: 2060, Charge: 60, CODCharge: 20, RateCardID: &rateCardID}, 1030, 30, 10},
+		{"rate card all collected", models.Order{Price: 2060, Charge: 60, CODCharge: 20, RateCardID: &rateCardID}, 2060, 60, 20},
+		{"rate card less than charge", models.Order{Price: 2060, Charge: 60, CODCharge: 20, RateCardID: &rateCardID}, 10, 20, 0},
+		{"rate card price within charge", models.Order{Price: 50, Charge: 60, CODCharge: 20, RateCardID: &rateCardID}, 50, 60, 0},
+	}
+	for _, tt := range tests {
+		if got := partialCODCharge(&tt.order, shop, tt.collected, tt.deliveryCharge); got != tt.want {
+			t.Errorf("%s: partialCODCharge() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,294 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/techartificer/swiftex/constants"
+	"github.com/techartificer/swiftex/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type WebhookRepository interface {
+	Create(db *mongo.Database, webhook *models.Webhook) error
+	Enqueue(db *mongo.Database, event *models.Event, webhookEvent string, data interface{}) error
+	Webhooks(db *mongo.Database, shopID string) (*[]models.Webhook, error)
+	Update(db *mongo.Database, webhook *models.Webhook, ID, shopID string) (*models.Webhook, error)
+	Delete(db *mongo.Database, ID, shopID string) error
+	Deliveries(db *mongo.Database, shopID, status, lastID string) (*[]models.WebhookDelivery, error)
+	Replay(db *mongo.Database, ID, shopID string) (*models.WebhookDelivery, error)
+	ClaimDelivery(db *mongo.Database, lease time.Duration) (*models.WebhookDelivery, error)
+	WebhookByID(db *mongo.Database, ID primitive.ObjectID) (*models.Webhook, error)
+	DeliverySucceeded(db *mongo.Database, ID primitive.ObjectID, statusCode int) error
+	DeliveryFailed(db *mongo.Database, ID primitive.ObjectID, statusCode int, reason string, nextAttemptAt *time.Time) error
+}
+
+type webhookRepoImpl struct{}
+
+var webhookRepo WebhookRepository
+
+func NewWebhookRepo() WebhookRepository {
+	if webhookRepo == nil {
+		webhookRepo = &webhookRepoImpl{}
+	}
+	return webhookRepo
+}
+
+type webhookPayload struct {
+	ID        primitive.ObjectID `json:"id"`
+	Event     string             `json:"event"`
+	CreatedAt time.Time          `json:"createdAt"`
+	Data      interface{}        `json:"data"`
+}
+
+// Enqueue queues a delivery of event for every active webhook of shop subscribed to webhookEvent,
+// an event is queued only once per webhook even if it is dispatched again
+func (w *webhookRepoImpl) Enqueue(db *mongo.Database, event *models.Event, webhookEvent string, data interface{}) error {
+	if event.ShopID == nil {
+		return nil
+	}
+	webhookCollection := db.Collection(models.Webhook{}.CollectionName())
+	query := bson.M{
+		"shopId": event.ShopID,
+		"status": constants.Active,
+		"$or": []bson.M{
+			{"events": webhookEvent},
+			{"events": bson.M{"$size": 0}},
+		},
+	}
+	cursor, err := webhookCollection.Find(context.Background(), query)
+	if err != nil {
+		return err
+	}
+	var webhooks []models.Webhook
+	if err := cursor.All(context.Background(), &webhooks); err != nil {
+		return err
+	}
+	// event id is sent as payload id so that receivers can drop duplicates
+	payload, err := json.Marshal(webhookPayload{ID: event.ID, Event: webhookEvent, CreatedAt: event.CreatedAt, Data: data})
+	if err != nil {
+		return err
+	}
+	deliveryCollection := db.Collection(models.WebhookDelivery{}.CollectionName())
+	t := time.Now().UTC()
+	for _, webhook := range webhooks {
+		delivery := models.WebhookDelivery{
+			ID:            primitive.NewObjectID(),
+			WebhookID:     webhook.ID,
+			EventID:       event.ID,
+			ShopID:        *event.ShopID,
+			Event:         webhookEvent,
+			Payload:       string(payload),
+			Status:        models.DeliveryPending,
+			NextAttemptAt: t,
+			CreatedAt:     t,
+			UpdatedAt:     t,
+		}
+		filter := bson.M{"eventId": event.ID, "webhookId": webhook.ID}
+		opts := options.Update().SetUpsert(true)
+		if _, err := deliveryCollection.UpdateOne(context.Background(), filter, bson.M{"$setOnInsert": delivery}, opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *webhookRepoImpl) Create(db *mongo.Database, webhook *models.Webhook) error {
+	webhookCollection := db.Collection(webhook.CollectionName())
+	_, err := webhookCollection.InsertOne(context.Background(), webhook)
+	return err
+}
+
+func (w *webhookRepoImpl) Webhooks(db *mongo.Database, shopID string) (*[]models.Webhook, error) {
+	_shopID, err := primitive.ObjectIDFromHex(shopID)
+	if err != nil {
+		return nil, err
+	}
+	webhookCollection := db.Collection(models.Webhook{}.CollectionName())
+	opts := options.Find().SetSort(bson.M{"_id": -1})
+	cursor, err := webhookCollection.Find(context.Background(), bson.M{"shopId": _shopID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	var webhooks []models.Webhook
+	if err = cursor.All(context.Background(), &webhooks); err != nil {
+		return nil, err
+	}
+	return &webhooks, nil
+}
+
+func (w *webhookRepoImpl) Update(db *mongo.Database, webhook *models.Webhook, ID, shopID string) (*models.Webhook, error) {
+	_id, err := primitive.ObjectIDFromHex(ID)
+	if err != nil {
+		return nil, err
+	}
+	_shopID, err := primitive.ObjectIDFromHex(shopID)
+	if err != nil {
+		return nil, err
+	}
+	webhookCollection := db.Collection(webhook.CollectionName())
+	after := options.After
+	opt := options.FindOneAndUpdateOptions{
+		ReturnDocument: &after,
+	}
+	set := bson.M{"updatedAt": time.Now().UTC()}
+	if webhook.URL != "" {
+		set["url"] = webhook.URL
+	}
+	if webhook.Events != nil {
+		set["events"] = webhook.Events
+	}
+	if webhook.Status != "" {
+		set["status"] = webhook.Status
+	}
+	if webhook.Secret != "" {
+		set["secret"] = webhook.Secret
+	}
+	filter := bson.M{"_id": _id, "shopId": _shopID}
+	updatedWebhook := &models.Webhook{}
+	err = webhookCollection.FindOneAndUpdate(context.Background(), filter, bson.M{"$set": set}, &opt).Decode(updatedWebhook)
+	return updatedWebhook, err
+}
+
+func (w *webhookRepoImpl) Delete(db *mongo.Database, ID, shopID string) error {
+	_id, err := primitive.ObjectIDFromHex(ID)
+	if err != nil {
+		return err
+	}
+	_shopID, err := primitive.ObjectIDFromHex(shopID)
+	if err != nil {
+		return err
+	}
+	webhookCollection := db.Collection(models.Webhook{}.CollectionName())
+	result, err := webhookCollection.DeleteOne(context.Background(), bson.M{"_id": _id, "shopId": _shopID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (w *webhookRepoImpl) Deliveries(db *mongo.Database, shopID, status, lastID string) (*[]models.WebhookDelivery, error) {
+	_shopID, err := primitive.ObjectIDFromHex(shopID)
+	if err != nil {
+		return nil, err
+	}
+	query := bson.M{"shopId": _shopID}
+	if status != "" {
+		query["status"] = status
+	}
+	if lastID != "" {
+		_lastID, err := primitive.ObjectIDFromHex(lastID)
+		if err != nil {
+			return nil, err
+		}
+		query["_id"] = bson.M{"$lt": _lastID}
+	}
+	deliveryCollection := db.Collection(models.WebhookDelivery{}.CollectionName())
+	opts := options.Find().SetSort(bson.M{"_id": -1}).SetLimit(15)
+	cursor, err := deliveryCollection.Find(context.Background(), query, opts)
+	if err != nil {
+		return nil, err
+	}
+	var deliveries []models.WebhookDelivery
+	if err = cursor.All(context.Background(), &deliveries); err != nil {
+		return nil, err
+	}
+	return &deliveries, nil
+}
+
+// Replay queues a delivery again with a fresh set of attempts
+func (w *webhookRepoImpl) Replay(db *mongo.Database, ID, shopID string) (*models.WebhookDelivery, error) {
+	_id, err := primitive.ObjectIDFromHex(ID)
+	if err != nil {
+		return nil, err
+	}
+	_shopID, err := primitive.ObjectIDFromHex(shopID)
+	if err != nil {
+		return nil, err
+	}
+	deliveryCollection := db.Collection(models.WebhookDelivery{}.CollectionName())
+	after := options.After
+	opt := options.FindOneAndUpdateOptions{
+		ReturnDocument: &after,
+	}
+	t := time.Now().UTC()
+	filter := bson.M{"_id": _id, "shopId": _shopID}
+	update := bson.M{
+		"$set": bson.M{
+			"status":        models.DeliveryPending,
+			"attempts":      0,
+			"nextAttemptAt": t,
+			"updatedAt":     t,
+		},
+		"$unset": bson.M{"lastError": "", "lastStatusCode": "", "deliveredAt": ""},
+	}
+	delivery := &models.WebhookDelivery{}
+	err = deliveryCollection.FindOneAndUpdate(context.Background(), filter, update, &opt).Decode(delivery)
+	return delivery, err
+}
+
+// ClaimDelivery picks a due delivery and postpones it by lease,
+// so that it isn't sent twice while being processed
+func (w *webhookRepoImpl) ClaimDelivery(db *mongo.Database, lease time.Duration) (*models.WebhookDelivery, error) {
+	deliveryCollection := db.Collection(models.WebhookDelivery{}.CollectionName())
+	t := time.Now().UTC()
+	filter := bson.M{"status": models.DeliveryPending, "nextAttemptAt": bson.M{"$lte": t}}
+	update := bson.M{"$set": bson.M{"nextAttemptAt": t.Add(lease)}}
+	opt := options.FindOneAndUpdate().SetSort(bson.M{"nextAttemptAt": 1})
+	delivery := &models.WebhookDelivery{}
+	if err := deliveryCollection.FindOneAndUpdate(context.Background(), filter, update, opt).Decode(delivery); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+func (w *webhookRepoImpl) WebhookByID(db *mongo.Database, ID primitive.ObjectID) (*models.Webhook, error) {
+	webhook := &models.Webhook{}
+	webhookCollection := db.Collection(webhook.CollectionName())
+	if err := webhookCollection.FindOne(context.Background(), bson.M{"_id": ID}).Decode(webhook); err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+func (w *webhookRepoImpl) DeliverySucceeded(db *mongo.Database, ID primitive.ObjectID, statusCode int) error {
+	deliveryCollection := db.Collection(models.WebhookDelivery{}.CollectionName())
+	t := time.Now().UTC()
+	update := bson.M{
+		"$set": bson.M{
+			"status":         models.DeliveryDelivered,
+			"lastStatusCode": statusCode,
+			"deliveredAt":    t,
+			"updatedAt":      t,
+		},
+		"$inc":   bson.M{"attempts": 1},
+		"$unset": bson.M{"lastError": ""},
+	}
+	_, err := deliveryCollection.UpdateOne(context.Background(), bson.M{"_id": ID}, update)
+	return err
+}
+
+// DeliveryFailed records a failed attempt, delivery is dead lettered if nextAttemptAt is nil
+func (w *webhookRepoImpl) DeliveryFailed(db *mongo.Database, ID primitive.ObjectID, statusCode int, reason string, nextAttemptAt *time.Time) error {
+	deliveryCollection := db.Collection(models.WebhookDelivery{}.CollectionName())
+	set := bson.M{
+		"lastStatusCode": statusCode,
+		"lastError":      reason,
+		"updatedAt":      time.Now().UTC(),
+	}
+	if nextAttemptAt == nil {
+		set["status"] = models.DeliveryDead
+	} else {
+		set["nextAttemptAt"] = nextAttemptAt
+	}
+	update := bson.M{"$set": set, "$inc": bson.M{"attempts": 1}}
+	_, err := deliveryCollection.UpdateOne(context.Background(), bson.M{"_id": ID}, update)
+	return err
+}
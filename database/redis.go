@@ -31,3 +31,8 @@ func ConnectRedis() error {
 func GetLimmiterStore() *limiter.Store {
 	return &limmiterStore
 }
+
+// GetRedisClient returns the connected redis client
+func GetRedisClient() *goredis.Client {
+	return client
+}
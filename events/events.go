@@ -0,0 +1,115 @@
+package events
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/techartificer/swiftex/data"
+	"github.com/techartificer/swiftex/lib/helper"
+	"github.com/techartificer/swiftex/logger"
+	"github.com/techartificer/swiftex/models"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	maxAttempts = 10
+	batchSize   = 100
+	lease       = time.Minute
+	baseDelay   = 10 * time.Second
+	maxDelay    = time.Hour
+)
+
+// Handler handles an event, returning error makes the event to be dispatched again.
+// Events are delivered at least once so handlers should be idempotent
+type Handler func(db *mongo.Database, event *models.Event) error
+
+type subscriber struct {
+	name   string
+	types  map[models.EventType]bool
+	handle Handler
+}
+
+var (
+	mu          sync.RWMutex
+	subscribers []subscriber
+)
+
+// Subscribe registers handler for given event types, handler gets all events if no type is given.
+// Name must be unique, it is used to remember which subscribers have handled an event
+func Subscribe(name string, handle Handler, types ...models.EventType) {
+	mu.Lock()
+	defer mu.Unlock()
+	sub := subscriber{name: name, handle: handle, types: make(map[models.EventType]bool)}
+	for _, t := range types {
+		sub.types[t] = true
+	}
+	subscribers = append(subscribers, sub)
+}
+
+func (s subscriber) wants(eventType models.EventType) bool {
+	return len(s.types) == 0 || s.types[eventType]
+}
+
+func handle(db *mongo.Database, sub subscriber, event *models.Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("subscriber %s panicked, %v", sub.name, r)
+		}
+	}()
+	return sub.handle(db, event)
+}
+
+// Dispatch delivers pending events of the outbox to subscribers
+func Dispatch(db *mongo.Database) error {
+	eventRepo := data.NewEventRepo()
+	for i := 0; i < batchSize; i++ {
+		event, err := eventRepo.ClaimEvent(db, lease)
+		if err == mongo.ErrNoDocuments {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := dispatch(db, event); err != nil {
+			logger.Log.Errorln(err)
+		}
+	}
+	return nil
+}
+
+func dispatch(db *mongo.Database, event *models.Event) error {
+	eventRepo := data.NewEventRepo()
+	handled := make(map[string]bool)
+	for _, name := range event.HandledBy {
+		handled[name] = true
+	}
+	mu.RLock()
+	subs := subscribers
+	mu.RUnlock()
+
+	var failure error
+	for _, sub := range subs {
+		if handled[sub.name] || !sub.wants(event.Type) {
+			continue
+		}
+		if err := handle(db, sub, event); err != nil {
+			failure = fmt.Errorf("subscriber %s failed, %v", sub.name, err)
+			logger.Log.Errorln("Event", event.ID.Hex(), event.Type, failure)
+			continue
+		}
+		if err := eventRepo.EventHandled(db, event.ID, sub.name); err != nil {
+			return err
+		}
+	}
+	if failure == nil {
+		return eventRepo.EventDispatched(db, event.ID)
+	}
+	attempts := event.Attempts + 1
+	if attempts >= maxAttempts {
+		logger.Log.Warnln("Event", event.ID.Hex(), event.Type, "failed after", attempts, "attempts")
+		return eventRepo.EventFailed(db, event.ID, failure.Error(), nil)
+	}
+	next := time.Now().UTC().Add(helper.Backoff(attempts, baseDelay, maxDelay))
+	return eventRepo.EventFailed(db, event.ID, failure.Error(), &next)
+}
@@ -0,0 +1,31 @@
+package events
+
+import (
+	"github.com/techartificer/swiftex/data"
+	"github.com/techartificer/swiftex/models"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func init() {
+	Subscribe("webhooks", queueWebhooks, models.OrderStatusChanged, models.CashOutCompleted)
+}
+
+// queueWebhooks queues webhook deliveries of event for shop's subscribed webhooks
+func queueWebhooks(db *mongo.Database, event *models.Event) error {
+	webhookRepo := data.NewWebhookRepo()
+	switch event.Type {
+	case models.OrderStatusChanged:
+		payload := models.OrderStatusChangedPayload{}
+		if err := event.DecodePayload(&payload); err != nil {
+			return err
+		}
+		return webhookRepo.Enqueue(db, event, models.OrderStatusChangedEvent, payload)
+	case models.CashOutCompleted:
+		payload := models.CashOutCompletedPayload{}
+		if err := event.DecodePayload(&payload); err != nil {
+			return err
+		}
+		return webhookRepo.Enqueue(db, event, models.CashOutCompletedEvent, payload)
+	}
+	return nil
+}
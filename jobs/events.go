@@ -0,0 +1,21 @@
+package jobs
+
+import (
+	"time"
+
+	"github.com/techartificer/swiftex/database"
+	"github.com/techartificer/swiftex/events"
+)
+
+func init() {
+	Register(Job{
+		Name:     "event-dispatcher",
+		Interval: 2 * time.Second,
+		Run:      dispatchEvents,
+	})
+}
+
+// dispatchEvents delivers outbox events to subscribers
+func dispatchEvents() error {
+	return events.Dispatch(database.GetDB())
+}
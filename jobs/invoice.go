@@ -0,0 +1,40 @@
+package jobs
+
+import (
+	"time"
+
+	"github.com/techartificer/swiftex/data"
+	"github.com/techartificer/swiftex/database"
+	"github.com/techartificer/swiftex/logger"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func init() {
+	Register(Job{
+		Name:     "invoice-generation",
+		Interval: 6 * time.Hour,
+		Run:      generateInvoices,
+	})
+}
+
+// generateInvoices invoices shops for the last month once the month is over,
+// a failed shop is retried on the next run
+func generateInvoices() error {
+	now := time.Now().UTC()
+	periodStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -1, 0)
+	db := database.GetDB()
+	invoiceRepo := data.NewInvoiceRepo()
+	shopIDs, err := invoiceRepo.DueShops(db, periodStart)
+	if err != nil {
+		return err
+	}
+	for _, shopID := range shopIDs {
+		invoice, err := invoiceRepo.Generate(db, primitive.NewObjectID(), shopID, periodStart, nil)
+		if err != nil {
+			logger.Log.Errorln("Invoice generation failed,", shopID.Hex(), err)
+			continue
+		}
+		logger.Log.Infoln("Invoice generated,", invoice.Number, "shop:", shopID.Hex())
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package jobs
+
+import (
+	"sync"
+	"time"
+
+	"github.com/techartificer/swiftex/logger"
+)
+
+// Job is a background task which runs periodically
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func() error
+}
+
+var (
+	mu   sync.Mutex
+	jobs []Job
+	stop chan struct{}
+	wg   sync.WaitGroup
+)
+
+// Register adds a job to run when jobs are started
+func Register(job Job) {
+	mu.Lock()
+	defer mu.Unlock()
+	jobs = append(jobs, job)
+}
+
+func run(job Job) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Log.Errorln("Job", job.Name, "panicked,", r)
+		}
+	}()
+	if err := job.Run(); err != nil {
+		logger.Log.Errorln("Job", job.Name, "failed,", err)
+	}
+}
+
+// Start starts all registered jobs
+func Start() {
+	mu.Lock()
+	defer mu.Unlock()
+	stop = make(chan struct{})
+	for _, job := range jobs {
+		wg.Add(1)
+		go func(job Job) {
+			defer wg.Done()
+			ticker := time.NewTicker(job.Interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+					run(job)
+				case <-stop:
+					return
+				}
+			}
+		}(job)
+	}
+	logger.Log.Infoln("Background jobs have been started")
+}
+
+// Stop stops all running jobs and waits for them to return
+func Stop() {
+	mu.Lock()
+	if stop != nil {
+		close(stop)
+		stop = nil
+	}
+	mu.Unlock()
+	wg.Wait()
+}
@@ -0,0 +1,31 @@
+package jobs
+
+import (
+	"time"
+
+	"github.com/techartificer/swiftex/data"
+	"github.com/techartificer/swiftex/database"
+	"github.com/techartificer/swiftex/logger"
+)
+
+func init() {
+	Register(Job{
+		Name:     "ledger-verification",
+		Interval: 6 * time.Hour,
+		Run:      verifyLedger,
+	})
+}
+
+// verifyLedger reports balances which don't match the journal
+func verifyLedger() error {
+	db := database.GetDB()
+	ledgerRepo := data.NewLedgerRepo()
+	mismatches, err := ledgerRepo.Verify(db, false)
+	if err != nil {
+		return err
+	}
+	for _, m := range *mismatches {
+		logger.Log.Warnln("Ledger mismatch,", m.AccountType, m.OwnerID, "stored:", m.Stored, "expected:", m.Expected)
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package jobs
+
+import (
+	"time"
+
+	"github.com/techartificer/swiftex/data"
+	"github.com/techartificer/swiftex/database"
+	"github.com/techartificer/swiftex/logger"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func init() {
+	Register(Job{
+		Name:     "payout-batching",
+		Interval: time.Hour,
+		Run:      batchPayouts,
+	})
+}
+
+// batchPayouts creates a payout batch of due shop schedules for admins to review
+func batchPayouts() error {
+	db := database.GetDB()
+	payoutRepo := data.NewPayoutRepo()
+	batch, err := payoutRepo.CreateBatch(db, time.Now().UTC())
+	if err == mongo.ErrNoDocuments {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	logger.Log.Infoln("Payout batch created,", batch.ID.Hex(), "shops:", batch.Count, "amount:", batch.TotalAmount)
+	return nil
+}
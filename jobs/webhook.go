@@ -0,0 +1,71 @@
+package jobs
+
+import (
+	"time"
+
+	"github.com/techartificer/swiftex/data"
+	"github.com/techartificer/swiftex/database"
+	"github.com/techartificer/swiftex/lib/helper"
+	"github.com/techartificer/swiftex/lib/webhook"
+	"github.com/techartificer/swiftex/logger"
+	"github.com/techartificer/swiftex/models"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	webhookMaxAttempts = 8
+	webhookBatchSize   = 50
+	webhookLease       = time.Minute
+	webhookBaseDelay   = 30 * time.Second
+	webhookMaxDelay    = 6 * time.Hour
+)
+
+func init() {
+	Register(Job{
+		Name:     "webhook-delivery",
+		Interval: 10 * time.Second,
+		Run:      deliverWebhooks,
+	})
+}
+
+// deliverWebhooks sends due webhook deliveries, failed deliveries are retried
+// with exponential backoff and dead lettered after webhookMaxAttempts
+func deliverWebhooks() error {
+	db := database.GetDB()
+	webhookRepo := data.NewWebhookRepo()
+	for i := 0; i < webhookBatchSize; i++ {
+		delivery, err := webhookRepo.ClaimDelivery(db, webhookLease)
+		if err == mongo.ErrNoDocuments {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := deliverWebhook(db, delivery); err != nil {
+			logger.Log.Errorln(err)
+		}
+	}
+	return nil
+}
+
+func deliverWebhook(db *mongo.Database, delivery *models.WebhookDelivery) error {
+	webhookRepo := data.NewWebhookRepo()
+	hook, err := webhookRepo.WebhookByID(db, delivery.WebhookID)
+	if err == mongo.ErrNoDocuments {
+		return webhookRepo.DeliveryFailed(db, delivery.ID, 0, "webhook has been deleted", nil)
+	}
+	if err != nil {
+		return err
+	}
+	statusCode, err := webhook.Send(hook.URL, hook.Secret, delivery.Event, delivery.ID.Hex(), []byte(delivery.Payload))
+	if err == nil {
+		return webhookRepo.DeliverySucceeded(db, delivery.ID, statusCode)
+	}
+	attempts := delivery.Attempts + 1
+	if attempts >= webhookMaxAttempts {
+		logger.Log.Warnln("Webhook delivery", delivery.ID.Hex(), "dead lettered,", err)
+		return webhookRepo.DeliveryFailed(db, delivery.ID, statusCode, err.Error(), nil)
+	}
+	next := time.Now().UTC().Add(helper.Backoff(attempts, webhookBaseDelay, webhookMaxDelay))
+	return webhookRepo.DeliveryFailed(db, delivery.ID, statusCode, err.Error(), &next)
+}
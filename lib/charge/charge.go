@@ -28,3 +28,12 @@ func Calculate(weight float32, deliverType string, city string, deliveryCharge f
 	}
 	return charge
 }
+
+// ReturnCharge returns charge of a returned parcel, flat charge is taken if set
+// otherwise percent of the delivery charge
+func ReturnCharge(deliveryCharge, flat, percent float64) float64 {
+	if flat > 0 {
+		return flat
+	}
+	return math.Round(deliveryCharge*percent) / 100
+}
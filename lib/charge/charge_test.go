@@ -0,0 +1,23 @@
+package charge
+
+import "testing"
+
+func TestReturnCharge(t *testing.T) {
+	tests := []struct {
+		name                          string
+		deliveryCharge, flat, percent float64
+		want                          float64
+	}{
+		{"flat", 60, 30, 50, 30},
+		{"half of delivery charge", 60, 0, 50, 30},
+		{"percent rounded to paisa", 65, 0, 33, 21.45},
+		{"full delivery charge", 120, 0, 100, 120},
+		{"no charge", 60, 0, 0, 0},
+		{"free delivery", 0, 0, 50, 0},
+	}
+	for _, tt := range tests {
+		if got := ReturnCharge(tt.deliveryCharge, tt.flat, tt.percent); got != tt.want {
+			t.Errorf("%s: ReturnCharge(%v, %v, %v) = %v, want %v", tt.name, tt.deliveryCharge, tt.flat, tt.percent, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package charge
+
+import (
+	"math"
+	"strings"
+
+	"github.com/techartificer/swiftex/constants"
+	"github.com/techartificer/swiftex/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AnyZone matches every zone of a zone rate
+const AnyZone string = "*"
+
+// Parcel holds the parcel attributes that affect charge
+type Parcel struct {
+	FromZone      string
+	ToZone        string
+	Weight        float32
+	DeliveryType  string
+	PercelType    string
+	PaymentStatus string
+	Price         float64
+}
+
+// Breakdown holds itemised charge of a parcel
+type Breakdown struct {
+	RateCardID         *primitive.ObjectID `json:"rateCardId,omitempty"`
+	RateCardVersion    int                 `json:"rateCardVersion,omitempty"`
+	WeightCharge       float64             `json:"weightCharge"`
+	ExtraWeightCharge  float64             `json:"extraWeightCharge"`
+	DeliveryTypeCharge float64             `json:"deliveryTypeCharge"`
+	ParcelTypeCharge   float64             `json:"parcelTypeCharge"`
+	MinimumChargeAdded float64             `json:"minimumChargeAdded"`
+	Charge             float64             `json:"charge"`
+	CODCharge          float64             `json:"codCharge"`
+	Total              float64             `json:"total"`
+}
+
+// CODCharge returns cash on delivery fee of a parcel
+// price (aka total payable) is sum of product price and delivery charge,
+// percent is only applicable on product price, it is never negative
+func CODCharge(price, charge, percent float64) float64 {
+	return math.Max(math.Floor((price-charge)/100)*percent, 0)
+}
+
+func zoneMatches(zone, value string) bool {
+	return zone == AnyZone || strings.EqualFold(zone, value)
+}
+
+// findZoneRate returns the most specific zone rate of the parcel's zone pair
+func findZoneRate(card *models.RateCard, from, to string) *models.ZoneRate {
+	var found *models.ZoneRate
+	score := -1
+	for i := range card.ZoneRates {
+		zr := &card.ZoneRates[i]
+		if !zoneMatches(zr.FromZone, from) || !zoneMatches(zr.ToZone, to) {
+			continue
+		}
+		s := 0
+		if zr.FromZone != AnyZone {
+			s++
+		}
+		if zr.ToZone != AnyZone {
+			s += 2
+		}
+		if s > score {
+			found, score = zr, s
+		}
+	}
+	return found
+}
+
+// Quote calculates itemised charge of parcel using rate card
+// returns false if rate card has no rate for parcel's zone pair
+func Quote(card *models.RateCard, parcel Parcel) (*Breakdown, bool) {
+	zoneRate := findZoneRate(card, parcel.FromZone, parcel.ToZone)
+	if zoneRate == nil || len(zoneRate.WeightSlabs) == 0 {
+		return nil, false
+	}
+	b := &Breakdown{
+		RateCardID:      &card.ID,
+		RateCardVersion: card.Version,
+	}
+	lastSlab := zoneRate.WeightSlabs[len(zoneRate.WeightSlabs)-1]
+	b.WeightCharge = lastSlab.Charge
+	for _, slab := range zoneRate.WeightSlabs {
+		if parcel.Weight <= slab.MaxWeight {
+			b.WeightCharge = slab.Charge
+			break
+		}
+	}
+	if parcel.Weight > lastSlab.MaxWeight {
+		extra := math.Ceil(float64(parcel.Weight - lastSlab.MaxWeight))
+		b.ExtraWeightCharge = extra * zoneRate.ExtraPerKg
+	}
+	for k, v := range card.DeliveryTypeCharges {
+		if strings.EqualFold(k, parcel.DeliveryType) {
+			b.DeliveryTypeCharge = v
+		}
+	}
+	for k, v := range card.ParcelTypeCharges {
+		if strings.EqualFold(k, parcel.PercelType) {
+			b.ParcelTypeCharge = v
+		}
+	}
+	b.Charge = b.WeightCharge + b.ExtraWeightCharge + b.DeliveryTypeCharge + b.ParcelTypeCharge
+	if b.Charge < card.MinimumCharge {
+		b.MinimumChargeAdded = card.MinimumCharge - b.Charge
+		b.Charge = card.MinimumCharge
+	}
+	if parcel.PaymentStatus == constants.COD {
+		b.CODCharge = CODCharge(parcel.Price, b.Charge, card.CODPercent)
+	}
+	b.Total = b.Charge + b.CODCharge
+	return b, true
+}
+
+// Legacy returns breakdown of charge calculated without rate card
+func Legacy(parcel Parcel, deliveryCharge, codPercent float64) *Breakdown {
+	b := &Breakdown{}
+	b.Charge = Calculate(parcel.Weight, parcel.DeliveryType, parcel.ToZone, deliveryCharge)
+	b.WeightCharge = b.Charge
+	if parcel.PaymentStatus == constants.COD {
+		b.CODCharge = CODCharge(parcel.Price, b.Charge, codPercent)
+	}
+	b.Total = b.Charge + b.CODCharge
+	return b
+}
@@ -0,0 +1,66 @@
+package charge
+
+import (
+	"testing"
+
+	"github.com/techartificer/swiftex/constants"
+	"github.com/techartificer/swiftex/models"
+)
+
+func TestCODCharge(t *testing.T) {
+	tests := []struct {
+		price, charge, percent float64
+		want                   float64
+	}{
+		{1060, 60, 1, 10},
+		{1099, 60, 1, 10},
+		{560, 60, 1.5, 7.5},
+		{60, 60, 1, 0},
+		{0, 60, 1, 0},
+	}
+	for _, tt := range tests {
+		if got := CODCharge(tt.price, tt.charge, tt.percent); got != tt.want {
+			t.Errorf("CODCharge(%v, %v, %v) = %v, want %v", tt.price, tt.charge, tt.percent, got, tt.want)
+		}
+	}
+}
+
+func TestQuote(t *testing.T) {
+	card := &models.RateCard{
+		Version: 2,
+		ZoneRates: []models.ZoneRate{
+			{FromZone: AnyZone, ToZone: AnyZone, WeightSlabs: []models.WeightSlab{{MaxWeight: 1, Charge: 120}}, ExtraPerKg: 25},
+			{FromZone: "Dhaka", ToZone: "Dhaka", WeightSlabs: []models.WeightSlab{{MaxWeight: 1, Charge: 60}, {MaxWeight: 2, Charge: 80}}, ExtraPerKg: 15},
+		},
+		DeliveryTypeCharges: map[string]float64{constants.Express: 40},
+		ParcelTypeCharges:   map[string]float64{"Fragile": 30},
+		CODPercent:          1,
+		MinimumCharge:       70,
+	}
+	tests := []struct {
+		name   string
+		parcel Parcel
+		charge float64
+		cod    float64
+	}{
+		{"minimum charge", Parcel{FromZone: "Dhaka", ToZone: "dhaka", Weight: 0.5}, 70, 0},
+		{"second slab", Parcel{FromZone: "Dhaka", ToZone: "Dhaka", Weight: 1.5}, 80, 0},
+		{"extra weight", Parcel{FromZone: "Dhaka", ToZone: "Dhaka", Weight: 3.2}, 80 + 2*15, 0},
+		{"any zone", Parcel{FromZone: "Dhaka", ToZone: "Sylhet", Weight: 1}, 120, 0},
+		{"express fragile", Parcel{FromZone: "Dhaka", ToZone: "Dhaka", Weight: 1, DeliveryType: constants.Express, PercelType: "Fragile"}, 60 + 40 + 30, 0},
+		{"cod", Parcel{FromZone: "Dhaka", ToZone: "Sylhet", Weight: 1, PaymentStatus: constants.COD, Price: 1120}, 120, 10},
+	}
+	for _, tt := range tests {
+		b, ok := Quote(card, tt.parcel)
+		if !ok {
+			t.Errorf("%s: Quote() found no rate", tt.name)
+			continue
+		}
+		if b.Charge != tt.charge || b.CODCharge != tt.cod || b.Total != tt.charge+tt.cod {
+			t.Errorf("%s: Quote() = charge %v cod %v total %v, want charge %v cod %v", tt.name, b.Charge, b.CODCharge, b.Total, tt.charge, tt.cod)
+		}
+	}
+	if _, ok := Quote(&models.RateCard{}, Parcel{FromZone: "Dhaka", ToZone: "Dhaka"}); ok {
+		t.Error("Quote() of a card without zone rates found a rate")
+	}
+}
@@ -0,0 +1,15 @@
+package helper
+
+import "time"
+
+// Backoff returns delay before next attempt, doubles every attempt upto max
+func Backoff(attempts int, base, max time.Duration) time.Duration {
+	delay := base
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= max {
+			return max
+		}
+	}
+	return delay
+}
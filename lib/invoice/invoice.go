@@ -0,0 +1,238 @@
+package invoice
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/jung-kurt/gofpdf"
+	"github.com/techartificer/swiftex/lib/sheet"
+	"github.com/techartificer/swiftex/models"
+)
+
+// column of an invoice table, widths are in mm of an A4 page
+type column struct {
+	title string
+	width float64
+	align string
+}
+
+var orderColumns = []column{
+	{"#", 8, "C"},
+	{"Track ID", 30, "L"},
+	{"Delivered", 26, "C"},
+	{"Items", 14, "C"},
+	{"Collected", 28, "R"},
+	{"Charge", 28, "R"},
+	{"COD Fee", 24, "R"},
+	{"Payable", 32, "R"},
+}
+
+var returnColumns = []column{
+	{"#", 8, "C"},
+	{"Track ID", 30, "L"},
+	{"Returned", 26, "C"},
+	{"Reason", 98, "L"},
+	{"Charge", 28, "R"},
+}
+
+var payoutColumns = []column{
+	{"#", 8, "C"},
+	{"Paid", 26, "C"},
+	{"Description", 128, "L"},
+	{"Amount", 28, "R"},
+}
+
+const (
+	rowHeight  = 6.0
+	dateFormat = "02 Jan 2006"
+)
+
+// fit shortens s to fit in width
+func fit(pdf *gofpdf.Fpdf, s string, width float64) string {
+	width -= 2
+	if pdf.GetStringWidth(s) <= width {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && pdf.GetStringWidth(string(runes)+"...") > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "..."
+}
+
+func amount(v float64) string {
+	return fmt.Sprintf("%.2f", v)
+}
+
+// items returns delivered and total items of a partially delivered order, empty otherwise
+func items(order models.InvoiceOrder) string {
+	if order.DeliveredItems == 0 || order.DeliveredItems == order.NumberOfItems {
+		return fmt.Sprintf("%d", order.NumberOfItems)
+	}
+	return fmt.Sprintf("%d/%d", order.DeliveredItems, order.NumberOfItems)
+}
+
+// table writes a titled table, header is repeated on new pages
+func table(pdf *gofpdf.Fpdf, title string, columns []column, rows [][]string) {
+	_, pageHeight := pdf.GetPageSize()
+	_, _, _, bottom := pdf.GetMargins()
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	header := func() {
+		pdf.SetFont("Helvetica", "B", 9)
+		pdf.SetFillColor(230, 230, 230)
+		for _, col := range columns {
+			pdf.CellFormat(col.width, rowHeight, col.title, "1", 0, col.align, true, 0, "")
+		}
+		pdf.Ln(-1)
+		pdf.SetFont("Helvetica", "", 8)
+	}
+	if pdf.GetY()+3*rowHeight > pageHeight-bottom-10 {
+		pdf.AddPage()
+	}
+	pdf.SetFont("Helvetica", "B", 11)
+	pdf.CellFormat(0, 8, title, "", 1, "L", false, 0, "")
+	header()
+	if len(rows) == 0 {
+		pdf.CellFormat(0, rowHeight, "None", "1", 1, "C", false, 0, "")
+	}
+	for _, row := range rows {
+		if pdf.GetY()+rowHeight > pageHeight-bottom-10 {
+			pdf.AddPage()
+			header()
+		}
+		for j, col := range columns {
+			pdf.CellFormat(col.width, rowHeight, fit(pdf, tr(row[j]), col.width), "1", 0, col.align, false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+	pdf.Ln(4)
+}
+
+// Render writes a pdf of the invoice with delivered orders, returns, payouts and totals
+func Render(w io.Writer, invoice *models.Invoice) error {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(10, 10, 10)
+	pdf.SetAutoPageBreak(false, 10)
+	pdf.SetTitle("Invoice "+invoice.Number, true)
+	pdf.AliasNbPages("")
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-10)
+		pdf.SetFont("Helvetica", "I", 7)
+		pdf.CellFormat(0, 5, fmt.Sprintf("%s  Page %d/{nb}", invoice.Number, pdf.PageNo()), "", 0, "C", false, 0, "")
+	})
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.AddPage()
+	pageWidth, _ := pdf.GetPageSize()
+	left, _, right, _ := pdf.GetMargins()
+	width := pageWidth - left - right
+
+	// header with shop, number and period
+	pdf.SetFont("Helvetica", "B", 15)
+	pdf.CellFormat(width/2, 8, "Invoice", "", 0, "L", false, 0, "")
+	pdf.CellFormat(width/2, 8, invoice.Number, "", 1, "R", false, 0, "")
+	pdf.SetFont("Helvetica", "", 9)
+	period := invoice.PeriodStart.Format(dateFormat) + " - " + invoice.PeriodEnd.AddDate(0, 0, -1).Format(dateFormat)
+	pdf.CellFormat(width/2, 5, tr("Shop: "+invoice.ShopName), "", 0, "L", false, 0, "")
+	pdf.CellFormat(width/2, 5, "Period: "+period, "", 1, "R", false, 0, "")
+	pdf.CellFormat(width/2, 5, fit(pdf, tr(invoice.ShopAddress), width/2), "", 0, "L", false, 0, "")
+	pdf.CellFormat(width/2, 5, "Issued: "+invoice.CreatedAt.Format(dateFormat), "", 1, "R", false, 0, "")
+	pdf.Ln(3)
+
+	table(pdf, "Delivered orders", orderColumns, OrderRows(invoice))
+	table(pdf, "Returns", returnColumns, ReturnRows(invoice))
+	table(pdf, "Payouts", payoutColumns, PayoutRows(invoice))
+
+	// totals
+	pdf.SetFont("Helvetica", "B", 10)
+	for _, row := range totals(invoice) {
+		pdf.CellFormat(50, 6, row[0], "", 0, "L", false, 0, "")
+		pdf.CellFormat(40, 6, "Tk "+row[1], "", 1, "R", false, 0, "")
+	}
+	if err := pdf.Error(); err != nil {
+		return err
+	}
+	return pdf.Output(w)
+}
+
+// OrderRows returns a row per delivered order of the invoice
+func OrderRows(invoice *models.Invoice) [][]string {
+	rows := [][]string{}
+	for i, order := range invoice.Orders {
+		rows = append(rows, []string{
+			fmt.Sprintf("%d", i+1), order.TrackID, order.DeliveredAt.Format(dateFormat), items(order),
+			amount(order.Collected), amount(order.Charge), amount(order.CODCharge), amount(order.Payable),
+		})
+	}
+	return rows
+}
+
+// ReturnRows returns a row per returned order of the invoice
+func ReturnRows(invoice *models.Invoice) [][]string {
+	rows := [][]string{}
+	for i, orderReturn := range invoice.Returns {
+		rows = append(rows, []string{
+			fmt.Sprintf("%d", i+1), orderReturn.TrackID, orderReturn.ReturnedAt.Format(dateFormat),
+			orderReturn.Reason, amount(orderReturn.Charge),
+		})
+	}
+	return rows
+}
+
+// PayoutRows returns a row per payout of the invoice
+func PayoutRows(invoice *models.Invoice) [][]string {
+	rows := [][]string{}
+	for i, payout := range invoice.Payouts {
+		rows = append(rows, []string{
+			fmt.Sprintf("%d", i+1), payout.PaidAt.Format(dateFormat), payout.Description, amount(payout.Amount),
+		})
+	}
+	return rows
+}
+
+func totals(invoice *models.Invoice) [][2]string {
+	return [][2]string{
+		{"Total collected", amount(invoice.TotalCollected)},
+		{"Delivery charge", amount(invoice.TotalCharge)},
+		{"COD fee", amount(invoice.TotalCODCharge)},
+		{"Return charge", amount(invoice.TotalReturn)},
+		{"Net payable", amount(invoice.NetPayable)},
+		{"Paid out", amount(invoice.TotalPayout)},
+	}
+}
+
+// CSV returns the invoice as csv, cells starting like a formula are escaped
+func CSV(invoice *models.Invoice) ([]byte, error) {
+	return sheet.WriteCSV(csvRows(invoice))
+}
+
+// csvRows returns the invoice as csv rows, a section per table followed by totals
+func csvRows(invoice *models.Invoice) [][]string {
+	rows := [][]string{
+		{"Invoice", invoice.Number},
+		{"Shop", invoice.ShopName},
+		{"Period", invoice.Period},
+		{},
+	}
+	sections := []struct {
+		title   string
+		columns []column
+		rows    [][]string
+	}{
+		{"Delivered orders", orderColumns, OrderRows(invoice)},
+		{"Returns", returnColumns, ReturnRows(invoice)},
+		{"Payouts", payoutColumns, PayoutRows(invoice)},
+	}
+	for _, section := range sections {
+		header := []string{}
+		for _, col := range section.columns {
+			header = append(header, col.title)
+		}
+		rows = append(rows, []string{section.title}, header)
+		rows = append(rows, section.rows...)
+		rows = append(rows, []string{})
+	}
+	for _, row := range totals(invoice) {
+		rows = append(rows, []string{row[0], row[1]})
+	}
+	return rows
+}
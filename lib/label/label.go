@@ -0,0 +1,206 @@
+package label
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/code128"
+	"github.com/boombuler/barcode/qr"
+	"github.com/jung-kurt/gofpdf"
+)
+
+const (
+	// A6 is 105mm x 148mm paper
+	A6 = "a6"
+	// Thermal4x6 is 4in x 6in thermal label
+	Thermal4x6 = "4x6"
+)
+
+// sizes holds page size of labels in mm
+var sizes = map[string]gofpdf.SizeType{
+	A6:         {Wd: 105, Ht: 148},
+	Thermal4x6: {Wd: 101.6, Ht: 152.4},
+}
+
+// ErrInvalidSize is returned for an unknown label size
+var ErrInvalidSize = errors.New("label size must be a6 or 4x6")
+
+// IsValidSize returns true if labels can be rendered in size
+func IsValidSize(size string) bool {
+	_, ok := sizes[size]
+	return ok
+}
+
+// Label holds data printed on a shipping label
+type Label struct {
+	TrackID          string
+	ShopName         string
+	ShopPhone        string
+	PickHub          string
+	RecipientName    string
+	RecipientPhone   string
+	RecipientAddress string
+	RecipientArea    string
+	RecipientThana   string
+	RecipientCity    string
+	DeliveryType     string
+	PercelType       string
+	PaymentStatus    string
+	PackageCode      string
+	Weight           float32
+	NumberOfItems    int
+	COD              float64
+	Charge           float64
+	Comments         string
+	CreatedAt        time.Time
+}
+
+// imageOf registers barcode as png image of pdf and returns its name
+func imageOf(pdf *gofpdf.Fpdf, name string, code barcode.Barcode, width, height int) (string, error) {
+	scaled, err := barcode.Scale(code, width, height)
+	if err != nil {
+		return "", err
+	}
+	// barcodes are 16 bit gray which is not supported by pdf
+	gray := image.NewGray(scaled.Bounds())
+	draw.Draw(gray, gray.Bounds(), scaled, scaled.Bounds().Min, draw.Src)
+	buf := &bytes.Buffer{}
+	if err := png.Encode(buf, gray); err != nil {
+		return "", err
+	}
+	pdf.RegisterImageOptionsReader(name, gofpdf.ImageOptions{ImageType: "PNG"}, buf)
+	return name, pdf.Error()
+}
+
+func joinNonEmpty(sep string, values ...string) string {
+	var parts []string
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			parts = append(parts, strings.TrimSpace(v))
+		}
+	}
+	return strings.Join(parts, sep)
+}
+
+func render(pdf *gofpdf.Fpdf, l *Label, page int) error {
+	pdf.AddPage()
+	w, h := pdf.GetPageSize()
+	left, top, _, _ := pdf.GetMargins()
+	width := w - 2*left
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	// header with shop and hub
+	pdf.SetFont("Helvetica", "B", 13)
+	pdf.CellFormat(width, 7, tr(l.ShopName), "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 8)
+	pdf.CellFormat(width, 4, tr(joinNonEmpty("  |  ", l.ShopPhone, "Hub: "+l.PickHub, l.CreatedAt.Format("02 Jan 2006"))), "", 1, "L", false, 0, "")
+	pdf.Line(left, pdf.GetY()+1, w-left, pdf.GetY()+1)
+	pdf.Ln(3)
+
+	// code128 of track id
+	bar, err := code128.Encode(l.TrackID)
+	if err != nil {
+		return err
+	}
+	barName, err := imageOf(pdf, fmt.Sprintf("bar-%d", page), bar, 600, 120)
+	if err != nil {
+		return err
+	}
+	pdf.ImageOptions(barName, left, pdf.GetY(), width, 18, false, gofpdf.ImageOptions{ImageType: "PNG"}, 0, "")
+	pdf.SetY(pdf.GetY() + 19)
+	pdf.SetFont("Courier", "B", 16)
+	pdf.CellFormat(width, 7, l.TrackID, "", 1, "C", false, 0, "")
+	pdf.Ln(2)
+
+	// recipient with qr of track id on the right
+	qrSize := 28.0
+	qrCode, err := qr.Encode(l.TrackID, qr.M, qr.Auto)
+	if err != nil {
+		return err
+	}
+	qrName, err := imageOf(pdf, fmt.Sprintf("qr-%d", page), qrCode, 200, 200)
+	if err != nil {
+		return err
+	}
+	y := pdf.GetY()
+	pdf.ImageOptions(qrName, w-left-qrSize, y, qrSize, qrSize, false, gofpdf.ImageOptions{ImageType: "PNG"}, 0, "")
+	textWidth := width - qrSize - 2
+	pdf.SetFont("Helvetica", "", 8)
+	pdf.CellFormat(textWidth, 4, "DELIVER TO", "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "B", 11)
+	pdf.MultiCell(textWidth, 5, tr(l.RecipientName), "", "L", false)
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.MultiCell(textWidth, 5, tr(l.RecipientPhone), "", "L", false)
+	pdf.MultiCell(textWidth, 4.5, tr(l.RecipientAddress), "", "L", false)
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.MultiCell(textWidth, 5, tr(joinNonEmpty(", ", l.RecipientArea, l.RecipientThana, l.RecipientCity)), "", "L", false)
+	if pdf.GetY() < y+qrSize {
+		pdf.SetY(y + qrSize)
+	}
+	pdf.Ln(2)
+	pdf.Line(left, pdf.GetY(), w-left, pdf.GetY())
+	pdf.Ln(2)
+
+	// amount to collect
+	pdf.SetFont("Helvetica", "B", 18)
+	if l.COD > 0 {
+		pdf.CellFormat(width, 10, fmt.Sprintf("COD: Tk %.2f", l.COD), "1", 1, "C", false, 0, "")
+	} else {
+		pdf.CellFormat(width, 10, "PAID", "1", 1, "C", false, 0, "")
+	}
+	pdf.Ln(2)
+
+	// parcel details
+	pdf.SetFont("Helvetica", "", 9)
+	rows := [][2]string{
+		{"Delivery", l.DeliveryType},
+		{"Parcel", l.PercelType},
+		{"Weight", fmt.Sprintf("%.2f kg", l.Weight)},
+		{"Items", fmt.Sprintf("%d", l.NumberOfItems)},
+		{"Charge", fmt.Sprintf("Tk %.2f", l.Charge)},
+		{"Package", l.PackageCode},
+	}
+	half := width / 2
+	for i, row := range rows {
+		if row[1] == "" {
+			row[1] = "-"
+		}
+		ln := 0
+		if i%2 == 1 {
+			ln = 1
+		}
+		pdf.CellFormat(half, 5, tr(row[0]+": "+row[1]), "", ln, "L", false, 0, "")
+	}
+	if l.Comments != "" && pdf.GetY() < h-top-10 {
+		pdf.Ln(1)
+		pdf.SetFont("Helvetica", "I", 8)
+		pdf.MultiCell(width, 4, tr("Note: "+l.Comments), "", "L", false)
+	}
+	return pdf.Error()
+}
+
+// Render writes a pdf with one page per label
+func Render(w io.Writer, size string, labels []Label) error {
+	pageSize, ok := sizes[size]
+	if !ok {
+		return ErrInvalidSize
+	}
+	pdf := gofpdf.NewCustom(&gofpdf.InitType{UnitStr: "mm", Size: pageSize})
+	pdf.SetMargins(5, 5, 5)
+	pdf.SetAutoPageBreak(false, 5)
+	pdf.SetTitle("Shipping labels", true)
+	for i := range labels {
+		if err := render(pdf, &labels[i], i); err != nil {
+			return err
+		}
+	}
+	return pdf.Output(w)
+}
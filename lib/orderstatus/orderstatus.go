@@ -0,0 +1,44 @@
+package orderstatus
+
+import "github.com/techartificer/swiftex/constants"
+
+// transitions holds the allowed next statuses of every order status
+var transitions = map[string][]string{
+	constants.Created:     {constants.Accepted, constants.Declined, constants.Cancelled},
+	constants.Accepted:    {constants.Picked, constants.Assigned, constants.InTransit, constants.Declined, constants.Cancelled},
+	constants.Picked:      {constants.Assigned, constants.InTransit, constants.Returned},
+	constants.Assigned:    {constants.InTransit, constants.Returned},
+	constants.InTransit:   {constants.Delivered, constants.Rescheduled, constants.Returned},
+	constants.Rescheduled: {constants.Assigned, constants.InTransit, constants.Returned},
+	constants.Delivered:   {},
+	constants.Returned:    {},
+	constants.Cancelled:   {},
+	constants.Declined:    {},
+}
+
+// Next returns the statuses an order can move to from current status
+func Next(current string) []string {
+	next, ok := transitions[current]
+	if !ok {
+		return []string{}
+	}
+	return append([]string{}, next...)
+}
+
+// CanTransition reports whether an order can move from current to next status
+func CanTransition(current, next string) bool {
+	for _, s := range transitions[current] {
+		if s == next {
+			return true
+		}
+	}
+	return false
+}
+
+// Current returns the current status of an order, orders without any status are treated as created
+func Current(currentStatus *string) string {
+	if currentStatus == nil || *currentStatus == "" {
+		return constants.Created
+	}
+	return *currentStatus
+}
@@ -0,0 +1,68 @@
+package orderstatus
+
+import (
+	"testing"
+
+	"github.com/techartificer/swiftex/constants"
+)
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		current string
+		next    string
+		want    bool
+	}{
+		{constants.Created, constants.Accepted, true},
+		{constants.Created, constants.Cancelled, true},
+		{constants.Created, constants.Delivered, false},
+		{constants.Accepted, constants.Assigned, true},
+		{constants.Picked, constants.Returned, true},
+		{constants.Picked, constants.Cancelled, false},
+		{constants.Assigned, constants.InTransit, true},
+		{constants.Assigned, constants.Delivered, false},
+		{constants.InTransit, constants.Delivered, true},
+		{constants.InTransit, constants.Rescheduled, true},
+		{constants.Rescheduled, constants.InTransit, true},
+		{constants.Delivered, constants.Returned, false},
+		{constants.Returned, constants.InTransit, false},
+		{constants.Cancelled, constants.Accepted, false},
+		{constants.Declined, constants.Accepted, false},
+		{"Unknown", constants.Accepted, false},
+	}
+	for _, tt := range tests {
+		if got := CanTransition(tt.current, tt.next); got != tt.want {
+			t.Errorf("CanTransition(%q, %q) = %v, want %v", tt.current, tt.next, got, tt.want)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	next := Next(constants.Created)
+	if len(next) != 3 {
+		t.Fatalf("Next(%q) = %v, want 3 statuses", constants.Created, next)
+	}
+	next[0] = constants.Delivered
+	if CanTransition(constants.Created, constants.Delivered) {
+		t.Error("changing result of Next changed the transitions")
+	}
+	if got := Next("Unknown"); len(got) != 0 {
+		t.Errorf("Next(%q) = %v, want none", "Unknown", got)
+	}
+}
+
+func TestCurrent(t *testing.T) {
+	empty, accepted := "", constants.Accepted
+	tests := []struct {
+		status *string
+		want   string
+	}{
+		{nil, constants.Created},
+		{&empty, constants.Created},
+		{&accepted, constants.Accepted},
+	}
+	for _, tt := range tests {
+		if got := Current(tt.status); got != tt.want {
+			t.Errorf("Current() = %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package runsheet
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/jung-kurt/gofpdf"
+	"github.com/techartificer/swiftex/models"
+)
+
+// column of the parcel table, widths are in mm of an A4 page
+type column struct {
+	title string
+	width float64
+	align string
+}
+
+var columns = []column{
+	{"#", 8, "C"},
+	{"Track ID", 28, "L"},
+	{"Recipient", 38, "L"},
+	{"Address", 54, "L"},
+	{"COD", 20, "R"},
+	{"Opening", 17, "C"},
+	{"Closing", 25, "C"},
+}
+
+const rowHeight = 6.0
+
+// fit shortens s to fit in width
+func fit(pdf *gofpdf.Fpdf, s string, width float64) string {
+	width -= 2
+	if pdf.GetStringWidth(s) <= width {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && pdf.GetStringWidth(string(runes)+"...") > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "..."
+}
+
+func tableHeader(pdf *gofpdf.Fpdf) {
+	pdf.SetFont("Helvetica", "B", 9)
+	pdf.SetFillColor(230, 230, 230)
+	for _, col := range columns {
+		pdf.CellFormat(col.width, rowHeight, col.title, "1", 0, col.align, true, 0, "")
+	}
+	pdf.Ln(-1)
+	pdf.SetFont("Helvetica", "", 8)
+}
+
+// Render writes a pdf of the run sheet with a row per parcel, totals and signature lines
+func Render(w io.Writer, sheet *models.RunSheet) error {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(10, 10, 10)
+	pdf.SetAutoPageBreak(false, 10)
+	pdf.SetTitle("Run sheet "+sheet.Date, true)
+	pdf.AliasNbPages("")
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-10)
+		pdf.SetFont("Helvetica", "I", 7)
+		pdf.CellFormat(0, 5, fmt.Sprintf("Page %d/{nb}", pdf.PageNo()), "", 0, "C", false, 0, "")
+	})
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.AddPage()
+	pageWidth, pageHeight := pdf.GetPageSize()
+	left, _, right, bottom := pdf.GetMargins()
+	width := pageWidth - left - right
+
+	// header with rider, hub and date
+	pdf.SetFont("Helvetica", "B", 15)
+	pdf.CellFormat(width, 8, "Delivery Run Sheet", "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 9)
+	pdf.CellFormat(width/2, 5, tr("Rider: "+sheet.RiderName+"  ("+sheet.RiderPhone+")"), "", 0, "L", false, 0, "")
+	pdf.CellFormat(width/2, 5, "Date: "+sheet.Date, "", 1, "R", false, 0, "")
+	pdf.CellFormat(width/2, 5, tr("Hub: "+sheet.Hub), "", 0, "L", false, 0, "")
+	pdf.CellFormat(width/2, 5, "Status: "+sheet.Status, "", 1, "R", false, 0, "")
+	pdf.Ln(3)
+
+	tableHeader(pdf)
+	for i, parcel := range sheet.Parcels {
+		if pdf.GetY()+rowHeight > pageHeight-bottom-10 {
+			pdf.AddPage()
+			tableHeader(pdf)
+		}
+		closing := parcel.ClosingStatus
+		if closing == "" {
+			closing = "-"
+		}
+		recipient := parcel.RecipientName + " " + parcel.RecipientPhone
+		address := parcel.RecipientAddress
+		if parcel.RecipientArea != "" {
+			address += ", " + parcel.RecipientArea
+		}
+		values := []string{
+			fmt.Sprintf("%d", i+1),
+			parcel.TrackID,
+			recipient,
+			address,
+			fmt.Sprintf("%.2f", parcel.COD),
+			parcel.OpeningStatus,
+			closing,
+		}
+		for j, col := range columns {
+			pdf.CellFormat(col.width, rowHeight, fit(pdf, tr(values[j]), col.width), "1", 0, col.align, false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+
+	// totals and reconciliation
+	if pdf.GetY()+45 > pageHeight-bottom-10 {
+		pdf.AddPage()
+	}
+	pdf.Ln(4)
+	pdf.SetFont("Helvetica", "B", 10)
+	rows := [][2]string{
+		{"Parcels", fmt.Sprintf("%d", len(sheet.Parcels))},
+		{"COD to collect", fmt.Sprintf("Tk %.2f", sheet.TotalCOD)},
+	}
+	if sheet.ClosedAt != nil {
+		rows = append(rows,
+			[2]string{"Delivered", fmt.Sprintf("%d", sheet.Delivered)},
+			[2]string{"Returned", fmt.Sprintf("%d", sheet.Returned)},
+			[2]string{"Carried over", fmt.Sprintf("%d", sheet.CarriedOver)},
+			[2]string{"COD collected", fmt.Sprintf("Tk %.2f", sheet.CollectedCOD)},
+		)
+	}
+	for _, row := range rows {
+		pdf.CellFormat(40, 6, row[0], "", 0, "L", false, 0, "")
+		pdf.CellFormat(40, 6, row[1], "", 1, "L", false, 0, "")
+	}
+
+	// signatures of rider and hub
+	pdf.Ln(14)
+	y := pdf.GetY()
+	pdf.Line(left, y, left+60, y)
+	pdf.Line(pageWidth-right-60, y, pageWidth-right, y)
+	pdf.SetFont("Helvetica", "", 9)
+	pdf.CellFormat(60, 5, "Rider signature", "", 0, "C", false, 0, "")
+	pdf.SetX(pageWidth - right - 60)
+	pdf.CellFormat(60, 5, "Hub signature", "", 1, "C", false, 0, "")
+	if err := pdf.Error(); err != nil {
+		return err
+	}
+	return pdf.Output(w)
+}
@@ -0,0 +1,89 @@
+package sheet
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ErrUnsupportedFormat is returned for files other than csv and xlsx
+var ErrUnsupportedFormat = errors.New("only csv and xlsx files are supported")
+
+// ErrTooManyRows is returned for files having more than the allowed rows
+var ErrTooManyRows = errors.New("file has too many rows")
+
+// ErrTooManyColumns is returned for files having more than the allowed columns
+var ErrTooManyColumns = errors.New("file has too many columns")
+
+// Read returns rows of a csv file or of the first worksheet of a xlsx file,
+// reading stops with an error once a row or column passes maxRows or maxColumns
+func Read(filename string, content []byte, maxRows, maxColumns int) ([][]string, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return readCSV(content, maxRows, maxColumns)
+	case ".xlsx":
+		return readXLSX(content, maxRows, maxColumns)
+	}
+	return nil, ErrUnsupportedFormat
+}
+
+func readCSV(content []byte, maxRows, maxColumns int) ([][]string, error) {
+	// excel adds byte order mark while saving as utf-8 csv
+	content = bytes.TrimPrefix(content, []byte("\xef\xbb\xbf"))
+	r := csv.NewReader(bytes.NewReader(content))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	var rows [][]string
+	for {
+		row, err := r.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(rows) >= maxRows {
+			return nil, ErrTooManyRows
+		}
+		if len(row) > maxColumns {
+			return nil, ErrTooManyColumns
+		}
+		rows = append(rows, row)
+	}
+}
+
+// formulaPrefixes are leading characters spreadsheets read a cell as a formula by
+const formulaPrefixes = "=+-@\t\r"
+
+// escapeCell prefixes a cell which would be read as a formula with a quote, numbers such as
+// negative amounts are kept as they are
+func escapeCell(cell string) string {
+	if cell == "" || !strings.ContainsRune(formulaPrefixes, rune(cell[0])) {
+		return cell
+	}
+	if _, err := strconv.ParseFloat(cell, 64); err == nil {
+		return cell
+	}
+	return "'" + cell
+}
+
+// WriteCSV returns rows as csv, cells starting like a formula are escaped
+func WriteCSV(rows [][]string) ([]byte, error) {
+	escaped := make([][]string, len(rows))
+	for i, row := range rows {
+		escaped[i] = make([]string, len(row))
+		for j, cell := range row {
+			escaped[i][j] = escapeCell(cell)
+		}
+	}
+	buf := &bytes.Buffer{}
+	w := csv.NewWriter(buf)
+	if err := w.WriteAll(escaped); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,41 @@
+package sheet
+
+import "testing"
+
+func TestEscapeCell(t *testing.T) {
+	tests := []struct {
+		cell string
+		want string
+	}{
+		{"", ""},
+		{"Dhaka", "Dhaka"},
+		{"120.00", "120.00"},
+		{"-120.00", "-120.00"},
+		{"+8801700000000", "+8801700000000"},
+		{"=SUM(A1:A2)", "'=SUM(A1:A2)"},
+		{"+cmd", "'+cmd"},
+		{"-1+1", "'-1+1"},
+		{"@SUM(A1)", "'@SUM(A1)"},
+		{"\tname", "'\tname"},
+		{"\rname", "'\rname"},
+	}
+	for _, tt := range tests {
+		if got := escapeCell(tt.cell); got != tt.want {
+			t.Errorf("escapeCell(%q) = %q, want %q", tt.cell, got, tt.want)
+		}
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	rows := [][]string{{"=1+1", "-5"}}
+	got, err := WriteCSV(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "'=1+1,-5\n"; string(got) != want {
+		t.Errorf("WriteCSV() = %q, want %q", got, want)
+	}
+	if rows[0][0] != "=1+1" {
+		t.Errorf("WriteCSV() changed rows to %q", rows[0])
+	}
+}
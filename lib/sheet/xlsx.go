@@ -0,0 +1,178 @@
+package sheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"io/ioutil"
+	"path"
+	"strconv"
+	"strings"
+)
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		ID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxText struct {
+	T string `xml:"t"`
+	R []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	if len(t.R) == 0 {
+		return t.T
+	}
+	var sb strings.Builder
+	for _, r := range t.R {
+		sb.WriteString(r.T)
+	}
+	return sb.String()
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxText `xml:"si"`
+}
+
+type xlsxWorksheet struct {
+	Rows []struct {
+		R     int `xml:"r,attr"`
+		Cells []struct {
+			R  string   `xml:"r,attr"`
+			T  string   `xml:"t,attr"`
+			V  string   `xml:"v"`
+			Is xlsxText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// maxXMLSize is the largest decompressed size of a part of a xlsx package
+const maxXMLSize = 32 << 20
+
+// readZipXML decodes a part of the package, parts larger than maxXMLSize are rejected
+func readZipXML(files map[string]*zip.File, name string, v interface{}) error {
+	f, ok := files[name]
+	if !ok {
+		return errors.New("invalid xlsx file, " + name + " not found")
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	content, err := ioutil.ReadAll(io.LimitReader(rc, maxXMLSize+1))
+	if err != nil {
+		return err
+	}
+	if len(content) > maxXMLSize {
+		return errors.New("invalid xlsx file, " + name + " is too large")
+	}
+	return xml.Unmarshal(content, v)
+}
+
+// firstSheetPath returns path of the first worksheet in the package
+func firstSheetPath(files map[string]*zip.File) string {
+	workbook := xlsxWorkbook{}
+	rels := xlsxRelationships{}
+	if readZipXML(files, "xl/workbook.xml", &workbook) != nil || len(workbook.Sheets) == 0 {
+		return "xl/worksheets/sheet1.xml"
+	}
+	if readZipXML(files, "xl/_rels/workbook.xml.rels", &rels) != nil {
+		return "xl/worksheets/sheet1.xml"
+	}
+	for _, rel := range rels.Relationships {
+		if rel.ID != workbook.Sheets[0].ID {
+			continue
+		}
+		if strings.HasPrefix(rel.Target, "/") {
+			return strings.TrimPrefix(rel.Target, "/")
+		}
+		return path.Join("xl", rel.Target)
+	}
+	return "xl/worksheets/sheet1.xml"
+}
+
+// columnIndex returns zero based column of a cell reference like "AB12"
+func columnIndex(ref string) int {
+	col := 0
+	for _, c := range ref {
+		if c < 'A' || c > 'Z' {
+			break
+		}
+		col = col*26 + int(c-'A'+1)
+	}
+	return col - 1
+}
+
+func readXLSX(content []byte, maxRows, maxColumns int) ([][]string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string]*zip.File)
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+	shared := xlsxSharedStrings{}
+	if _, ok := files["xl/sharedStrings.xml"]; ok {
+		if err := readZipXML(files, "xl/sharedStrings.xml", &shared); err != nil {
+			return nil, err
+		}
+	}
+	worksheet := xlsxWorksheet{}
+	if err := readZipXML(files, firstSheetPath(files), &worksheet); err != nil {
+		return nil, err
+	}
+	var rows [][]string
+	for _, row := range worksheet.Rows {
+		if row.R > maxRows || (row.R == 0 && len(rows) >= maxRows) {
+			return nil, ErrTooManyRows
+		}
+		// empty rows are not stored, keep row numbers same as the sheet
+		for row.R > len(rows)+1 {
+			rows = append(rows, []string{})
+		}
+		var cells []string
+		for i, cell := range row.Cells {
+			col := i
+			if cell.R != "" {
+				col = columnIndex(cell.R)
+			}
+			if col >= maxColumns {
+				return nil, ErrTooManyColumns
+			}
+			for col > len(cells) {
+				cells = append(cells, "")
+			}
+			var value string
+			switch cell.T {
+			case "s":
+				idx, err := strconv.Atoi(cell.V)
+				if err != nil || idx < 0 || idx >= len(shared.Items) {
+					return nil, errors.New("invalid shared string in cell " + cell.R)
+				}
+				value = shared.Items[idx].String()
+			case "inlineStr":
+				value = cell.Is.String()
+			default:
+				value = cell.V
+			}
+			cells = append(cells, value)
+		}
+		rows = append(rows, cells)
+	}
+	return rows, nil
+}
@@ -0,0 +1,91 @@
+package sms
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/techartificer/swiftex/config"
+)
+
+// ErrNotConfigured is returned when messages are sent without a gateway
+var ErrNotConfigured = errors.New("sms gateway is not configured")
+
+// Sender delivers text messages to phone numbers
+type Sender interface {
+	Send(phone, text string) error
+}
+
+// httpSender posts messages to a gateway as form values api_key, senderid, number and message
+type httpSender struct {
+	url      string
+	apiKey   string
+	senderID string
+	client   *http.Client
+}
+
+// Send never includes the message in errors as it may hold a delivery code
+func (s *httpSender) Send(phone, text string) error {
+	form := url.Values{}
+	form.Set("api_key", s.apiKey)
+	form.Set("senderid", s.senderID)
+	form.Set("number", phone)
+	form.Set("message", text)
+	resp, err := s.client.Post(s.url, "application/x-www-form-urlencoded", strings.NewReader(form.Encode()))
+	if err != nil {
+		return errors.New("sms gateway request failed")
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 1<<16))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("sms gateway responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+var sender Sender
+
+// Initialize sets up the sender of the configured driver
+func Initialize() error {
+	cfg := config.GetSMS()
+	switch cfg.Driver {
+	case "none":
+		sender = nil
+	case "http":
+		if cfg.URL == "" || cfg.APIKey == "" {
+			return errors.New("sms url and api key are required for the http driver")
+		}
+		sender = &httpSender{
+			url:      cfg.URL,
+			apiKey:   cfg.APIKey,
+			senderID: cfg.SenderID,
+			client:   &http.Client{Timeout: 10 * time.Second},
+		}
+	default:
+		return errors.New("unknown sms driver " + cfg.Driver)
+	}
+	return nil
+}
+
+// SetSender replaces the sender messages are delivered by
+func SetSender(s Sender) {
+	sender = s
+}
+
+// Enabled reports whether messages can be delivered, delivery codes are not used otherwise
+func Enabled() bool {
+	return sender != nil
+}
+
+// Send delivers text to the phone
+func Send(phone, text string) error {
+	if sender == nil {
+		return ErrNotConfigured
+	}
+	return sender.Send(phone, text)
+}
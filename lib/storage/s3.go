@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/techartificer/swiftex/config"
+)
+
+type s3Store struct {
+	client *s3.S3
+	bucket string
+}
+
+// NewS3Store returns a store keeping files in a bucket of AWS S3 or an s3 compatible server
+func NewS3Store(cfg config.Storage) (Store, error) {
+	awsConfig := &aws.Config{
+		Region:           aws.String(cfg.S3Region),
+		Credentials:      credentials.NewStaticCredentials(cfg.S3AccessKey, cfg.S3SecretKey, ""),
+		S3ForcePathStyle: aws.Bool(cfg.S3PathStyle),
+	}
+	if cfg.S3Endpoint != "" {
+		awsConfig.Endpoint = aws.String(cfg.S3Endpoint)
+	}
+	sess, err := session.NewSession(awsConfig)
+	if err != nil {
+		return nil, err
+	}
+	return &s3Store{client: s3.New(sess), bucket: cfg.S3Bucket}, nil
+}
+
+func (s *s3Store) Put(key string, r io.Reader, contentType string) error {
+	body, ok := r.(io.ReadSeeker)
+	if !ok {
+		content, err := ioutil.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(content)
+	}
+	_, err := s.client.PutObject(&s3.PutObjectInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(key),
+		Body:        body,
+		ContentType: aws.String(contentType),
+	})
+	return err
+}
+
+func (s *s3Store) Open(key string) (io.ReadCloser, error) {
+	out, err := s.client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == s3.ErrCodeNoSuchKey {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return out.Body, nil
+}
+
+func (s *s3Store) Delete(key string) error {
+	_, err := s.client.DeleteObject(&s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	return err
+}
@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"errors"
+	"io"
+
+	"github.com/techartificer/swiftex/config"
+)
+
+// ErrNotFound is returned when a key does not exist in the store
+var ErrNotFound = errors.New("file not found")
+
+// Store keeps uploaded files, keys are slash separated paths
+type Store interface {
+	Put(key string, r io.Reader, contentType string) error
+	Open(key string) (io.ReadCloser, error)
+	Delete(key string) error
+}
+
+var store Store
+
+// Initialize sets up the blob store of the configured driver
+func Initialize() error {
+	cfg := config.GetStorage()
+	switch cfg.Driver {
+	case "local":
+		store = NewLocalStore(cfg.LocalPath)
+	case "s3":
+		s, err := NewS3Store(cfg)
+		if err != nil {
+			return err
+		}
+		store = s
+	default:
+		return errors.New("unknown storage driver " + cfg.Driver)
+	}
+	return nil
+}
+
+// GetStore returns the configured blob store
+func GetStore() Store {
+	return store
+}
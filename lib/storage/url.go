@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/techartificer/swiftex/config"
+)
+
+// FilePrefix is the route signed download urls are served under
+const FilePrefix = "/v1/fs/"
+
+func urlSecret() []byte {
+	if secret := config.GetStorage().URLSecret; secret != "" {
+		return []byte(secret)
+	}
+	return []byte(config.GetJWT().Secret)
+}
+
+func sign(key string, expires int64) string {
+	mac := hmac.New(sha256.New, urlSecret())
+	mac.Write([]byte(fmt.Sprintf("%s:%d", key, expires)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// IsKey tells if a stored value is a key of the store, older records may hold external urls
+func IsKey(value string) bool {
+	return value != "" && !strings.Contains(value, "://")
+}
+
+// SignedURL returns a download url of the key which expires after configured ttl
+func SignedURL(key string) string {
+	if !IsKey(key) {
+		return key
+	}
+	expires := time.Now().Add(time.Duration(config.GetStorage().URLTTL) * time.Second).Unix()
+	return fmt.Sprintf("%s%s?expires=%d&signature=%s", FilePrefix, key, expires, sign(key, expires))
+}
+
+// VerifySignature checks signature and expiry of a download url
+func VerifySignature(key, expires, signature string) bool {
+	exp, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || exp < time.Now().Unix() {
+		return false
+	}
+	return hmac.Equal([]byte(sign(key, exp)), []byte(signature))
+}
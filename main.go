@@ -3,8 +3,11 @@ package main
 import (
 	"github.com/techartificer/swiftex/config"
 	"github.com/techartificer/swiftex/database"
+	"github.com/techartificer/swiftex/jobs"
 	"github.com/techartificer/swiftex/lib/firebase"
 	"github.com/techartificer/swiftex/lib/random"
+	"github.com/techartificer/swiftex/lib/sms"
+	"github.com/techartificer/swiftex/lib/storage"
 	"github.com/techartificer/swiftex/logger"
 	"github.com/techartificer/swiftex/models"
 	"github.com/techartificer/swiftex/server"
@@ -30,6 +33,15 @@ func init() {
 	if err := firebase.Initialize(); err != nil {
 		panic(err)
 	}
+	if err := storage.Initialize(); err != nil {
+		panic(err)
+	}
+	if err := sms.Initialize(); err != nil {
+		panic(err)
+	}
+	if !sms.Enabled() {
+		logger.Log.Warnln("SMS gateway is not configured, delivery codes are disabled")
+	}
 }
 
 func main() {
@@ -39,6 +51,8 @@ func main() {
 			logger.Log.Errorln(err)
 		}
 	}()
+	jobs.Start()
+	defer jobs.Stop()
 	server.Start()
 	//! Don't write code here
 }
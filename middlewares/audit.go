@@ -0,0 +1,193 @@
+package middlewares
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/techartificer/swiftex/config"
+	"github.com/techartificer/swiftex/constants"
+	"github.com/techartificer/swiftex/data"
+	"github.com/techartificer/swiftex/database"
+	"github.com/techartificer/swiftex/logger"
+	"github.com/techartificer/swiftex/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const auditStateKey = "auditState"
+
+// AuditEntity tells the audit log where an entity is stored, Key is the field targets are matched by
+type AuditEntity struct {
+	Name       string
+	Collection string
+	Key        string
+}
+
+var (
+	AuditAdmin           = AuditEntity{Name: "admin", Collection: models.Admin{}.CollectionName(), Key: "_id"}
+	AuditRolePermission  = AuditEntity{Name: "rolePermission", Collection: models.RolePermission{}.CollectionName(), Key: "role"}
+	AuditOrder           = AuditEntity{Name: "order", Collection: models.Order{}.CollectionName(), Key: "_id"}
+	AuditShop            = AuditEntity{Name: "shop", Collection: models.Shop{}.CollectionName(), Key: "_id"}
+	AuditShopModerator   = AuditEntity{Name: "shopModerator", Collection: models.ShopModerator{}.CollectionName(), Key: "_id"}
+	AuditTransaction     = AuditEntity{Name: "transaction", Collection: models.Transaction{}.CollectionName(), Key: "_id"}
+	AuditShopTransaction = AuditEntity{Name: "transaction", Collection: models.Transaction{}.CollectionName(), Key: "shopId"}
+	AuditRider           = AuditEntity{Name: "rider", Collection: models.Rider{}.CollectionName(), Key: "_id"}
+	AuditRiderParcel     = AuditEntity{Name: "riderParcel", Collection: models.RiderParcel{}.CollectionName(), Key: "_id"}
+	AuditRunSheet        = AuditEntity{Name: "runSheet", Collection: models.RunSheet{}.CollectionName(), Key: "_id"}
+	AuditPickupRequest   = AuditEntity{Name: "pickupRequest", Collection: models.PickupRequest{}.CollectionName(), Key: "_id"}
+	AuditPayoutSchedule  = AuditEntity{Name: "payoutSchedule", Collection: models.PayoutSchedule{}.CollectionName(), Key: "shopId"}
+	AuditPayoutBatch     = AuditEntity{Name: "payoutBatch", Collection: models.PayoutBatch{}.CollectionName(), Key: "_id"}
+	AuditPayoutMethod    = AuditEntity{Name: "payoutMethod", Collection: models.PayoutMethod{}.CollectionName(), Key: "_id"}
+	AuditInvoice         = AuditEntity{Name: "invoice", Collection: models.Invoice{}.CollectionName(), Key: "_id"}
+)
+
+// auditSkippedFields are not worth a diff entry
+var auditSkippedFields = map[string]bool{"_id": true, "updatedAt": true}
+
+// auditRedactedFields are recorded as changed without their values
+var auditRedactedFields = map[string]bool{"password": true, "trxCode": true, "deliveryOtp": true}
+
+type auditState struct {
+	entity AuditEntity
+	keys   []interface{}
+	seen   map[string]bool
+	before map[string]bson.M
+}
+
+// auditKey returns comparable form of a target key
+func auditKey(v interface{}) string {
+	if id, ok := v.(primitive.ObjectID); ok {
+		return id.Hex()
+	}
+	return fmt.Sprint(v)
+}
+
+// Audit records actor, route and changes of targets for successful requests,
+// param is the route param holding the target key, empty if targets are set by the handler
+func Audit(entity AuditEntity, param string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			state := &auditState{entity: entity, seen: make(map[string]bool), before: make(map[string]bson.M)}
+			ctx.Set(auditStateKey, state)
+			if param != "" && ctx.Param(param) != "" {
+				AuditTargets(ctx, ctx.Param(param))
+			}
+			err := next(ctx)
+			if status := ctx.Response().Status; status >= 200 && status < 300 {
+				recordAudit(ctx, state)
+			}
+			return err
+		}
+	}
+}
+
+// AuditTargets snapshots targets of an audited request before they are changed,
+// handlers call it for targets which are not route params, created entities included
+func AuditTargets(ctx echo.Context, keys ...interface{}) {
+	state, ok := ctx.Get(auditStateKey).(*auditState)
+	if !ok {
+		return
+	}
+	values := []interface{}{}
+	for _, key := range keys {
+		if hex, ok := key.(string); ok {
+			if id, err := primitive.ObjectIDFromHex(hex); err == nil {
+				key = id
+			}
+		}
+		if state.seen[auditKey(key)] {
+			continue
+		}
+		state.seen[auditKey(key)] = true
+		values = append(values, key)
+	}
+	if len(values) == 0 {
+		return
+	}
+	state.keys = append(state.keys, values...)
+	docs, err := data.NewAuditRepo().Snapshot(database.GetDB(), state.entity.Collection, state.entity.Key, values)
+	if err != nil {
+		logger.Log.Errorln(err)
+		return
+	}
+	for _, doc := range docs {
+		state.before[auditKey(doc[state.entity.Key])] = doc
+	}
+}
+
+// auditDiff returns changed fields of a document, before is nil for created and after is nil for removed documents
+func auditDiff(before, after bson.M) []models.AuditChange {
+	fields := make(map[string]bool)
+	for field := range before {
+		fields[field] = true
+	}
+	for field := range after {
+		fields[field] = true
+	}
+	names := []string{}
+	for field := range fields {
+		if !auditSkippedFields[field] {
+			names = append(names, field)
+		}
+	}
+	sort.Strings(names)
+	changes := []models.AuditChange{}
+	for _, field := range names {
+		b, a := before[field], after[field]
+		if reflect.DeepEqual(b, a) {
+			continue
+		}
+		change := models.AuditChange{Field: field, Before: b, After: a}
+		if auditRedactedFields[field] {
+			change.Before, change.After = nil, nil
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+func recordAudit(ctx echo.Context, state *auditState) {
+	db := database.GetDB()
+	auditRepo := data.NewAuditRepo()
+	after := make(map[string]bson.M)
+	if len(state.keys) > 0 {
+		docs, err := auditRepo.Snapshot(db, state.entity.Collection, state.entity.Key, state.keys)
+		if err != nil {
+			logger.Log.Errorln(err)
+			return
+		}
+		for _, doc := range docs {
+			after[auditKey(doc[state.entity.Key])] = doc
+		}
+	}
+	targets := []models.AuditTarget{}
+	for _, key := range state.keys {
+		k := auditKey(key)
+		targets = append(targets, models.AuditTarget{ID: k, Changes: auditDiff(state.before[k], after[k])})
+	}
+	actorID, _ := ctx.Get(constants.UserID).(primitive.ObjectID)
+	accountType, _ := ctx.Get(constants.AccountType).(string)
+	role, _ := ctx.Get(constants.Role).(string)
+	now := time.Now().UTC()
+	auditLog := &models.AuditLog{
+		ID:          primitive.NewObjectID(),
+		ActorID:     actorID,
+		AccountType: accountType,
+		Role:        role,
+		IP:          ctx.RealIP(),
+		Method:      ctx.Request().Method,
+		Route:       ctx.Path(),
+		Path:        ctx.Request().URL.Path,
+		Status:      ctx.Response().Status,
+		Entity:      state.entity.Name,
+		Targets:     targets,
+		CreatedAt:   now,
+		ExpiresAt:   now.AddDate(0, 0, config.GetAudit().RetentionDays),
+	}
+	if err := auditRepo.Create(db, auditLog); err != nil {
+		logger.Log.Errorln(err)
+	}
+}
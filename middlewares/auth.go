@@ -30,6 +30,7 @@ func setHeader(resp response.Response, ctx echo.Context, claims *jwt.Claims) err
 	ctx.Set(constants.UserID, userID)
 	ctx.Set(constants.Role, claims.Audience)
 	ctx.Set(constants.Phone, claims.Phone)
+	ctx.Set(constants.AccountType, claims.AccountType)
 	return nil
 }
 
@@ -0,0 +1,177 @@
+package middlewares
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+	"github.com/labstack/echo/v4"
+	"github.com/techartificer/swiftex/constants"
+	"github.com/techartificer/swiftex/constants/codes"
+	"github.com/techartificer/swiftex/database"
+	"github.com/techartificer/swiftex/lib/errors"
+	"github.com/techartificer/swiftex/lib/response"
+	"github.com/techartificer/swiftex/logger"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	idempotencyHeader = "Idempotency-Key"
+	idempotencyTTL    = 24 * time.Hour
+	// processingTTL releases the key if the first request never finishes
+	processingTTL = 2 * time.Minute
+)
+
+type idempotentResponse struct {
+	BodyHash    string `json:"bodyHash"`
+	Done        bool   `json:"done"`
+	Status      int    `json:"status"`
+	ContentType string `json:"contentType"`
+	Body        []byte `json:"body"`
+}
+
+// captureWriter writes response to client and keeps a copy of it
+type captureWriter struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *captureWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *captureWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *captureWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return w.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+// Idempotency replays the first response of a request for retries with same Idempotency-Key header,
+// must be used after JWTAuth
+func Idempotency() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			resp := response.Response{}
+			key := ctx.Request().Header.Get(idempotencyHeader)
+			if key == "" {
+				return next(ctx)
+			}
+			body, err := ioutil.ReadAll(ctx.Request().Body)
+			if err != nil {
+				logger.Log.Errorln(err)
+				resp.Title = "Something went wrong"
+				resp.Status = http.StatusInternalServerError
+				resp.Code = codes.SomethingWentWrong
+				resp.Errors = err
+				return resp.Send(ctx)
+			}
+			ctx.Request().Body = ioutil.NopCloser(bytes.NewReader(body))
+			sum := sha256.Sum256(body)
+			bodyHash := hex.EncodeToString(sum[:])
+
+			userID := ctx.Get(constants.UserID).(primitive.ObjectID)
+			redisKey := "idempotency:" + userID.Hex() + ":" + ctx.Request().Method + ":" + ctx.Request().URL.Path + ":" + key
+			redisClient := database.GetRedisClient()
+
+			pending, _ := json.Marshal(idempotentResponse{BodyHash: bodyHash})
+			ok, err := redisClient.SetNX(context.Background(), redisKey, pending, processingTTL).Result()
+			if err != nil {
+				logger.Log.Errorln(err)
+				resp.Title = "Something went wrong"
+				resp.Status = http.StatusInternalServerError
+				resp.Code = codes.SomethingWentWrong
+				resp.Errors = err
+				return resp.Send(ctx)
+			}
+			if !ok {
+				return replay(ctx, redisKey, bodyHash)
+			}
+
+			writer := &captureWriter{ResponseWriter: ctx.Response().Writer}
+			ctx.Response().Writer = writer
+			if err := next(ctx); err != nil {
+				ctx.Error(err)
+			}
+			status := ctx.Response().Status
+			if status >= http.StatusInternalServerError {
+				// failed requests can be retried with the same key
+				redisClient.Del(context.Background(), redisKey)
+				return nil
+			}
+			stored, _ := json.Marshal(idempotentResponse{
+				BodyHash:    bodyHash,
+				Done:        true,
+				Status:      status,
+				ContentType: ctx.Response().Header().Get(echo.HeaderContentType),
+				Body:        writer.body.Bytes(),
+			})
+			if err := redisClient.Set(context.Background(), redisKey, stored, idempotencyTTL).Err(); err != nil {
+				logger.Log.Errorln(err)
+			}
+			return nil
+		}
+	}
+}
+
+func replay(ctx echo.Context, redisKey, bodyHash string) error {
+	val, err := database.GetRedisClient().Get(context.Background(), redisKey).Bytes()
+	if err == goredis.Nil {
+		return sendReplay(ctx, nil, bodyHash)
+	}
+	previous := idempotentResponse{}
+	if err == nil {
+		err = json.Unmarshal(val, &previous)
+	}
+	if err != nil {
+		logger.Log.Errorln(err)
+		resp := response.Response{}
+		resp.Title = "Something went wrong"
+		resp.Status = http.StatusInternalServerError
+		resp.Code = codes.SomethingWentWrong
+		resp.Errors = err
+		return resp.Send(ctx)
+	}
+	return sendReplay(ctx, &previous, bodyHash)
+}
+
+// sendReplay answers a retry with the stored response of its key, previous is nil if the key
+// was released before it could be read
+func sendReplay(ctx echo.Context, previous *idempotentResponse, bodyHash string) error {
+	resp := response.Response{}
+	if previous == nil {
+		resp.Title = "Request with this idempotency key is in progress"
+		resp.Status = http.StatusConflict
+		resp.Code = codes.IdempotentRequestInProgress
+		resp.Errors = errors.NewError("Retry after a while")
+		return resp.Send(ctx)
+	}
+	if previous.BodyHash != bodyHash {
+		resp.Title = "Idempotency key already used with different request"
+		resp.Status = http.StatusConflict
+		resp.Code = codes.IdempotencyKeyReused
+		resp.Errors = errors.NewError("Idempotency key reused")
+		return resp.Send(ctx)
+	}
+	if !previous.Done {
+		resp.Title = "Request with this idempotency key is in progress"
+		resp.Status = http.StatusConflict
+		resp.Code = codes.IdempotentRequestInProgress
+		resp.Errors = errors.NewError("Retry after a while")
+		return resp.Send(ctx)
+	}
+	ctx.Response().Header().Set("Idempotent-Replayed", "true")
+	return ctx.Blob(previous.Status, previous.ContentType, previous.Body)
+}
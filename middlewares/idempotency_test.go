@@ -0,0 +1,61 @@
+package middlewares
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/techartificer/swiftex/constants/codes"
+)
+
+func TestSendReplay(t *testing.T) {
+	done := &idempotentResponse{
+		BodyHash:    "hash",
+		Done:        true,
+		Status:      http.StatusCreated,
+		ContentType: echo.MIMEApplicationJSON,
+		Body:        []byte(`{"data":{"id":"1"}}`),
+	}
+	tests := []struct {
+		name     string
+		previous *idempotentResponse
+		bodyHash string
+		status   int
+		code     codes.ErrorCode
+		replayed bool
+	}{
+		{"replayed", done, "hash", http.StatusCreated, "", true},
+		{"other body", done, "other", http.StatusConflict, codes.IdempotencyKeyReused, false},
+		{"in progress", &idempotentResponse{BodyHash: "hash"}, "hash", http.StatusConflict, codes.IdempotentRequestInProgress, false},
+		{"released", nil, "hash", http.StatusConflict, codes.IdempotentRequestInProgress, false},
+	}
+	e := echo.New()
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(httptest.NewRequest(http.MethodPost, "/", nil), rec)
+		if err := sendReplay(ctx, tt.previous, tt.bodyHash); err != nil {
+			t.Errorf("%s: sendReplay() = %v", tt.name, err)
+			continue
+		}
+		if rec.Code != tt.status {
+			t.Errorf("%s: status = %d, want %d", tt.name, rec.Code, tt.status)
+		}
+		if replayed := rec.Header().Get("Idempotent-Replayed") == "true"; replayed != tt.replayed {
+			t.Errorf("%s: replayed = %v, want %v", tt.name, replayed, tt.replayed)
+		}
+		if tt.replayed {
+			if rec.Body.String() != string(done.Body) || rec.Header().Get(echo.HeaderContentType) != done.ContentType {
+				t.Errorf("%s: replayed %q as %q", tt.name, rec.Body.String(), rec.Header().Get(echo.HeaderContentType))
+			}
+			continue
+		}
+		body := struct {
+			Code codes.ErrorCode `json:"code"`
+		}{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body.Code != tt.code {
+			t.Errorf("%s: code = %q, want %q", tt.name, body.Code, tt.code)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/techartificer/swiftex/constants"
+	"github.com/techartificer/swiftex/constants/codes"
+	"github.com/techartificer/swiftex/data"
+	"github.com/techartificer/swiftex/database"
+	"github.com/techartificer/swiftex/lib/errors"
+	"github.com/techartificer/swiftex/lib/response"
+	"github.com/techartificer/swiftex/logger"
+)
+
+// RequirePermission allows admins whose role has all of the permissions, must be used after JWTAuth,
+// merchants and riders are not checked here as their access is bound to their own shops and parcels
+func RequirePermission(permissions ...constants.Permission) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			resp := response.Response{}
+			if ctx.Get(constants.AccountType) != constants.AdminType {
+				return next(ctx)
+			}
+			role, _ := ctx.Get(constants.Role).(string)
+			db := database.GetDB()
+			permissionRepo := data.NewPermissionRepo()
+			rolePermission, err := permissionRepo.RolePermissions(db, constants.AdminRole(role))
+			if err != nil {
+				logger.Log.Errorln(err)
+				resp.Title = "Something went wrong"
+				resp.Status = http.StatusInternalServerError
+				resp.Code = codes.DatabaseQueryFailed
+				resp.Errors = err
+				return resp.Send(ctx)
+			}
+			for _, permission := range permissions {
+				if !hasPermission(rolePermission.Permissions, permission) {
+					resp.Title = "You are not allowed"
+					resp.Status = http.StatusForbidden
+					resp.Code = codes.PermissionDenied
+					resp.Errors = errors.NewError("Missing permission " + string(permission))
+					return resp.Send(ctx)
+				}
+			}
+			return next(ctx)
+		}
+	}
+}
+
+func hasPermission(granted []constants.Permission, permission constants.Permission) bool {
+	for _, p := range granted {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
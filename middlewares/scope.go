@@ -0,0 +1,65 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/techartificer/swiftex/constants"
+	"github.com/techartificer/swiftex/constants/codes"
+	"github.com/techartificer/swiftex/data"
+	"github.com/techartificer/swiftex/database"
+	"github.com/techartificer/swiftex/lib/errors"
+	"github.com/techartificer/swiftex/lib/response"
+	"github.com/techartificer/swiftex/logger"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// loadHubScope sets assigned hubs of a zone manager to context,
+// other roles are not restricted so nothing is set for them
+func loadHubScope(ctx echo.Context) error {
+	role, _ := ctx.Get(constants.Role).(string)
+	if constants.AdminRole(role) != constants.ZoneManager {
+		return nil
+	}
+	if _, ok := ctx.Get(constants.HubScope).([]primitive.ObjectID); ok {
+		return nil
+	}
+	db := database.GetDB()
+	adminRepo := data.NewAdminRepo()
+	admin, err := adminRepo.FindByID(db, ctx.Get(constants.UserID).(primitive.ObjectID))
+	if err != nil {
+		return err
+	}
+	hubs := admin.Hubs
+	if hubs == nil {
+		hubs = []primitive.ObjectID{}
+	}
+	ctx.Set(constants.HubScope, hubs)
+	return nil
+}
+
+// HubScope restricts zone managers to their assigned hubs, must be used after JWTAuth
+func HubScope() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			resp := response.Response{}
+			if err := loadHubScope(ctx); err != nil {
+				logger.Log.Errorln(err)
+				if err == mongo.ErrNoDocuments {
+					resp.Title = "Admin not found"
+					resp.Status = http.StatusNotFound
+					resp.Code = codes.AdminNotFound
+					resp.Errors = errors.NewError(err.Error())
+					return resp.Send(ctx)
+				}
+				resp.Title = "Something went wrong"
+				resp.Status = http.StatusInternalServerError
+				resp.Code = codes.DatabaseQueryFailed
+				resp.Errors = err
+				return resp.Send(ctx)
+			}
+			return next(ctx)
+		}
+	}
+}
@@ -18,10 +18,6 @@ import (
 )
 
 func isModerator(shop *models.Shop, userID primitive.ObjectID) bool {
-	if shop.Moderators == nil {
-		return false
-	}
-	shop.Moderators = []primitive.ObjectID{primitive.NewObjectID()}
 	for _, v := range shop.Moderators {
 		if v == userID {
 			return true
@@ -30,6 +26,27 @@ func isModerator(shop *models.Shop, userID primitive.ObjectID) bool {
 	return false
 }
 
+// isShopMember reports whether the merchant owns or moderates the shop,
+// active moderator is set in context for RequireShopPermission
+func isShopMember(ctx echo.Context, db *mongo.Database, shop *models.Shop) (bool, error) {
+	userID := ctx.Get(constants.UserID).(primitive.ObjectID)
+	if shop.Owner == userID {
+		return true, nil
+	}
+	if !isModerator(shop, userID) {
+		return false, nil
+	}
+	moderator, err := data.NewShopModeratorRepo().ActiveModerator(db, shop.ID, userID)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return false, nil
+		}
+		return false, err
+	}
+	ctx.Set(constants.ActiveModerator, moderator)
+	return true, nil
+}
+
 func HasShopAccess() echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(ctx echo.Context) error {
@@ -55,14 +72,50 @@ func HasShopAccess() echo.MiddlewareFunc {
 				resp.Errors = err
 				return resp.Send(ctx)
 			}
-			isAdmin := role == constants.Admin || role == constants.SuperAdmin || role == constants.Moderator || role == constants.ZoneManager
-			if isAdmin {
+			if role == constants.ZoneManager {
+				if err := loadHubScope(ctx); err != nil {
+					logger.Log.Errorln(err)
+					resp.Title = "Something went wrong"
+					resp.Status = http.StatusInternalServerError
+					resp.Code = codes.DatabaseQueryFailed
+					resp.Errors = err
+					return resp.Send(ctx)
+				}
+				hubs := ctx.Get(constants.HubScope).([]primitive.ObjectID)
+				inScope, err := data.NewLocationRepo().IsShopInHubs(db, shop, hubs)
+				if err != nil {
+					logger.Log.Errorln(err)
+					resp.Title = "Something went wrong"
+					resp.Status = http.StatusInternalServerError
+					resp.Code = codes.DatabaseQueryFailed
+					resp.Errors = err
+					return resp.Send(ctx)
+				}
+				if !inScope {
+					resp.Title = "Shop is out of your hubs"
+					resp.Status = http.StatusForbidden
+					resp.Code = codes.OutOfHubScope
+					resp.Errors = errors.NewError("Shop is out of your hubs")
+					return resp.Send(ctx)
+				}
+				ctx.Set("shop", shop)
+				return next(ctx)
+			}
+			// admin roles are checked by RequirePermission
+			if ctx.Get(constants.AccountType) == constants.AdminType {
 				ctx.Set("shop", shop)
 				return next(ctx)
 			}
-			userID := ctx.Get(constants.UserID).(primitive.ObjectID)
-			isModerator := isModerator(shop, userID)
-			if shop.Owner != userID && !isModerator {
+			isMember, err := isShopMember(ctx, db, shop)
+			if err != nil {
+				logger.Log.Errorln(err)
+				resp.Title = "Something went wrong"
+				resp.Status = http.StatusInternalServerError
+				resp.Code = codes.DatabaseQueryFailed
+				resp.Errors = err
+				return resp.Send(ctx)
+			}
+			if !isMember {
 				resp.Title = "You don not have access"
 				resp.Status = http.StatusForbidden
 				resp.Code = codes.AccessDenied
@@ -75,13 +128,11 @@ func HasShopAccess() echo.MiddlewareFunc {
 	}
 }
 
-func IsShopOwner() echo.MiddlewareFunc {
+// IsShopMember only for shop owner and its active moderators
+func IsShopMember() echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(ctx echo.Context) error {
 			resp := response.Response{}
-			r := ctx.Get(constants.Role).(string)
-			role := constants.AdminRole(r)
-
 			shopID := ctx.Param("shopId")
 			db := database.GetDB()
 			shopRepo := data.NewShopRepo()
@@ -101,11 +152,84 @@ func IsShopOwner() echo.MiddlewareFunc {
 				resp.Errors = err
 				return resp.Send(ctx)
 			}
-			isAdmin := role == constants.Admin || role == constants.SuperAdmin
-			if isAdmin {
-				ctx.Set("shop", shop)
+			isMember, err := isShopMember(ctx, db, shop)
+			if err != nil {
+				logger.Log.Errorln(err)
+				resp.Title = "Something went wrong"
+				resp.Status = http.StatusInternalServerError
+				resp.Code = codes.DatabaseQueryFailed
+				resp.Errors = err
+				return resp.Send(ctx)
+			}
+			if !isMember {
+				resp.Title = "You don not have access"
+				resp.Status = http.StatusForbidden
+				resp.Code = codes.AccessDenied
+				resp.Errors = err
+				return resp.Send(ctx)
+			}
+			ctx.Set("shop", shop)
+			return next(ctx)
+		}
+	}
+}
+
+// RequireShopPermission checks permissions granted to a shop moderator, owners and admins are passed,
+// must be used after HasShopAccess or IsShopMember
+func RequireShopPermission(perm constants.ShopPermission) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			moderator, ok := ctx.Get(constants.ActiveModerator).(*models.ShopModerator)
+			if !ok || moderator.HasPermission(perm) {
 				return next(ctx)
 			}
+			resp := response.Response{}
+			resp.Title = "Shop permission denied"
+			resp.Status = http.StatusForbidden
+			resp.Code = codes.ShopPermissionDenied
+			resp.Errors = errors.NewError("Missing shop permission " + string(perm))
+			return resp.Send(ctx)
+		}
+	}
+}
+
+func IsShopOwner() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			resp := response.Response{}
+			shopID := ctx.Param("shopId")
+			db := database.GetDB()
+			shopRepo := data.NewShopRepo()
+			shop, err := shopRepo.ShopByID(db, shopID)
+			if err != nil {
+				logger.Log.Errorln(err)
+				if err == mongo.ErrNoDocuments {
+					resp.Title = "Shop not found"
+					resp.Status = http.StatusNotFound
+					resp.Code = codes.ShopNotFound
+					resp.Errors = errors.NewError(err.Error())
+					return resp.Send(ctx)
+				}
+				resp.Title = "Something went wrong"
+				resp.Status = http.StatusInternalServerError
+				resp.Code = codes.DatabaseQueryFailed
+				resp.Errors = err
+				return resp.Send(ctx)
+			}
+			if ctx.Get(constants.AccountType) == constants.AdminType {
+				// only admins and super admins can act as owner of any shop
+				r, _ := ctx.Get(constants.Role).(string)
+				role := constants.AdminRole(r)
+				if role == constants.Admin || role == constants.SuperAdmin {
+					ctx.Set("shop", shop)
+					return next(ctx)
+				}
+				resp.Title = "You don not have access"
+				resp.Status = http.StatusForbidden
+				resp.Code = codes.AccessDenied
+				resp.Errors = errors.NewError("Only admins can manage shops")
+				return resp.Send(ctx)
+			}
 			userID := ctx.Get(constants.UserID).(primitive.ObjectID)
 			if shop.Owner != userID {
 				resp.Title = "You don not have access"
@@ -11,16 +11,18 @@ import (
 
 // Admin model holds the admin's data
 type Admin struct {
-	ID         primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
-	Name       string              `bson:"name,omitempty" json:"name"`
-	Phone      string              `bson:"phone,omitempty" json:"phone"`
-	Email      string              `bson:"email,omitempty" json:"email"`
-	Password   string              `bson:"password,omitempty" json:"-"`
-	ProfilePic string              `bson:"profilePic,omitempty" json:"profilePic,omitempty"`
-	Status     string              `bson:"status,omitempty" json:"status"`
-	Role       constants.AdminRole `bson:"role,omitempty" json:"role"`
-	CreatedAt  time.Time           `bson:"createdAt,omitempty" json:"createdAt"`
-	UpdatedAt  time.Time           `bson:"updatedAt,omitempty" json:"updatedAt"`
+	ID            primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+	Name          string               `bson:"name,omitempty" json:"name"`
+	Phone         string               `bson:"phone,omitempty" json:"phone"`
+	Email         string               `bson:"email,omitempty" json:"email"`
+	Password      string               `bson:"password,omitempty" json:"-"`
+	ProfilePic    string               `bson:"profilePic,omitempty" json:"profilePic,omitempty"`
+	ProfilePicURL string               `bson:"-" json:"profilePicUrl,omitempty"`
+	Status        string               `bson:"status,omitempty" json:"status"`
+	Role          constants.AdminRole  `bson:"role,omitempty" json:"role"`
+	Hubs          []primitive.ObjectID `bson:"hubs,omitempty" json:"hubs,omitempty"`
+	CreatedAt     time.Time            `bson:"createdAt,omitempty" json:"createdAt"`
+	UpdatedAt     time.Time            `bson:"updatedAt,omitempty" json:"updatedAt"`
 }
 
 // CollectionName returns name of the models
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// AuditChange holds a changed field of an audited entity
+type AuditChange struct {
+	Field  string      `bson:"field" json:"field"`
+	Before interface{} `bson:"before,omitempty" json:"before,omitempty"`
+	After  interface{} `bson:"after,omitempty" json:"after,omitempty"`
+}
+
+// AuditTarget is an entity changed by an audited request
+type AuditTarget struct {
+	ID      string        `bson:"id" json:"id"`
+	Changes []AuditChange `bson:"changes" json:"changes"`
+}
+
+// AuditLog holds a privileged mutation, logs are removed once expired
+type AuditLog struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ActorID     primitive.ObjectID `bson:"actorId,omitempty" json:"actorId"`
+	AccountType string             `bson:"accountType,omitempty" json:"accountType"`
+	Role        string             `bson:"role,omitempty" json:"role"`
+	IP          string             `bson:"ip,omitempty" json:"ip"`
+	Method      string             `bson:"method,omitempty" json:"method"`
+	Route       string             `bson:"route,omitempty" json:"route"`
+	Path        string             `bson:"path,omitempty" json:"path"`
+	Status      int                `bson:"status,omitempty" json:"status"`
+	Entity      string             `bson:"entity,omitempty" json:"entity"`
+	Targets     []AuditTarget      `bson:"targets" json:"targets"`
+	CreatedAt   time.Time          `bson:"createdAt,omitempty" json:"createdAt"`
+	ExpiresAt   time.Time          `bson:"expiresAt,omitempty" json:"expiresAt"`
+}
+
+// CollectionName returns name of the models
+func (a AuditLog) CollectionName() string {
+	return "auditLogs"
+}
+
+func initAuditLogIndex(db *mongo.Database) error {
+	auditCol := db.Collection(AuditLog{}.CollectionName())
+	if err := createIndex(auditCol, bson.M{"actorId": 1}, false); err != nil {
+		return err
+	}
+	if err := createIndex(auditCol, bson.D{{Key: "entity", Value: 1}, {Key: "targets.id", Value: 1}}, false); err != nil {
+		return err
+	}
+	if err := createIndex(auditCol, bson.M{"createdAt": -1}, false); err != nil {
+		return err
+	}
+	if err := createIndexWithTTL(auditCol, bson.M{"expiresAt": 1}, 1); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// CashNote holds count of a denomination in a deposit
+type CashNote struct {
+	Value int `bson:"value" json:"value"`
+	Count int `bson:"count" json:"count"`
+}
+
+// CashDeposit holds cash handed over by a rider at hub
+type CashDeposit struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	RiderID        primitive.ObjectID `bson:"riderId,omitempty" json:"riderId"`
+	Hub            string             `bson:"hub,omitempty" json:"hub"`
+	Amount         float64            `bson:"amount" json:"amount"`
+	Notes          []CashNote         `bson:"notes,omitempty" json:"notes"`
+	Comment        string             `bson:"comment,omitempty" json:"comment,omitempty"`
+	CashInHand     float64            `bson:"cashInHand" json:"cashInHand"`
+	JournalEntryID primitive.ObjectID `bson:"journalEntryId,omitempty" json:"journalEntryId"`
+	ReceivedBy     primitive.ObjectID `bson:"receivedBy,omitempty" json:"receivedBy"`
+	CreatedAt      time.Time          `bson:"createdAt,omitempty" json:"createdAt"`
+}
+
+// CollectionName returns name of the models
+func (c CashDeposit) CollectionName() string {
+	return "cashDeposits"
+}
+
+func initCashDepositIndex(db *mongo.Database) error {
+	depositCol := db.Collection(CashDeposit{}.CollectionName())
+	if err := createIndex(depositCol, bson.M{"riderId": 1}, false); err != nil {
+		return err
+	}
+	if err := createIndex(depositCol, bson.M{"hub": 1}, false); err != nil {
+		return err
+	}
+	return nil
+}
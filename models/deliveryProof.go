@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// ProofPolicy tells which proofs a rider has to collect to deliver parcels of a shop
+type ProofPolicy struct {
+	OTP       bool `bson:"otp" json:"otp"`
+	Signature bool `bson:"signature" json:"signature"`
+	Photo     bool `bson:"photo" json:"photo"`
+}
+
+// DeliveryProof holds proofs collected on delivery, signature and photo are kept in the blob store
+type DeliveryProof struct {
+	OTPVerified  bool      `bson:"otpVerified,omitempty" json:"otpVerified"`
+	SignatureKey string    `bson:"signatureKey,omitempty" json:"-"`
+	PhotoKey     string    `bson:"photoKey,omitempty" json:"-"`
+	SignatureURL string    `bson:"-" json:"signatureUrl,omitempty"`
+	PhotoURL     string    `bson:"-" json:"photoUrl,omitempty"`
+	Time         time.Time `bson:"time" json:"time"`
+}
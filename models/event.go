@@ -0,0 +1,140 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type EventType string
+
+const (
+	OrderStatusChanged EventType = "OrderStatusChanged"
+	ParcelAssigned     EventType = "ParcelAssigned"
+	ParcelDelivered    EventType = "ParcelDelivered"
+	CashOutRequested   EventType = "CashOutRequested"
+	CashOutCompleted   EventType = "CashOutCompleted"
+	BalanceCredited    EventType = "BalanceCredited"
+	BalanceDebited     EventType = "BalanceDebited"
+)
+
+type EventStatus string
+
+const (
+	EventPending    EventStatus = "Pending"
+	EventDispatched EventStatus = "Dispatched"
+	// EventFailed is set when subscribers kept failing after all retries
+	EventFailed EventStatus = "Failed"
+)
+
+// Event is a domain event written to the outbox in the same transaction as the change
+type Event struct {
+	ID            primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	Type          EventType           `bson:"type,omitempty" json:"type"`
+	ShopID        *primitive.ObjectID `bson:"shopId,omitempty" json:"shopId,omitempty"`
+	OrderID       *primitive.ObjectID `bson:"orderId,omitempty" json:"orderId,omitempty"`
+	Payload       bson.Raw            `bson:"payload,omitempty" json:"-"`
+	Status        EventStatus         `bson:"status,omitempty" json:"status"`
+	Attempts      int                 `bson:"attempts" json:"attempts"`
+	HandledBy     []string            `bson:"handledBy" json:"handledBy"`
+	LastError     string              `bson:"lastError,omitempty" json:"lastError,omitempty"`
+	NextAttemptAt time.Time           `bson:"nextAttemptAt,omitempty" json:"nextAttemptAt"`
+	DispatchedAt  *time.Time          `bson:"dispatchedAt,omitempty" json:"dispatchedAt,omitempty"`
+	CreatedAt     time.Time           `bson:"createdAt,omitempty" json:"createdAt"`
+}
+
+// CollectionName returns name of the models
+func (e Event) CollectionName() string {
+	return "events"
+}
+
+// DecodePayload decodes payload of event into v
+func (e *Event) DecodePayload(v interface{}) error {
+	return bson.Unmarshal(e.Payload, v)
+}
+
+// OrderStatusChangedPayload is payload of OrderStatusChanged event
+type OrderStatusChangedPayload struct {
+	OrderID primitive.ObjectID  `bson:"orderId" json:"orderId"`
+	TrackID string              `bson:"trackId" json:"trackId"`
+	ShopID  primitive.ObjectID  `bson:"shopId" json:"shopId"`
+	RiderID *primitive.ObjectID `bson:"riderId,omitempty" json:"riderId,omitempty"`
+	Status  string              `bson:"status" json:"status"`
+	Text    string              `bson:"text" json:"text"`
+	Price   float64             `bson:"price" json:"price"`
+	Charge  float64             `bson:"charge" json:"charge"`
+	Time    time.Time           `bson:"time" json:"statusAt"`
+}
+
+// ParcelAssignedPayload is payload of ParcelAssigned event
+type ParcelAssignedPayload struct {
+	OrderID    primitive.ObjectID `bson:"orderId" json:"orderId"`
+	TrackID    string             `bson:"trackId" json:"trackId"`
+	ShopID     primitive.ObjectID `bson:"shopId" json:"shopId"`
+	RiderID    primitive.ObjectID `bson:"riderId" json:"riderId"`
+	AssignedBy primitive.ObjectID `bson:"assignedBy" json:"assignedBy"`
+	Time       time.Time          `bson:"time" json:"assignedAt"`
+}
+
+// ParcelDeliveredPayload is payload of ParcelDelivered event
+type ParcelDeliveredPayload struct {
+	OrderID   primitive.ObjectID `bson:"orderId" json:"orderId"`
+	TrackID   string             `bson:"trackId" json:"trackId"`
+	ShopID    primitive.ObjectID `bson:"shopId" json:"shopId"`
+	RiderID   primitive.ObjectID `bson:"riderId" json:"riderId"`
+	Collected float64            `bson:"collected" json:"collected"`
+	Charge    float64            `bson:"charge" json:"charge"`
+	CODCharge float64            `bson:"codCharge" json:"codCharge"`
+	Time      time.Time          `bson:"time" json:"deliveredAt"`
+}
+
+// CashOutRequestedPayload is payload of CashOutRequested event
+type CashOutRequestedPayload struct {
+	TransactionID primitive.ObjectID `bson:"transactionId" json:"transactionId"`
+	ShopID        primitive.ObjectID `bson:"shopId" json:"shopId"`
+	Amount        int64              `bson:"amount" json:"amount"`
+	ExpiresAt     int64              `bson:"expiresAt" json:"expiresAt"`
+}
+
+// CashOutCompletedPayload is payload of CashOutCompleted event
+type CashOutCompletedPayload struct {
+	TransactionID primitive.ObjectID `bson:"transactionId" json:"transactionId"`
+	TrxHistoryID  primitive.ObjectID `bson:"trxHistoryId" json:"trxHistoryId"`
+	ShopID        primitive.ObjectID `bson:"shopId" json:"shopId"`
+	Amount        int64              `bson:"amount" json:"amount"`
+	Balance       float64            `bson:"balance" json:"balance"`
+	Time          time.Time          `bson:"time" json:"paidAt"`
+}
+
+// BalanceCreditedPayload is payload of BalanceCredited event
+type BalanceCreditedPayload struct {
+	TransactionID primitive.ObjectID  `bson:"transactionId" json:"transactionId"`
+	TrxHistoryID  primitive.ObjectID  `bson:"trxHistoryId" json:"trxHistoryId"`
+	ShopID        primitive.ObjectID  `bson:"shopId" json:"shopId"`
+	OrderID       *primitive.ObjectID `bson:"orderId,omitempty" json:"orderId,omitempty"`
+	Amount        float64             `bson:"amount" json:"amount"`
+	Balance       float64             `bson:"balance" json:"balance"`
+	Time          time.Time           `bson:"time" json:"creditedAt"`
+}
+
+func initEventIndex(db *mongo.Database) error {
+	eventCol := db.Collection(Event{}.CollectionName())
+	if err := createIndex(eventCol, bson.D{{Key: "status", Value: 1}, {Key: "nextAttemptAt", Value: 1}}, false); err != nil {
+		return err
+	}
+	return nil
+}
+
+// BalanceDebitedPayload is payload of BalanceDebited event
+type BalanceDebitedPayload struct {
+	TransactionID primitive.ObjectID  `bson:"transactionId" json:"transactionId"`
+	TrxHistoryID  primitive.ObjectID  `bson:"trxHistoryId" json:"trxHistoryId"`
+	ShopID        primitive.ObjectID  `bson:"shopId" json:"shopId"`
+	OrderID       *primitive.ObjectID `bson:"orderId,omitempty" json:"orderId,omitempty"`
+	Amount        float64             `bson:"amount" json:"amount"`
+	Balance       float64             `bson:"balance" json:"balance"`
+	Remarks       string              `bson:"remarks" json:"remarks"`
+	Time          time.Time           `bson:"time" json:"debitedAt"`
+}
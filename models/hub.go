@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Hub holds a pickup and delivery hub
+type Hub struct {
+	ID        primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	Name      string              `bson:"name,omitempty" json:"name"`
+	Slug      string              `bson:"slug,omitempty" json:"slug"`
+	CityID    *primitive.ObjectID `bson:"cityId,omitempty" json:"cityId,omitempty"`
+	Address   string              `bson:"address,omitempty" json:"address"`
+	Phone     string              `bson:"phone,omitempty" json:"phone"`
+	Status    string              `bson:"status,omitempty" json:"status"`
+	CreatedBy *primitive.ObjectID `bson:"createdBy,omitempty" json:"-"`
+	CreatedAt time.Time           `bson:"createdAt,omitempty" json:"createdAt"`
+	UpdatedAt time.Time           `bson:"updatedAt,omitempty" json:"updatedAt"`
+}
+
+// CollectionName returns name of the models
+func (h Hub) CollectionName() string {
+	return "hubs"
+}
+
+func initHubIndex(db *mongo.Database) error {
+	hubCol := db.Collection(Hub{}.CollectionName())
+	if err := createIndex(hubCol, bson.M{"slug": 1}, true); err != nil {
+		return err
+	}
+	return nil
+}
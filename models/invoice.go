@@ -0,0 +1,91 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// InvoiceOrder holds amounts of an order delivered in the invoice period
+type InvoiceOrder struct {
+	OrderID        primitive.ObjectID `bson:"orderId" json:"orderId"`
+	TrackID        string             `bson:"trackId" json:"trackId"`
+	DeliveredItems int                `bson:"deliveredItems,omitempty" json:"deliveredItems,omitempty"`
+	NumberOfItems  int                `bson:"numberOfItems,omitempty" json:"numberOfItems,omitempty"`
+	Collected      float64            `bson:"collected" json:"collected"`
+	Charge         float64            `bson:"charge" json:"charge"`
+	CODCharge      float64            `bson:"codCharge" json:"codCharge"`
+	Payable        float64            `bson:"payable" json:"payable"`
+	DeliveredAt    time.Time          `bson:"deliveredAt" json:"deliveredAt"`
+}
+
+// InvoiceReturn holds charge of an order returned in the invoice period
+type InvoiceReturn struct {
+	OrderID    primitive.ObjectID `bson:"orderId" json:"orderId"`
+	TrackID    string             `bson:"trackId" json:"trackId"`
+	Reason     string             `bson:"reason,omitempty" json:"reason"`
+	Charge     float64            `bson:"charge" json:"charge"`
+	ReturnedAt time.Time          `bson:"returnedAt" json:"returnedAt"`
+}
+
+// InvoicePayout holds a payout made to the shop in the invoice period
+type InvoicePayout struct {
+	TrxHistoryID *primitive.ObjectID `bson:"trxHistoryId,omitempty" json:"trxHistoryId,omitempty"`
+	Description  string              `bson:"description" json:"description"`
+	Amount       float64             `bson:"amount" json:"amount"`
+	PaidAt       time.Time           `bson:"paidAt" json:"paidAt"`
+}
+
+// Invoice is a snapshot of deliveries, returns and payouts of a shop in a settlement period,
+// the period starts at PeriodStart and ends before PeriodEnd
+type Invoice struct {
+	ID             primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	Number         string              `bson:"number" json:"number"`
+	Sequence       int64               `bson:"sequence" json:"sequence"`
+	ShopID         primitive.ObjectID  `bson:"shopId" json:"shopId"`
+	ShopName       string              `bson:"shopName" json:"shopName"`
+	ShopAddress    string              `bson:"shopAddress,omitempty" json:"shopAddress,omitempty"`
+	Period         string              `bson:"period" json:"period"`
+	PeriodStart    time.Time           `bson:"periodStart" json:"periodStart"`
+	PeriodEnd      time.Time           `bson:"periodEnd" json:"periodEnd"`
+	Orders         []InvoiceOrder      `bson:"orders" json:"orders"`
+	Returns        []InvoiceReturn     `bson:"returns" json:"returns"`
+	Payouts        []InvoicePayout     `bson:"payouts" json:"payouts"`
+	TotalCollected float64             `bson:"totalCollected" json:"totalCollected"`
+	TotalCharge    float64             `bson:"totalCharge" json:"totalCharge"`
+	TotalCODCharge float64             `bson:"totalCodCharge" json:"totalCodCharge"`
+	TotalReturn    float64             `bson:"totalReturn" json:"totalReturn"`
+	TotalPayout    float64             `bson:"totalPayout" json:"totalPayout"`
+	NetPayable     float64             `bson:"netPayable" json:"netPayable"`
+	CreatedBy      *primitive.ObjectID `bson:"createdBy,omitempty" json:"createdBy,omitempty"`
+	CreatedAt      time.Time           `bson:"createdAt" json:"createdAt"`
+}
+
+// CollectionName returns name of the models
+func (i Invoice) CollectionName() string {
+	return "invoices"
+}
+
+// Counter holds the last number of a sequence
+type Counter struct {
+	ID  string `bson:"_id" json:"id"`
+	Seq int64  `bson:"seq" json:"seq"`
+}
+
+// CollectionName returns name of the models
+func (c Counter) CollectionName() string {
+	return "counters"
+}
+
+func initInvoiceIndex(db *mongo.Database) error {
+	invoiceCol := db.Collection(Invoice{}.CollectionName())
+	if err := createIndex(invoiceCol, bson.D{{Key: "shopId", Value: 1}, {Key: "periodStart", Value: 1}}, true); err != nil {
+		return err
+	}
+	if err := createIndex(invoiceCol, bson.M{"number": 1}, true); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type AccountType string
+
+const (
+	// ShopReceivable is the amount platform owes to a shop
+	ShopReceivable AccountType = "Shop Receivable"
+	// PlatformRevenue is the delivery charge earned by platform
+	PlatformRevenue AccountType = "Platform Revenue"
+	// CODFee is the cash on delivery fee earned by platform
+	CODFee AccountType = "COD Fee"
+	// RiderCashInHand is the collected cash a rider is holding
+	RiderCashInHand AccountType = "Rider Cash In Hand"
+	// ReturnFee is the charge earned by platform for returning parcels to shops
+	ReturnFee AccountType = "Return Fee"
+	// PlatformCash is the cash held by platform
+	PlatformCash AccountType = "Platform Cash"
+	// OpeningBalance holds balances existed before the ledger
+	OpeningBalance AccountType = "Opening Balance"
+)
+
+// IsDebitNormal returns true if balance of account type increases with debit
+func (a AccountType) IsDebitNormal() bool {
+	return a == RiderCashInHand || a == PlatformCash
+}
+
+// LedgerAccount holds balance of an account, OwnerID is the shop or rider of the account
+type LedgerAccount struct {
+	ID        primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	Type      AccountType         `bson:"type,omitempty" json:"type"`
+	OwnerID   *primitive.ObjectID `bson:"ownerId,omitempty" json:"ownerId,omitempty"`
+	Debit     float64             `bson:"debit" json:"debit"`
+	Credit    float64             `bson:"credit" json:"credit"`
+	Balance   float64             `bson:"balance" json:"balance"`
+	CreatedAt time.Time           `bson:"createdAt,omitempty" json:"createdAt"`
+	UpdatedAt time.Time           `bson:"updatedAt,omitempty" json:"updatedAt"`
+}
+
+// CollectionName returns name of the models
+func (l LedgerAccount) CollectionName() string {
+	return "ledgerAccounts"
+}
+
+// JournalLine holds one side of a journal entry
+type JournalLine struct {
+	AccountType AccountType         `bson:"accountType" json:"accountType"`
+	OwnerID     *primitive.ObjectID `bson:"ownerId,omitempty" json:"ownerId,omitempty"`
+	Debit       float64             `bson:"debit" json:"debit"`
+	Credit      float64             `bson:"credit" json:"credit"`
+	Memo        string              `bson:"memo,omitempty" json:"memo,omitempty"`
+}
+
+// JournalEntry holds a balanced set of journal lines
+type JournalEntry struct {
+	ID           primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	ShopID       *primitive.ObjectID `bson:"shopId,omitempty" json:"shopId,omitempty"`
+	OrderID      *primitive.ObjectID `bson:"orderId,omitempty" json:"orderId,omitempty"`
+	TrxHistoryID *primitive.ObjectID `bson:"trxHistoryId,omitempty" json:"trxHistoryId,omitempty"`
+	Description  string              `bson:"description,omitempty" json:"description"`
+	Lines        []JournalLine       `bson:"lines,omitempty" json:"lines"`
+	CreatedBy    *primitive.ObjectID `bson:"createdBy,omitempty" json:"createdBy,omitempty"`
+	CreatedAt    time.Time           `bson:"createdAt,omitempty" json:"createdAt"`
+}
+
+// CollectionName returns name of the models
+func (j JournalEntry) CollectionName() string {
+	return "journalEntries"
+}
+
+func initLedgerIndex(db *mongo.Database) error {
+	accountCol := db.Collection(LedgerAccount{}.CollectionName())
+	if err := createIndex(accountCol, bson.D{{Key: "type", Value: 1}, {Key: "ownerId", Value: 1}}, true); err != nil {
+		return err
+	}
+	journalCol := db.Collection(JournalEntry{}.CollectionName())
+	if err := createIndex(journalCol, bson.M{"shopId": 1}, false); err != nil {
+		return err
+	}
+	if err := createIndex(journalCol, bson.D{{Key: "lines.accountType", Value: 1}, {Key: "lines.ownerId", Value: 1}}, false); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// City holds a city of delivery zone registry
+type City struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name      string             `bson:"name,omitempty" json:"name"`
+	Slug      string             `bson:"slug,omitempty" json:"slug"`
+	Status    string             `bson:"status,omitempty" json:"status"`
+	CreatedAt time.Time          `bson:"createdAt,omitempty" json:"createdAt"`
+	UpdatedAt time.Time          `bson:"updatedAt,omitempty" json:"updatedAt"`
+}
+
+// CollectionName returns name of the models
+func (c City) CollectionName() string {
+	return "cities"
+}
+
+// Thana holds a thana of a city
+type Thana struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	CityID    primitive.ObjectID `bson:"cityId,omitempty" json:"cityId"`
+	Name      string             `bson:"name,omitempty" json:"name"`
+	Slug      string             `bson:"slug,omitempty" json:"slug"`
+	Status    string             `bson:"status,omitempty" json:"status"`
+	CreatedAt time.Time          `bson:"createdAt,omitempty" json:"createdAt"`
+	UpdatedAt time.Time          `bson:"updatedAt,omitempty" json:"updatedAt"`
+}
+
+// CollectionName returns name of the models
+func (t Thana) CollectionName() string {
+	return "thanas"
+}
+
+// Area holds a delivery area of a city, HubID is the hub which covers the area
+type Area struct {
+	ID        primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	CityID    primitive.ObjectID  `bson:"cityId,omitempty" json:"cityId"`
+	ThanaID   *primitive.ObjectID `bson:"thanaId,omitempty" json:"thanaId,omitempty"`
+	HubID     *primitive.ObjectID `bson:"hubId,omitempty" json:"hubId,omitempty"`
+	Name      string              `bson:"name,omitempty" json:"name"`
+	Slug      string              `bson:"slug,omitempty" json:"slug"`
+	Status    string              `bson:"status,omitempty" json:"status"`
+	CreatedAt time.Time           `bson:"createdAt,omitempty" json:"createdAt"`
+	UpdatedAt time.Time           `bson:"updatedAt,omitempty" json:"updatedAt"`
+}
+
+// CollectionName returns name of the models
+func (a Area) CollectionName() string {
+	return "areas"
+}
+
+// OrderLocation holds registry entries of an order's pickup hub and recipient address
+type OrderLocation struct {
+	PickHub       *Hub
+	City          *City
+	Thana         *Thana
+	Area          *Area
+	DeliveryHubID *primitive.ObjectID
+}
+
+func initLocationIndex(db *mongo.Database) error {
+	cityCol := db.Collection(City{}.CollectionName())
+	if err := createIndex(cityCol, bson.M{"slug": 1}, true); err != nil {
+		return err
+	}
+	thanaCol := db.Collection(Thana{}.CollectionName())
+	if err := createIndex(thanaCol, bson.D{{Key: "cityId", Value: 1}, {Key: "slug", Value: 1}}, true); err != nil {
+		return err
+	}
+	areaCol := db.Collection(Area{}.CollectionName())
+	if err := createIndex(areaCol, bson.D{{Key: "cityId", Value: 1}, {Key: "slug", Value: 1}}, true); err != nil {
+		return err
+	}
+	if err := createIndex(areaCol, bson.M{"hubId": 1}, false); err != nil {
+		return err
+	}
+	return nil
+}
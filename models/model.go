@@ -16,6 +16,16 @@ func createIndex(collection *mongo.Collection, keys interface{}, unique bool) er
 	return err
 }
 
+// createPartialIndex creates a unique index only over documents matching filter
+func createPartialIndex(collection *mongo.Collection, name string, keys, filter interface{}) error {
+	opts := options.Index().SetName(name).SetUnique(true).SetPartialFilterExpression(filter)
+	_, err := collection.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys:    keys,
+		Options: opts,
+	})
+	return err
+}
+
 func createIndexWithTTL(collection *mongo.Collection, keys interface{}, TTL int32) error {
 	opts := options.Index().SetExpireAfterSeconds(TTL)
 	_, err := collection.Indexes().CreateOne(context.Background(), mongo.IndexModel{
@@ -54,5 +64,50 @@ func InitializeIndex(db *mongo.Database) error {
 	if err := initTrxHistoryIndex(db); err != nil {
 		return err
 	}
+	if err := initRateCardIndex(db); err != nil {
+		return err
+	}
+	if err := initLedgerIndex(db); err != nil {
+		return err
+	}
+	if err := initWebhookIndex(db); err != nil {
+		return err
+	}
+	if err := initEventIndex(db); err != nil {
+		return err
+	}
+	if err := initCashDepositIndex(db); err != nil {
+		return err
+	}
+	if err := initHubIndex(db); err != nil {
+		return err
+	}
+	if err := initLocationIndex(db); err != nil {
+		return err
+	}
+	if err := initRolePermissionIndex(db); err != nil {
+		return err
+	}
+	if err := initShopModeratorIndex(db); err != nil {
+		return err
+	}
+	if err := initAuditLogIndex(db); err != nil {
+		return err
+	}
+	if err := initRunSheetIndex(db); err != nil {
+		return err
+	}
+	if err := initPickupRequestIndex(db); err != nil {
+		return err
+	}
+	if err := initPayoutIndex(db); err != nil {
+		return err
+	}
+	if err := initPayoutMethodIndex(db); err != nil {
+		return err
+	}
+	if err := initInvoiceIndex(db); err != nil {
+		return err
+	}
 	return nil
 }
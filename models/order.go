@@ -34,16 +34,25 @@ type Order struct {
 	RecipientArea         string              `bson:"recipientArea,omitempty" json:"recipientArea"`
 	RecipientZip          string              `bson:"recipientZip,omitempty" json:"recipientZip"`
 	RecipientAddress      string              `bson:"recipientAddress,omitempty" json:"recipientAddress"`
+	RecipientCityID       *primitive.ObjectID `bson:"recipientCityId,omitempty" json:"recipientCityId,omitempty"`
+	RecipientThanaID      *primitive.ObjectID `bson:"recipientThanaId,omitempty" json:"recipientThanaId,omitempty"`
+	RecipientAreaID       *primitive.ObjectID `bson:"recipientAreaId,omitempty" json:"recipientAreaId,omitempty"`
 	PackageCode           string              `bson:"packageCode,omitempty" json:"packageCode"`
 	PaymentStatus         string              `bson:"paymentStatus,omitempty" json:"paymentStatus"`
 	Price                 float64             `bson:"price,omitempty" json:"price"`
 	Charge                float64             `bson:"charge,omitempty,truncate" json:"charge"`
+	CODCharge             float64             `bson:"codCharge,omitempty,truncate" json:"codCharge"`
+	RateCardID            *primitive.ObjectID `bson:"rateCardId,omitempty" json:"rateCardId,omitempty"`
 	PercelType            string              `bson:"percelType,omitempty" json:"percelType"`
 	RequestedDeliveryTime time.Time           `bson:"requestedDeliveryTime,omitempty" json:"requestedDeliveryTime"`
 	PickAddress           string              `bson:"pickAddress,omitempty" json:"pickAddress"`
 	PickHub               string              `bson:"pickHub,omitempty" json:"pickHub"`
+	PickHubID             *primitive.ObjectID `bson:"pickHubId,omitempty" json:"pickHubId,omitempty"`
+	DeliveryHubID         *primitive.ObjectID `bson:"deliveryHubId,omitempty" json:"deliveryHubId,omitempty"`
 	Comments              string              `bson:"comments,omitempty" json:"comments"`
 	NumberOfItems         int                 `bson:"numberOfItems,omitempty" json:"numberOfItems"`
+	DeliveredItems        int                 `bson:"deliveredItems,omitempty" json:"deliveredItems,omitempty"`
+	ExchangeItems         int                 `bson:"exchangeItems,omitempty" json:"exchangeItems,omitempty"`
 	TrackID               string              `bson:"trackId,omitempty" json:"trackId"`
 	DeliveryType          string              `bson:"deliveryType,omitempty" json:"deliveryType"`
 	Status                []OrderStatus       `bson:"status,omitempty" json:"status"`
@@ -53,6 +62,15 @@ type Order struct {
 	IsAccepted            bool                `bson:"isAccepted,omitempty" json:"isAccepted"`
 	IsPicked              bool                `bson:"isPicked,omitempty" json:"isPicked"`
 	DeliveredAt           *time.Time          `bson:"deliveredAt,omitempty" json:"deliveredAt,omitempty"`
+	Attempts              int                 `bson:"attempts,omitempty" json:"attempts,omitempty"`
+	RescheduledTo         *time.Time          `bson:"rescheduledTo,omitempty" json:"rescheduledTo,omitempty"`
+	DeliveryOTP           string              `bson:"deliveryOtp,omitempty" json:"-"`
+	DeliveryOTPExpiresAt  int64               `bson:"deliveryOtpExpiresAt,omitempty" json:"-"`
+	DeliveryOTPAttempts   int                 `bson:"deliveryOtpAttempts,omitempty" json:"-"`
+	Proof                 *DeliveryProof      `bson:"proof,omitempty" json:"proof,omitempty"`
+	Return                *OrderReturn        `bson:"return,omitempty" json:"return,omitempty"`
+	ReturnOrderID         *primitive.ObjectID `bson:"returnOrderId,omitempty" json:"returnOrderId,omitempty"`
+	ParentOrderID         *primitive.ObjectID `bson:"parentOrderId,omitempty" json:"parentOrderId,omitempty"`
 	CreatedAt             time.Time           `bson:"createdAt,omitempty" json:"createdAt"`
 	UpdateBy              *primitive.ObjectID `bson:"updatedBy,omitempty" json:"-"`
 	UpdatedAt             time.Time           `bson:"updatedAt" json:"updatedAt"`
@@ -78,8 +96,20 @@ func initOrderIndex(db *mongo.Database) error {
 	if err := createIndex(orderCol, bson.M{"pickHub": 1}, false); err != nil {
 		return err
 	}
+	if err := createIndex(orderCol, bson.M{"pickHubId": 1}, false); err != nil {
+		return err
+	}
+	if err := createIndex(orderCol, bson.M{"deliveryHubId": 1}, false); err != nil {
+		return err
+	}
+	if err := createIndex(orderCol, bson.M{"recipientAreaId": 1}, false); err != nil {
+		return err
+	}
 	if err := createIndex(orderCol, bson.M{"recipientArea": 1}, false); err != nil {
 		return err
 	}
+	if err := createIndex(orderCol, bson.D{{Key: "shopId", Value: 1}, {Key: "return.status", Value: 1}}, false); err != nil {
+		return err
+	}
 	return nil
 }
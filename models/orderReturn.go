@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// OrderReturn tracks a returned parcel on its way back to the shop,
+// Initiated -> At Hub -> Handed Over -> Confirmed
+type OrderReturn struct {
+	Reason        string              `bson:"reason,omitempty" json:"reason"`
+	Status        string              `bson:"status,omitempty" json:"status"`
+	Hub           string              `bson:"hub,omitempty" json:"hub"`
+	HubID         *primitive.ObjectID `bson:"hubId,omitempty" json:"hubId,omitempty"`
+	Charge        float64             `bson:"charge" json:"charge"`
+	TrxHistoryID  *primitive.ObjectID `bson:"trxHistoryId,omitempty" json:"trxHistoryId,omitempty"`
+	InitiatedBy   *primitive.ObjectID `bson:"initiatedBy,omitempty" json:"initiatedBy,omitempty"`
+	InitiatedAt   time.Time           `bson:"initiatedAt,omitempty" json:"initiatedAt"`
+	ReceivedBy    *primitive.ObjectID `bson:"receivedBy,omitempty" json:"receivedBy,omitempty"`
+	ReceivedAt    *time.Time          `bson:"receivedAt,omitempty" json:"receivedAt,omitempty"`
+	ReceiverName  string              `bson:"receiverName,omitempty" json:"receiverName,omitempty"`
+	ReceiverPhone string              `bson:"receiverPhone,omitempty" json:"receiverPhone,omitempty"`
+	HandedOverBy  *primitive.ObjectID `bson:"handedOverBy,omitempty" json:"handedOverBy,omitempty"`
+	HandedOverAt  *time.Time          `bson:"handedOverAt,omitempty" json:"handedOverAt,omitempty"`
+	ConfirmedBy   *primitive.ObjectID `bson:"confirmedBy,omitempty" json:"confirmedBy,omitempty"`
+	ConfirmedAt   *time.Time          `bson:"confirmedAt,omitempty" json:"confirmedAt,omitempty"`
+}
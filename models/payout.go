@@ -0,0 +1,89 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// PayoutAccount holds where payouts of a shop are sent, bank fields are set for bank
+// accounts and wallet number for mobile wallets
+type PayoutAccount struct {
+	Method        string `bson:"method" json:"method"`
+	AccountName   string `bson:"accountName,omitempty" json:"accountName"`
+	BankName      string `bson:"bankName,omitempty" json:"bankName,omitempty"`
+	Branch        string `bson:"branch,omitempty" json:"branch,omitempty"`
+	AccountNumber string `bson:"accountNumber,omitempty" json:"accountNumber,omitempty"`
+	RoutingNumber string `bson:"routingNumber,omitempty" json:"routingNumber,omitempty"`
+	WalletNumber  string `bson:"walletNumber,omitempty" json:"walletNumber,omitempty"`
+}
+
+// PayoutSchedule tells when balance of a shop is paid out, a shop has one schedule
+type PayoutSchedule struct {
+	ID            primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	ShopID        primitive.ObjectID  `bson:"shopId,omitempty" json:"shopId"`
+	Frequency     string              `bson:"frequency,omitempty" json:"frequency"`
+	Weekday       time.Weekday        `bson:"weekday" json:"weekday"`
+	MinimumAmount float64             `bson:"minimumAmount" json:"minimumAmount"`
+	Status        string              `bson:"status,omitempty" json:"status"`
+	NextRunAt     time.Time           `bson:"nextRunAt,omitempty" json:"nextRunAt"`
+	LastRunAt     *time.Time          `bson:"lastRunAt,omitempty" json:"lastRunAt,omitempty"`
+	UpdatedBy     *primitive.ObjectID `bson:"updatedBy,omitempty" json:"-"`
+	CreatedAt     time.Time           `bson:"createdAt,omitempty" json:"createdAt"`
+	UpdatedAt     time.Time           `bson:"updatedAt" json:"updatedAt"`
+}
+
+// CollectionName returns name of the models
+func (p PayoutSchedule) CollectionName() string {
+	return "payoutSchedules"
+}
+
+// PayoutItem holds the payout of a shop in a batch
+type PayoutItem struct {
+	ShopID        primitive.ObjectID  `bson:"shopId" json:"shopId"`
+	ShopName      string              `bson:"shopName" json:"shopName"`
+	TransactionID primitive.ObjectID  `bson:"transactionId" json:"transactionId"`
+	Amount        int64               `bson:"amount" json:"amount"`
+	Account       PayoutAccount       `bson:"account" json:"account"`
+	Status        string              `bson:"status" json:"status"`
+	Remarks       string              `bson:"remarks,omitempty" json:"remarks,omitempty"`
+	TrxHistoryID  *primitive.ObjectID `bson:"trxHistoryId,omitempty" json:"trxHistoryId,omitempty"`
+}
+
+// PayoutBatch holds payouts of due schedules, Pending -> Approved or Rejected.
+// Balances are only paid out when the batch is approved
+type PayoutBatch struct {
+	ID           primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	Items        []PayoutItem        `bson:"items" json:"items"`
+	Count        int                 `bson:"count" json:"count"`
+	TotalAmount  int64               `bson:"totalAmount" json:"totalAmount"`
+	PaidAmount   int64               `bson:"paidAmount" json:"paidAmount"`
+	Status       string              `bson:"status,omitempty" json:"status"`
+	ReviewedBy   *primitive.ObjectID `bson:"reviewedBy,omitempty" json:"reviewedBy,omitempty"`
+	ReviewedAt   *time.Time          `bson:"reviewedAt,omitempty" json:"reviewedAt,omitempty"`
+	RejectReason string              `bson:"rejectReason,omitempty" json:"rejectReason,omitempty"`
+	CreatedAt    time.Time           `bson:"createdAt,omitempty" json:"createdAt"`
+	UpdatedAt    time.Time           `bson:"updatedAt" json:"updatedAt"`
+}
+
+// CollectionName returns name of the models
+func (p PayoutBatch) CollectionName() string {
+	return "payoutBatches"
+}
+
+func initPayoutIndex(db *mongo.Database) error {
+	scheduleCol := db.Collection(PayoutSchedule{}.CollectionName())
+	if err := createIndex(scheduleCol, bson.M{"shopId": 1}, true); err != nil {
+		return err
+	}
+	if err := createIndex(scheduleCol, bson.D{{Key: "status", Value: 1}, {Key: "nextRunAt", Value: 1}}, false); err != nil {
+		return err
+	}
+	batchCol := db.Collection(PayoutBatch{}.CollectionName())
+	if err := createIndex(batchCol, bson.D{{Key: "status", Value: 1}, {Key: "items.shopId", Value: 1}}, false); err != nil {
+		return err
+	}
+	return nil
+}
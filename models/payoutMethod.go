@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// PayoutMethod holds an account a shop can be paid to, a method stays Pending until the shop owner
+// verifies it with their phone, and the wallet phone of mobile wallets if it is another number. Only
+// one method of a shop is Active at a time
+type PayoutMethod struct {
+	ID         primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	ShopID     primitive.ObjectID  `bson:"shopId,omitempty" json:"shopId"`
+	Account    PayoutAccount       `bson:"account" json:"account"`
+	Phone      string              `bson:"phone,omitempty" json:"phone"`
+	Status     string              `bson:"status,omitempty" json:"status"`
+	VerifiedAt *time.Time          `bson:"verifiedAt,omitempty" json:"verifiedAt,omitempty"`
+	VerifiedBy *primitive.ObjectID `bson:"verifiedBy,omitempty" json:"-"`
+	CreatedBy  primitive.ObjectID  `bson:"createdBy,omitempty" json:"-"`
+	CreatedAt  time.Time           `bson:"createdAt,omitempty" json:"createdAt"`
+	UpdatedAt  time.Time           `bson:"updatedAt" json:"updatedAt"`
+}
+
+// CollectionName returns name of the models
+func (p PayoutMethod) CollectionName() string {
+	return "payoutMethods"
+}
+
+func initPayoutMethodIndex(db *mongo.Database) error {
+	methodCol := db.Collection(PayoutMethod{}.CollectionName())
+	return createIndex(methodCol, bson.D{{Key: "shopId", Value: 1}, {Key: "status", Value: 1}}, false)
+}
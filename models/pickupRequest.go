@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// PickupItem holds an order to be picked up, Picked is set when the rider confirms it
+type PickupItem struct {
+	OrderID  primitive.ObjectID `bson:"orderId" json:"orderId"`
+	TrackID  string             `bson:"trackId" json:"trackId"`
+	Picked   bool               `bson:"picked" json:"picked"`
+	PickedAt *time.Time         `bson:"pickedAt,omitempty" json:"pickedAt,omitempty"`
+}
+
+// PickupRequest holds a request of a shop to collect parcels, status moves
+// Pending -> Assigned -> Picked or Pending -> Cancelled
+type PickupRequest struct {
+	ID            primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	ShopID        primitive.ObjectID  `bson:"shopId" json:"shopId"`
+	ShopName      string              `bson:"shopName" json:"shopName"`
+	ShopPhone     string              `bson:"shopPhone" json:"shopPhone"`
+	Address       string              `bson:"address" json:"address"`
+	Hub           string              `bson:"hub" json:"hub"`
+	HubID         *primitive.ObjectID `bson:"hubId,omitempty" json:"hubId,omitempty"`
+	WindowStart   time.Time           `bson:"windowStart" json:"windowStart"`
+	WindowEnd     time.Time           `bson:"windowEnd" json:"windowEnd"`
+	ExpectedCount int                 `bson:"expectedCount" json:"expectedCount"`
+	Items         []PickupItem        `bson:"items" json:"items"`
+	Note          string              `bson:"note,omitempty" json:"note,omitempty"`
+	Status        string              `bson:"status" json:"status"`
+	RiderID       *primitive.ObjectID `bson:"riderId,omitempty" json:"riderId,omitempty"`
+	AssignedBy    *primitive.ObjectID `bson:"assignedBy,omitempty" json:"assignedBy,omitempty"`
+	AssignedAt    *time.Time          `bson:"assignedAt,omitempty" json:"assignedAt,omitempty"`
+	PickedAt      *time.Time          `bson:"pickedAt,omitempty" json:"pickedAt,omitempty"`
+	RequestedBy   primitive.ObjectID  `bson:"requestedBy" json:"requestedBy"`
+	CreatedAt     time.Time           `bson:"createdAt" json:"createdAt"`
+	UpdatedAt     time.Time           `bson:"updatedAt" json:"updatedAt"`
+}
+
+func (p PickupRequest) CollectionName() string {
+	return "pickupRequests"
+}
+
+func initPickupRequestIndex(db *mongo.Database) error {
+	pickupCol := db.Collection(PickupRequest{}.CollectionName())
+	if err := createIndex(pickupCol, bson.M{"shopId": 1}, false); err != nil {
+		return err
+	}
+	if err := createIndex(pickupCol, bson.D{{Key: "riderId", Value: 1}, {Key: "status", Value: 1}}, false); err != nil {
+		return err
+	}
+	if err := createIndex(pickupCol, bson.M{"items.orderId": 1}, false); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// WeightSlab holds charge of parcels weighing up to MaxWeight kg
+type WeightSlab struct {
+	MaxWeight float32 `bson:"maxWeight" json:"maxWeight"`
+	Charge    float64 `bson:"charge" json:"charge"`
+}
+
+// ZoneRate holds charges between a pickup zone and a delivery zone, "*" matches any zone
+type ZoneRate struct {
+	FromZone    string       `bson:"fromZone" json:"fromZone"`
+	ToZone      string       `bson:"toZone" json:"toZone"`
+	WeightSlabs []WeightSlab `bson:"weightSlabs" json:"weightSlabs"`
+	ExtraPerKg  float64      `bson:"extraPerKg" json:"extraPerKg"`
+}
+
+// RateCard holds one effective dated version of a pricing rule set
+type RateCard struct {
+	ID                  primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Code                string             `bson:"code,omitempty" json:"code"`
+	Name                string             `bson:"name,omitempty" json:"name"`
+	Version             int                `bson:"version,omitempty" json:"version"`
+	ZoneRates           []ZoneRate         `bson:"zoneRates,omitempty" json:"zoneRates"`
+	DeliveryTypeCharges map[string]float64 `bson:"deliveryTypeCharges,omitempty" json:"deliveryTypeCharges"`
+	ParcelTypeCharges   map[string]float64 `bson:"parcelTypeCharges,omitempty" json:"parcelTypeCharges"`
+	CODPercent          float64            `bson:"codPercent" json:"codPercent"`
+	MinimumCharge       float64            `bson:"minimumCharge" json:"minimumCharge"`
+	IsDefault           bool               `bson:"isDefault" json:"isDefault"`
+	EffectiveFrom       time.Time          `bson:"effectiveFrom,omitempty" json:"effectiveFrom"`
+	CreatedBy           primitive.ObjectID `bson:"createdBy,omitempty" json:"createdBy"`
+	CreatedAt           time.Time          `bson:"createdAt,omitempty" json:"createdAt"`
+}
+
+// CollectionName returns name of the models
+func (r RateCard) CollectionName() string {
+	return "rateCards"
+}
+
+func initRateCardIndex(db *mongo.Database) error {
+	rateCard := RateCard{}
+	rateCardCol := db.Collection(rateCard.CollectionName())
+	if err := createIndex(rateCardCol, bson.D{{Key: "code", Value: 1}, {Key: "version", Value: 1}}, true); err != nil {
+		return err
+	}
+	if err := createIndex(rateCardCol, bson.M{"effectiveFrom": -1}, false); err != nil {
+		return err
+	}
+	return nil
+}
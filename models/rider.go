@@ -19,7 +19,9 @@ type Rider struct {
 	NID             string              `bson:"NID" json:"NID"`
 	Address         string              `bson:"address" json:"address"`
 	Hub             string              `bson:"hub" json:"hub"`
+	HubID           *primitive.ObjectID `bson:"hubId,omitempty" json:"hubId,omitempty"`
 	CurrentLocation string              `bson:"currentLocation" json:"currentLocation"`
+	DailyCapacity   int                 `bson:"dailyCapacity,omitempty" json:"dailyCapacity,omitempty"`
 	Status          string              `bson:"status,omitempty" json:"status"`
 	CreatedAt       time.Time           `bson:"createdAt,omitempty" json:"createdAt"`
 	CreatedBy       *primitive.ObjectID `bson:"createdBy,omitempty" json:"-"`
@@ -30,6 +32,14 @@ func (r Rider) CollectionName() string {
 	return "riders"
 }
 
+// Capacity returns the number of parcels the rider can be assigned a day
+func (r Rider) Capacity(defaultCapacity int) int {
+	if r.DailyCapacity > 0 {
+		return r.DailyCapacity
+	}
+	return defaultCapacity
+}
+
 func initRiderIndex(db *mongo.Database) error {
 	rider := Rider{}
 	riderCol := db.Collection(rider.CollectionName())
@@ -42,5 +52,8 @@ func initRiderIndex(db *mongo.Database) error {
 	if err := createIndex(riderCol, bson.M{"hub": 1}, false); err != nil {
 		return err
 	}
+	if err := createIndex(riderCol, bson.M{"hubId": 1}, false); err != nil {
+		return err
+	}
 	return nil
 }
@@ -8,12 +8,26 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
+// DeliveryAttempt holds a failed delivery attempt of a rider
+type DeliveryAttempt struct {
+	Reason       string     `bson:"reason,omitempty" json:"reason"`
+	Note         string     `bson:"note,omitempty" json:"note,omitempty"`
+	RescheduleAt *time.Time `bson:"rescheduleAt,omitempty" json:"rescheduleAt,omitempty"`
+	Time         time.Time  `bson:"time,omitempty" json:"time"`
+}
+
+// RiderParcel holds a parcel assigned to a rider, status moves
+// Assigned -> Accepted -> Picked -> Delivered or Failed -> Returned
 type RiderParcel struct {
 	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	RiderID    primitive.ObjectID `bson:"riderId,omitempty" json:"riderId"`
 	OrderID    primitive.ObjectID `bson:"orderId,omitempty" json:"orderId"`
 	AssignedBy primitive.ObjectID `bson:"assignedBy,omitempty" json:"assignedBy"`
 	Status     string             `bson:"status,omitempty" json:"status"`
+	Attempts   []DeliveryAttempt  `bson:"attempts,omitempty" json:"attempts,omitempty"`
+	AcceptedAt *time.Time         `bson:"acceptedAt,omitempty" json:"acceptedAt,omitempty"`
+	PickedAt   *time.Time         `bson:"pickedAt,omitempty" json:"pickedAt,omitempty"`
+	ReturnedAt *time.Time         `bson:"returnedAt,omitempty" json:"returnedAt,omitempty"`
 	CreatedAt  time.Time          `bson:"createdAt,omitempty" json:"createdAt"`
 	UpdatedAt  time.Time          `bson:"updatedAt" json:"updatedAt"`
 }
@@ -28,5 +42,8 @@ func initRiderParcelIndex(db *mongo.Database) error {
 	if err := createIndex(riderParcelCol, bson.M{"riderId": 1}, false); err != nil {
 		return err
 	}
+	if err := createIndex(riderParcelCol, bson.M{"orderId": 1}, false); err != nil {
+		return err
+	}
 	return nil
 }
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/techartificer/swiftex/constants"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// RolePermission holds permissions granted to an admin role
+type RolePermission struct {
+	ID          primitive.ObjectID     `bson:"_id,omitempty" json:"id"`
+	Role        constants.AdminRole    `bson:"role,omitempty" json:"role"`
+	Permissions []constants.Permission `bson:"permissions" json:"permissions"`
+	IsDefault   bool                   `bson:"-" json:"isDefault"`
+	UpdatedBy   *primitive.ObjectID    `bson:"updatedBy,omitempty" json:"updatedBy,omitempty"`
+	UpdatedAt   time.Time              `bson:"updatedAt,omitempty" json:"updatedAt"`
+}
+
+// CollectionName returns name of the models
+func (r RolePermission) CollectionName() string {
+	return "rolePermissions"
+}
+
+func initRolePermissionIndex(db *mongo.Database) error {
+	rolePermissionCol := db.Collection(RolePermission{}.CollectionName())
+	if err := createIndex(rolePermissionCol, bson.M{"role": 1}, true); err != nil {
+		return err
+	}
+	return nil
+}
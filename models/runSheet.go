@@ -0,0 +1,65 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// RunSheetDateLayout is the layout of run sheet dates
+const RunSheetDateLayout = "2006-01-02"
+
+// RunSheetParcel holds a parcel a rider carries out, opening status is the parcel status when
+// the sheet is opened and closing status is the outcome reconciled at end of day
+type RunSheetParcel struct {
+	ParcelID         primitive.ObjectID `bson:"parcelId" json:"parcelId"`
+	OrderID          primitive.ObjectID `bson:"orderId" json:"orderId"`
+	TrackID          string             `bson:"trackId" json:"trackId"`
+	RecipientName    string             `bson:"recipientName" json:"recipientName"`
+	RecipientPhone   string             `bson:"recipientPhone" json:"recipientPhone"`
+	RecipientAddress string             `bson:"recipientAddress" json:"recipientAddress"`
+	RecipientArea    string             `bson:"recipientArea" json:"recipientArea"`
+	COD              float64            `bson:"cod" json:"cod"`
+	OpeningStatus    string             `bson:"openingStatus" json:"openingStatus"`
+	ClosingStatus    string             `bson:"closingStatus,omitempty" json:"closingStatus,omitempty"`
+}
+
+// RunSheet holds the manifest of parcels a rider carries out on a date
+type RunSheet struct {
+	ID           primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	RiderID      primitive.ObjectID  `bson:"riderId" json:"riderId"`
+	RiderName    string              `bson:"riderName" json:"riderName"`
+	RiderPhone   string              `bson:"riderPhone" json:"riderPhone"`
+	Hub          string              `bson:"hub" json:"hub"`
+	HubID        *primitive.ObjectID `bson:"hubId,omitempty" json:"hubId,omitempty"`
+	Date         string              `bson:"date" json:"date"`
+	Status       string              `bson:"status" json:"status"`
+	Parcels      []RunSheetParcel    `bson:"parcels" json:"parcels"`
+	TotalCOD     float64             `bson:"totalCod" json:"totalCod"`
+	CollectedCOD float64             `bson:"collectedCod" json:"collectedCod"`
+	Delivered    int                 `bson:"delivered" json:"delivered"`
+	Returned     int                 `bson:"returned" json:"returned"`
+	CarriedOver  int                 `bson:"carriedOver" json:"carriedOver"`
+	OpenedBy     primitive.ObjectID  `bson:"openedBy" json:"openedBy"`
+	ClosedBy     *primitive.ObjectID `bson:"closedBy,omitempty" json:"closedBy,omitempty"`
+	ClosedAt     *time.Time          `bson:"closedAt,omitempty" json:"closedAt,omitempty"`
+	CreatedAt    time.Time           `bson:"createdAt" json:"createdAt"`
+	UpdatedAt    time.Time           `bson:"updatedAt" json:"updatedAt"`
+}
+
+func (r RunSheet) CollectionName() string {
+	return "runSheets"
+}
+
+func initRunSheetIndex(db *mongo.Database) error {
+	runSheetCol := db.Collection(RunSheet{}.CollectionName())
+	if err := createIndex(runSheetCol, bson.D{{Key: "riderId", Value: 1}, {Key: "date", Value: 1}}, true); err != nil {
+		return err
+	}
+	if err := createIndex(runSheetCol, bson.D{{Key: "hubId", Value: 1}, {Key: "date", Value: 1}}, false); err != nil {
+		return err
+	}
+	return nil
+}
@@ -18,15 +18,20 @@ type Shop struct {
 	Address        string               `bson:"address,omitempty" json:"address"`
 	PickupAddress  string               `bson:"pickupAddress,omitempty" json:"pickupAddress"`
 	PickupArea     string               `bson:"pickupArea,omitempty" json:"pickupArea"`
+	PickupAreaID   *primitive.ObjectID  `bson:"pickupAreaId,omitempty" json:"pickupAreaId,omitempty"`
 	DeliveryZone   string               `bson:"deliveryZone,omitempty" json:"deliveryZone"`
+	DeliveryZoneID *primitive.ObjectID  `bson:"deliveryZoneId,omitempty" json:"deliveryZoneId,omitempty"`
 	Coupon         string               `bson:"coupon,omitempty" json:"coupon,omitempty"`
 	Image          string               `bson:"image,omitempty" json:"image,omitempty"`
+	ImageURL       string               `bson:"-" json:"imageUrl,omitempty"`
 	Status         string               `bson:"status,omitempty" json:"status"`
 	Owner          primitive.ObjectID   `bson:"owner,omitempty" json:"owner"`
 	FBPage         string               `bson:"fbPage,omitempty" json:"fbPage"`
 	Moderators     []primitive.ObjectID `bson:"moderators,omitempty" json:"moderators,omitempty"`
 	DeliveryCharge float64              `bson:"DeliveryCharge,omitempty" json:"deliveryCharge"`
 	COD            float64              `bson:"cod" json:"cod"`
+	RateCard       string               `bson:"rateCard,omitempty" json:"rateCard,omitempty"`
+	ProofPolicy    *ProofPolicy         `bson:"proofPolicy,omitempty" json:"proofPolicy,omitempty"`
 	AdminID        primitive.ObjectID   `bson:"adminId,omitempty" json:"-"`
 	CreatedAt      time.Time            `bson:"createdAt,omitempty" json:"createdAt"`
 	UpdatedAt      time.Time            `bson:"updatedAt,omitempty" json:"updatedAt"`
@@ -46,6 +51,9 @@ func initShopIndex(db *mongo.Database) error {
 	if err := createIndex(shopCol, bson.M{"owner": 1}, false); err != nil {
 		return err
 	}
+	if err := createIndex(shopCol, bson.M{"moderators": 1}, false); err != nil {
+		return err
+	}
 	if err := createIndex(shopCol, bson.M{"phone": 1}, false); err != nil {
 		return err
 	}
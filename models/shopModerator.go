@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"github.com/techartificer/swiftex/constants"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ShopModerator holds a moderator invitation of a shop, merchant is set once the invitation is accepted
+type ShopModerator struct {
+	ID          primitive.ObjectID         `bson:"_id,omitempty" json:"id"`
+	ShopID      primitive.ObjectID         `bson:"shopId,omitempty" json:"shopId"`
+	ShopName    string                     `bson:"shopName,omitempty" json:"shopName"`
+	Phone       string                     `bson:"phone,omitempty" json:"phone"`
+	MerchantID  *primitive.ObjectID        `bson:"merchantId,omitempty" json:"merchantId,omitempty"`
+	Permissions []constants.ShopPermission `bson:"permissions" json:"permissions"`
+	Status      string                     `bson:"status,omitempty" json:"status"`
+	InvitedBy   primitive.ObjectID         `bson:"invitedBy,omitempty" json:"invitedBy"`
+	AcceptedAt  *time.Time                 `bson:"acceptedAt,omitempty" json:"acceptedAt,omitempty"`
+	CreatedAt   time.Time                  `bson:"createdAt,omitempty" json:"createdAt"`
+	UpdatedAt   time.Time                  `bson:"updatedAt,omitempty" json:"updatedAt"`
+}
+
+// CollectionName returns name of the models
+func (s ShopModerator) CollectionName() string {
+	return "shopModerators"
+}
+
+// HasPermission reports whether the moderator has been granted p
+func (s *ShopModerator) HasPermission(p constants.ShopPermission) bool {
+	for _, v := range s.Permissions {
+		if v == p {
+			return true
+		}
+	}
+	return false
+}
+
+func initShopModeratorIndex(db *mongo.Database) error {
+	shopModeratorCol := db.Collection(ShopModerator{}.CollectionName())
+	if err := createIndex(shopModeratorCol, bson.D{{Key: "shopId", Value: 1}, {Key: "phone", Value: 1}}, true); err != nil {
+		return err
+	}
+	if err := createIndex(shopModeratorCol, bson.M{"phone": 1}, false); err != nil {
+		return err
+	}
+	if err := createIndex(shopModeratorCol, bson.M{"merchantId": 1}, false); err != nil {
+		return err
+	}
+	return nil
+}
@@ -18,6 +18,7 @@ const (
 type TrxHistory struct {
 	ID          primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
 	Payment     float64             `bson:"payment,omitempty" json:"payment"`
+	Collected   float64             `bson:"collected,omitempty" json:"collected,omitempty"`
 	TrxID       primitive.ObjectID  `bson:"trxId,omitempty" json:"trxId"`
 	ShopID      primitive.ObjectID  `bson:"shopId,omitempty" json:"shopId"`
 	OrderID     *primitive.ObjectID `bson:"orderId,omitempty" json:"orderId"`
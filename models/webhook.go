@@ -0,0 +1,87 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	OrderStatusChangedEvent string = "order.status_changed"
+	CashOutCompletedEvent   string = "cashout.completed"
+)
+
+// WebhookEvents holds all events a webhook can subscribe
+var WebhookEvents = []string{OrderStatusChangedEvent, CashOutCompletedEvent}
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "Pending"
+	DeliveryDelivered DeliveryStatus = "Delivered"
+	// DeliveryDead is set when all retries of a delivery failed
+	DeliveryDead DeliveryStatus = "Dead"
+)
+
+// Webhook holds a shop's subscription, empty events means all events
+type Webhook struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ShopID    primitive.ObjectID `bson:"shopId,omitempty" json:"shopId"`
+	URL       string             `bson:"url,omitempty" json:"url"`
+	Secret    string             `bson:"secret,omitempty" json:"-"`
+	Events    []string           `bson:"events" json:"events"`
+	Status    string             `bson:"status,omitempty" json:"status"`
+	CreatedBy primitive.ObjectID `bson:"createdBy,omitempty" json:"createdBy"`
+	CreatedAt time.Time          `bson:"createdAt,omitempty" json:"createdAt"`
+	UpdatedAt time.Time          `bson:"updatedAt,omitempty" json:"updatedAt"`
+}
+
+// CollectionName returns name of the models
+func (w Webhook) CollectionName() string {
+	return "webhooks"
+}
+
+// WebhookDelivery holds a queued webhook call and its attempts
+type WebhookDelivery struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	WebhookID      primitive.ObjectID `bson:"webhookId,omitempty" json:"webhookId"`
+	EventID        primitive.ObjectID `bson:"eventId,omitempty" json:"eventId"`
+	ShopID         primitive.ObjectID `bson:"shopId,omitempty" json:"shopId"`
+	Event          string             `bson:"event,omitempty" json:"event"`
+	Payload        string             `bson:"payload,omitempty" json:"payload"`
+	Status         DeliveryStatus     `bson:"status,omitempty" json:"status"`
+	Attempts       int                `bson:"attempts" json:"attempts"`
+	LastStatusCode int                `bson:"lastStatusCode,omitempty" json:"lastStatusCode,omitempty"`
+	LastError      string             `bson:"lastError,omitempty" json:"lastError,omitempty"`
+	NextAttemptAt  time.Time          `bson:"nextAttemptAt,omitempty" json:"nextAttemptAt"`
+	DeliveredAt    *time.Time         `bson:"deliveredAt,omitempty" json:"deliveredAt,omitempty"`
+	CreatedAt      time.Time          `bson:"createdAt,omitempty" json:"createdAt"`
+	UpdatedAt      time.Time          `bson:"updatedAt,omitempty" json:"updatedAt"`
+}
+
+// CollectionName returns name of the models
+func (w WebhookDelivery) CollectionName() string {
+	return "webhookDeliveries"
+}
+
+func initWebhookIndex(db *mongo.Database) error {
+	webhookCol := db.Collection(Webhook{}.CollectionName())
+	if err := createIndex(webhookCol, bson.M{"shopId": 1}, false); err != nil {
+		return err
+	}
+	deliveryCol := db.Collection(WebhookDelivery{}.CollectionName())
+	if err := createIndex(deliveryCol, bson.D{{Key: "status", Value: 1}, {Key: "nextAttemptAt", Value: 1}}, false); err != nil {
+		return err
+	}
+	// deliveries created before events have no eventId
+	eventKeys := bson.D{{Key: "eventId", Value: 1}, {Key: "webhookId", Value: 1}}
+	if err := createPartialIndex(deliveryCol, "eventId_webhookId_unique", eventKeys, bson.M{"eventId": bson.M{"$exists": true}}); err != nil {
+		return err
+	}
+	if err := createIndex(deliveryCol, bson.M{"shopId": 1}, false); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package serializer
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// DispatchAssignment holds the rider proposed for an order
+type DispatchAssignment struct {
+	OrderID   primitive.ObjectID `json:"orderId"`
+	TrackID   string             `json:"trackId"`
+	RiderID   primitive.ObjectID `json:"riderId"`
+	RiderName string             `json:"riderName"`
+	Hub       string             `json:"hub"`
+}
+
+// DispatchSkipped holds an order no rider could be proposed for
+type DispatchSkipped struct {
+	OrderID primitive.ObjectID `json:"orderId"`
+	TrackID string             `json:"trackId,omitempty"`
+	Reason  string             `json:"reason"`
+}
+
+// DispatchRider holds load of a rider considered for dispatch
+type DispatchRider struct {
+	RiderID       primitive.ObjectID `bson:"_id" json:"riderId"`
+	Name          string             `bson:"-" json:"name"`
+	Hub           string             `bson:"-" json:"hub"`
+	Open          int                `bson:"open" json:"open"`
+	AssignedToday int                `bson:"assignedToday" json:"assignedToday"`
+	Capacity      int                `bson:"-" json:"capacity"`
+	Proposed      int                `bson:"-" json:"proposed"`
+	OverCashLimit bool               `bson:"-" json:"overCashLimit"`
+}
+
+// DispatchPlan holds proposed rider assignments of orders
+type DispatchPlan struct {
+	Assignments []DispatchAssignment `json:"assignments"`
+	Skipped     []DispatchSkipped    `json:"skipped"`
+	Riders      []DispatchRider      `json:"riders"`
+}
@@ -0,0 +1,29 @@
+package serializer
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// LedgerMismatch holds a stored balance which does not match the journal
+type LedgerMismatch struct {
+	AccountType string              `json:"accountType"`
+	OwnerID     *primitive.ObjectID `json:"ownerId,omitempty"`
+	Stored      float64             `json:"stored"`
+	Expected    float64             `json:"expected"`
+	Fixed       bool                `json:"fixed"`
+}
+
+// RiderCash holds cash a rider is holding
+type RiderCash struct {
+	RiderID    primitive.ObjectID `bson:"_id" json:"riderId"`
+	Name       string             `bson:"name" json:"name"`
+	Phone      string             `bson:"phone" json:"phone"`
+	Hub        string             `bson:"hub" json:"hub"`
+	CashInHand float64            `bson:"cashInHand" json:"cashInHand"`
+	OverLimit  bool               `bson:"-" json:"overLimit"`
+}
+
+// HubCash holds cash riders of a hub are holding
+type HubCash struct {
+	Hub        string  `bson:"_id" json:"hub"`
+	Riders     int     `bson:"riders" json:"riders"`
+	CashInHand float64 `bson:"cashInHand" json:"cashInHand"`
+}
@@ -0,0 +1,22 @@
+package serializer
+
+// UnresolvedLocation holds a free text location value which could not be mapped to registry
+type UnresolvedLocation struct {
+	Collection string `json:"collection"`
+	Field      string `json:"field"`
+	Value      string `json:"value"`
+	Count      int64  `json:"count"`
+}
+
+// LocationMigration holds result of free text location migration
+type LocationMigration struct {
+	DryRun        bool                 `json:"dryRun"`
+	CreatedHubs   int                  `json:"createdHubs"`
+	CreatedCities int                  `json:"createdCities"`
+	CreatedThanas int                  `json:"createdThanas"`
+	CreatedAreas  int                  `json:"createdAreas"`
+	Orders        int64                `json:"orders"`
+	Riders        int64                `json:"riders"`
+	Shops         int64                `json:"shops"`
+	Unresolved    []UnresolvedLocation `json:"unresolved"`
+}
@@ -0,0 +1,10 @@
+package serializer
+
+import "github.com/techartificer/swiftex/models"
+
+// OrderTracking holds public tracking data of an order
+type OrderTracking struct {
+	TrackID string                `json:"trackId"`
+	Status  []models.OrderStatus  `json:"status"`
+	Proof   *models.DeliveryProof `json:"proof,omitempty"`
+}
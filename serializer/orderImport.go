@@ -0,0 +1,29 @@
+package serializer
+
+const (
+	ImportRowValid   = "Valid"
+	ImportRowInvalid = "Invalid"
+	ImportRowCreated = "Created"
+	ImportRowFailed  = "Failed"
+)
+
+// ImportRowResult holds result of a row of order import
+type ImportRowResult struct {
+	Row       int               `json:"row"`
+	Status    string            `json:"status"`
+	TrackID   string            `json:"trackId,omitempty"`
+	Charge    float64           `json:"charge,omitempty"`
+	CODCharge float64           `json:"codCharge,omitempty"`
+	Errors    map[string]string `json:"errors,omitempty"`
+}
+
+// OrderImportReport holds row level result of an order import
+type OrderImportReport struct {
+	DryRun  bool              `json:"dryRun"`
+	Total   int               `json:"total"`
+	Valid   int               `json:"valid"`
+	Invalid int               `json:"invalid"`
+	Created int               `json:"created"`
+	Failed  int               `json:"failed"`
+	Rows    []ImportRowResult `json:"rows"`
+}
@@ -3,6 +3,7 @@ package serializer
 import (
 	"time"
 
+	"github.com/techartificer/swiftex/models"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -15,14 +16,15 @@ type shop struct {
 }
 
 type CashOutRequests struct {
-	Shop             shop               `bson:"shop,omitempty" json:"shop"`
-	ID               primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	ShopID           primitive.ObjectID `bson:"shopId,omitempty" json:"shopId"`
-	Owner            primitive.ObjectID `bson:"owner,omitempty" json:"owner"`
-	Balance          float64            `bson:"balance,omitempty" json:"balance"`
-	TrxCode          string             `bson:"trxCode,omitempty" json:"-"`
-	TrxCodeExpiresAt int64              `bson:"trxCodeExpiresAt,omitempty" json:"trxCodeExpiresAt"`
-	Amount           int64              `bson:"amount,omitempty" json:"amount"`
-	CreatedAt        time.Time          `bson:"createdAt,omitempty" json:"createdAt"`
-	UpdatedAt        time.Time          `bson:"updatedAt,omitempty" json:"updatedAt"`
+	Shop             shop                 `bson:"shop,omitempty" json:"shop"`
+	PayoutMethod     *models.PayoutMethod `bson:"payoutMethod,omitempty" json:"payoutMethod"`
+	ID               primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+	ShopID           primitive.ObjectID   `bson:"shopId,omitempty" json:"shopId"`
+	Owner            primitive.ObjectID   `bson:"owner,omitempty" json:"owner"`
+	Balance          float64              `bson:"balance,omitempty" json:"balance"`
+	TrxCode          string               `bson:"trxCode,omitempty" json:"-"`
+	TrxCodeExpiresAt int64                `bson:"trxCodeExpiresAt,omitempty" json:"trxCodeExpiresAt"`
+	Amount           int64                `bson:"amount,omitempty" json:"amount"`
+	CreatedAt        time.Time            `bson:"createdAt,omitempty" json:"createdAt"`
+	UpdatedAt        time.Time            `bson:"updatedAt,omitempty" json:"updatedAt"`
 }
@@ -73,4 +73,28 @@ func registerV1Routes() {
 	api.RegisterRiderRoutes(rider)
 	trx := v1.Group("/transaction")
 	api.RegisterTransactionRoutes(trx)
+	rateCard := v1.Group("/rate-card")
+	api.RegisterRateCardRoutes(rateCard)
+	ledger := v1.Group("/ledger")
+	api.RegisterLedgerRoutes(ledger)
+	webhook := v1.Group("/webhook")
+	api.RegisterWebhookRoutes(webhook)
+	riderCash := v1.Group("/rider-cash")
+	api.RegisterRiderCashRoutes(riderCash)
+	location := v1.Group("/location")
+	api.RegisterLocationRoutes(location)
+	audit := v1.Group("/audit")
+	api.RegisterAuditRoutes(audit)
+	runSheet := v1.Group("/run-sheet")
+	api.RegisterRunSheetRoutes(runSheet)
+	pickup := v1.Group("/pickup")
+	api.RegisterPickupRoutes(pickup)
+	orderReturn := v1.Group("/return")
+	api.RegisterReturnRoutes(orderReturn)
+	payout := v1.Group("/payout")
+	api.RegisterPayoutRoutes(payout)
+	invoice := v1.Group("/invoice")
+	api.RegisterInvoiceRoutes(invoice)
+	fs := v1.Group("/fs")
+	api.RegisterFileRoutes(fs)
 }
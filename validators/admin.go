@@ -12,11 +12,12 @@ import (
 
 // ReqAdminAdd holds admin add request data
 type ReqAdminAdd struct {
-	Phone    string              `json:"phone,omitempty" validate:"required"`
-	Name     string              `json:"name,omitempty" validate:"required"`
-	Email    string              `json:"email,omitempty" validate:"required,email"`
-	Role     constants.AdminRole `json:"role,omitempty" validate:"required,isValidRole"`
-	Password string              `json:"password,omitempty" validate:"required,min=6,max=26"`
+	Phone    string               `json:"phone,omitempty" validate:"required"`
+	Name     string               `json:"name,omitempty" validate:"required"`
+	Email    string               `json:"email,omitempty" validate:"required,email"`
+	Role     constants.AdminRole  `json:"role,omitempty" validate:"required,isValidRole"`
+	Password string               `json:"password,omitempty" validate:"required,min=6,max=26"`
+	Hubs     []primitive.ObjectID `json:"hubs,omitempty" validate:"omitempty"`
 }
 
 func isValidRole(fl validator.FieldLevel) bool {
@@ -46,6 +47,7 @@ func ValidateAddAdmin(ctx echo.Context) (*models.Admin, error) {
 		Phone:     body.Phone,
 		Password:  body.Password,
 		Role:      body.Role,
+		Hubs:      body.Hubs,
 		Status:    constants.Active,
 		CreatedAt: time.Now().UTC(),
 	}
@@ -54,12 +56,13 @@ func ValidateAddAdmin(ctx echo.Context) (*models.Admin, error) {
 
 // ReqAdminUpdate holds status update data request data
 type ReqAdminUpdate struct {
-	Phone    string              `json:"phone,omitempty"  bson:"phone,omitempty"`
-	Name     string              `json:"name,omitempty" bson:"name,omitempty"`
-	Email    *string             `json:"email,omitempty" validate:"omitempty,email" bson:"email,omitempty"`
-	Role     constants.AdminRole `json:"role,omitempty" validate:"omitempty,isValidRole" bson:"role,omitempty"`
-	Status   string              `json:"status,omitempty" validate:"omitempty,isValidStatus" bson:"status,omitempty"`
-	Password string              `json:"password,omitempty" validate:"omitempty" bson:"password,omitempty"`
+	Phone    string                `json:"phone,omitempty"  bson:"phone,omitempty"`
+	Name     string                `json:"name,omitempty" bson:"name,omitempty"`
+	Email    *string               `json:"email,omitempty" validate:"omitempty,email" bson:"email,omitempty"`
+	Role     constants.AdminRole   `json:"role,omitempty" validate:"omitempty,isValidRole" bson:"role,omitempty"`
+	Status   string                `json:"status,omitempty" validate:"omitempty,isValidStatus" bson:"status,omitempty"`
+	Password string                `json:"password,omitempty" validate:"omitempty" bson:"password,omitempty"`
+	Hubs     *[]primitive.ObjectID `json:"hubs,omitempty" validate:"omitempty" bson:"hubs,omitempty"`
 }
 
 func isValidStatus(fl validator.FieldLevel) bool {
@@ -0,0 +1,8 @@
+package validators
+
+// DeliveryProofReq holds proofs submitted with a delivery, proofs which are not sent are empty
+type DeliveryProofReq struct {
+	OTP       string
+	Signature *ImageFile
+	Photo     *ImageFile
+}
@@ -0,0 +1,64 @@
+package validators
+
+import (
+	"errors"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/techartificer/swiftex/constants"
+	"github.com/techartificer/swiftex/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type DispatchPreviewReq struct {
+	OrderIDs []primitive.ObjectID `json:"orderIds" validate:"required,min=1,max=500"`
+}
+
+func ValidateDispatchPreview(ctx echo.Context) ([]primitive.ObjectID, error) {
+	body := DispatchPreviewReq{}
+	if err := ctx.Bind(&body); err != nil {
+		return nil, err
+	}
+	if err := GetValidationError(body); err != nil {
+		return nil, err
+	}
+	return body.OrderIDs, nil
+}
+
+type DispatchAssignmentReq struct {
+	OrderID primitive.ObjectID `json:"orderId" validate:"required"`
+	RiderID primitive.ObjectID `json:"riderId" validate:"required"`
+}
+
+type DispatchCommitReq struct {
+	Assignments []DispatchAssignmentReq `json:"assignments" validate:"required,min=1,max=500,dive"`
+}
+
+// ValidateDispatchCommit returns rider parcels of a previewed or edited dispatch plan
+func ValidateDispatchCommit(ctx echo.Context) ([]*models.RiderParcel, error) {
+	body := DispatchCommitReq{}
+	if err := ctx.Bind(&body); err != nil {
+		return nil, err
+	}
+	if err := GetValidationError(body); err != nil {
+		return nil, err
+	}
+	creator := ctx.Get(constants.UserID).(primitive.ObjectID)
+	seen := make(map[primitive.ObjectID]bool)
+	parcels := []*models.RiderParcel{}
+	for _, assignment := range body.Assignments {
+		if seen[assignment.OrderID] {
+			return nil, errors.New("order " + assignment.OrderID.Hex() + " is assigned more than once")
+		}
+		seen[assignment.OrderID] = true
+		parcels = append(parcels, &models.RiderParcel{
+			ID:         primitive.NewObjectID(),
+			RiderID:    assignment.RiderID,
+			OrderID:    assignment.OrderID,
+			Status:     constants.Assigned,
+			AssignedBy: creator,
+			CreatedAt:  time.Now().UTC(),
+		})
+	}
+	return parcels, nil
+}
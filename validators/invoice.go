@@ -0,0 +1,40 @@
+package validators
+
+import (
+	"errors"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+type InvoiceGenerateReq struct {
+	Period string `json:"period" validate:"required"`
+}
+
+// ValidateInvoicePeriod returns start of the monthly period written as 2006-01
+func ValidateInvoicePeriod(period string) (time.Time, error) {
+	start, err := time.Parse("2006-01", period)
+	if err != nil {
+		return start, errors.New("period must be a month like 2006-01")
+	}
+	return start.UTC(), nil
+}
+
+// ValidateInvoiceGenerate returns start of the period to invoice, only ended periods are invoiced
+func ValidateInvoiceGenerate(ctx echo.Context) (time.Time, error) {
+	body := InvoiceGenerateReq{}
+	if err := ctx.Bind(&body); err != nil {
+		return time.Time{}, err
+	}
+	if err := GetValidationError(body); err != nil {
+		return time.Time{}, err
+	}
+	start, err := ValidateInvoicePeriod(body.Period)
+	if err != nil {
+		return start, err
+	}
+	if start.AddDate(0, 1, 0).After(time.Now().UTC()) {
+		return start, errors.New("period has not ended yet")
+	}
+	return start, nil
+}
@@ -0,0 +1,74 @@
+package validators
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/techartificer/swiftex/lib/label"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MaxLabelsPerBatch is the maximum number of labels in a pdf
+const MaxLabelsPerBatch = 300
+
+type LabelBatchReq struct {
+	OrderIDs []string `json:"orderIds" validate:"omitempty,max=300"`
+	PickHub  string   `json:"pickHub" validate:"omitempty"`
+	Size     string   `json:"size" validate:"omitempty"`
+}
+
+// LabelBatch holds orders to print labels of,
+// orders waiting for pickup at PickHub are printed if OrderIDs is empty
+type LabelBatch struct {
+	OrderIDs []primitive.ObjectID
+	PickHub  string
+	Size     string
+}
+
+// labelSize returns size in lower case, a6 is the default
+func labelSize(size string) (string, error) {
+	size = strings.ToLower(strings.TrimSpace(size))
+	if size == "" {
+		return label.A6, nil
+	}
+	if !label.IsValidSize(size) {
+		return "", label.ErrInvalidSize
+	}
+	return size, nil
+}
+
+// ValidateLabelSize returns label size from query param size
+func ValidateLabelSize(ctx echo.Context) (string, error) {
+	return labelSize(ctx.QueryParam("size"))
+}
+
+// ValidateLabelBatch returns orders of label batch or error
+func ValidateLabelBatch(ctx echo.Context, allowHub bool) (*LabelBatch, error) {
+	body := LabelBatchReq{}
+	if err := ctx.Bind(&body); err != nil {
+		return nil, err
+	}
+	if err := GetValidationError(body); err != nil {
+		return nil, err
+	}
+	size, err := labelSize(body.Size)
+	if err != nil {
+		return nil, err
+	}
+	batch := &LabelBatch{Size: size}
+	for _, ID := range body.OrderIDs {
+		_id, err := primitive.ObjectIDFromHex(ID)
+		if err != nil {
+			return nil, err
+		}
+		batch.OrderIDs = append(batch.OrderIDs, _id)
+	}
+	if allowHub {
+		batch.PickHub = strings.TrimSpace(body.PickHub)
+	}
+	if len(batch.OrderIDs) == 0 && batch.PickHub == "" {
+		return nil, errors.New("orderIds is required")
+	}
+	return batch, nil
+}
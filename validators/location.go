@@ -0,0 +1,206 @@
+package validators
+
+import (
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/techartificer/swiftex/constants"
+	"github.com/techartificer/swiftex/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type HubReq struct {
+	Name    string `json:"name" validate:"required,max=60"`
+	CityID  string `json:"cityId" validate:"omitempty,len=24"`
+	Address string `json:"address" validate:"omitempty"`
+	Phone   string `json:"phone" validate:"omitempty"`
+}
+
+type HubUpdateReq struct {
+	Name    string `json:"name" validate:"omitempty,max=60"`
+	CityID  string `json:"cityId" validate:"omitempty,len=24"`
+	Address string `json:"address" validate:"omitempty"`
+	Phone   string `json:"phone" validate:"omitempty"`
+	Status  string `json:"status" validate:"omitempty,oneof=Active Deactive"`
+}
+
+type LocationReq struct {
+	Name    string `json:"name" validate:"required,max=60"`
+	CityID  string `json:"cityId" validate:"omitempty,len=24"`
+	ThanaID string `json:"thanaId" validate:"omitempty,len=24"`
+	HubID   string `json:"hubId" validate:"omitempty,len=24"`
+}
+
+type LocationUpdateReq struct {
+	Name    string `json:"name" validate:"omitempty,max=60"`
+	ThanaID string `json:"thanaId" validate:"omitempty,len=24"`
+	Status  string `json:"status" validate:"omitempty,oneof=Active Deactive"`
+}
+
+type CoverageReq struct {
+	Add    []primitive.ObjectID `json:"add" validate:"omitempty"`
+	Remove []primitive.ObjectID `json:"remove" validate:"omitempty"`
+}
+
+// objectIDOf returns nil for empty hex
+func objectIDOf(hex string) (*primitive.ObjectID, error) {
+	if hex == "" {
+		return nil, nil
+	}
+	_id, err := primitive.ObjectIDFromHex(hex)
+	if err != nil {
+		return nil, err
+	}
+	return &_id, nil
+}
+
+func ValidateHubCreate(ctx echo.Context) (*models.Hub, error) {
+	body := HubReq{}
+	if err := ctx.Bind(&body); err != nil {
+		return nil, err
+	}
+	if err := GetValidationError(body); err != nil {
+		return nil, err
+	}
+	cityID, err := objectIDOf(body.CityID)
+	if err != nil {
+		return nil, err
+	}
+	creator := ctx.Get(constants.UserID).(primitive.ObjectID)
+	hub := &models.Hub{
+		ID:        primitive.NewObjectID(),
+		Name:      strings.TrimSpace(body.Name),
+		CityID:    cityID,
+		Address:   body.Address,
+		Phone:     body.Phone,
+		Status:    constants.Active,
+		CreatedBy: &creator,
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+	}
+	return hub, nil
+}
+
+func ValidateHubUpdate(ctx echo.Context) (*models.Hub, error) {
+	body := HubUpdateReq{}
+	if err := ctx.Bind(&body); err != nil {
+		return nil, err
+	}
+	if err := GetValidationError(body); err != nil {
+		return nil, err
+	}
+	cityID, err := objectIDOf(body.CityID)
+	if err != nil {
+		return nil, err
+	}
+	hub := &models.Hub{
+		Name:    strings.TrimSpace(body.Name),
+		CityID:  cityID,
+		Address: body.Address,
+		Phone:   body.Phone,
+		Status:  body.Status,
+	}
+	return hub, nil
+}
+
+func validateLocationReq(ctx echo.Context) (*LocationReq, error) {
+	body := LocationReq{}
+	if err := ctx.Bind(&body); err != nil {
+		return nil, err
+	}
+	if err := GetValidationError(body); err != nil {
+		return nil, err
+	}
+	body.Name = strings.TrimSpace(body.Name)
+	return &body, nil
+}
+
+func ValidateCityCreate(ctx echo.Context) (*models.City, error) {
+	body, err := validateLocationReq(ctx)
+	if err != nil {
+		return nil, err
+	}
+	city := &models.City{
+		ID:        primitive.NewObjectID(),
+		Name:      body.Name,
+		Status:    constants.Active,
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+	}
+	return city, nil
+}
+
+func ValidateThanaCreate(ctx echo.Context) (*models.Thana, error) {
+	body, err := validateLocationReq(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cityID, err := primitive.ObjectIDFromHex(body.CityID)
+	if err != nil {
+		return nil, err
+	}
+	thana := &models.Thana{
+		ID:        primitive.NewObjectID(),
+		CityID:    cityID,
+		Name:      body.Name,
+		Status:    constants.Active,
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+	}
+	return thana, nil
+}
+
+func ValidateAreaCreate(ctx echo.Context) (*models.Area, error) {
+	body, err := validateLocationReq(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cityID, err := primitive.ObjectIDFromHex(body.CityID)
+	if err != nil {
+		return nil, err
+	}
+	thanaID, err := objectIDOf(body.ThanaID)
+	if err != nil {
+		return nil, err
+	}
+	hubID, err := objectIDOf(body.HubID)
+	if err != nil {
+		return nil, err
+	}
+	area := &models.Area{
+		ID:        primitive.NewObjectID(),
+		CityID:    cityID,
+		ThanaID:   thanaID,
+		HubID:     hubID,
+		Name:      body.Name,
+		Status:    constants.Active,
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+	}
+	return area, nil
+}
+
+// ValidateLocationUpdate returns request body or error, thana is only applicable to areas
+func ValidateLocationUpdate(ctx echo.Context) (*LocationUpdateReq, error) {
+	body := LocationUpdateReq{}
+	if err := ctx.Bind(&body); err != nil {
+		return nil, err
+	}
+	if err := GetValidationError(body); err != nil {
+		return nil, err
+	}
+	body.Name = strings.TrimSpace(body.Name)
+	return &body, nil
+}
+
+func ValidateCoverage(ctx echo.Context) (*CoverageReq, error) {
+	body := CoverageReq{}
+	if err := ctx.Bind(&body); err != nil {
+		return nil, err
+	}
+	if err := GetValidationError(body); err != nil {
+		return nil, err
+	}
+	return &body, nil
+}
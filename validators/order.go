@@ -27,6 +27,7 @@ type OrderCreateReq struct {
 	PickHub               string    `validate:"required" json:"pickHub"`
 	Comments              string    `validate:"omitempty,max=300" json:"comments"`
 	NumberOfItems         int       `validate:"omitempty" json:"numberOfItems"`
+	ExchangeItems         int       `validate:"omitempty,gte=0" json:"exchangeItems"`
 	Weight                float32   `validate:"required,number,gt=0" json:"weight"`
 	DeliveryType          string    `validate:"required" json:"deliveryType"`
 }
@@ -38,19 +39,10 @@ func isPriceAcceptable(paymentStatus string, price float64) bool {
 	return true
 }
 
-func ValidateOrderCreate(ctx echo.Context) (*models.Order, error) {
-	body := OrderCreateReq{}
-	if err := ctx.Bind(&body); err != nil {
-		return nil, err
-	}
-	if err := GetValidationError(body); err != nil {
-		return nil, err
-	}
-	if flag := isPriceAcceptable(body.PaymentStatus, body.Price); !flag {
-		return nil, errors.New("price can not be zero")
-	}
+// newOrder returns a new order in created status from request body
+func newOrder(body *OrderCreateReq) *models.Order {
 	created := constants.Created
-	order := &models.Order{
+	return &models.Order{
 		ID:                    primitive.NewObjectID(),
 		RiderID:               nil,
 		ShopModeratorID:       nil,
@@ -69,6 +61,7 @@ func ValidateOrderCreate(ctx echo.Context) (*models.Order, error) {
 		PickHub:               body.PickHub,
 		Price:                 body.Price,
 		NumberOfItems:         body.NumberOfItems,
+		ExchangeItems:         body.ExchangeItems,
 		Comments:              body.Comments,
 		DeliveryType:          body.DeliveryType,
 		PaymentStatus:         body.PaymentStatus,
@@ -86,7 +79,20 @@ func ValidateOrderCreate(ctx echo.Context) (*models.Order, error) {
 		},
 		CreatedAt: time.Now().UTC(),
 	}
-	return order, nil
+}
+
+func ValidateOrderCreate(ctx echo.Context) (*models.Order, error) {
+	body := OrderCreateReq{}
+	if err := ctx.Bind(&body); err != nil {
+		return nil, err
+	}
+	if err := GetValidationError(body); err != nil {
+		return nil, err
+	}
+	if flag := isPriceAcceptable(body.PaymentStatus, body.Price); !flag {
+		return nil, errors.New("price can not be zero")
+	}
+	return newOrder(&body), nil
 }
 
 type MultipleOrderCreateReq struct {
@@ -101,49 +107,13 @@ func ValidateMultipleOrderCreate(ctx echo.Context) ([]models.Order, error) {
 	if err := GetValidationError(body); err != nil {
 		return nil, err
 	}
-	created := constants.Created
 	var orders []models.Order
-	for _, o := range body.Orders {
+	for i := range body.Orders {
+		o := &body.Orders[i]
 		if flag := isPriceAcceptable(o.PaymentStatus, o.Price); !flag {
 			return nil, errors.New("price can not be zero")
 		}
-		order := models.Order{
-			ID:                    primitive.NewObjectID(),
-			RiderID:               nil,
-			ShopModeratorID:       nil,
-			MerchantID:            nil,
-			RecipientName:         o.RecipientName,
-			RecipientPhone:        o.RecipientPhone,
-			RecipientCity:         o.RecipientCity,
-			RecipientThana:        o.RecipientThana,
-			RecipientZip:          o.RecipientZip,
-			RecipientArea:         o.RecipientArea,
-			RecipientAddress:      o.RecipientAddress,
-			PackageCode:           o.PackageCode,
-			PercelType:            o.PercelType,
-			RequestedDeliveryTime: o.RequestedDeliveryTime,
-			PickAddress:           o.PickAddress,
-			PickHub:               o.PickHub,
-			Price:                 o.Price,
-			NumberOfItems:         o.NumberOfItems,
-			Comments:              o.Comments,
-			DeliveryType:          o.DeliveryType,
-			PaymentStatus:         o.PaymentStatus,
-			Weight:                o.Weight,
-			IsCancelled:           false,
-			CurrentStatus:         &created,
-			IsAccepted:            false,
-			Status: []models.OrderStatus{
-				{
-					ID:     primitive.NewObjectID(),
-					Text:   "Your order have benn placed successfully",
-					Status: constants.Created,
-					Time:   time.Now().UTC(),
-				},
-			},
-			CreatedAt: time.Now().UTC(),
-		}
-		orders = append(orders, order)
+		orders = append(orders, *newOrder(o))
 	}
 	return orders, nil
 }
@@ -197,6 +167,7 @@ type OrderUpdateReq struct {
 	PickHub               string             `validate:"omitempty" json:"pickHub"`
 	Comments              string             `validate:"omitempty,max=300" json:"comments"`
 	NumberOfItems         int                `validate:"omitempty" json:"numberOfItems"`
+	ExchangeItems         int                `validate:"omitempty,gte=0" json:"exchangeItems"`
 	DeliveryType          string             `validate:"required" json:"deliveryType"`
 	Weight                float32            `validate:"required,number,gt=0" json:"weight"`
 }
@@ -229,6 +200,7 @@ func UpdateOrder(ctx echo.Context) (*models.Order, error) {
 		PickHub:               body.PickHub,
 		Price:                 body.Price,
 		NumberOfItems:         body.NumberOfItems,
+		ExchangeItems:         body.ExchangeItems,
 		Comments:              body.Comments,
 		DeliveryType:          body.DeliveryType,
 		PaymentStatus:         body.PaymentStatus,
@@ -241,28 +213,39 @@ func UpdateOrder(ctx echo.Context) (*models.Order, error) {
 }
 
 type OrderDeliverReq struct {
-	Payment float64 `validate:"required,number,gt=-1" json:"payment"`
-	Remarks string  `validate:"omitempty" json:"remarks"`
-	ShopID  string  `validate:"required" json:"shopId"`
+	Payment float64 `validate:"required,number,gt=-1" json:"payment" form:"payment"`
+	Remarks string  `validate:"omitempty" json:"remarks" form:"remarks"`
+	ShopID  string  `validate:"required" json:"shopId" form:"shopId"`
+	OTP     string  `validate:"omitempty,numeric" json:"otp" form:"otp"`
+	// DeliveredItems is the number of items the recipient accepted, all items are delivered if it is 0
+	DeliveredItems int `validate:"omitempty,gte=0" json:"deliveredItems" form:"deliveredItems"`
 }
 
-func ValidateOrderDeliver(ctx echo.Context) (*models.TrxHistory, error) {
+// ValidateOrderDeliver accepts json or multipart body, signature and photo can only be sent as multipart files
+func ValidateOrderDeliver(ctx echo.Context) (*models.TrxHistory, *DeliveryProofReq, int, error) {
 	body := OrderDeliverReq{}
 	if err := ctx.Bind(&body); err != nil {
-		return nil, err
+		return nil, nil, 0, err
 	}
 	if err := GetValidationError(body); err != nil {
-		return nil, err
+		return nil, nil, 0, err
 	}
 	_shopID, err := primitive.ObjectIDFromHex(body.ShopID)
 	if err != nil {
-		return nil, err
+		return nil, nil, 0, err
 	}
 	orderID := ctx.Param("orderId")
 	UserID := ctx.Get(constants.UserID).(primitive.ObjectID)
 	_orderID, err := primitive.ObjectIDFromHex(orderID)
 	if err != nil {
-		return nil, err
+		return nil, nil, 0, err
+	}
+	proof := &DeliveryProofReq{OTP: body.OTP}
+	if proof.Signature, err = validateImageFile(ctx, constants.ProofSignature); err != nil {
+		return nil, nil, 0, err
+	}
+	if proof.Photo, err = validateImageFile(ctx, constants.ProofPhoto); err != nil {
+		return nil, nil, 0, err
 	}
 	trxHistory := &models.TrxHistory{
 		ID:          primitive.NewObjectID(),
@@ -274,7 +257,7 @@ func ValidateOrderDeliver(ctx echo.Context) (*models.TrxHistory, error) {
 		ShopID:      _shopID,
 		CreatedAt:   time.Now().UTC(),
 	}
-	return trxHistory, nil
+	return trxHistory, proof, body.DeliveredItems, nil
 }
 
 type OrderChangeReq struct {
@@ -0,0 +1,266 @@
+package validators
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/techartificer/swiftex/lib/sheet"
+	"github.com/techartificer/swiftex/models"
+)
+
+const (
+	// MaxImportRows is the maximum number of orders in an import file
+	MaxImportRows = 2000
+	// maxExtraImportColumns is the number of columns a file can have besides import columns
+	maxExtraImportColumns = 10
+	// MaxImportFileSize is the maximum size of an import file in bytes
+	MaxImportFileSize = 5 << 20
+)
+
+// ImportColumns holds columns of the order import template
+var ImportColumns = []string{
+	"recipientName", "recipientPhone", "recipientCity", "recipientThana", "recipientArea",
+	"recipientZip", "recipientAddress", "packageCode", "paymentStatus", "price", "percelType",
+	"requestedDeliveryTime", "pickAddress", "pickHub", "comments", "numberOfItems", "weight", "deliveryType",
+	"exchangeItems",
+}
+
+// requiredImportColumns holds columns which are required to create an order
+var requiredImportColumns = []string{
+	"recipientName", "recipientPhone", "recipientCity", "recipientArea", "recipientAddress",
+	"paymentStatus", "percelType", "pickAddress", "pickHub", "weight", "deliveryType",
+}
+
+// ImportRow holds an order parsed from a row of import file,
+// Errors is set if the row is invalid
+type ImportRow struct {
+	Row    int
+	Order  *models.Order
+	Errors map[string]string
+}
+
+// OrderImport holds parsed rows of an order import file
+type OrderImport struct {
+	DryRun bool
+	Rows   []ImportRow
+}
+
+// normalizeColumn makes column names comparable, "Recipient Name" matches recipientName
+func normalizeColumn(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	return strings.NewReplacer(" ", "", "_", "", "-", "").Replace(name)
+}
+
+func isImportColumn(column string) bool {
+	for _, c := range ImportColumns {
+		if c == column {
+			return true
+		}
+	}
+	return false
+}
+
+// columnPositions returns position of every template column in the header,
+// mapping holds header of the file for template columns which are named differently
+func columnPositions(header []string, mapping map[string]string) (map[string]int, error) {
+	positions := make(map[string]int)
+	for i, h := range header {
+		positions[normalizeColumn(h)] = i
+	}
+	columns := make(map[string]int)
+	for _, column := range ImportColumns {
+		name := column
+		if mapped, ok := mapping[column]; ok && mapped != "" {
+			name = mapped
+		}
+		if i, ok := positions[normalizeColumn(name)]; ok {
+			columns[column] = i
+		}
+	}
+	for column := range mapping {
+		if !isImportColumn(column) {
+			return nil, fmt.Errorf("unknown template column %s", column)
+		}
+		if _, ok := columns[column]; !ok {
+			return nil, fmt.Errorf("column %s is not found in file", mapping[column])
+		}
+	}
+	for _, column := range requiredImportColumns {
+		if _, ok := columns[column]; !ok {
+			return nil, fmt.Errorf("column %s is missing", column)
+		}
+	}
+	return columns, nil
+}
+
+// parseDeliveryTime accepts RFC3339, date and excel serial date
+func parseDeliveryTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	if serial, err := strconv.ParseFloat(value, 64); err == nil {
+		days := math.Floor(serial)
+		seconds := math.Round((serial - days) * 86400)
+		return time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC).AddDate(0, 0, int(days)).Add(time.Duration(seconds) * time.Second), nil
+	}
+	return time.Time{}, errors.New("invalid date")
+}
+
+// orderCreateReqOf converts cells of a row into order create request
+func orderCreateReqOf(cells []string, columns map[string]int) (*OrderCreateReq, map[string]string) {
+	errs := make(map[string]string)
+	cell := func(column string) string {
+		i, ok := columns[column]
+		if !ok || i >= len(cells) {
+			return ""
+		}
+		return strings.TrimSpace(cells[i])
+	}
+	body := &OrderCreateReq{
+		RecipientName:    cell("recipientName"),
+		RecipientPhone:   cell("recipientPhone"),
+		RecipientCity:    cell("recipientCity"),
+		RecipientThana:   cell("recipientThana"),
+		RecipientArea:    cell("recipientArea"),
+		RecipientZip:     cell("recipientZip"),
+		RecipientAddress: cell("recipientAddress"),
+		PackageCode:      cell("packageCode"),
+		PaymentStatus:    cell("paymentStatus"),
+		PercelType:       cell("percelType"),
+		PickAddress:      cell("pickAddress"),
+		PickHub:          cell("pickHub"),
+		Comments:         cell("comments"),
+		DeliveryType:     cell("deliveryType"),
+	}
+	if value := cell("price"); value != "" {
+		price, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			errs["Price"] = "Price must be a number"
+		}
+		body.Price = price
+	}
+	if value := cell("weight"); value != "" {
+		weight, err := strconv.ParseFloat(value, 32)
+		if err != nil {
+			errs["Weight"] = "Weight must be a number"
+		}
+		body.Weight = float32(weight)
+	}
+	if value := cell("numberOfItems"); value != "" {
+		items, err := strconv.Atoi(value)
+		if err != nil {
+			errs["NumberOfItems"] = "NumberOfItems must be an integer"
+		}
+		body.NumberOfItems = items
+	}
+	if value := cell("exchangeItems"); value != "" {
+		items, err := strconv.Atoi(value)
+		if err != nil {
+			errs["ExchangeItems"] = "ExchangeItems must be an integer"
+		}
+		body.ExchangeItems = items
+	}
+	if value := cell("requestedDeliveryTime"); value != "" {
+		t, err := parseDeliveryTime(value)
+		if err != nil {
+			errs["RequestedDeliveryTime"] = "RequestedDeliveryTime must be a date"
+		}
+		body.RequestedDeliveryTime = t
+	}
+	return body, errs
+}
+
+// validateImportRow validates a row with the same rules as order create
+func validateImportRow(cells []string, columns map[string]int) (*models.Order, map[string]string) {
+	body, errs := orderCreateReqOf(cells, columns)
+	if err := GetValidationError(body); err != nil {
+		for k, v := range *err.(*ValidationError) {
+			if _, ok := errs[k]; !ok {
+				errs[k] = v
+			}
+		}
+	}
+	if flag := isPriceAcceptable(body.PaymentStatus, body.Price); !flag {
+		errs["Price"] = "Price can not be zero"
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return newOrder(body), nil
+}
+
+func isEmptyRow(cells []string) bool {
+	for _, c := range cells {
+		if strings.TrimSpace(c) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateOrderImport parses the uploaded file of multipart field "file",
+// "mapping" is an optional json object of template column to file column
+func ValidateOrderImport(ctx echo.Context) (*OrderImport, error) {
+	fileHeader, err := ctx.FormFile("file")
+	if err != nil {
+		return nil, err
+	}
+	if fileHeader.Size > MaxImportFileSize {
+		return nil, errors.New("file is too large")
+	}
+	mapping := make(map[string]string)
+	if value := ctx.FormValue("mapping"); value != "" {
+		if err := json.Unmarshal([]byte(value), &mapping); err != nil {
+			return nil, errors.New("mapping must be a json object")
+		}
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	content, err := ioutil.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+	// header row and a few unknown columns are allowed
+	rows, err := sheet.Read(fileHeader.Filename, content, MaxImportRows+1, len(ImportColumns)+maxExtraImportColumns)
+	if err == sheet.ErrTooManyRows {
+		return nil, fmt.Errorf("file can have at most %d orders", MaxImportRows)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) < 2 {
+		return nil, errors.New("file has no orders")
+	}
+	if len(rows)-1 > MaxImportRows {
+		return nil, fmt.Errorf("file can have at most %d orders", MaxImportRows)
+	}
+	columns, err := columnPositions(rows[0], mapping)
+	if err != nil {
+		return nil, err
+	}
+	dryRun, _ := strconv.ParseBool(ctx.FormValue("dryRun"))
+	orderImport := &OrderImport{DryRun: dryRun}
+	for i, cells := range rows[1:] {
+		if isEmptyRow(cells) {
+			continue
+		}
+		// row number as shown in spreadsheet, header is the first row
+		row := ImportRow{Row: i + 2}
+		row.Order, row.Errors = validateImportRow(cells, columns)
+		orderImport.Rows = append(orderImport.Rows, row)
+	}
+	return orderImport, nil
+}
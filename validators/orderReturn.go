@@ -0,0 +1,51 @@
+package validators
+
+import (
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/techartificer/swiftex/constants"
+	"github.com/techartificer/swiftex/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ReturnInitiateReq struct {
+	Reason string `json:"reason" validate:"required,max=200"`
+}
+
+// ValidateReturnInitiate returns Returned status of an order, reason of the return is the status text
+func ValidateReturnInitiate(ctx echo.Context) (*models.OrderStatus, error) {
+	body := ReturnInitiateReq{}
+	if err := ctx.Bind(&body); err != nil {
+		return nil, err
+	}
+	if err := GetValidationError(body); err != nil {
+		return nil, err
+	}
+	adminID := ctx.Get(constants.UserID).(primitive.ObjectID)
+	status := &models.OrderStatus{
+		ID:      primitive.NewObjectID(),
+		AdminID: &adminID,
+		Status:  constants.Returned,
+		Text:    strings.TrimSpace(body.Reason),
+		Time:    time.Now().UTC(),
+	}
+	return status, nil
+}
+
+type ReturnHandOverReq struct {
+	ReceiverName  string `json:"receiverName" validate:"required,max=100"`
+	ReceiverPhone string `json:"receiverPhone" validate:"required,max=20"`
+}
+
+func ValidateReturnHandOver(ctx echo.Context) (*ReturnHandOverReq, error) {
+	body := ReturnHandOverReq{}
+	if err := ctx.Bind(&body); err != nil {
+		return nil, err
+	}
+	if err := GetValidationError(body); err != nil {
+		return nil, err
+	}
+	return &body, nil
+}
@@ -0,0 +1,123 @@
+package validators
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/techartificer/swiftex/constants"
+	"github.com/techartificer/swiftex/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type PayoutAccountReq struct {
+	Method        string `json:"method" validate:"required,oneof=Bank bKash Nagad Rocket"`
+	AccountName   string `json:"accountName" validate:"required,max=100"`
+	BankName      string `json:"bankName" validate:"omitempty,max=100"`
+	Branch        string `json:"branch" validate:"omitempty,max=100"`
+	AccountNumber string `json:"accountNumber" validate:"omitempty,number,max=30"`
+	RoutingNumber string `json:"routingNumber" validate:"omitempty,number,max=20"`
+	WalletNumber  string `json:"walletNumber" validate:"omitempty,number,max=20"`
+}
+
+// payoutAccount returns account of the request, bank accounts need bank details
+// and mobile wallets need the wallet number
+func payoutAccount(body PayoutAccountReq) (models.PayoutAccount, error) {
+	account := models.PayoutAccount{
+		Method:      body.Method,
+		AccountName: strings.TrimSpace(body.AccountName),
+	}
+	if body.Method == constants.Bank {
+		account.BankName = strings.TrimSpace(body.BankName)
+		account.Branch = strings.TrimSpace(body.Branch)
+		account.AccountNumber = strings.TrimSpace(body.AccountNumber)
+		account.RoutingNumber = strings.TrimSpace(body.RoutingNumber)
+		if account.BankName == "" || account.AccountNumber == "" || account.RoutingNumber == "" {
+			return account, errors.New("bankName, accountNumber and routingNumber are required for bank accounts")
+		}
+		return account, nil
+	}
+	account.WalletNumber = strings.TrimSpace(body.WalletNumber)
+	if account.WalletNumber == "" {
+		return account, errors.New("walletNumber is required for mobile wallets")
+	}
+	return account, nil
+}
+
+type PayoutScheduleReq struct {
+	Frequency     string  `json:"frequency" validate:"required,oneof=Daily Weekly"`
+	Weekday       *int    `json:"weekday" validate:"omitempty,gte=0,lte=6"`
+	MinimumAmount float64 `json:"minimumAmount" validate:"gte=0"`
+	Status        string  `json:"status" validate:"omitempty,oneof=Active Deactive"`
+}
+
+// ValidatePayoutSchedule returns payout schedule of the shop, weekly schedules need a weekday
+// where 0 is Sunday. Balance is paid out to the verified payout method of the shop
+func ValidatePayoutSchedule(ctx echo.Context, shopID primitive.ObjectID) (*models.PayoutSchedule, error) {
+	body := PayoutScheduleReq{}
+	if err := ctx.Bind(&body); err != nil {
+		return nil, err
+	}
+	if err := GetValidationError(body); err != nil {
+		return nil, err
+	}
+	schedule := &models.PayoutSchedule{
+		ShopID:        shopID,
+		Frequency:     body.Frequency,
+		MinimumAmount: body.MinimumAmount,
+		Status:        body.Status,
+	}
+	if body.Frequency == constants.Weekly {
+		if body.Weekday == nil {
+			return nil, errors.New("weekday is required for weekly payouts")
+		}
+		schedule.Weekday = time.Weekday(*body.Weekday)
+	}
+	if schedule.Status == "" {
+		schedule.Status = constants.Active
+	}
+	updatedBy := ctx.Get(constants.UserID).(primitive.ObjectID)
+	schedule.UpdatedBy = &updatedBy
+	return schedule, nil
+}
+
+type PayoutRejectReq struct {
+	Reason string `json:"reason" validate:"required,max=300"`
+}
+
+func ValidatePayoutReject(ctx echo.Context) (*PayoutRejectReq, error) {
+	body := PayoutRejectReq{}
+	if err := ctx.Bind(&body); err != nil {
+		return nil, err
+	}
+	if err := GetValidationError(body); err != nil {
+		return nil, err
+	}
+	return &body, nil
+}
+
+// walletPhone returns wallet number in the format firebase tokens carry, 01XXXXXXXXX becomes 8801XXXXXXXXX
+func walletPhone(number string) string {
+	if len(number) == 11 && strings.HasPrefix(number, "01") {
+		return "88" + number
+	}
+	return number
+}
+
+// ValidatePayoutMethod returns account of a payout method and phone of its mobile wallet, empty for
+// bank accounts. The shop owner verifies every method with their phone, the wallet phone is verified too
+func ValidatePayoutMethod(ctx echo.Context) (*models.PayoutAccount, string, error) {
+	body := PayoutAccountReq{}
+	if err := ctx.Bind(&body); err != nil {
+		return nil, "", err
+	}
+	if err := GetValidationError(body); err != nil {
+		return nil, "", err
+	}
+	account, err := payoutAccount(body)
+	if err != nil {
+		return nil, "", err
+	}
+	return &account, walletPhone(account.WalletNumber), nil
+}
@@ -0,0 +1,21 @@
+package validators
+
+import "testing"
+
+func TestWalletPhone(t *testing.T) {
+	tests := []struct {
+		number string
+		want   string
+	}{
+		{"", ""},
+		{"01712345678", "8801712345678"},
+		{"8801712345678", "8801712345678"},
+		{"1712345678", "1712345678"},
+		{"0171234567", "0171234567"},
+	}
+	for _, tt := range tests {
+		if got := walletPhone(tt.number); got != tt.want {
+			t.Errorf("walletPhone(%q) = %q, want %q", tt.number, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package validators
+
+import (
+	"errors"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/techartificer/swiftex/constants"
+	"github.com/techartificer/swiftex/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type RolePermissionReq struct {
+	Role        constants.AdminRole    `json:"role" validate:"required,isValidRole"`
+	Permissions []constants.Permission `json:"permissions" validate:"required"`
+}
+
+// ValidateRolePermission returns role permission or error, super admin permissions can not be changed
+func ValidateRolePermission(ctx echo.Context) (*models.RolePermission, error) {
+	v.RegisterValidation("isValidRole", isValidRole)
+	body := RolePermissionReq{}
+	if err := ctx.Bind(&body); err != nil {
+		return nil, err
+	}
+	if err := GetValidationError(body); err != nil {
+		return nil, err
+	}
+	if body.Role == constants.SuperAdmin {
+		return nil, errors.New("super admin has all permissions")
+	}
+	seen := make(map[constants.Permission]bool)
+	permissions := []constants.Permission{}
+	for _, permission := range body.Permissions {
+		if !constants.IsValidPermission(permission) {
+			return nil, errors.New("unknown permission " + string(permission))
+		}
+		if !seen[permission] {
+			seen[permission] = true
+			permissions = append(permissions, permission)
+		}
+	}
+	updatedBy := ctx.Get(constants.UserID).(primitive.ObjectID)
+	rolePermission := &models.RolePermission{
+		Role:        body.Role,
+		Permissions: permissions,
+		UpdatedBy:   &updatedBy,
+		UpdatedAt:   time.Now().UTC(),
+	}
+	return rolePermission, nil
+}
@@ -0,0 +1,117 @@
+package validators
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/techartificer/swiftex/constants"
+	"github.com/techartificer/swiftex/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type PickupCreateReq struct {
+	PickHub       string               `json:"pickHub" validate:"required"`
+	Address       string               `json:"address" validate:"omitempty,max=300"`
+	WindowStart   time.Time            `json:"windowStart" validate:"required"`
+	WindowEnd     time.Time            `json:"windowEnd" validate:"required"`
+	ExpectedCount int                  `json:"expectedCount" validate:"omitempty,gte=0"`
+	OrderIDs      []primitive.ObjectID `json:"orderIds" validate:"omitempty,max=500"`
+	Note          string               `json:"note" validate:"omitempty,max=300"`
+}
+
+// ValidatePickupCreate returns a pending pickup request of the shop, address of the shop
+// is used if address is empty and expected count is at least the number of linked orders
+func ValidatePickupCreate(ctx echo.Context, shop *models.Shop) (*models.PickupRequest, error) {
+	body := PickupCreateReq{}
+	if err := ctx.Bind(&body); err != nil {
+		return nil, err
+	}
+	if err := GetValidationError(body); err != nil {
+		return nil, err
+	}
+	if !body.WindowEnd.After(body.WindowStart) {
+		return nil, errors.New("windowEnd must be after windowStart")
+	}
+	if body.WindowEnd.Before(time.Now()) {
+		return nil, errors.New("windowEnd must be in future")
+	}
+	address := strings.TrimSpace(body.Address)
+	if address == "" {
+		address = shop.PickupAddress
+	}
+	if address == "" {
+		return nil, errors.New("address is required")
+	}
+	items := []models.PickupItem{}
+	seen := make(map[primitive.ObjectID]bool)
+	for _, orderID := range body.OrderIDs {
+		if seen[orderID] {
+			continue
+		}
+		seen[orderID] = true
+		items = append(items, models.PickupItem{OrderID: orderID})
+	}
+	expected := body.ExpectedCount
+	if expected < len(items) {
+		expected = len(items)
+	}
+	if expected == 0 {
+		return nil, errors.New("expectedCount or orderIds is required")
+	}
+	now := time.Now().UTC()
+	pickup := &models.PickupRequest{
+		ID:            primitive.NewObjectID(),
+		ShopID:        shop.ID,
+		ShopName:      shop.Name,
+		ShopPhone:     shop.Phone,
+		Address:       address,
+		Hub:           strings.TrimSpace(body.PickHub),
+		WindowStart:   body.WindowStart.UTC(),
+		WindowEnd:     body.WindowEnd.UTC(),
+		ExpectedCount: expected,
+		Items:         items,
+		Note:          body.Note,
+		Status:        constants.Pending,
+		RequestedBy:   ctx.Get(constants.UserID).(primitive.ObjectID),
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	return pickup, nil
+}
+
+type PickupAssignReq struct {
+	RiderID primitive.ObjectID `json:"riderId" validate:"required"`
+}
+
+func ValidatePickupAssign(ctx echo.Context) (primitive.ObjectID, error) {
+	body := PickupAssignReq{}
+	if err := ctx.Bind(&body); err != nil {
+		return primitive.NilObjectID, err
+	}
+	if err := GetValidationError(body); err != nil {
+		return primitive.NilObjectID, err
+	}
+	return body.RiderID, nil
+}
+
+type PickupConfirmReq struct {
+	OrderIDs []primitive.ObjectID `json:"orderIds" validate:"omitempty,max=500"`
+	Complete bool                 `json:"complete"`
+}
+
+// ValidatePickupConfirm returns orders picked by the rider and whether the pickup is complete
+func ValidatePickupConfirm(ctx echo.Context) (*PickupConfirmReq, error) {
+	body := PickupConfirmReq{}
+	if err := ctx.Bind(&body); err != nil {
+		return nil, err
+	}
+	if err := GetValidationError(body); err != nil {
+		return nil, err
+	}
+	if len(body.OrderIDs) == 0 && !body.Complete {
+		return nil, errors.New("orderIds is required")
+	}
+	return &body, nil
+}
@@ -0,0 +1,144 @@
+package validators
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/techartificer/swiftex/constants"
+	"github.com/techartificer/swiftex/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type WeightSlabReq struct {
+	MaxWeight float32 `json:"maxWeight" validate:"required,gt=0"`
+	Charge    float64 `json:"charge" validate:"gte=0"`
+}
+
+type ZoneRateReq struct {
+	FromZone    string          `json:"fromZone" validate:"required"`
+	ToZone      string          `json:"toZone" validate:"required"`
+	WeightSlabs []WeightSlabReq `json:"weightSlabs" validate:"required,min=1,dive"`
+	ExtraPerKg  float64         `json:"extraPerKg" validate:"gte=0"`
+}
+
+type RateCardReq struct {
+	Name                string             `json:"name" validate:"required"`
+	ZoneRates           []ZoneRateReq      `json:"zoneRates" validate:"required,min=1,dive"`
+	DeliveryTypeCharges map[string]float64 `json:"deliveryTypeCharges" validate:"omitempty"`
+	ParcelTypeCharges   map[string]float64 `json:"parcelTypeCharges" validate:"omitempty"`
+	CODPercent          float64            `json:"codPercent" validate:"gte=0,lte=100"`
+	MinimumCharge       float64            `json:"minimumCharge" validate:"gte=0"`
+	IsDefault           bool               `json:"isDefault"`
+	EffectiveFrom       time.Time          `json:"effectiveFrom" validate:"omitempty"`
+}
+
+type RateCardCreateReq struct {
+	Code string `json:"code" validate:"required,max=30"`
+	RateCardReq
+}
+
+func rateCardFromReq(ctx echo.Context, code string, body *RateCardReq) (*models.RateCard, error) {
+	var zoneRates []models.ZoneRate
+	for _, zr := range body.ZoneRates {
+		var slabs []models.WeightSlab
+		for i, slab := range zr.WeightSlabs {
+			if i > 0 && slab.MaxWeight <= zr.WeightSlabs[i-1].MaxWeight {
+				return nil, errors.New("weight slabs must be in ascending order")
+			}
+			slabs = append(slabs, models.WeightSlab{MaxWeight: slab.MaxWeight, Charge: slab.Charge})
+		}
+		zoneRates = append(zoneRates, models.ZoneRate{
+			FromZone:    strings.TrimSpace(zr.FromZone),
+			ToZone:      strings.TrimSpace(zr.ToZone),
+			WeightSlabs: slabs,
+			ExtraPerKg:  zr.ExtraPerKg,
+		})
+	}
+	effectiveFrom := body.EffectiveFrom.UTC()
+	if body.EffectiveFrom.IsZero() {
+		effectiveFrom = time.Now().UTC()
+	}
+	creator := ctx.Get(constants.UserID).(primitive.ObjectID)
+	card := &models.RateCard{
+		ID:                  primitive.NewObjectID(),
+		Code:                code,
+		Name:                body.Name,
+		ZoneRates:           zoneRates,
+		DeliveryTypeCharges: body.DeliveryTypeCharges,
+		ParcelTypeCharges:   body.ParcelTypeCharges,
+		CODPercent:          body.CODPercent,
+		MinimumCharge:       body.MinimumCharge,
+		IsDefault:           body.IsDefault,
+		EffectiveFrom:       effectiveFrom,
+		CreatedBy:           creator,
+		CreatedAt:           time.Now().UTC(),
+	}
+	return card, nil
+}
+
+// ValidateRateCardCreate returns rate card or error
+func ValidateRateCardCreate(ctx echo.Context) (*models.RateCard, error) {
+	body := RateCardCreateReq{}
+	if err := ctx.Bind(&body); err != nil {
+		return nil, err
+	}
+	if err := GetValidationError(body); err != nil {
+		return nil, err
+	}
+	return rateCardFromReq(ctx, strings.TrimSpace(body.Code), &body.RateCardReq)
+}
+
+// ValidateRateCardVersion returns new version of rate card or error
+func ValidateRateCardVersion(ctx echo.Context) (*models.RateCard, error) {
+	body := RateCardReq{}
+	if err := ctx.Bind(&body); err != nil {
+		return nil, err
+	}
+	if err := GetValidationError(body); err != nil {
+		return nil, err
+	}
+	return rateCardFromReq(ctx, ctx.Param("code"), &body)
+}
+
+type RateCardAssignReq struct {
+	Code string `json:"code" validate:"required"`
+}
+
+// ValidateRateCardAssign returns request body or error
+func ValidateRateCardAssign(ctx echo.Context) (*RateCardAssignReq, error) {
+	body := RateCardAssignReq{}
+	if err := ctx.Bind(&body); err != nil {
+		return nil, err
+	}
+	if err := GetValidationError(body); err != nil {
+		return nil, err
+	}
+	return &body, nil
+}
+
+type QuoteReq struct {
+	PickHub       string  `json:"pickHub" validate:"required"`
+	RecipientCity string  `json:"recipientCity" validate:"required"`
+	Weight        float32 `json:"weight" validate:"required,number,gt=0"`
+	DeliveryType  string  `json:"deliveryType" validate:"required"`
+	PercelType    string  `json:"percelType" validate:"omitempty"`
+	PaymentStatus string  `json:"paymentStatus" validate:"required"`
+	Price         float64 `json:"price" validate:"omitempty,number,gte=0"`
+}
+
+// ValidateQuote returns request body or error
+func ValidateQuote(ctx echo.Context) (*QuoteReq, error) {
+	body := QuoteReq{}
+	if err := ctx.Bind(&body); err != nil {
+		return nil, err
+	}
+	if err := GetValidationError(body); err != nil {
+		return nil, err
+	}
+	if flag := isPriceAcceptable(body.PaymentStatus, body.Price); !flag {
+		return nil, errors.New("price can not be zero")
+	}
+	return &body, nil
+}
@@ -10,15 +10,16 @@ import (
 )
 
 type RiderCreate struct {
-	Name     string `json:"name,omitempty" validate:"required"`
-	Password string `json:"password,omitempty" validate:"required,min=6,max=26"`
-	Phone    string `json:"phone,omitempty" validate:"required"`
-	Contact  string `json:"contact,omitempty" validate:"required"`
-	NID      string `json:"NID,omitempty" validate:"required"`
-	Salary   int32  `json:"Salary,omitempty" validate:"required"`
-	Address  string `json:"address,omitempty" validate:"required"`
-	Remark   string `json:"remark,omitempty" validate:"required"`
-	Hub      string `json:"hub,omitempty" validate:"required"`
+	Name          string `json:"name,omitempty" validate:"required"`
+	Password      string `json:"password,omitempty" validate:"required,min=6,max=26"`
+	Phone         string `json:"phone,omitempty" validate:"required"`
+	Contact       string `json:"contact,omitempty" validate:"required"`
+	NID           string `json:"NID,omitempty" validate:"required"`
+	Salary        int32  `json:"Salary,omitempty" validate:"required"`
+	Address       string `json:"address,omitempty" validate:"required"`
+	Remark        string `json:"remark,omitempty" validate:"required"`
+	Hub           string `json:"hub,omitempty" validate:"required"`
+	DailyCapacity int    `json:"dailyCapacity,omitempty" validate:"omitempty,gte=0"`
 }
 
 func ValidateRiderReq(ctx echo.Context) (*models.Rider, error) {
@@ -31,19 +32,36 @@ func ValidateRiderReq(ctx echo.Context) (*models.Rider, error) {
 	}
 	creator := ctx.Get(constants.UserID).(primitive.ObjectID)
 	rider := &models.Rider{
-		ID:        primitive.NewObjectID(),
-		Name:      body.Name,
-		Password:  body.Password,
-		Phone:     body.Phone,
-		Status:    constants.Active,
-		Contact:   body.Contact,
-		NID:       body.NID,
-		Salary:    body.Salary,
-		Address:   body.Address,
-		Remark:    body.Remark,
-		Hub:       body.Hub,
-		CreatedBy: &creator,
-		CreatedAt: time.Now().UTC(),
+		ID:            primitive.NewObjectID(),
+		Name:          body.Name,
+		Password:      body.Password,
+		Phone:         body.Phone,
+		Status:        constants.Active,
+		Contact:       body.Contact,
+		NID:           body.NID,
+		Salary:        body.Salary,
+		Address:       body.Address,
+		Remark:        body.Remark,
+		Hub:           body.Hub,
+		CreatedBy:     &creator,
+		DailyCapacity: body.DailyCapacity,
+		CreatedAt:     time.Now().UTC(),
 	}
 	return rider, nil
 }
+
+type RiderCapacityReq struct {
+	DailyCapacity int `json:"dailyCapacity" validate:"gte=0"`
+}
+
+// ValidateRiderCapacity returns daily capacity of a rider, 0 falls back to the default capacity
+func ValidateRiderCapacity(ctx echo.Context) (int, error) {
+	body := RiderCapacityReq{}
+	if err := ctx.Bind(&body); err != nil {
+		return 0, err
+	}
+	if err := GetValidationError(body); err != nil {
+		return 0, err
+	}
+	return body.DailyCapacity, nil
+}
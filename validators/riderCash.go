@@ -0,0 +1,58 @@
+package validators
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/techartificer/swiftex/constants"
+	"github.com/techartificer/swiftex/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type CashNoteReq struct {
+	Value int `json:"value" validate:"required,gt=0"`
+	Count int `json:"count" validate:"required,gt=0"`
+}
+
+type CashDepositReq struct {
+	Amount  float64       `json:"amount" validate:"required,number,gt=0"`
+	Notes   []CashNoteReq `json:"notes" validate:"omitempty,dive"`
+	Comment string        `json:"comment" validate:"omitempty,max=200"`
+}
+
+// ValidateCashDeposit returns cash deposit of rider or error,
+// sum of notes must match the amount if notes are given
+func ValidateCashDeposit(ctx echo.Context) (*models.CashDeposit, error) {
+	body := CashDepositReq{}
+	if err := ctx.Bind(&body); err != nil {
+		return nil, err
+	}
+	if err := GetValidationError(body); err != nil {
+		return nil, err
+	}
+	riderID, err := primitive.ObjectIDFromHex(ctx.Param("riderId"))
+	if err != nil {
+		return nil, err
+	}
+	var notes []models.CashNote
+	total := 0
+	for _, note := range body.Notes {
+		total += note.Value * note.Count
+		notes = append(notes, models.CashNote{Value: note.Value, Count: note.Count})
+	}
+	if len(notes) > 0 && math.Abs(float64(total)-body.Amount) > 0.001 {
+		return nil, errors.New("sum of notes does not match amount")
+	}
+	deposit := &models.CashDeposit{
+		ID:         primitive.NewObjectID(),
+		RiderID:    riderID,
+		Amount:     body.Amount,
+		Notes:      notes,
+		Comment:    body.Comment,
+		ReceivedBy: ctx.Get(constants.UserID).(primitive.ObjectID),
+		CreatedAt:  time.Now().UTC(),
+	}
+	return deposit, nil
+}
@@ -1,6 +1,7 @@
 package validators
 
 import (
+	"errors"
 	"time"
 
 	"github.com/labstack/echo/v4"
@@ -41,3 +42,30 @@ func ValidateRiderParcelCreate(ctx echo.Context) (*models.RiderParcel, error) {
 	}
 	return riderParcel, nil
 }
+
+type DeliveryAttemptReq struct {
+	Reason       string     `json:"reason" validate:"required,oneof=RecipientUnavailable RecipientRefused WrongAddress PaymentNotReady RescheduleRequested Other"`
+	Note         string     `json:"note" validate:"omitempty,max=300"`
+	RescheduleAt *time.Time `json:"rescheduleAt" validate:"omitempty"`
+}
+
+// ValidateDeliveryAttempt returns failed attempt or error, reschedule date has to be in future
+func ValidateDeliveryAttempt(ctx echo.Context) (*models.DeliveryAttempt, error) {
+	body := DeliveryAttemptReq{}
+	if err := ctx.Bind(&body); err != nil {
+		return nil, err
+	}
+	if err := GetValidationError(body); err != nil {
+		return nil, err
+	}
+	if body.RescheduleAt != nil && !body.RescheduleAt.After(time.Now()) {
+		return nil, errors.New("reschedule date has to be in future")
+	}
+	attempt := &models.DeliveryAttempt{
+		Reason:       body.Reason,
+		Note:         body.Note,
+		RescheduleAt: body.RescheduleAt,
+		Time:         time.Now().UTC(),
+	}
+	return attempt, nil
+}
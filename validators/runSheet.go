@@ -0,0 +1,50 @@
+package validators
+
+import (
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/techartificer/swiftex/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type RunSheetOpenReq struct {
+	RiderID primitive.ObjectID `json:"riderId" validate:"required"`
+	Date    string             `json:"date" validate:"omitempty"`
+}
+
+// runSheetDate returns date in run sheet layout, today is the default
+func runSheetDate(date string) (string, error) {
+	date = strings.TrimSpace(date)
+	if date == "" {
+		return time.Now().Format(models.RunSheetDateLayout), nil
+	}
+	t, err := time.Parse(models.RunSheetDateLayout, date)
+	if err != nil {
+		return "", err
+	}
+	return t.Format(models.RunSheetDateLayout), nil
+}
+
+// ValidateRunSheetDate returns run sheet date from query param date
+func ValidateRunSheetDate(ctx echo.Context) (string, error) {
+	return runSheetDate(ctx.QueryParam("date"))
+}
+
+// ValidateRunSheetOpen returns rider and date of a run sheet to open
+func ValidateRunSheetOpen(ctx echo.Context) (*RunSheetOpenReq, error) {
+	body := RunSheetOpenReq{}
+	if err := ctx.Bind(&body); err != nil {
+		return nil, err
+	}
+	if err := GetValidationError(body); err != nil {
+		return nil, err
+	}
+	date, err := runSheetDate(body.Date)
+	if err != nil {
+		return nil, err
+	}
+	body.Date = date
+	return &body, nil
+}
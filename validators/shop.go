@@ -1,10 +1,12 @@
 package validators
 
 import (
+	"errors"
 	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/techartificer/swiftex/constants"
+	"github.com/techartificer/swiftex/lib/sms"
 	"github.com/techartificer/swiftex/models"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
@@ -47,17 +49,18 @@ func ValidateShopCreate(ctx echo.Context) (*models.Shop, error) {
 }
 
 type ShopUpdateReq struct {
-	Phone          string  `json:"phone,omitempty"`
-	Name           string  `json:"name,omitempty" validate:"omitempty,min=3,max=30"`
-	Email          string  `json:"email,omitempty" validate:"omitempty,email"`
-	Address        string  `json:"address,omitempty"`
-	PickupAddress  string  `json:"pickupAddress,omitempty"`
-	DeliveryZone   string  `json:"deliveryZone,omitempty"`
-	FBPage         string  `json:"fbPage,omitempty"`
-	PickupArea     string  `json:"pickupArea,omitempty"`
-	DeliveryCharge float64 `json:"deliveryCharge,omitempty"`
-	COD            float64 `json:"cod" validate:"number,gte=0"`
-	Status         string  `json:"status,omitempty"`
+	Phone          string              `json:"phone,omitempty"`
+	Name           string              `json:"name,omitempty" validate:"omitempty,min=3,max=30"`
+	Email          string              `json:"email,omitempty" validate:"omitempty,email"`
+	Address        string              `json:"address,omitempty"`
+	PickupAddress  string              `json:"pickupAddress,omitempty"`
+	DeliveryZone   string              `json:"deliveryZone,omitempty"`
+	FBPage         string              `json:"fbPage,omitempty"`
+	PickupArea     string              `json:"pickupArea,omitempty"`
+	DeliveryCharge float64             `json:"deliveryCharge,omitempty"`
+	COD            float64             `json:"cod" validate:"number,gte=0"`
+	Status         string              `json:"status,omitempty"`
+	ProofPolicy    *models.ProofPolicy `json:"proofPolicy,omitempty"`
 }
 
 func ValidateShopUpdate(ctx echo.Context) (*models.Shop, error) {
@@ -68,6 +71,9 @@ func ValidateShopUpdate(ctx echo.Context) (*models.Shop, error) {
 	if err := GetValidationError(body); err != nil {
 		return nil, err
 	}
+	if body.ProofPolicy != nil && body.ProofPolicy.OTP && !sms.Enabled() {
+		return nil, errors.New("delivery codes are not available without an sms gateway")
+	}
 	shop := &models.Shop{
 		Name:          body.Name,
 		Email:         body.Email,
@@ -77,6 +83,7 @@ func ValidateShopUpdate(ctx echo.Context) (*models.Shop, error) {
 		PickupArea:    body.PickupArea,
 		FBPage:        body.FBPage,
 		DeliveryZone:  body.DeliveryZone,
+		ProofPolicy:   body.ProofPolicy,
 		UpdatedAt:     time.Now().UTC(),
 	}
 	role := ctx.Get(constants.Role).(string)
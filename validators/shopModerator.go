@@ -0,0 +1,79 @@
+package validators
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/techartificer/swiftex/constants"
+	"github.com/techartificer/swiftex/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ModeratorInviteReq struct {
+	Phone       string                     `json:"phone" validate:"required"`
+	Permissions []constants.ShopPermission `json:"permissions" validate:"omitempty"`
+}
+
+type ModeratorPermissionReq struct {
+	Permissions []constants.ShopPermission `json:"permissions" validate:"required"`
+}
+
+// shopPermissionsOf rejects unknown permissions and removes duplicates
+func shopPermissionsOf(perms []constants.ShopPermission) ([]constants.ShopPermission, error) {
+	seen := make(map[constants.ShopPermission]bool)
+	permissions := []constants.ShopPermission{}
+	for _, permission := range perms {
+		if !constants.IsValidShopPermission(permission) {
+			return nil, errors.New("unknown permission " + string(permission))
+		}
+		if !seen[permission] {
+			seen[permission] = true
+			permissions = append(permissions, permission)
+		}
+	}
+	return permissions, nil
+}
+
+// ValidateModeratorInvite returns invitation of the shop or error, owner can not invite himself
+func ValidateModeratorInvite(ctx echo.Context, shop *models.Shop) (*models.ShopModerator, error) {
+	body := ModeratorInviteReq{}
+	if err := ctx.Bind(&body); err != nil {
+		return nil, err
+	}
+	if err := GetValidationError(body); err != nil {
+		return nil, err
+	}
+	phone := strings.TrimSpace(body.Phone)
+	if phone == ctx.Get(constants.Phone).(string) {
+		return nil, errors.New("shop owner can not be a moderator")
+	}
+	permissions, err := shopPermissionsOf(body.Permissions)
+	if err != nil {
+		return nil, err
+	}
+	moderator := &models.ShopModerator{
+		ID:          primitive.NewObjectID(),
+		ShopID:      shop.ID,
+		ShopName:    shop.Name,
+		Phone:       phone,
+		Permissions: permissions,
+		Status:      constants.Invited,
+		InvitedBy:   ctx.Get(constants.UserID).(primitive.ObjectID),
+		CreatedAt:   time.Now().UTC(),
+		UpdatedAt:   time.Now().UTC(),
+	}
+	return moderator, nil
+}
+
+func ValidateModeratorPermissions(ctx echo.Context) ([]constants.ShopPermission, error) {
+	body := ModeratorPermissionReq{}
+	if err := ctx.Bind(&body); err != nil {
+		return nil, err
+	}
+	if err := GetValidationError(body); err != nil {
+		return nil, err
+	}
+	return shopPermissionsOf(body.Permissions)
+}
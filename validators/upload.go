@@ -0,0 +1,72 @@
+package validators
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/techartificer/swiftex/lib/imaging"
+)
+
+// MaxImageFileSize is the maximum size of an uploaded image in bytes
+const MaxImageFileSize = 5 << 20
+
+// imageContentTypes maps accepted image types to their file extension
+var imageContentTypes = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+}
+
+// ImageFile holds an uploaded image, content type is detected from the content
+type ImageFile struct {
+	Content     []byte
+	ContentType string
+	Ext         string
+}
+
+// validateImageFile reads an image of multipart field, nil if the field is not sent
+func validateImageFile(ctx echo.Context, field string) (*ImageFile, error) {
+	fileHeader, err := ctx.FormFile(field)
+	if err == http.ErrMissingFile || err == http.ErrNotMultipart {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if fileHeader.Size > MaxImageFileSize {
+		return nil, errors.New(field + " is too large")
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	content, err := ioutil.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+	contentType := http.DetectContentType(content)
+	ext, ok := imageContentTypes[contentType]
+	if !ok {
+		return nil, errors.New(field + " must be a png or jpeg image")
+	}
+	if err := imaging.Check(content); err == imaging.ErrTooManyPixels {
+		return nil, errors.New(field + " dimensions are too large")
+	} else if err != nil {
+		return nil, errors.New(field + " is not a valid image")
+	}
+	return &ImageFile{Content: content, ContentType: contentType, Ext: ext}, nil
+}
+
+// ValidateImageUpload returns image of multipart field "image"
+func ValidateImageUpload(ctx echo.Context) (*ImageFile, error) {
+	image, err := validateImageFile(ctx, "image")
+	if err != nil {
+		return nil, err
+	}
+	if image == nil {
+		return nil, errors.New("image is required")
+	}
+	return image, nil
+}
@@ -0,0 +1,96 @@
+package validators
+
+import (
+	"errors"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/techartificer/swiftex/constants"
+	"github.com/techartificer/swiftex/lib/webhook"
+	"github.com/techartificer/swiftex/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type WebhookCreateReq struct {
+	URL    string   `json:"url" validate:"required,url,startswith=https://"`
+	Events []string `json:"events" validate:"omitempty"`
+}
+
+type WebhookUpdateReq struct {
+	URL          string   `json:"url" validate:"omitempty,url,startswith=https://"`
+	Events       []string `json:"events" validate:"omitempty"`
+	Status       string   `json:"status" validate:"omitempty,oneof=Active Deactive"`
+	RotateSecret bool     `json:"rotateSecret"`
+}
+
+func validateWebhookEvents(events []string) error {
+	for _, event := range events {
+		valid := false
+		for _, e := range models.WebhookEvents {
+			if e == event {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return errors.New("unknown webhook event " + event)
+		}
+	}
+	return nil
+}
+
+// ValidateWebhookCreate returns webhook or error, secret is set by the caller
+func ValidateWebhookCreate(ctx echo.Context) (*models.Webhook, error) {
+	body := WebhookCreateReq{}
+	if err := ctx.Bind(&body); err != nil {
+		return nil, err
+	}
+	if err := GetValidationError(body); err != nil {
+		return nil, err
+	}
+	if err := validateWebhookEvents(body.Events); err != nil {
+		return nil, err
+	}
+	if err := webhook.ValidateURL(body.URL); err != nil {
+		return nil, err
+	}
+	shopID, err := primitive.ObjectIDFromHex(ctx.Param("shopId"))
+	if err != nil {
+		return nil, err
+	}
+	events := body.Events
+	if events == nil {
+		events = []string{}
+	}
+	webhook := &models.Webhook{
+		ID:        primitive.NewObjectID(),
+		ShopID:    shopID,
+		URL:       body.URL,
+		Events:    events,
+		Status:    constants.Active,
+		CreatedBy: ctx.Get(constants.UserID).(primitive.ObjectID),
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+	}
+	return webhook, nil
+}
+
+// ValidateWebhookUpdate returns request body or error
+func ValidateWebhookUpdate(ctx echo.Context) (*WebhookUpdateReq, error) {
+	body := WebhookUpdateReq{}
+	if err := ctx.Bind(&body); err != nil {
+		return nil, err
+	}
+	if err := GetValidationError(body); err != nil {
+		return nil, err
+	}
+	if err := validateWebhookEvents(body.Events); err != nil {
+		return nil, err
+	}
+	if body.URL != "" {
+		if err := webhook.ValidateURL(body.URL); err != nil {
+			return nil, err
+		}
+	}
+	return &body, nil
+}